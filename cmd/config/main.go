@@ -0,0 +1,34 @@
+// Command config prints the effective configuration of the server: the
+// defaults overridden by the configuration file, the environment and the
+// flags it is given, exactly as the server would load them. It takes the
+// same flags as the server, and exits with status 1 after listing the
+// problems when the configuration is invalid.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reddit-clone/core/config"
+)
+
+func main() {
+	loader := config.NewLoader(flag.CommandLine)
+	format := flag.String("format", "yaml", "output format: yaml or toml")
+	flag.Parse()
+
+	cfg, err := loader.Load(os.LookupEnv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config:", err)
+		os.Exit(2)
+	}
+	if err := cfg.Write(os.Stdout, *format); err != nil {
+		fmt.Fprintln(os.Stderr, "config:", err)
+		os.Exit(2)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "config: invalid configuration:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,65 @@
+// Command simulator runs the managers in process and drives them with
+// simulated users, then prints the throughput and latency of every kind of
+// request. See core/simulator for what the users do.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/simulator"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func main() {
+	config := simulator.DefaultConfig()
+	flag.IntVar(&config.Users, "users", config.Users, "number of simulated users")
+	flag.IntVar(&config.Forums, "forums", config.Forums, "number of forums")
+	flag.IntVar(&config.ForumsPerUser, "joins", config.ForumsPerUser, "forums each user joins")
+	flag.Float64Var(&config.ZipfExponent, "zipf", config.ZipfExponent, "exponent of the Zipf distribution of forum popularity, greater than 1")
+	flag.Float64Var(&config.PostRate, "post-rate", config.PostRate, "posts per second of each online user")
+	flag.Float64Var(&config.CommentRate, "comment-rate", config.CommentRate, "comments per second of each online user")
+	flag.Float64Var(&config.VoteRate, "vote-rate", config.VoteRate, "votes per second of each online user")
+	flag.Float64Var(&config.MessageRate, "message-rate", config.MessageRate, "direct messages per second of each online user")
+	flag.DurationVar(&config.OnlineTime, "online", config.OnlineTime, "mean time a user stays online")
+	flag.DurationVar(&config.OfflineTime, "offline", config.OfflineTime, "mean time a user stays offline, 0 to stay online")
+	flag.DurationVar(&config.Duration, "duration", config.Duration, "how long to run")
+	flag.DurationVar(&config.Timeout, "timeout", config.Timeout, "timeout of each request")
+	flag.Uint64Var(&config.Seed, "seed", config.Seed, "random seed")
+	verbose := flag.Bool("v", false, "log every request the managers handle")
+	flag.Parse()
+
+	if err := config.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "simulator:", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	system := actor.NewActorSystem()
+	managers, err := proto_actor.SpawnManagers(system.Root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "simulator: starting managers:", err)
+		os.Exit(1)
+	}
+
+	// An interrupt ends the run early but still prints the report.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Simulating %d users in %d forums for %v...\n", config.Users, config.Forums, config.Duration)
+	report, err := simulator.Run(ctx, system.Root, managers, config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "simulator:", err)
+		os.Exit(1)
+	}
+	report.Print(os.Stdout)
+}
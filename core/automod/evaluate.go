@@ -0,0 +1,233 @@
+package automod
+
+import (
+	"fmt"
+	"reddit-clone/core/content"
+	"reddit-clone/schemas"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Item is the content rules are checked on.
+type Item struct {
+	// Kind is schemas.KindPost or KindComment.
+	Kind            string
+	Text            string
+	AuthorName      string
+	AuthorKarma     int
+	AuthorCreatedAt time.Time
+
+	title   *string
+	domains []string
+}
+
+// Title is the first non-blank line of a post, without the marks of a
+// Markdown heading, and empty for a comment.
+func (item *Item) Title() string {
+	if item.title == nil {
+		var title string
+		if item.Kind == schemas.KindPost {
+			for _, line := range strings.Split(item.Text, "\n") {
+				if line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#")); line != "" {
+					title = line
+					break
+				}
+			}
+		}
+		item.title = &title
+	}
+	return *item.title
+}
+
+// Domains returns the hosts the text links to.
+func (item *Item) Domains() []string {
+	if item.domains == nil {
+		item.domains = append([]string{}, content.Domains(item.Text)...)
+	}
+	return item.domains
+}
+
+// Result is what the rules of a forum do with an item. Action is the
+// strongest action of the matching rules, taken for Reason by the rule named
+// Rule. Flair is that of the first matching rule that sets one, and Comments
+// are the replies of every matching rule, in order.
+type Result struct {
+	Action   Action
+	Rule     string
+	Reason   string
+	Flair    string
+	Comments []string
+	// Matched names every matching rule.
+	Matched []string
+}
+
+// Evaluate checks item against every rule at the time now.
+func (r *Ruleset) Evaluate(item *Item, now time.Time) *Result {
+	result := &Result{}
+	for _, rule := range r.Rules {
+		if !rule.Matches(item, now) {
+			continue
+		}
+		result.Matched = append(result.Matched, rule.Name)
+		if rule.Action > result.Action {
+			result.Action = rule.Action
+			result.Rule = rule.Name
+			result.Reason = rule.Reason
+			if result.Reason == "" {
+				result.Reason = fmt.Sprintf("matched the rule %q", rule.Name)
+			}
+		}
+		if rule.Flair != "" && result.Flair == "" && item.Kind == schemas.KindPost {
+			result.Flair = rule.Flair
+		}
+		if rule.Comment != "" {
+			result.Comments = append(result.Comments, strings.ReplaceAll(rule.Comment, "{{author}}", item.AuthorName))
+		}
+	}
+	return result
+}
+
+// Matches reports whether the rule checks item's kind and all its conditions
+// match it.
+func (rule *Rule) Matches(item *Item, now time.Time) bool {
+	if rule.Kind != "" && rule.Kind != item.Kind {
+		return false
+	}
+	for _, condition := range rule.Conditions {
+		if !condition.Match(item, now) {
+			return false
+		}
+	}
+	return true
+}
+
+// textMatch matches the text or title of an item against any of a list of
+// values.
+type textMatch struct {
+	title bool
+	match func(text string) bool
+}
+
+func newTextMatch(title bool, modifier string, values []string) (*textMatch, error) {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	anyOf := func(test func(text, value string) bool) func(string) bool {
+		return func(text string) bool {
+			text = strings.ToLower(strings.TrimSpace(text))
+			for _, value := range lowered {
+				if test(text, value) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	m := &textMatch{title: title}
+	switch modifier {
+	case "includes-word":
+		phrases := make([][]string, 0, len(values))
+		for _, value := range values {
+			if phrase := content.Words(value); len(phrase) > 0 {
+				phrases = append(phrases, phrase)
+			}
+		}
+		if len(phrases) == 0 {
+			return nil, fmt.Errorf("includes-word needs values with letters or digits")
+		}
+		m.match = func(text string) bool {
+			words := content.Words(text)
+			for _, phrase := range phrases {
+				if content.HasPhrase(words, phrase) {
+					return true
+				}
+			}
+			return false
+		}
+	case "includes":
+		m.match = anyOf(strings.Contains)
+	case "starts-with":
+		m.match = anyOf(strings.HasPrefix)
+	case "ends-with":
+		m.match = anyOf(strings.HasSuffix)
+	case "full-exact":
+		m.match = anyOf(func(text, value string) bool { return text == strings.TrimSpace(value) })
+	case "regex":
+		patterns := make([]*regexp.Regexp, len(values))
+		for i, value := range values {
+			pattern, err := regexp.Compile("(?i)" + value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex %q: %v", value, strings.TrimPrefix(err.Error(), "error parsing regexp: "))
+			}
+			patterns[i] = pattern
+		}
+		m.match = func(text string) bool {
+			for _, pattern := range patterns {
+				if pattern.MatchString(text) {
+					return true
+				}
+			}
+			return false
+		}
+	default:
+		return nil, fmt.Errorf("unknown match modifier %q; use includes-word, includes, starts-with, ends-with, full-exact or regex", modifier)
+	}
+	return m, nil
+}
+
+func (m *textMatch) Match(item *Item, _ time.Time) bool {
+	if m.title {
+		return item.Kind == schemas.KindPost && m.match(item.Title())
+	}
+	return m.match(item.Text)
+}
+
+// domainMatch matches items linking to any of a list of domains or their
+// subdomains.
+type domainMatch []string
+
+func (m domainMatch) Match(item *Item, _ time.Time) bool {
+	for _, host := range item.Domains() {
+		for _, domain := range m {
+			if content.MatchDomain(host, domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type karmaMatch struct {
+	op    string
+	karma int
+}
+
+func (m karmaMatch) Match(item *Item, _ time.Time) bool {
+	return compare(m.op, item.AuthorKarma, m.karma)
+}
+
+type ageMatch struct {
+	op  string
+	age time.Duration
+}
+
+func (m ageMatch) Match(item *Item, now time.Time) bool {
+	return compare(m.op, now.Sub(item.AuthorCreatedAt), m.age)
+}
+
+func compare[T int | time.Duration](op string, a, b T) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return a == b
+}
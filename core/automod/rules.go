@@ -0,0 +1,342 @@
+// Package automod is the AutoModerator of forums: rules that moderators
+// write in YAML and that are checked on every new post and comment.
+//
+// A rule is a YAML mapping; the rules of a forum are a list of them, or
+// YAML documents separated by "---". A rule matches content when all of its
+// conditions do, and then takes its actions:
+//
+//	name: Links from new accounts
+//	type: post                 # post, comment or any (the default)
+//	body: [free money, crypto] # any of these, as whole words
+//	title (regex): '^\[sale\]'
+//	domain: [spam.example]     # links to the domain or its subdomains
+//	author:
+//	  karma: "< 10"
+//	  account_age: "< 7 days"
+//	action: hold               # remove, hold or report
+//	action_reason: Possible spam
+//	set_flair: Needs review    # posts only
+//	comment: Thanks {{author}}, a moderator will have a look.
+//
+// body and title take a match modifier in parentheses: includes-word (the
+// default), includes, starts-with, ends-with, full-exact or regex. Matching
+// ignores case. Posts have no separate title, so title is the first line of
+// a post's text, and never matches a comment.
+package automod
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reddit-clone/schemas"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Name is the username of the account AutoModerator comments as.
+const Name = "AutoModerator"
+
+// Filter names AutoModerator in the modqueue items it queues.
+const Filter = "automoderator"
+
+// MaxRules bounds the number of rules of a forum.
+const MaxRules = 100
+
+// Action is what a matching rule does with content besides flairing and
+// replying to it. Later actions are stronger.
+type Action int
+
+const (
+	None Action = iota
+	// Report queues the content for review but leaves it in sight.
+	Report
+	// Hold keeps the content out of sight until it is approved.
+	Hold
+	// Remove refuses the content.
+	Remove
+)
+
+var actionNames = map[Action]string{None: "none", Report: "report", Hold: "hold", Remove: "remove"}
+
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+// ParseAction returns the action named s.
+func ParseAction(s string) (Action, bool) {
+	for action, name := range actionNames {
+		if name == s {
+			return action, true
+		}
+	}
+	return None, false
+}
+
+// Rule is one parsed rule.
+type Rule struct {
+	Name string
+	// Kind is the kind of content the rule checks, schemas.KindPost or
+	// KindComment, or empty for both.
+	Kind       string
+	Conditions []Condition
+	Action     Action
+	Reason     string
+	Flair      string
+	Comment    string
+}
+
+// Condition is one test of a rule on content.
+type Condition interface {
+	Match(item *Item, now time.Time) bool
+}
+
+// Ruleset is the parsed rules of a forum, in order.
+type Ruleset struct {
+	Rules []*Rule
+}
+
+// Error is a rule that does not parse. Line is where in the source it is.
+type Error struct {
+	Rule    int
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rule %d (line %d): %s", e.Rule, e.Line, e.Message)
+}
+
+// Parse parses source into a Ruleset. Empty source parses to no rules.
+func Parse(source string) (*Ruleset, error) {
+	ruleset := &Ruleset{}
+	decoder := yaml.NewDecoder(strings.NewReader(source))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %v", strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+
+		nodes := []*yaml.Node{document.Content[0]}
+		if document.Content[0].Kind == yaml.SequenceNode {
+			nodes = document.Content[0].Content
+		}
+		for _, node := range nodes {
+			if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+				continue
+			}
+			rule, err := parseRule(node, len(ruleset.Rules)+1)
+			if err != nil {
+				return nil, err
+			}
+			ruleset.Rules = append(ruleset.Rules, rule)
+			if len(ruleset.Rules) > MaxRules {
+				return nil, &Error{Rule: len(ruleset.Rules), Line: node.Line, Message: fmt.Sprintf("a forum may have at most %d rules", MaxRules)}
+			}
+		}
+	}
+	return ruleset, nil
+}
+
+// keyPattern splits a condition key such as "title (regex)" into its field
+// and modifier.
+var keyPattern = regexp.MustCompile(`^([a-z_]+)(?:\s*\(\s*([a-z-]+)\s*\))?$`)
+
+func parseRule(node *yaml.Node, n int) (*Rule, error) {
+	fail := func(node *yaml.Node, format string, args ...any) error {
+		return &Error{Rule: n, Line: node.Line, Message: fmt.Sprintf(format, args...)}
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fail(node, "a rule must be a mapping of keys to values")
+	}
+
+	rule := &Rule{Name: fmt.Sprintf("rule %d", n)}
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if seen[key.Value] {
+			return nil, fail(key, "%s is given twice", key.Value)
+		}
+		seen[key.Value] = true
+
+		switch key.Value {
+		case "name", "type", "action", "action_reason", "set_flair", "comment":
+			if value.Kind != yaml.ScalarNode {
+				return nil, fail(value, "%s must be a string", key.Value)
+			}
+		}
+
+		switch key.Value {
+		case "name":
+			rule.Name = strings.TrimSpace(value.Value)
+		case "type":
+			switch value.Value {
+			case schemas.KindPost, schemas.KindComment:
+				rule.Kind = value.Value
+			case "any":
+			default:
+				return nil, fail(value, "type must be post, comment or any, not %q", value.Value)
+			}
+		case "action":
+			action, ok := ParseAction(value.Value)
+			if !ok || action == None {
+				return nil, fail(value, "action must be remove, hold or report, not %q", value.Value)
+			}
+			rule.Action = action
+		case "action_reason":
+			rule.Reason = strings.TrimSpace(value.Value)
+		case "set_flair":
+			rule.Flair = strings.TrimSpace(value.Value)
+		case "comment":
+			rule.Comment = strings.TrimSpace(value.Value)
+		case "author":
+			conditions, err := parseAuthor(value, fail)
+			if err != nil {
+				return nil, err
+			}
+			rule.Conditions = append(rule.Conditions, conditions...)
+		default:
+			condition, err := parseMatch(key, value, fail)
+			if err != nil {
+				return nil, err
+			}
+			rule.Conditions = append(rule.Conditions, condition)
+		}
+	}
+
+	if rule.Action == None && rule.Flair == "" && rule.Comment == "" {
+		return nil, fail(node, "a rule must have an action, set_flair or comment")
+	}
+	if rule.Flair != "" && rule.Kind == schemas.KindComment {
+		return nil, fail(node, "set_flair applies only to posts")
+	}
+	return rule, nil
+}
+
+type failFunc func(node *yaml.Node, format string, args ...any) error
+
+func parseMatch(key, value *yaml.Node, fail failFunc) (Condition, error) {
+	parts := keyPattern.FindStringSubmatch(key.Value)
+	if parts == nil {
+		return nil, fail(key, "unknown key %q", key.Value)
+	}
+	field, modifier := parts[1], parts[2]
+
+	var values []string
+	switch value.Kind {
+	case yaml.ScalarNode:
+		values = []string{value.Value}
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fail(item, "%s must be a string or a list of strings", key.Value)
+			}
+			values = append(values, item.Value)
+		}
+	default:
+		return nil, fail(value, "%s must be a string or a list of strings", key.Value)
+	}
+	if len(values) == 0 {
+		return nil, fail(value, "%s must not be empty", key.Value)
+	}
+
+	switch field {
+	case "body", "title":
+		if modifier == "" {
+			modifier = "includes-word"
+		}
+		match, err := newTextMatch(field == "title", modifier, values)
+		if err != nil {
+			return nil, fail(key, "%s: %v", key.Value, err)
+		}
+		return match, nil
+	case "domain":
+		if modifier != "" {
+			return nil, fail(key, "domain takes no match modifier")
+		}
+		return domainMatch(values), nil
+	}
+	return nil, fail(key, "unknown key %q", key.Value)
+}
+
+func parseAuthor(node *yaml.Node, fail failFunc) ([]Condition, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fail(node, "author must be a mapping")
+	}
+	var conditions []Condition
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return nil, fail(value, "author %s must be a comparison such as \"< 10\"", key.Value)
+		}
+		op, operand, err := parseComparison(value.Value)
+		if err != nil {
+			return nil, fail(value, "author %s: %v", key.Value, err)
+		}
+
+		switch key.Value {
+		case "karma":
+			karma, err := strconv.Atoi(operand)
+			if err != nil {
+				return nil, fail(value, "author karma must compare with a whole number, not %q", operand)
+			}
+			conditions = append(conditions, karmaMatch{op: op, karma: karma})
+		case "account_age":
+			age, err := parseAge(operand)
+			if err != nil {
+				return nil, fail(value, "author account_age: %v", err)
+			}
+			conditions = append(conditions, ageMatch{op: op, age: age})
+		default:
+			return nil, fail(key, "unknown author key %q; use karma or account_age", key.Value)
+		}
+	}
+	return conditions, nil
+}
+
+// parseComparison splits a comparison such as "< 10" into its operator and
+// operand.
+func parseComparison(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	for _, op := range []string{"<=", ">=", "==", "<", ">"} {
+		if strings.HasPrefix(s, op) {
+			return op, strings.TrimSpace(strings.TrimPrefix(s, op)), nil
+		}
+	}
+	return "", "", fmt.Errorf("%q must start with <, <=, >, >= or ==", s)
+}
+
+var ageUnits = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// parseAge parses an account age such as "7 days" or "36h".
+func parseAge(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 2 {
+		n, err := strconv.Atoi(fields[0])
+		unit, known := ageUnits[strings.TrimSuffix(fields[1], "s")]
+		if err == nil && known {
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return 0, fmt.Errorf("%q is not an age such as \"7 days\"", s)
+}
@@ -0,0 +1,117 @@
+// Package clusternode starts a process as a member of a protoactor cluster
+// that hosts the post and comment-thread grains.
+//
+// Members find each other through the automanaged provider: every member
+// serves its kinds over HTTP on ProviderPort and polls the providers listed
+// in Peers, and cluster state is then spread by gossip. Grains are placed by
+// a consistent hash of their entity ID over the live members.
+//
+// Grains keep their state in the proto_actor.Storage of the member hosting
+// them, so every member must be given the same one. Only members in one
+// process can share a Storage until there is a backend shared between
+// processes, and a member that finds another with a different Storage
+// refuses to join.
+package clusternode
+
+import (
+	"errors"
+	"fmt"
+	"reddit-clone/core/proto_actors"
+	"slices"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/cluster"
+	"github.com/asynkron/protoactor-go/cluster/clusterproviders/automanaged"
+	"github.com/asynkron/protoactor-go/cluster/identitylookup/disthash"
+	"github.com/asynkron/protoactor-go/remote"
+)
+
+// DefaultName is the cluster name used when Config.Name is empty.
+const DefaultName = "reddit-clone"
+
+// DefaultRefresh is how often members poll their peers' providers.
+const DefaultRefresh = time.Second
+
+// Config describes one member.
+type Config struct {
+	Name string
+	// Host and Port are where the member serves remote actor traffic.
+	Host string
+	Port int
+	// ProviderPort is where the member answers discovery requests.
+	ProviderPort int
+	// Peers are the "host:providerPort" addresses of all members, this one
+	// included.
+	Peers []string
+	// Refresh overrides DefaultRefresh.
+	Refresh time.Duration
+}
+
+var (
+	ErrNoPeers          = errors.New("cluster member needs at least one peer")
+	ErrStorageNotShared = errors.New("cluster members do not share a storage")
+)
+
+// Start joins the cluster described by config on system, hosting the grain
+// kinds built from options. Every member must be given the same Storage
+// through proto_actor.WithStorage, see proto_actor.Storage. Start waits for
+// the members listed in Peers for up to three refreshes, and leaves the
+// cluster with ErrStorageNotShared if any of those it found was given
+// another Storage.
+func Start(system *actor.ActorSystem, config Config, options ...proto_actor.Option) (*cluster.Cluster, error) {
+	if len(config.Peers) == 0 {
+		return nil, ErrNoPeers
+	}
+	if config.Port <= 0 || config.ProviderPort <= 0 {
+		return nil, fmt.Errorf("invalid cluster ports %d and %d", config.Port, config.ProviderPort)
+	}
+	if config.Name == "" {
+		config.Name = DefaultName
+	}
+	if config.Host == "" {
+		config.Host = "127.0.0.1"
+	}
+	if config.Refresh <= 0 {
+		config.Refresh = DefaultRefresh
+	}
+
+	kinds := proto_actor.ClusterKinds(options...)
+	provider := automanaged.NewWithConfig(config.Refresh, config.ProviderPort, config.Peers...)
+	clusterConfig := cluster.Configure(
+		config.Name,
+		provider,
+		disthash.New(),
+		remote.Configure(config.Host, config.Port),
+		cluster.WithKinds(kinds...),
+	)
+
+	c := cluster.New(system, clusterConfig)
+	c.StartMember()
+	if err := checkMembers(c, kinds, config); err != nil {
+		c.Shutdown(true)
+		return nil, err
+	}
+	return c, nil
+}
+
+// checkMembers waits until c sees every peer, or for three refreshes, and
+// then checks that each member it sees registered the same kinds, which
+// name its Storage.
+func checkMembers(c *cluster.Cluster, kinds []*cluster.Kind, config Config) error {
+	deadline := time.Now().Add(3 * config.Refresh)
+	for {
+		members := c.MemberList.Members().Members()
+		for _, member := range members {
+			for _, kind := range kinds {
+				if !slices.Contains(member.Kinds, kind.Kind) {
+					return fmt.Errorf("%w: member %s", ErrStorageNotShared, member.Address())
+				}
+			}
+		}
+		if len(members) >= len(config.Peers) || time.Now().After(deadline) {
+			return nil
+		}
+		time.Sleep(config.Refresh / 10)
+	}
+}
@@ -0,0 +1,346 @@
+// Package config loads the settings of the server: where it listens, how
+// it stores data, the timeouts of its actors, the limits on content and
+// request rates, how content is screened, and which optional APIs it serves.
+//
+// Each setting has a key such as "actors.request_timeout", which names it in
+// a YAML or TOML file and is the name of its command-line flag, and an
+// environment variable such as ACTOR_REQUEST_TIMEOUT. Defaults are
+// overridden by the file, the file by the environment, and the environment
+// by flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/filter"
+	"reddit-clone/core/ids"
+	"reddit-clone/core/logging"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/ratelimit"
+	"strings"
+	"time"
+)
+
+// Config holds every setting. Default returns the values used when nothing
+// overrides them.
+type Config struct {
+	Server     Server
+	Storage    Storage
+	Cluster    Cluster
+	Actors     Actors
+	Limits     Limits
+	RateLimits RateLimits
+	Filters    Filters
+	Features   Features
+	Logging    Logging
+	Tracing    Tracing
+}
+
+// Server is where the APIs listen.
+type Server struct {
+	// Host is the interface to listen on; empty means all of them.
+	Host     string
+	Port     int
+	GRPCPort int
+	// NodeID tells apart the IDs generated by processes sharing a data set.
+	NodeID int64
+	// TrustedProxies are the IPs and CIDR ranges of the reverse proxies
+	// whose X-Forwarded-For header names the client. The header of any
+	// other peer is ignored, so clients cannot pick the IP they are rate
+	// limited by. None are trusted by default.
+	TrustedProxies []string
+}
+
+// Addr is the listen address of the HTTP server.
+func (s Server) Addr() string {
+	return fmt.Sprintf("%s:%d", s.Host, s.Port)
+}
+
+// GRPCAddr is the listen address of the gRPC server.
+func (s Server) GRPCAddr() string {
+	return fmt.Sprintf("%s:%d", s.Host, s.GRPCPort)
+}
+
+// Storage selects where the managers keep their state. The only backend is
+// "memory".
+type Storage struct {
+	Backend string
+}
+
+// Cluster makes the process a cluster member when Port is set. See
+// clusternode.Config. Members must share their storage, which the memory
+// backend cannot between processes, so Peers may only list this process.
+type Cluster struct {
+	Host         string
+	Port         int
+	ProviderPort int
+	Peers        []string
+}
+
+// Enabled reports whether the process joins a cluster.
+func (c Cluster) Enabled() bool {
+	return c.Port != 0
+}
+
+// Actors are the timeouts and restart policy of the managers and their
+// per-entity actors.
+type Actors struct {
+	// RequestTimeout bounds how long a request waits for an actor's reply.
+	RequestTimeout time.Duration
+	// PassivationTimeout is how long an idle per-entity actor stays active.
+	PassivationTimeout time.Duration
+	Restart            proto_actor.RestartPolicy
+}
+
+// Limits bound the size of what users write and of GraphQL queries. Lengths
+// are in characters.
+type Limits struct {
+	PostLength             int
+	CommentLength          int
+	MessageLength          int
+	ForumTitleLength       int
+	ForumDescriptionLength int
+	ForumSidebarLength     int
+	BioLength              int
+	GraphQLDepth           int
+	GraphQLComplexity      int
+}
+
+// Length returns the length limit named by a binding rule, such as "post"
+// in limit=post.
+func (l Limits) Length(name string) (int, bool) {
+	switch name {
+	case "post":
+		return l.PostLength, true
+	case "comment":
+		return l.CommentLength, true
+	case "message":
+		return l.MessageLength, true
+	case "forum_title":
+		return l.ForumTitleLength, true
+	case "forum_description":
+		return l.ForumDescriptionLength, true
+	case "forum_sidebar":
+		return l.ForumSidebarLength, true
+	case "bio":
+		return l.BioLength, true
+	}
+	return 0, false
+}
+
+// RateLimits bound how fast clients may use the API. Each client IP, each
+// user, each user's posts, comments, votes and messages, and the posts of
+// each forum have their own Rate. Users with at least TrustedKarma karma get
+// TrustedFactor times the burst and refill rate.
+type RateLimits struct {
+	Enabled       bool
+	IP            ratelimit.Rate
+	User          ratelimit.Rate
+	Post          ratelimit.Rate
+	Comment       ratelimit.Rate
+	Vote          ratelimit.Rate
+	Message       ratelimit.Rate
+	Forum         ratelimit.Rate
+	TrustedKarma  int
+	TrustedFactor float64
+}
+
+// Policy returns the limits as a ratelimit.Policy.
+func (r RateLimits) Policy() ratelimit.Policy {
+	return ratelimit.Policy{
+		Rates: map[ratelimit.Action]ratelimit.Rate{
+			ratelimit.IP:      r.IP,
+			ratelimit.User:    r.User,
+			ratelimit.Post:    r.Post,
+			ratelimit.Comment: r.Comment,
+			ratelimit.Vote:    r.Vote,
+			ratelimit.Message: r.Message,
+			ratelimit.Forum:   r.Forum,
+		},
+		TrustedKarma:  r.TrustedKarma,
+		TrustedFactor: r.TrustedFactor,
+	}
+}
+
+// Filters screen posts, comments and messages before they are stored.
+// Content repeating what its author wrote within DuplicateWindow, or with
+// more than MaxLinks links, is held for review; content with a banned word
+// or a link to a blocked domain is rejected. Forums add their own words and
+// domains to these lists. A zero DuplicateWindow or MaxLinks turns that
+// check off. Held posts and comments are reviewed by the moderators of their
+// forum, and held private messages by the users named in
+// MessageModerators.
+type Filters struct {
+	Enabled           bool
+	DuplicateWindow   time.Duration
+	MaxLinks          int
+	BannedWords       []string
+	BlockedDomains    []string
+	MessageModerators []string
+}
+
+// Pipeline returns the filters as a filter.Pipeline, timing duplicates by c.
+func (f Filters) Pipeline(c clock.Clock) *filter.Pipeline {
+	pipeline := filter.New(filter.NewBannedWords(f.BannedWords), filter.NewBlockedDomains(f.BlockedDomains))
+	if f.DuplicateWindow > 0 {
+		pipeline.Use(filter.NewDuplicates(f.DuplicateWindow, c))
+	}
+	if f.MaxLinks > 0 {
+		pipeline.Use(filter.NewLinks(f.MaxLinks))
+	}
+	return pipeline
+}
+
+// Features turn the optional APIs on and off.
+type Features struct {
+	GraphQL bool
+	GRPC    bool
+	Metrics bool
+}
+
+// Logging is passed to logging.New.
+type Logging struct {
+	Level  string
+	Format string
+}
+
+// Tracing names the span exporter passed to tracing.NewExporter.
+type Tracing struct {
+	Exporter string
+}
+
+// Default returns the settings used when nothing overrides them.
+func Default() *Config {
+	return &Config{
+		Server:  Server{Port: 8080, GRPCPort: 9090},
+		Storage: Storage{Backend: "memory"},
+		Actors: Actors{
+			RequestTimeout:     proto_actor.DefaultRequestTimeout,
+			PassivationTimeout: proto_actor.DefaultPassivationTimeout,
+			Restart:            proto_actor.DefaultRestartPolicy,
+		},
+		Limits: Limits{
+			PostLength:             40000,
+			CommentLength:          10000,
+			MessageLength:          10000,
+			ForumTitleLength:       100,
+			ForumDescriptionLength: 500,
+			ForumSidebarLength:     10000,
+			BioLength:              500,
+			GraphQLDepth:           10,
+			GraphQLComplexity:      10000,
+		},
+		RateLimits: RateLimits{
+			Enabled:       true,
+			IP:            ratelimit.Rate{Every: 50 * time.Millisecond, Burst: 100},
+			User:          ratelimit.Rate{Every: 200 * time.Millisecond, Burst: 50},
+			Post:          ratelimit.Rate{Every: 30 * time.Second, Burst: 5},
+			Comment:       ratelimit.Rate{Every: 5 * time.Second, Burst: 10},
+			Vote:          ratelimit.Rate{Every: time.Second, Burst: 30},
+			Message:       ratelimit.Rate{Every: 10 * time.Second, Burst: 10},
+			Forum:         ratelimit.Rate{Every: time.Second, Burst: 60},
+			TrustedKarma:  100,
+			TrustedFactor: 2,
+		},
+		Filters: Filters{
+			Enabled:         true,
+			DuplicateWindow: 10 * time.Minute,
+			MaxLinks:        10,
+		},
+		Features: Features{GraphQL: true, GRPC: true, Metrics: true},
+		Logging:  Logging{Level: "info", Format: "text"},
+		Tracing:  Tracing{Exporter: "none"},
+	}
+}
+
+// Validate reports every setting whose value cannot be used, joined into
+// one error.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	port := func(key string, port int, optional bool) {
+		check(port > 0 && port < 1<<16 || optional && port == 0, "%s must be between 1 and 65535, got %d", key, port)
+	}
+	positive := func(key string, d time.Duration) {
+		check(d > 0, "%s must be positive, got %v", key, d)
+	}
+
+	port("server.port", c.Server.Port, false)
+	port("server.grpc_port", c.Server.GRPCPort, !c.Features.GRPC)
+	check(c.Server.NodeID >= 0 && c.Server.NodeID <= ids.MaxNode, "server.node_id must be between 0 and %d, got %d", ids.MaxNode, c.Server.NodeID)
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		check(err == nil || net.ParseIP(proxy) != nil, "server.trusted_proxies must list IPs and CIDR ranges, got %q", proxy)
+	}
+	check(c.Storage.Backend == "memory", "storage.backend %q is not supported; the only backend is memory", c.Storage.Backend)
+
+	if c.Cluster.Enabled() {
+		port("cluster.port", c.Cluster.Port, false)
+		port("cluster.provider_port", c.Cluster.ProviderPort, false)
+		check(len(c.Cluster.Peers) > 0, "cluster.peers must list at least one member")
+		check(len(c.Cluster.Peers) <= 1 || c.Storage.Backend != "memory", "cluster.peers lists %d members, but the memory storage backend cannot be shared between processes; list only this member", len(c.Cluster.Peers))
+	}
+
+	positive("actors.request_timeout", c.Actors.RequestTimeout)
+	positive("actors.passivation_timeout", c.Actors.PassivationTimeout)
+	positive("actors.restart_window", c.Actors.Restart.Window)
+	positive("actors.initial_backoff", c.Actors.Restart.InitialBackoff)
+	check(c.Actors.Restart.MaxRestarts >= 0, "actors.max_restarts must not be negative, got %d", c.Actors.Restart.MaxRestarts)
+	check(c.Actors.Restart.MaxBackoff >= c.Actors.Restart.InitialBackoff, "actors.max_backoff must be at least actors.initial_backoff")
+
+	for _, limit := range []struct {
+		key   string
+		value int
+	}{
+		{"limits.post_length", c.Limits.PostLength},
+		{"limits.comment_length", c.Limits.CommentLength},
+		{"limits.message_length", c.Limits.MessageLength},
+		{"limits.forum_title_length", c.Limits.ForumTitleLength},
+		{"limits.forum_description_length", c.Limits.ForumDescriptionLength},
+		{"limits.forum_sidebar_length", c.Limits.ForumSidebarLength},
+		{"limits.bio_length", c.Limits.BioLength},
+		{"limits.graphql_depth", c.Limits.GraphQLDepth},
+		{"limits.graphql_complexity", c.Limits.GraphQLComplexity},
+	} {
+		check(limit.value > 0, "%s must be positive, got %d", limit.key, limit.value)
+	}
+
+	if c.RateLimits.Enabled {
+		for _, rate := range []struct {
+			key  string
+			rate ratelimit.Rate
+		}{
+			{"ip", c.RateLimits.IP},
+			{"user", c.RateLimits.User},
+			{"post", c.RateLimits.Post},
+			{"comment", c.RateLimits.Comment},
+			{"vote", c.RateLimits.Vote},
+			{"message", c.RateLimits.Message},
+			{"forum", c.RateLimits.Forum},
+		} {
+			positive("rate_limits."+rate.key+".every", rate.rate.Every)
+			check(rate.rate.Burst > 0, "rate_limits.%s.burst must be positive, got %d", rate.key, rate.rate.Burst)
+		}
+		check(c.RateLimits.TrustedFactor >= 1, "rate_limits.trusted_factor must be at least 1, got %g", c.RateLimits.TrustedFactor)
+	}
+
+	check(c.Filters.DuplicateWindow >= 0, "filters.duplicate_window must not be negative, got %v", c.Filters.DuplicateWindow)
+	check(c.Filters.MaxLinks >= 0, "filters.max_links must not be negative, got %d", c.Filters.MaxLinks)
+
+	if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level: %v", err))
+	}
+	check(c.Logging.Format == "text" || c.Logging.Format == "json", "logging.format must be text or json, got %q", c.Logging.Format)
+	switch strings.ToLower(c.Tracing.Exporter) {
+	case "", "none", "stdout", "memory":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be none, stdout or memory, got %q", c.Tracing.Exporter))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the configuration file when the -config flag does not.
+const FileEnv = "CONFIG_FILE"
+
+// Loader reads a Config from a file, the environment and the flags it
+// registered.
+type Loader struct {
+	flags *flag.FlagSet
+	path  *string
+}
+
+// NewLoader registers -config and a flag for every setting, named by its
+// key, on flags.
+func NewLoader(flags *flag.FlagSet) *Loader {
+	l := &Loader{flags: flags}
+	l.path = flags.String("config", "", "YAML or TOML configuration `file` (default $"+FileEnv+")")
+	// The flags are bound to a scratch Config; Load copies those that were
+	// set, after the file and the environment.
+	for _, s := range Default().settings() {
+		flags.Var(s.value, s.key, fmt.Sprintf("%s ($%s)", s.usage, s.env))
+	}
+	return l
+}
+
+// Load returns the defaults overridden by the configuration file, then by
+// the environment variables lookupEnv finds, then by the flags set on the
+// command line. It is called after the flag set has been parsed. Load does
+// not validate the result.
+func (l *Loader) Load(lookupEnv func(string) (string, bool)) (*Config, error) {
+	config := Default()
+	path := *l.path
+	if path == "" {
+		path, _ = lookupEnv(FileEnv)
+	}
+	if path != "" {
+		if err := config.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	settings := config.index()
+	for _, s := range settings {
+		if value, ok := lookupEnv(s.env); ok && value != "" {
+			if err := s.value.Set(value); err != nil {
+				return nil, fmt.Errorf("$%s: invalid value %q: %v", s.env, value, err)
+			}
+		}
+	}
+	var err error
+	l.flags.Visit(func(f *flag.Flag) {
+		if s, ok := settings[f.Name]; ok && err == nil {
+			err = s.value.Set(f.Value.String())
+		}
+	})
+	return config, err
+}
+
+// Parse loads the Config from args, which are parsed as command-line flags,
+// and lookupEnv, as a program taking no other flags would.
+func Parse(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	loader := NewLoader(flags)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	return loader.Load(lookupEnv)
+}
+
+func (c *Config) index() map[string]setting {
+	index := make(map[string]setting)
+	for _, s := range c.settings() {
+		index[s.key] = s
+	}
+	return index
+}
+
+// loadFile sets the settings named in the file at path, which is YAML or
+// TOML by its extension. Unknown keys are errors, so a misspelt setting is
+// not silently ignored.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var tree map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return fmt.Errorf("%s: configuration files must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	values := make(map[string]any)
+	flatten("", tree, values)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	settings := c.index()
+	for _, key := range keys {
+		s, ok := settings[key]
+		if !ok {
+			return fmt.Errorf("%s: unknown setting %s", path, key)
+		}
+		if err := s.value.Set(scalar(values[key])); err != nil {
+			return fmt.Errorf("%s: %s: invalid value %v: %v", path, key, values[key], err)
+		}
+	}
+	return nil
+}
+
+// flatten collects the leaves of tree under their dotted keys.
+func flatten(prefix string, tree map[string]any, values map[string]any) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if table, ok := value.(map[string]any); ok {
+			flatten(key, table, values)
+		} else {
+			values[key] = value
+		}
+	}
+}
+
+// scalar spells a value decoded from a file the way it would be written in
+// the environment.
+func scalar(value any) string {
+	if list, ok := value.([]any); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value)
+}
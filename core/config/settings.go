@@ -0,0 +1,196 @@
+package config
+
+import (
+	"flag"
+	"reddit-clone/core/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting binds one field of a Config to its key, environment variable and
+// flag.
+type setting struct {
+	key   string
+	env   string
+	usage string
+	value flag.Getter
+}
+
+// settings lists the settings of c, bound to its fields.
+func (c *Config) settings() []setting {
+	s := []setting{
+		{"server.host", "HOST", "interface to listen on, empty for all", (*stringValue)(&c.Server.Host)},
+		{"server.port", "PORT", "port of the HTTP server", (*intValue)(&c.Server.Port)},
+		{"server.grpc_port", "GRPC_PORT", "port of the gRPC server", (*intValue)(&c.Server.GRPCPort)},
+		{"server.node_id", "NODE_ID", "ID of this process among those sharing a data set", (*int64Value)(&c.Server.NodeID)},
+		{"server.trusted_proxies", "TRUSTED_PROXIES", "comma-separated IPs and CIDR ranges of the proxies whose X-Forwarded-For is believed", (*listValue)(&c.Server.TrustedProxies)},
+
+		{"storage.backend", "STORAGE_BACKEND", "where managers keep their state: memory", (*stringValue)(&c.Storage.Backend)},
+
+		{"cluster.host", "CLUSTER_HOST", "host serving remote actor traffic", (*stringValue)(&c.Cluster.Host)},
+		{"cluster.port", "CLUSTER_PORT", "port serving remote actor traffic, 0 to run alone", (*intValue)(&c.Cluster.Port)},
+		{"cluster.provider_port", "CLUSTER_PROVIDER_PORT", "port answering discovery requests", (*intValue)(&c.Cluster.ProviderPort)},
+		{"cluster.peers", "CLUSTER_PEERS", "comma-separated host:provider_port of every member", (*listValue)(&c.Cluster.Peers)},
+
+		{"actors.request_timeout", "ACTOR_REQUEST_TIMEOUT", "how long a request waits for an actor's reply", (*durationValue)(&c.Actors.RequestTimeout)},
+		{"actors.passivation_timeout", "ACTOR_PASSIVATION_TIMEOUT", "how long an idle per-entity actor stays active", (*durationValue)(&c.Actors.PassivationTimeout)},
+		{"actors.max_restarts", "ACTOR_MAX_RESTARTS", "failures within the restart window before an actor is stopped, 0 for no limit", (*intValue)(&c.Actors.Restart.MaxRestarts)},
+		{"actors.restart_window", "ACTOR_RESTART_WINDOW", "window in which failures are counted", (*durationValue)(&c.Actors.Restart.Window)},
+		{"actors.initial_backoff", "ACTOR_INITIAL_BACKOFF", "delay before restarting after a first failure", (*durationValue)(&c.Actors.Restart.InitialBackoff)},
+		{"actors.max_backoff", "ACTOR_MAX_BACKOFF", "longest delay before a restart", (*durationValue)(&c.Actors.Restart.MaxBackoff)},
+
+		{"limits.post_length", "MAX_POST_LENGTH", "longest post, in characters", (*intValue)(&c.Limits.PostLength)},
+		{"limits.comment_length", "MAX_COMMENT_LENGTH", "longest comment, in characters", (*intValue)(&c.Limits.CommentLength)},
+		{"limits.message_length", "MAX_MESSAGE_LENGTH", "longest private message, in characters", (*intValue)(&c.Limits.MessageLength)},
+		{"limits.forum_title_length", "MAX_FORUM_TITLE_LENGTH", "longest forum title, in characters", (*intValue)(&c.Limits.ForumTitleLength)},
+		{"limits.forum_description_length", "MAX_FORUM_DESCRIPTION_LENGTH", "longest forum description, in characters", (*intValue)(&c.Limits.ForumDescriptionLength)},
+		{"limits.forum_sidebar_length", "MAX_FORUM_SIDEBAR_LENGTH", "longest forum sidebar, in characters", (*intValue)(&c.Limits.ForumSidebarLength)},
+		{"limits.bio_length", "MAX_BIO_LENGTH", "longest profile bio, in characters", (*intValue)(&c.Limits.BioLength)},
+		{"limits.graphql_depth", "MAX_GRAPHQL_DEPTH", "deepest GraphQL query", (*intValue)(&c.Limits.GraphQLDepth)},
+		{"limits.graphql_complexity", "MAX_GRAPHQL_COMPLEXITY", "most complex GraphQL query", (*intValue)(&c.Limits.GraphQLComplexity)},
+
+		{"rate_limits.enabled", "RATE_LIMITS", "limit the rate of requests", (*boolValue)(&c.RateLimits.Enabled)},
+	}
+	for _, rate := range []struct {
+		name, usage string
+		rate        *ratelimit.Rate
+	}{
+		{"ip", "requests per client IP", &c.RateLimits.IP},
+		{"user", "writes per user", &c.RateLimits.User},
+		{"post", "posts per user", &c.RateLimits.Post},
+		{"comment", "comments per user", &c.RateLimits.Comment},
+		{"vote", "votes per user", &c.RateLimits.Vote},
+		{"message", "private messages per user", &c.RateLimits.Message},
+		{"forum", "posts per forum", &c.RateLimits.Forum},
+	} {
+		env := "RATE_LIMIT_" + strings.ToUpper(rate.name)
+		s = append(s,
+			setting{"rate_limits." + rate.name + ".every", env + "_EVERY", "time to earn one more of the " + rate.usage, (*durationValue)(&rate.rate.Every)},
+			setting{"rate_limits." + rate.name + ".burst", env + "_BURST", "most " + rate.usage + " allowed at once", (*intValue)(&rate.rate.Burst)},
+		)
+	}
+	return append(s,
+		setting{"rate_limits.trusted_karma", "RATE_LIMIT_TRUSTED_KARMA", "karma from which a user's limits are relaxed", (*intValue)(&c.RateLimits.TrustedKarma)},
+		setting{"rate_limits.trusted_factor", "RATE_LIMIT_TRUSTED_FACTOR", "how many times more a trusted user may do", (*floatValue)(&c.RateLimits.TrustedFactor)},
+
+		setting{"filters.enabled", "FILTERS", "screen posts, comments and messages before they are stored", (*boolValue)(&c.Filters.Enabled)},
+		setting{"filters.duplicate_window", "FILTER_DUPLICATE_WINDOW", "how long repeating one's own content is held for review, 0 to allow it", (*durationValue)(&c.Filters.DuplicateWindow)},
+		setting{"filters.max_links", "FILTER_MAX_LINKS", "most links before content is held for review, 0 for no limit", (*intValue)(&c.Filters.MaxLinks)},
+		setting{"filters.banned_words", "FILTER_BANNED_WORDS", "comma-separated words and phrases rejected everywhere", (*listValue)(&c.Filters.BannedWords)},
+		setting{"filters.blocked_domains", "FILTER_BLOCKED_DOMAINS", "comma-separated domains whose links are rejected everywhere", (*listValue)(&c.Filters.BlockedDomains)},
+		setting{"filters.message_moderators", "FILTER_MESSAGE_MODERATORS", "comma-separated usernames of the users who review held private messages", (*listValue)(&c.Filters.MessageModerators)},
+
+		setting{"features.graphql", "GRAPHQL", "serve the GraphQL API", (*boolValue)(&c.Features.GraphQL)},
+		setting{"features.grpc", "GRPC", "serve the gRPC API", (*boolValue)(&c.Features.GRPC)},
+		setting{"features.metrics", "METRICS", "serve Prometheus metrics at /metrics", (*boolValue)(&c.Features.Metrics)},
+
+		setting{"logging.level", "LOG_LEVEL", "least severe level logged: debug, info, warn or error", (*stringValue)(&c.Logging.Level)},
+		setting{"logging.format", "LOG_FORMAT", "log format: text or json", (*stringValue)(&c.Logging.Format)},
+
+		setting{"tracing.exporter", "TRACE_EXPORTER", "where spans go: none, stdout or memory", (*stringValue)(&c.Tracing.Exporter)},
+	)
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+func (v *stringValue) String() string     { return string(*v) }
+func (v *stringValue) Get() any           { return string(*v) }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return numError(err)
+	}
+	*v = intValue(n)
+	return nil
+}
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+func (v *intValue) Get() any       { return int(*v) }
+
+type int64Value int64
+
+func (v *int64Value) Set(s string) error {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return numError(err)
+	}
+	*v = int64Value(n)
+	return nil
+}
+func (v *int64Value) String() string { return strconv.FormatInt(int64(*v), 10) }
+func (v *int64Value) Get() any       { return int64(*v) }
+
+type floatValue float64
+
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return numError(err)
+	}
+	*v = floatValue(f)
+	return nil
+}
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+func (v *floatValue) Get() any       { return float64(*v) }
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return numError(err)
+	}
+	*v = boolValue(b)
+	return nil
+}
+func (v *boolValue) String() string   { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) Get() any         { return bool(*v) }
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+// durationValue is written the way time.ParseDuration reads it, such as
+// "1m30s", in files as well as on the command line.
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(d)
+	return nil
+}
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+func (v *durationValue) Get() any       { return time.Duration(*v).String() }
+
+// listValue is comma-separated in the environment and on the command line,
+// and a list in files.
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	*v = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*v = append(*v, item)
+		}
+	}
+	return nil
+}
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+func (v *listValue) Get() any {
+	if *v == nil {
+		return []string{}
+	}
+	return []string(*v)
+}
+
+// numError drops the strconv function name from a parse error.
+func numError(err error) error {
+	if ne, ok := err.(*strconv.NumError); ok {
+		return ne.Err
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Write writes every setting of c to w as a configuration file in format,
+// "yaml" or "toml", that Load reads back to the same Config.
+func (c *Config) Write(w io.Writer, format string) error {
+	tree := make(map[string]any)
+	for _, s := range c.settings() {
+		path := strings.Split(s.key, ".")
+		table := tree
+		for _, name := range path[:len(path)-1] {
+			next, ok := table[name].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				table[name] = next
+			}
+			table = next
+		}
+		table[path[len(path)-1]] = s.value.Get()
+	}
+
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(tree); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "toml":
+		return toml.NewEncoder(w).Encode(tree)
+	}
+	return fmt.Errorf("unknown configuration format %q", format)
+}
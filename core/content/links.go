@@ -0,0 +1,55 @@
+package content
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// linkPattern matches absolute http and https URLs, bare or as the target of
+// a Markdown link, and bare www. addresses.
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>()\[\]"'` + "`" + `]+`)
+
+// Links returns the URLs found in text, in order. Bare www. addresses are
+// taken to be http.
+func Links(text string) []*url.URL {
+	var links []*url.URL
+	for _, match := range linkPattern.FindAllString(text, -1) {
+		match = strings.TrimRight(match, ".,;:!?")
+		if strings.HasPrefix(strings.ToLower(match), "www.") {
+			match = "http://" + match
+		}
+		link, err := url.Parse(match)
+		if err != nil || link.Hostname() == "" {
+			continue
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// Domains returns the lower-cased host of every link in text, in order and
+// without repeats.
+func Domains(text string) []string {
+	var domains []string
+	seen := make(map[string]bool)
+	for _, link := range Links(text) {
+		domain := strings.ToLower(link.Hostname())
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// MatchDomain reports whether host is domain or one of its subdomains.
+// domain may be written with a leading "*." or ".".
+func MatchDomain(host, domain string) bool {
+	domain = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "*"), ".")
+	if domain == "" {
+		return false
+	}
+	host = strings.ToLower(host)
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,379 @@
+package content
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RenderMarkdown converts the Reddit-flavored Markdown subset used for posts,
+// comments and messages into HTML. Raw HTML in the source is always escaped
+// and link targets are restricted to safe schemes, so the output only ever
+// contains the tags generated here.
+func RenderMarkdown(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	var out strings.Builder
+	renderBlocks(&out, strings.Split(source, "\n"))
+	return out.String()
+}
+
+var (
+	fencePattern      = regexp.MustCompile("^\\s*(```+)\\s*([A-Za-z0-9_+-]*)\\s*$")
+	headingPattern    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	rulePattern       = regexp.MustCompile(`^\s*(?:-{3,}|\*{3,}|_{3,})\s*$`)
+	bulletPattern     = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedPattern    = regexp.MustCompile(`^\s*(\d{1,9})[.)]\s+(.*)$`)
+	quotePattern      = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	tableDelimPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	bareURLPattern    = regexp.MustCompile(`^https?://[^\s<>()\[\]]+[^\s<>()\[\].,;:!?'"]`)
+)
+
+func renderBlocks(out *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fencePattern.MatchString(line):
+			m := fencePattern.FindStringSubmatch(line)
+			i++
+			var code []string
+			for i < len(lines) && strings.TrimSpace(lines[i]) != m[1] {
+				code = append(code, lines[i])
+				i++
+			}
+			i++
+			if m[2] != "" {
+				out.WriteString(`<pre><code class="language-` + m[2] + `">`)
+			} else {
+				out.WriteString("<pre><code>")
+			}
+			if len(code) > 0 {
+				out.WriteString(html.EscapeString(strings.Join(code, "\n")) + "\n")
+			}
+			out.WriteString("</code></pre>\n")
+
+		case isQuoteLine(line):
+			var inner []string
+			for i < len(lines) && isQuoteLine(lines[i]) {
+				inner = append(inner, quotePattern.FindStringSubmatch(lines[i])[1])
+				i++
+			}
+			out.WriteString("<blockquote>\n")
+			renderBlocks(out, inner)
+			out.WriteString("</blockquote>\n")
+
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			out.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">\n")
+			i++
+
+		case rulePattern.MatchString(line):
+			out.WriteString("<hr>\n")
+			i++
+
+		case i+1 < len(lines) && strings.Contains(line, "|") && tableDelimPattern.MatchString(lines[i+1]):
+			i = renderTable(out, lines, i)
+
+		case bulletPattern.MatchString(line):
+			out.WriteString("<ul>\n")
+			for i < len(lines) && bulletPattern.MatchString(lines[i]) {
+				out.WriteString("<li>" + renderInline(bulletPattern.FindStringSubmatch(lines[i])[1]) + "</li>\n")
+				i++
+			}
+			out.WriteString("</ul>\n")
+
+		case orderedPattern.MatchString(line):
+			start, _ := strconv.Atoi(orderedPattern.FindStringSubmatch(line)[1])
+			if start != 1 {
+				out.WriteString(`<ol start="` + strconv.Itoa(start) + `">` + "\n")
+			} else {
+				out.WriteString("<ol>\n")
+			}
+			for i < len(lines) && orderedPattern.MatchString(lines[i]) {
+				out.WriteString("<li>" + renderInline(orderedPattern.FindStringSubmatch(lines[i])[2]) + "</li>\n")
+				i++
+			}
+			out.WriteString("</ol>\n")
+
+		default:
+			var para []string
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !startsBlock(lines, i) {
+				para = append(para, strings.TrimSpace(lines[i]))
+				i++
+			}
+			if len(para) == 0 {
+				para = append(para, strings.TrimSpace(line))
+				i++
+			}
+			out.WriteString("<p>" + renderInline(strings.Join(para, "\n")) + "</p>\n")
+		}
+	}
+}
+
+// isQuoteLine reports whether line opens a blockquote. A line that starts
+// with an inline spoiler (>!text!<) is a paragraph, not a quote.
+func isQuoteLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, ">!") && strings.Contains(trimmed[2:], "!<") {
+		return false
+	}
+	return quotePattern.MatchString(line)
+}
+
+func startsBlock(lines []string, i int) bool {
+	line := lines[i]
+	return fencePattern.MatchString(line) ||
+		isQuoteLine(line) ||
+		headingPattern.MatchString(line) ||
+		rulePattern.MatchString(line) ||
+		bulletPattern.MatchString(line) ||
+		orderedPattern.MatchString(line) ||
+		(i+1 < len(lines) && strings.Contains(line, "|") && tableDelimPattern.MatchString(lines[i+1]))
+}
+
+func renderTable(out *strings.Builder, lines []string, i int) int {
+	header := splitTableRow(lines[i])
+	var aligns []string
+	for _, cell := range splitTableRow(lines[i+1]) {
+		cell = strings.TrimSpace(cell)
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, "center")
+		case strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, "right")
+		case strings.HasPrefix(cell, ":"):
+			aligns = append(aligns, "left")
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+
+	writeRow := func(cells []string, tag string) {
+		out.WriteString("<tr>")
+		for c := range header {
+			text := ""
+			if c < len(cells) {
+				text = cells[c]
+			}
+			if c < len(aligns) && aligns[c] != "" {
+				out.WriteString("<" + tag + ` style="text-align: ` + aligns[c] + `">`)
+			} else {
+				out.WriteString("<" + tag + ">")
+			}
+			out.WriteString(renderInline(strings.TrimSpace(text)) + "</" + tag + ">")
+		}
+		out.WriteString("</tr>\n")
+	}
+
+	out.WriteString("<table>\n<thead>\n")
+	writeRow(header, "th")
+	out.WriteString("</thead>\n")
+
+	i += 2
+	if i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != "" {
+		out.WriteString("<tbody>\n")
+		for i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != "" {
+			writeRow(splitTableRow(lines[i]), "td")
+			i++
+		}
+		out.WriteString("</tbody>\n")
+	}
+	out.WriteString("</table>\n")
+	return i
+}
+
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	return strings.Split(line, "|")
+}
+
+// renderInline renders emphasis, strikethrough, code spans, spoilers and
+// links within a single block, escaping everything else.
+func renderInline(text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_{}[]()#+-.!~|>", rune(rest[1])):
+			out.WriteString(html.EscapeString(rest[1:2]))
+			i += 2
+			continue
+
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			if end := strings.Index(rest[ticks:], rest[:ticks]); end >= 0 {
+				code := strings.TrimSpace(rest[ticks : ticks+end])
+				out.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += 2*ticks + end
+				continue
+			}
+			out.WriteString(html.EscapeString(rest[:ticks]))
+			i += ticks
+			continue
+
+		case strings.HasPrefix(rest, ">!"):
+			if end := strings.Index(rest[2:], "!<"); end > 0 {
+				out.WriteString(`<span class="spoiler">` + renderInline(rest[2:2+end]) + "</span>")
+				i += end + 4
+				continue
+			}
+
+		case rest[0] == '[':
+			if label, target, n, ok := parseLink(rest); ok {
+				if href, safe := safeHref(target); safe {
+					out.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow">` + renderInline(label) + "</a>")
+				} else {
+					out.WriteString(renderInline(label))
+				}
+				i += n
+				continue
+			}
+
+		case strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://"):
+			if i == 0 || !isWordByte(text[i-1]) {
+				if m := bareURLPattern.FindString(rest); m != "" {
+					out.WriteString(`<a href="` + html.EscapeString(m) + `" rel="nofollow">` + html.EscapeString(m) + "</a>")
+					i += len(m)
+					continue
+				}
+			}
+
+		case strings.HasPrefix(rest, "~~"):
+			if inner, n, ok := delimited(text, i, "~~"); ok {
+				out.WriteString("<del>" + renderInline(inner) + "</del>")
+				i += n
+				continue
+			}
+
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if inner, n, ok := delimited(text, i, rest[:2]); ok {
+				out.WriteString("<strong>" + renderInline(inner) + "</strong>")
+				i += n
+				continue
+			}
+
+		case rest[0] == '*' || rest[0] == '_':
+			if inner, n, ok := delimited(text, i, rest[:1]); ok {
+				out.WriteString("<em>" + renderInline(inner) + "</em>")
+				i += n
+				continue
+			}
+
+		case rest[0] == '\n':
+			out.WriteString("<br>\n")
+			i++
+			continue
+		}
+
+		out.WriteString(html.EscapeString(rest[:1]))
+		i++
+	}
+	return out.String()
+}
+
+// delimited finds the span opened by delim at text[i]. The inner text must
+// not start or end with whitespace, and underscore delimiters must sit on word
+// boundaries so snake_case identifiers are left alone.
+func delimited(text string, i int, delim string) (string, int, bool) {
+	if delim[0] == '_' && i > 0 && isWordByte(text[i-1]) {
+		return "", 0, false
+	}
+	start := i + len(delim)
+	if start >= len(text) || text[start] == ' ' || text[start] == '\n' {
+		return "", 0, false
+	}
+	for j := start + 1; j+len(delim) <= len(text); j++ {
+		if text[j:j+len(delim)] != delim || text[j-1] == ' ' || text[j-1] == '\\' {
+			continue
+		}
+		end := j + len(delim)
+		if delim[0] == '_' && end < len(text) && isWordByte(text[end]) {
+			continue
+		}
+		if len(delim) == 1 && end < len(text) && text[end] == delim[0] {
+			j++
+			continue
+		}
+		return text[start:j], end - i, true
+	}
+	return "", 0, false
+}
+
+func parseLink(text string) (string, string, int, bool) {
+	depth := 0
+	for j := 0; j < len(text); j++ {
+		switch text[j] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				if j+1 >= len(text) || text[j+1] != '(' {
+					return "", "", 0, false
+				}
+				parens := 1
+				for k := j + 2; k < len(text) && text[k] != '\n'; k++ {
+					switch text[k] {
+					case '(':
+						parens++
+					case ')':
+						parens--
+						if parens == 0 {
+							return text[1:j], strings.TrimSpace(text[j+2 : k]), k + 1, true
+						}
+					}
+				}
+				return "", "", 0, false
+			}
+		case '\n':
+			return "", "", 0, false
+		}
+	}
+	return "", "", 0, false
+}
+
+// safeHref allows absolute http, https and mailto links plus site-relative
+// paths. Anything else (javascript:, data:, protocol-relative) is rejected.
+// Browsers read a backslash as a slash, so /\host leaves the site as //host
+// does.
+func safeHref(target string) (string, bool) {
+	if target == "" || strings.ContainsAny(target, " \t\n\x00") {
+		return "", false
+	}
+	if target[0] == '/' {
+		if len(target) > 1 && (target[1] == '/' || target[1] == '\\') {
+			return "", false
+		}
+		return target, true
+	}
+	if strings.HasPrefix(target, "#") {
+		return target, true
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+		return u.String(), true
+	case "mailto":
+		return u.String(), true
+	}
+	return "", false
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
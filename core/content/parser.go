@@ -0,0 +1,99 @@
+package content
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"reddit-clone/schemas"
+)
+
+// referencePatterns match the forms a mention can take. Group 1 is the whole
+// reference and group 2 the bare name.
+var referencePatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{schemas.MentionUser, regexp.MustCompile(`(?:^|[^\w@/])(@([A-Za-z0-9_-]{3,20}))\b`)},
+	{schemas.MentionUser, regexp.MustCompile(`(?:^|[^\w/])(/?u/([A-Za-z0-9_-]{3,20}))\b`)},
+	{schemas.MentionForum, regexp.MustCompile(`(?:^|[^\w/])(/?r/([A-Za-z0-9][A-Za-z0-9_-]{1,20}))\b`)},
+}
+
+// Parse extracts @username and u/username mentions and r/forum references
+// from text. The returned mentions are ordered by position and have no
+// TargetID yet; callers resolve them against the member and forum managers.
+func Parse(text string) []schemas.Mention {
+	var mentions []schemas.Mention
+
+	for _, ref := range referencePatterns {
+		for _, m := range ref.pattern.FindAllStringSubmatchIndex(text, -1) {
+			mentions = append(mentions, schemas.Mention{
+				Kind:  ref.kind,
+				Name:  text[m[4]:m[5]],
+				Start: m[2],
+				End:   m[3],
+			})
+		}
+	}
+
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].Start < mentions[j].Start })
+	return mentions
+}
+
+// MentionedUserIDs returns the distinct resolved user IDs in mentions.
+func MentionedUserIDs(mentions []schemas.Mention) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, m := range mentions {
+		if m.Kind != schemas.MentionUser || m.TargetID == "" || seen[m.TargetID] {
+			continue
+		}
+		seen[m.TargetID] = true
+		ids = append(ids, m.TargetID)
+	}
+	return ids
+}
+
+// Span is a run of rendered content. Href is set when the span is a link to a
+// resolved user or forum.
+type Span struct {
+	Text string `json:"text"`
+	Href string `json:"href,omitempty"`
+}
+
+// Spans splits text into plain and link spans using the resolved mentions.
+// Unresolved mentions are left as plain text.
+func Spans(text string, mentions []schemas.Mention) []Span {
+	var spans []Span
+	var plain strings.Builder
+	pos := 0
+
+	for _, m := range mentions {
+		if m.TargetID == "" || m.Start < pos || m.End > len(text) {
+			continue
+		}
+		plain.WriteString(text[pos:m.Start])
+		if plain.Len() > 0 {
+			spans = append(spans, Span{Text: plain.String()})
+			plain.Reset()
+		}
+		spans = append(spans, Span{Text: text[m.Start:m.End], Href: mentionHref(m)})
+		pos = m.End
+	}
+
+	plain.WriteString(text[pos:])
+	if plain.Len() > 0 {
+		spans = append(spans, Span{Text: plain.String()})
+	}
+	return spans
+}
+
+func mentionHref(m schemas.Mention) string {
+	switch m.Kind {
+	case schemas.MentionUser:
+		return "/users/" + m.TargetID
+	case schemas.MentionForum:
+		return "/r/" + strings.ToLower(m.Name)
+	}
+	return ""
+}
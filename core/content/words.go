@@ -0,0 +1,32 @@
+package content
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words lower-cases text and reduces it to its words, so that text differing
+// only in case, spacing or punctuation compares equal.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// HasPhrase reports whether phrase occurs in words as a run of whole words.
+// Both are as returned by Words.
+func HasPhrase(words, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		match := true
+		for j, word := range phrase {
+			if words[i+j] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"reddit-clone/core/clock"
 	proto_actor "reddit-clone/core/proto_actors"
 	"reddit-clone/schemas"
 	"sync"
@@ -15,6 +16,7 @@ type Engine struct {
 	Mutex        sync.Mutex                    
 	System       *actor.ActorSystem
 	PostActorPID *actor.PID
+	Clock        clock.Clock
 }
 
 
@@ -24,6 +26,7 @@ func NewEngine() *Engine {
 		Subreddits: make(map[string]*schemas.Subreddit),
 		Messages:   make(map[string][]schemas.Message),
 		System:     actor.NewActorSystem(),
+		Clock:      clock.Real,
 	}
 }
 
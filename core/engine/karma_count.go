@@ -10,10 +10,10 @@ func (e *Engine) AddUpvote(postID, userID string) error {
         return errors.New("post not found")
     }
 
-    post.AddUpvote()
+    post.AddUpvote(e.Clock.Now())
     author, exists := e.Users[post.AuthorID]
     if exists {
-        author.IncrementKarma(1)
+        author.IncrementKarma(1, e.Clock.Now())
     }
     return nil
 }
@@ -25,10 +25,10 @@ func (e *Engine) AddDownvote(postID, userID string) error {
         return errors.New("post not found")
     }
 
-    post.AddDownvote()
+    post.AddDownvote(e.Clock.Now())
     author, exists := e.Users[post.AuthorID]
     if exists {
-        author.DecrementKarma(1)
+        author.DecrementKarma(1, e.Clock.Now())
     }
     return nil
 }
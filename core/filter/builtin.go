@@ -0,0 +1,176 @@
+package filter
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/content"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many records pass between sweeps of expired hashes.
+const sweepEvery = 1024
+
+// Duplicates holds content that repeats what the same author posted,
+// commented or sent within a window. It remembers a hash of the normalized
+// text of everything recorded, per author and kind. Check reserves the hash
+// of the content it allows, so the same text submitted twice at once is
+// held the second time.
+type Duplicates struct {
+	window time.Duration
+	clock  clock.Clock
+
+	mutex   sync.Mutex
+	seen    map[[sha256.Size]byte]sighting
+	records int
+}
+
+// sighting is when a hash was last checked or recorded. reservedBy is the
+// content that reserved it in Check until that content is recorded or
+// released.
+type sighting struct {
+	at         time.Time
+	reservedBy *Content
+}
+
+func NewDuplicates(window time.Duration, c clock.Clock) *Duplicates {
+	return &Duplicates{window: window, clock: c, seen: make(map[[sha256.Size]byte]sighting)}
+}
+
+func (d *Duplicates) Name() string { return "duplicate" }
+
+func (d *Duplicates) Check(c *Content) (Verdict, string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	key, now := d.key(c), d.clock.Now()
+	if seen, exists := d.seen[key]; exists && now.Sub(seen.at) < d.window {
+		return Hold, fmt.Sprintf("repeats a %s by the same author from the last %v", c.Kind, d.window)
+	}
+	d.seen[key] = sighting{at: now, reservedBy: c}
+	return Allow, ""
+}
+
+func (d *Duplicates) Record(c *Content) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.clock.Now()
+	d.seen[d.key(c)] = sighting{at: now}
+	d.records++
+	if d.records%sweepEvery == 0 {
+		for key, seen := range d.seen {
+			if now.Sub(seen.at) >= d.window {
+				delete(d.seen, key)
+			}
+		}
+	}
+}
+
+// Release forgets the hash c reserved in Check, unless other content has
+// been recorded with it since.
+func (d *Duplicates) Release(c *Content) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	key := d.key(c)
+	if seen, exists := d.seen[key]; exists && seen.reservedBy == c {
+		delete(d.seen, key)
+	}
+}
+
+// Len returns the number of hashes remembered.
+func (d *Duplicates) Len() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return len(d.seen)
+}
+
+func (d *Duplicates) key(c *Content) [sha256.Size]byte {
+	var author string
+	if c.Author != nil {
+		author = c.Author.ID
+	}
+	return sha256.Sum256([]byte(c.Kind + "\x00" + author + "\x00" + strings.Join(content.Words(c.Text), " ")))
+}
+
+// BannedWords rejects content containing a banned word or phrase, from the
+// site-wide list or the forum's. Matching ignores case and punctuation and
+// only matches whole words, so "ass" does not match "class".
+type BannedWords struct {
+	words []string
+}
+
+func NewBannedWords(words []string) *BannedWords {
+	return &BannedWords{words: words}
+}
+
+func (b *BannedWords) Name() string { return "banned_words" }
+
+func (b *BannedWords) Check(c *Content) (Verdict, string) {
+	text := content.Words(c.Text)
+	lists := [][]string{b.words}
+	if c.Forum != nil {
+		lists = append(lists, c.Forum.BannedWords)
+	}
+	for _, list := range lists {
+		for _, banned := range list {
+			if phrase := content.Words(banned); len(phrase) > 0 && content.HasPhrase(text, phrase) {
+				return Reject, fmt.Sprintf("contains the banned word %q", strings.Join(phrase, " "))
+			}
+		}
+	}
+	return Allow, ""
+}
+
+// BlockedDomains rejects content linking to a blocked domain or any of its
+// subdomains, from the site-wide list or the forum's.
+type BlockedDomains struct {
+	domains []string
+}
+
+func NewBlockedDomains(domains []string) *BlockedDomains {
+	return &BlockedDomains{domains: domains}
+}
+
+func (b *BlockedDomains) Name() string { return "blocked_domains" }
+
+func (b *BlockedDomains) Check(c *Content) (Verdict, string) {
+	blocked := b.domains
+	if c.Forum != nil {
+		blocked = append(append([]string(nil), blocked...), c.Forum.BlockedDomains...)
+	}
+	if len(blocked) == 0 {
+		return Allow, ""
+	}
+	for _, host := range content.Domains(c.Text) {
+		for _, domain := range blocked {
+			if content.MatchDomain(host, domain) {
+				return Reject, fmt.Sprintf("links to the blocked domain %s", host)
+			}
+		}
+	}
+	return Allow, ""
+}
+
+// Links holds content with more than a number of links, which is typical of
+// link spam.
+type Links struct {
+	max int
+}
+
+func NewLinks(max int) *Links {
+	return &Links{max: max}
+}
+
+func (l *Links) Name() string { return "links" }
+
+func (l *Links) Check(c *Content) (Verdict, string) {
+	if n := len(content.Links(c.Text)); n > l.max {
+		return Hold, fmt.Sprintf("has %d links, more than %d", n, l.max)
+	}
+	return Allow, ""
+}
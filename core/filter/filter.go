@@ -0,0 +1,118 @@
+// Package filter screens posts, comments and messages before they are
+// stored. A Pipeline runs every Filter on the content and combines their
+// decisions: content may be allowed, held for review in the modqueue, where
+// it stays out of listings until a moderator approves it, or rejected
+// outright.
+package filter
+
+import "reddit-clone/schemas"
+
+// Content is what is screened: the text an author is about to post,
+// comment or send.
+type Content struct {
+	// Kind is schemas.KindPost, KindComment or KindMessage.
+	Kind   string
+	Author *schemas.Account
+	// Forum is the forum the post or comment goes to; nil for messages.
+	Forum *schemas.Subreddit
+	Text  string
+}
+
+// Verdict is what becomes of screened content. Later verdicts are stricter.
+type Verdict int
+
+const (
+	Allow Verdict = iota
+	// Hold stores the content but keeps it out of sight until it is
+	// approved. Its author is not told.
+	Hold
+	// Reject refuses the content with the Decision's reason.
+	Reject
+)
+
+var verdictNames = map[Verdict]string{Allow: "allow", Hold: "hold", Reject: "reject"}
+
+func (v Verdict) String() string {
+	return verdictNames[v]
+}
+
+// Decision is the outcome of screening. Filter names the filter that decided
+// and Reason says why; both are empty when the content is allowed.
+type Decision struct {
+	Verdict Verdict
+	Filter  string
+	Reason  string
+}
+
+// Filter is one check of a Pipeline. Check must be safe for concurrent use.
+type Filter interface {
+	// Name identifies the filter in decisions and in the modqueue.
+	Name() string
+	// Check returns the verdict of the filter on content and, unless it is
+	// Allow, why.
+	Check(content *Content) (Verdict, string)
+}
+
+// Recorder is implemented by filters that remember content, such as
+// Duplicates. They reserve the content they allow in Check, so that two
+// submissions checked at once cannot both pass. Pipeline.Record confirms
+// the content once it is stored, and Pipeline.Release drops the reservation
+// when it is not.
+type Recorder interface {
+	Record(content *Content)
+	Release(content *Content)
+}
+
+// Pipeline runs filters in order. The zero Pipeline allows everything.
+type Pipeline struct {
+	filters []Filter
+}
+
+// New returns a pipeline running filters in order.
+func New(filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters}
+}
+
+// Use appends filters to the pipeline. It must not be called while the
+// pipeline is in use.
+func (p *Pipeline) Use(filters ...Filter) {
+	p.filters = append(p.filters, filters...)
+}
+
+// Check screens content. The first filter to reject it decides; otherwise
+// the first to hold it does. Rejected content is released, so the caller
+// only has to Record or Release content that was allowed or held.
+func (p *Pipeline) Check(content *Content) Decision {
+	var decision Decision
+	for _, f := range p.filters {
+		verdict, reason := f.Check(content)
+		if verdict > decision.Verdict {
+			decision = Decision{Verdict: verdict, Filter: f.Name(), Reason: reason}
+		}
+		if verdict == Reject {
+			p.Release(content)
+			break
+		}
+	}
+	return decision
+}
+
+// Record tells the filters that content was stored, whether it was allowed
+// or held.
+func (p *Pipeline) Record(content *Content) {
+	for _, f := range p.filters {
+		if recorder, ok := f.(Recorder); ok {
+			recorder.Record(content)
+		}
+	}
+}
+
+// Release tells the filters that content they checked was not stored after
+// all, so they forget what they reserved for it.
+func (p *Pipeline) Release(content *Content) {
+	for _, f := range p.filters {
+		if recorder, ok := f.(Recorder); ok {
+			recorder.Release(content)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package ids generates the identifiers used for every stored entity.
+//
+// IDs have the form "<prefix>_<19 digits>". The digits are a zero-padded
+// Snowflake value, so IDs with the same prefix sort lexicographically in
+// creation order and can be used directly as pagination cursors.
+package ids
+
+import (
+	"errors"
+	"fmt"
+	"reddit-clone/core/clock"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+
+	MaxNode     = 1<<nodeBits - 1
+	maxSequence = 1<<sequenceBits - 1
+)
+
+// Epoch is the zero point of the timestamp embedded in generated IDs.
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var ErrNodeOutOfRange = fmt.Errorf("node ID must be between 0 and %d", MaxNode)
+
+// Generator hands out unique IDs. Implementations must be safe for
+// concurrent use by several actors.
+type Generator interface {
+	NewID(prefix string) string
+}
+
+// Snowflake packs milliseconds since Epoch, a node ID and a per-millisecond
+// sequence into 63 bits. IDs from distinct nodes never collide, and IDs
+// from one node strictly increase even if the wall clock steps backwards.
+type Snowflake struct {
+	mutex    sync.Mutex
+	node     int64
+	clock    clock.Clock
+	lastTick int64
+	sequence int64
+}
+
+func NewSnowflake(node int64) (*Snowflake, error) {
+	return NewSnowflakeWithClock(node, clock.Real)
+}
+
+// NewSnowflakeWithClock is NewSnowflake with an explicit time source, for
+// deterministic tests.
+func NewSnowflakeWithClock(node int64, c clock.Clock) (*Snowflake, error) {
+	if node < 0 || node > MaxNode {
+		return nil, ErrNodeOutOfRange
+	}
+	return &Snowflake{node: node, clock: c, lastTick: -1}, nil
+}
+
+func (s *Snowflake) NewID(prefix string) string {
+	return Format(prefix, s.next())
+}
+
+func (s *Snowflake) next() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tick := s.clock.Now().Sub(Epoch).Milliseconds()
+	if tick <= s.lastTick {
+		// Same millisecond, or the clock went backwards: keep counting from
+		// the last tick, borrowing the next millisecond once the sequence
+		// is exhausted rather than blocking.
+		tick = s.lastTick
+		s.sequence++
+		if s.sequence > maxSequence {
+			tick++
+			s.sequence = 0
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastTick = tick
+	return tick<<(nodeBits+sequenceBits) | s.node<<sequenceBits | s.sequence
+}
+
+// Sequential numbers IDs 1, 2, 3, ... and is meant for tests that need
+// predictable IDs.
+type Sequential struct {
+	mutex sync.Mutex
+	count int64
+}
+
+func NewSequential() *Sequential {
+	return &Sequential{}
+}
+
+func (s *Sequential) NewID(prefix string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.count++
+	return Format(prefix, s.count)
+}
+
+// Format renders value as a prefixed, fixed-width ID.
+func Format(prefix string, value int64) string {
+	return fmt.Sprintf("%s_%019d", prefix, value)
+}
+
+// Parse splits an ID produced by Format into its prefix and numeric value.
+func Parse(id string) (string, int64, error) {
+	cut := strings.LastIndexByte(id, '_')
+	if cut < 0 {
+		return "", 0, errors.New("malformed ID")
+	}
+	value, err := strconv.ParseInt(id[cut+1:], 10, 64)
+	if err != nil || value < 0 {
+		return "", 0, errors.New("malformed ID")
+	}
+	return id[:cut], value, nil
+}
+
+// Timestamp reports when a Snowflake ID was generated, to the millisecond.
+func Timestamp(id string) (time.Time, error) {
+	_, value, err := Parse(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return Epoch.Add(time.Duration(value>>(nodeBits+sequenceBits)) * time.Millisecond), nil
+}
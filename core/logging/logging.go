@@ -0,0 +1,236 @@
+// Package logging builds the service's slog loggers. Records carry the ID of
+// the request they were logged for, which travels with the request into the
+// headers of the actor messages it causes, and attributes that hold user
+// content or credentials are redacted.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the HTTP header, and the gRPC metadata key, that
+// carries a request ID in and out of the service.
+const RequestIDHeader = "X-Request-ID"
+
+// messageHeader is the key of the request ID in actor message headers.
+const messageHeader = "request-id"
+
+// Redacted replaces the value of a redacted attribute.
+const Redacted = "[redacted]"
+
+// redactedKeys are the attribute keys whose values are never written. They
+// name user content and credentials, wherever they appear in a group.
+var redactedKeys = map[string]bool{
+	"text":          true,
+	"content":       true,
+	"body":          true,
+	"password":      true,
+	"token":         true,
+	"secret":        true,
+	"cookie":        true,
+	"authorization": true,
+	"session":       true,
+}
+
+// Config selects the level and format of a logger.
+type Config struct {
+	// Level is the minimum level written: debug, info, warn or error.
+	Level string
+	// Format is "text" or "json".
+	Format string
+	// Output defaults to standard error.
+	Output io.Writer
+}
+
+// New returns a logger for config. It fails on an unknown level or format.
+func New(config Config) (*slog.Logger, error) {
+	level, err := ParseLevel(config.Level)
+	if err != nil {
+		return nil, err
+	}
+	output := config.Output
+	if output == nil {
+		output = os.Stderr
+	}
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+
+	var handler slog.Handler
+	switch strings.ToLower(config.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(output, options)
+	case "json":
+		handler = slog.NewJSONHandler(output, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", config.Format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// ParseLevel parses a level name. The empty string is info.
+func ParseLevel(name string) (slog.Level, error) {
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if redactedKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, Redacted)
+	}
+	return attr
+}
+
+// contextHandler adds the request ID and trace ID of the context a record
+// was logged with.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx that carries id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID is the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// ValidRequestID reports whether an ID supplied by a client may be used:
+// at most 64 letters, digits, dashes and underscores.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Inject adds the request ID of ctx to header, the headers of an actor
+// message.
+func Inject(ctx context.Context, header map[string]string) {
+	if id := RequestID(ctx); id != "" {
+		header[messageHeader] = id
+	}
+}
+
+// ForMessage returns logger with the request ID of the message being
+// handled, if it has one.
+func ForMessage(logger *slog.Logger, ctx actor.Context) *slog.Logger {
+	if header := ctx.MessageHeader(); header != nil {
+		if id := header.Get(messageHeader); id != "" {
+			return logger.With("request_id", id)
+		}
+	}
+	return logger
+}
+
+// Stamp copies the request ID of the message being handled onto every
+// message sent while handling it.
+func Stamp(next actor.SenderFunc) actor.SenderFunc {
+	return func(ctx actor.SenderContext, target *actor.PID, envelope *actor.MessageEnvelope) {
+		current := ctx.MessageHeader()
+		if current == nil || current.Get(messageHeader) == "" || envelope.GetHeader(messageHeader) != "" {
+			next(ctx, target, envelope)
+			return
+		}
+		stamped := &actor.MessageEnvelope{Message: envelope.Message, Sender: envelope.Sender}
+		for key, value := range envelope.Header {
+			stamped.SetHeader(key, value)
+		}
+		stamped.SetHeader(messageHeader, current.Get(messageHeader))
+		next(ctx, target, stamped)
+	}
+}
+
+// Instrumentation logs, at debug level, every message an actor handles
+// that belongs to a request, and passes the request ID on with the messages
+// the actor sends.
+type Instrumentation struct {
+	Logger *slog.Logger
+}
+
+// Receiver logs the messages of requests handled by actors of kind.
+func (i Instrumentation) Receiver(kind string) actor.ReceiverMiddleware {
+	return func(next actor.ReceiverFunc) actor.ReceiverFunc {
+		return func(ctx actor.ReceiverContext, envelope *actor.MessageEnvelope) {
+			id := envelope.GetHeader(messageHeader)
+			if id == "" || !i.Logger.Enabled(context.Background(), slog.LevelDebug) {
+				next(ctx, envelope)
+				return
+			}
+			start := time.Now()
+			next(ctx, envelope)
+			i.Logger.Debug("message handled",
+				"request_id", id,
+				"actor", kind,
+				"message", messageName(envelope.Message),
+				"duration", time.Since(start))
+		}
+	}
+}
+
+// Sender stamps outgoing messages with the request ID.
+func (i Instrumentation) Sender(kind string) actor.SenderMiddleware {
+	return Stamp
+}
+
+// Mailbox is nil: messages are logged as they are handled.
+func (i Instrumentation) Mailbox(kind string) actor.MailboxMiddleware {
+	return nil
+}
+
+// messageName is the type name of message without its package, such as
+// "AddPost".
+func messageName(message interface{}) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", message), "*")
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,195 @@
+// Package metrics exports Prometheus metrics for the actors, the HTTP API and
+// the stored entities. A Metrics has its own registry, so several can live
+// in one process, as they do in tests.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"reddit-clone/core/proto_actors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/eventstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "reddit"
+
+// Metrics holds the collectors. It implements proto_actor.Instrumentation,
+// so passing it to proto_actor.WithInstrumentation instruments the managers
+// and per-entity actors.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	messages     *prometheus.CounterVec
+	processing   *prometheus.HistogramVec
+	mailbox      *prometheus.GaugeVec
+	timeouts     *prometheus.CounterVec
+	httpRequests *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "actor_messages_total",
+			Help:      "Messages received by actors, by actor kind and message type.",
+		}, []string{"actor", "message"}),
+		processing: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "actor_message_duration_seconds",
+			Help:      "Time actors spent handling a message, by actor kind and message type.",
+			Buckets:   []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}, []string{"actor", "message"}),
+		mailbox: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "actor_mailbox_depth",
+			Help:      "Messages queued or being handled, summed over the actors of a kind.",
+		}, []string{"actor"}),
+		timeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "request_timeouts_total",
+			Help:      "Requests to actors that got no reply in time, by message type.",
+		}, []string{"message"}),
+		httpRequests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests, by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.messages, m.processing, m.mailbox, m.timeouts, m.httpRequests,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Receiver counts and times the messages an actor of kind handles. Lifecycle
+// and other built-in messages are left out.
+func (m *Metrics) Receiver(kind string) actor.ReceiverMiddleware {
+	return func(next actor.ReceiverFunc) actor.ReceiverFunc {
+		return func(ctx actor.ReceiverContext, envelope *actor.MessageEnvelope) {
+			if builtin(envelope.Message) {
+				next(ctx, envelope)
+				return
+			}
+			message := messageName(envelope.Message)
+			start := time.Now()
+			next(ctx, envelope)
+			m.messages.WithLabelValues(kind, message).Inc()
+			m.processing.WithLabelValues(kind, message).Observe(time.Since(start).Seconds())
+		}
+	}
+}
+
+// Sender is nil: metrics are taken as messages arrive.
+func (m *Metrics) Sender(kind string) actor.SenderMiddleware {
+	return nil
+}
+
+// Mailbox tracks how many messages wait in the mailboxes of kind.
+func (m *Metrics) Mailbox(kind string) actor.MailboxMiddleware {
+	return mailboxDepth{m.mailbox.WithLabelValues(kind)}
+}
+
+// WatchTimeouts counts the proto_actor.AskTimeout events of system until the
+// returned subscription is unsubscribed.
+func (m *Metrics) WatchTimeouts(system *actor.ActorSystem) *eventstream.Subscription {
+	return system.EventStream.Subscribe(func(event interface{}) {
+		if timeout, ok := event.(*proto_actor.AskTimeout); ok {
+			m.timeouts.WithLabelValues(messageName(timeout.Request)).Inc()
+		}
+	})
+}
+
+// ObserveHTTP records one HTTP request. route is the route's pattern rather
+// than the path, so that IDs do not become labels.
+func (m *Metrics) ObserveHTTP(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveStorage exports the number of users, forums, posts, comments and
+// messages in storage, counted at every scrape.
+func (m *Metrics) ObserveStorage(storage *proto_actor.Storage) {
+	m.registry.MustRegister(newStorageCollector(storage))
+}
+
+type mailboxDepth struct {
+	gauge prometheus.Gauge
+}
+
+func (d mailboxDepth) MailboxStarted()             {}
+func (d mailboxDepth) MessagePosted(interface{})   { d.gauge.Inc() }
+func (d mailboxDepth) MessageReceived(interface{}) { d.gauge.Dec() }
+func (d mailboxDepth) MailboxEmpty()               {}
+
+// storageCollector reports the entity counts of a Storage as one gauge per
+// kind of entity.
+type storageCollector struct {
+	storage  *proto_actor.Storage
+	users    *prometheus.Desc
+	forums   *prometheus.Desc
+	posts    *prometheus.Desc
+	comments *prometheus.Desc
+	messages *prometheus.Desc
+}
+
+func newStorageCollector(storage *proto_actor.Storage) *storageCollector {
+	desc := func(kind string) *prometheus.Desc {
+		return prometheus.NewDesc(namespace+"_"+kind, "Number of stored "+kind+".", nil, nil)
+	}
+	return &storageCollector{
+		storage:  storage,
+		users:    desc("users"),
+		forums:   desc("forums"),
+		posts:    desc("posts"),
+		comments: desc("comments"),
+		messages: desc("messages"),
+	}
+}
+
+func (c *storageCollector) Describe(descs chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{c.users, c.forums, c.posts, c.comments, c.messages} {
+		descs <- desc
+	}
+}
+
+func (c *storageCollector) Collect(metrics chan<- prometheus.Metric) {
+	counts := c.storage.Counts()
+	gauge := func(desc *prometheus.Desc, count int) {
+		metrics <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(count))
+	}
+	gauge(c.users, counts.Users)
+	gauge(c.forums, counts.Forums)
+	gauge(c.posts, counts.Posts)
+	gauge(c.comments, counts.Comments)
+	gauge(c.messages, counts.Messages)
+}
+
+func builtin(message interface{}) bool {
+	switch message.(type) {
+	case actor.SystemMessage, actor.AutoReceiveMessage, actor.InfrastructureMessage:
+		return true
+	}
+	return false
+}
+
+// messageName is the type name of message without its package, such as
+// "AddPost".
+func messageName(message interface{}) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", message), "*")
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,180 @@
+package proto_actor
+
+import (
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// entityRouter delivers messages to per-entity actors. activations keeps
+// them as local children; clusterRouter finds them as grains anywhere in a
+// cluster.
+type entityRouter interface {
+	// forward delivers the message to the entity for key. The entity replies
+	// directly to the sender of the current message.
+	forward(ctx actor.Context, key string, message interface{})
+	// handle consumes the router's own bookkeeping messages.
+	handle(ctx actor.Context) bool
+	isActive(key string) bool
+	// count reports how many entities are activated on this node.
+	count() int
+}
+
+// activations gives a router actor virtual-actor semantics over its
+// children: one child per key, spawned on the first message for that key
+// and stopped again once it has been idle for a while.
+//
+// Passivation is a handshake. An idle child asks its parent to passivate
+// it; the parent stops routing to it and poisons it, so everything already
+// forwarded is still processed. Messages for the key that arrive before the
+// child has terminated are buffered and replayed to a fresh activation, which
+// therefore always loads the final state its predecessor wrote.
+type activations struct {
+	props    func(key string) *actor.Props
+	active   map[string]*actor.PID
+	keys     map[string]string
+	draining map[string][]deferredMessage
+}
+
+type deferredMessage struct {
+	message interface{}
+	sender  *actor.PID
+}
+
+// passivate is sent by an idle child to its router.
+type passivate struct {
+	key string
+	pid *actor.PID
+}
+
+func newActivations(props func(key string) *actor.Props) *activations {
+	return &activations{
+		props:    props,
+		active:   make(map[string]*actor.PID),
+		keys:     make(map[string]string),
+		draining: make(map[string][]deferredMessage),
+	}
+}
+
+// forward activates the child for key if needed.
+func (a *activations) forward(ctx actor.Context, key string, message interface{}) {
+	if pending, draining := a.draining[key]; draining {
+		a.draining[key] = append(pending, deferredMessage{message: message, sender: ctx.Sender()})
+		return
+	}
+	ctx.RequestWithCustomSender(a.activate(ctx, key), message, ctx.Sender())
+}
+
+func (a *activations) isActive(key string) bool {
+	_, active := a.active[key]
+	_, draining := a.draining[key]
+	return active || draining
+}
+
+func (a *activations) activate(ctx actor.Context, key string) *actor.PID {
+	if pid, exists := a.active[key]; exists {
+		return pid
+	}
+	pid := ctx.SpawnPrefix(a.props(key), key)
+	a.active[key] = pid
+	a.keys[pid.Id] = key
+	return pid
+}
+
+func (a *activations) count() int {
+	return len(a.active)
+}
+
+// handle processes the lifecycle messages of children.
+func (a *activations) handle(ctx actor.Context) bool {
+	switch msg := ctx.Message().(type) {
+	case *passivate:
+		if pid, exists := a.active[msg.key]; exists && pid.Equal(msg.pid) {
+			delete(a.active, msg.key)
+			a.draining[msg.key] = nil
+			ctx.Poison(msg.pid)
+		}
+		return true
+
+	case *actor.Terminated:
+		key, exists := a.keys[msg.Who.Id]
+		if !exists {
+			return true
+		}
+		delete(a.keys, msg.Who.Id)
+		if pid, active := a.active[key]; active && pid.Equal(msg.Who) {
+			// Stopped without passivating, e.g. after a crash.
+			delete(a.active, key)
+		}
+
+		pending := a.draining[key]
+		delete(a.draining, key)
+		if len(pending) > 0 {
+			pid := a.activate(ctx, key)
+			for _, deferred := range pending {
+				ctx.RequestWithCustomSender(pid, deferred.message, deferred.sender)
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// idleTracker lets a child notice that it has been idle for its timeout
+// and ask its router to passivate it. It is driven by idleCheck messages sent
+// to the child itself rather than by ReceiveTimeout, whose timer callback
+// races with the mailbox.
+type idleTracker struct {
+	key         string
+	timeout     time.Duration
+	lastSeen    time.Time
+	timer       *time.Timer
+	passivating bool
+}
+
+type idleCheck struct{}
+
+// track must see every message the child receives. It returns true when it
+// consumed the message itself.
+func (it *idleTracker) track(ctx actor.Context) bool {
+	switch ctx.Message().(type) {
+	case *actor.Started:
+		it.lastSeen = time.Now()
+		it.schedule(ctx, it.timeout)
+		return false
+
+	case *actor.Stopping, *actor.Restarting:
+		if it.timer != nil {
+			it.timer.Stop()
+		}
+		return false
+
+	case *idleCheck:
+		if it.passivating {
+			return true
+		}
+		if idle := time.Since(it.lastSeen); idle < it.timeout {
+			it.schedule(ctx, it.timeout-idle)
+			return true
+		}
+		it.passivate(ctx)
+		return true
+	}
+
+	it.lastSeen = time.Now()
+	return false
+}
+
+func (it *idleTracker) schedule(ctx actor.Context, after time.Duration) {
+	root, self := ctx.ActorSystem().Root, ctx.Self()
+	it.timer = time.AfterFunc(after, func() {
+		root.Send(self, &idleCheck{})
+	})
+}
+
+// passivate asks the router to stop routing to this child. Messages already
+// on their way are still processed before the child stops.
+func (it *idleTracker) passivate(ctx actor.Context) {
+	it.passivating = true
+	ctx.Send(ctx.Parent(), &passivate{key: it.key, pid: ctx.Self()})
+}
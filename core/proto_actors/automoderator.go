@@ -0,0 +1,77 @@
+package proto_actor
+
+import (
+	"reddit-clone/core/automod"
+	"reddit-clone/core/clock"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// AutoModerator evaluates the AutoModerator rules of forums on new posts and
+// comments. It keeps the rules of each forum it has seen compiled, and
+// compiles them again when their source changes. The compiled rules are only
+// a cache: a restarted AutoModerator compiles them again as it needs them.
+type AutoModerator struct {
+	clock    clock.Clock
+	rulesets map[string]*compiledRules
+}
+
+type compiledRules struct {
+	source  string
+	ruleset *automod.Ruleset
+}
+
+func NewAutoModerator(options ...Option) *AutoModerator {
+	config := newManagerConfig(options)
+	return &AutoModerator{
+		clock:    config.clock,
+		rulesets: make(map[string]*compiledRules),
+	}
+}
+
+// EvaluateContent checks new content against Rules, the AutoModerator rules
+// of the forum ForumID, and replies with an *automod.Result. When ForumID is
+// empty the rules are only compiled for this request, which is how a dry run
+// tries out rules that are not saved.
+type EvaluateContent struct {
+	ForumID         string
+	Rules           string
+	Kind            string
+	Text            string
+	AuthorName      string
+	AuthorKarma     int
+	AuthorCreatedAt time.Time
+}
+
+func (am *AutoModerator) Receive(ctx actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case *EvaluateContent:
+		ruleset, err := am.compile(msg.ForumID, msg.Rules)
+		if err != nil {
+			ctx.Respond(Errorf(Invalid, "%v", err))
+			return
+		}
+		ctx.Respond(ruleset.Evaluate(&automod.Item{
+			Kind:            msg.Kind,
+			Text:            msg.Text,
+			AuthorName:      msg.AuthorName,
+			AuthorKarma:     msg.AuthorKarma,
+			AuthorCreatedAt: msg.AuthorCreatedAt,
+		}, am.clock.Now()))
+	}
+}
+
+func (am *AutoModerator) compile(forumID, source string) (*automod.Ruleset, error) {
+	if compiled, cached := am.rulesets[forumID]; cached && compiled.source == source {
+		return compiled.ruleset, nil
+	}
+	ruleset, err := automod.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	if forumID != "" {
+		am.rulesets[forumID] = &compiledRules{source: source, ruleset: ruleset}
+	}
+	return ruleset, nil
+}
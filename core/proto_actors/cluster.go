@@ -0,0 +1,151 @@
+package proto_actor
+
+import (
+	"reddit-clone/core/proto_actors/wire"
+	"reflect"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/cluster"
+	"google.golang.org/protobuf/proto"
+)
+
+// Cluster kinds of the per-entity grains. Grain identities are entity IDs:
+// post IDs for both kinds, since a thread is keyed by its post.
+const (
+	PostKind   = "post"
+	ThreadKind = "thread"
+)
+
+// storageKindPrefix names the kind every member registers for its Storage.
+// No grain of it is ever activated; members only compare it to tell whether
+// they share a Storage.
+const storageKindPrefix = "storage-"
+
+// ClusterKinds returns the grain kinds a cluster member must register to
+// host posts and comment threads. Options are applied as for the managers;
+// WithStorage should name the Storage shared by all nodes. The kinds also
+// name that Storage, so members given different ones register different
+// kinds.
+func ClusterKinds(options ...Option) []*cluster.Kind {
+	config := newManagerConfig(options)
+	kind := func(name string, props func(string, managerConfig) *actor.Props) *cluster.Kind {
+		return cluster.NewKind(name, config.props(name+"_grain", func() actor.Actor {
+			return &entityGrain{props: func(id string) *actor.Props { return props(id, config) }, timeout: config.timeout}
+		}))
+	}
+	storage := cluster.NewKind(storageKindPrefix+config.storage.id, actor.PropsFromFunc(func(actor.Context) {}))
+	return []*cluster.Kind{kind(PostKind, postProps), kind(ThreadKind, threadProps), storage}
+}
+
+// entityGrain hosts one post or thread actor as a cluster grain. It decodes
+// wire messages from other nodes, asks its entity and encodes the reply. When
+// the entity reports itself idle the grain stops, and the cluster activates
+// a new one on whichever member owns the identity at the next request.
+type entityGrain struct {
+	props   func(id string) *actor.Props
+	timeout time.Duration
+	entity  *actor.PID
+}
+
+func (g *entityGrain) Receive(ctx actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case *cluster.ClusterInit:
+		g.entity = ctx.Spawn(g.props(msg.Identity.Identity))
+
+	case proto.Message:
+		if !grainRequests[reflect.TypeOf(msg)] {
+			return
+		}
+		request, err := DecodeMessage(msg)
+		if err != nil {
+			ctx.Respond(encodeReply(Errorf(Invalid, "%v", err)))
+			return
+		}
+		reply, err := ctx.RequestFuture(g.entity, request, g.timeout).Result()
+		if err != nil {
+			ctx.Respond(encodeReply(Errorf(Unavailable, "%T: %v", request, err)))
+			return
+		}
+		ctx.Respond(encodeReply(reply))
+
+	case *passivate:
+		ctx.Poison(ctx.Self())
+	}
+}
+
+// clusterRouter forwards entity messages to grains through the cluster.
+type clusterRouter struct {
+	cluster *cluster.Cluster
+	kind    string
+}
+
+func newClusterRouter(c *cluster.Cluster, kind string) *clusterRouter {
+	return &clusterRouter{cluster: c, kind: kind}
+}
+
+// forward does not block the manager: the cluster request runs on its own
+// goroutine and the decoded reply is sent to the original sender.
+func (r *clusterRouter) forward(ctx actor.Context, key string, message interface{}) {
+	request, err := EncodeMessage(message)
+	if err != nil || !grainRequests[reflect.TypeOf(request)] {
+		ctx.Respond(Errorf(Internal, "%T cannot be sent to a grain", message))
+		return
+	}
+
+	root, sender := ctx.ActorSystem().Root, ctx.Sender()
+	go func() {
+		var reply interface{}
+		res, err := r.cluster.Request(key, r.kind, request)
+		if err != nil {
+			reply = Errorf(Unavailable, "%s grain %s: %v", r.kind, key, err)
+		} else if wireReply, ok := res.(proto.Message); ok {
+			if reply, err = DecodeMessage(wireReply); err != nil {
+				reply = Errorf(Internal, "%s grain %s: %v", r.kind, key, err)
+			}
+		} else {
+			reply = Errorf(Internal, "unexpected reply %T from %s grain", res, r.kind)
+		}
+		if sender != nil {
+			root.Send(sender, reply)
+		}
+	}()
+}
+
+func (r *clusterRouter) handle(actor.Context) bool {
+	return false
+}
+
+func (r *clusterRouter) isActive(string) bool {
+	return false
+}
+
+func (r *clusterRouter) count() int {
+	if kind, ok := r.cluster.TryGetClusterKind(r.kind); ok {
+		return int(kind.Count())
+	}
+	return 0
+}
+
+// grainRequests lists the wire messages that may be sent to a grain.
+var grainRequests = map[reflect.Type]bool{}
+
+func init() {
+	for _, message := range []proto.Message{
+		&wire.RetrievePost{}, &wire.VotePost{}, &wire.RemovePost{}, &wire.ApprovePost{},
+		&wire.AddComment{}, &wire.FetchComment{}, &wire.VoteComment{}, &wire.RemoveComment{}, &wire.ApproveComment{},
+		&wire.FetchPostComments{},
+	} {
+		grainRequests[reflect.TypeOf(message)] = true
+	}
+}
+
+// encodeReply encodes the reply of an entity, which is an entity, a comment
+// list, a bool or an *Error.
+func encodeReply(reply interface{}) proto.Message {
+	encoded, err := EncodeMessage(reply)
+	if err != nil {
+		return &wire.Error{Code: wire.Error_INTERNAL, Message: err.Error()}
+	}
+	return encoded
+}
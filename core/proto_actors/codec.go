@@ -0,0 +1,432 @@
+package proto_actor
+
+import (
+	"fmt"
+	"reddit-clone/core/automod"
+	"reddit-clone/core/proto_actors/wire"
+	"reddit-clone/schemas"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// EncodeMessage converts a manager request or reply to its protobuf form in
+// package wire, so that it can cross a process boundary. DecodeMessage is its
+// inverse. Errors that are not an *Error are encoded with their CodeOf.
+func EncodeMessage(message interface{}) (proto.Message, error) {
+	switch msg := message.(type) {
+	case *AddForum:
+		return &wire.AddForum{Title: msg.Title, Name: msg.Name, CreatorId: msg.CreatorID}, nil
+	case *RetrieveForum:
+		return &wire.RetrieveForum{ForumId: msg.ForumID}, nil
+	case *RetrieveForumByName:
+		return &wire.RetrieveForumByName{Name: msg.Name}, nil
+	case *RetrieveAllForums:
+		return &wire.RetrieveAllForums{}, nil
+	case *RetrieveForums:
+		return &wire.RetrieveForums{ForumIds: msg.ForumIDs}, nil
+	case *RenameForum:
+		return &wire.RenameForum{ForumId: msg.ForumID, Title: msg.Title, ModeratorId: msg.ModeratorID}, nil
+	case *UpdateForum:
+		return &wire.UpdateForum{
+			ForumId:     msg.ForumID,
+			Description: msg.Info.Description,
+			Sidebar:     msg.Info.Sidebar,
+			Rules:       wire.FromForumRules(msg.Info.Rules),
+			BannerUrl:   msg.Info.BannerURL,
+			IconUrl:     msg.Info.IconURL,
+			Nsfw:        msg.Info.NSFW,
+			ModeratorId: msg.ModeratorID,
+		}, nil
+	case *RemoveForum:
+		return &wire.RemoveForum{ForumId: msg.ForumID}, nil
+	case *JoinForum:
+		return &wire.JoinForum{ForumId: msg.ForumID, UserId: msg.UserID}, nil
+	case *LeaveForum:
+		return &wire.LeaveForum{ForumId: msg.ForumID, UserId: msg.UserID}, nil
+	case *SetForumType:
+		return &wire.SetForumType{ForumId: msg.ForumID, Type: msg.Type, ModeratorId: msg.ModeratorID}, nil
+	case *ApproveUser:
+		return &wire.ApproveUser{ForumId: msg.ForumID, UserId: msg.UserID, ModeratorId: msg.ModeratorID}, nil
+	case *UnapproveUser:
+		return &wire.UnapproveUser{ForumId: msg.ForumID, UserId: msg.UserID, ModeratorId: msg.ModeratorID}, nil
+	case *RequestJoin:
+		return &wire.RequestJoin{ForumId: msg.ForumID, UserId: msg.UserID}, nil
+	case *ResolveJoinRequest:
+		return &wire.ResolveJoinRequest{ForumId: msg.ForumID, UserId: msg.UserID, Accept: msg.Accept, ModeratorId: msg.ModeratorID}, nil
+	case *AddModerator:
+		return &wire.AddModerator{ForumId: msg.ForumID, UserId: msg.UserID, ModeratorId: msg.ModeratorID}, nil
+	case *SetForumFilters:
+		return &wire.SetForumFilters{ForumId: msg.ForumID, BannedWords: msg.BannedWords, BlockedDomains: msg.BlockedDomains, ModeratorId: msg.ModeratorID}, nil
+	case *SetAutoModerator:
+		return &wire.SetAutoModerator{ForumId: msg.ForumID, Rules: msg.Rules, ModeratorId: msg.ModeratorID}, nil
+
+	case *RegisterUser:
+		return &wire.RegisterUser{DisplayName: msg.DisplayName}, nil
+	case *EnsureUser:
+		return &wire.EnsureUser{Username: msg.Username}, nil
+	case *FetchUser:
+		return &wire.FetchUser{ProfileId: msg.ProfileID}, nil
+	case *FetchUserByName:
+		return &wire.FetchUserByName{Username: msg.Username}, nil
+	case *FetchUsers:
+		return &wire.FetchUsers{ProfileIds: msg.ProfileIDs}, nil
+	case *RemoveUser:
+		return &wire.RemoveUser{ProfileId: msg.ProfileID}, nil
+	case *AdjustKarma:
+		return &wire.AdjustKarma{ProfileId: msg.ProfileID, Delta: int32(msg.Delta)}, nil
+	case *UpdateProfile:
+		return &wire.UpdateProfile{ProfileId: msg.ProfileID, Bio: msg.Bio, AvatarUrl: msg.AvatarURL}, nil
+
+	case *AddPost:
+		return &wire.AddPost{ForumId: msg.ForumID, AuthorId: msg.AuthorID, Text: msg.Text, Mentions: wire.FromMentions(msg.Mentions), Held: msg.Held, Flair: msg.Flair}, nil
+	case *RetrievePost:
+		return &wire.RetrievePost{ContentId: msg.ContentID}, nil
+	case *RetrieveAllPosts:
+		return &wire.RetrieveAllPosts{HiddenForums: msg.HiddenForums}, nil
+	case *RetrievePosts:
+		return &wire.RetrievePosts{ContentIds: msg.ContentIDs}, nil
+	case *RetrieveForumPosts:
+		return &wire.RetrieveForumPosts{ForumId: msg.ForumID}, nil
+	case *VotePost:
+		return &wire.VotePost{ContentId: msg.ContentID, Upvote: msg.Upvote}, nil
+	case *RetrieveAuthorPosts:
+		return &wire.RetrieveAuthorPosts{AuthorId: msg.AuthorID, Page: encodePage(msg.Page), HiddenForums: msg.HiddenForums}, nil
+	case *RemovePost:
+		return &wire.RemovePost{ContentId: msg.ContentID}, nil
+	case *ApprovePost:
+		return &wire.ApprovePost{ContentId: msg.ContentID}, nil
+	case *CountActive:
+		return &wire.CountActive{}, nil
+
+	case *SendMessage:
+		return &wire.SendMessage{FromUserId: msg.FromUserID, ToUserId: msg.ToUserID, Body: msg.Body, Held: msg.Held}, nil
+	case *FetchMessages:
+		return &wire.FetchMessages{UserId: msg.UserID}, nil
+	case *RemoveMessage:
+		return &wire.RemoveMessage{MessageId: msg.MessageID}, nil
+	case *DeliverMessage:
+		return &wire.DeliverMessage{MessageId: msg.MessageID}, nil
+
+	case *AddComment:
+		return &wire.AddComment{PostId: msg.PostID, ParentId: msg.ParentID, AuthorId: msg.AuthorID, Content: msg.Content, Mentions: wire.FromMentions(msg.Mentions), Held: msg.Held}, nil
+	case *FetchComment:
+		return &wire.FetchComment{CommentId: msg.CommentID}, nil
+	case *RemoveComment:
+		return &wire.RemoveComment{CommentId: msg.CommentID}, nil
+	case *ApproveComment:
+		return &wire.ApproveComment{CommentId: msg.CommentID}, nil
+	case *FetchPostComments:
+		return &wire.FetchPostComments{PostId: msg.PostID}, nil
+	case *FetchThreads:
+		return &wire.FetchThreads{PostIds: msg.PostIDs}, nil
+	case *VoteComment:
+		return &wire.VoteComment{CommentId: msg.CommentID, Upvote: msg.Upvote}, nil
+	case *FetchAuthorComments:
+		return &wire.FetchAuthorComments{AuthorId: msg.AuthorID, Page: encodePage(msg.Page), HiddenForums: msg.HiddenForums}, nil
+
+	case *PushNotification:
+		return &wire.PushNotification{UserId: msg.UserID, Kind: msg.Kind, SourceId: msg.SourceID, ActorId: msg.ActorID}, nil
+	case *FetchNotifications:
+		return &wire.FetchNotifications{UserId: msg.UserID}, nil
+
+	case *HoldContent:
+		return &wire.HoldContent{Kind: msg.Kind, ContentId: msg.ContentID, ForumId: msg.ForumID, AuthorId: msg.AuthorID, Filter: msg.Filter, Reason: msg.Reason}, nil
+	case *ReportContent:
+		return &wire.ReportContent{Kind: msg.Kind, ContentId: msg.ContentID, ForumId: msg.ForumID, AuthorId: msg.AuthorID, Filter: msg.Filter, Reason: msg.Reason}, nil
+	case *FetchModQueue:
+		return &wire.FetchModQueue{ForumId: msg.ForumID, ModeratorId: msg.ModeratorID}, nil
+	case *ResolveQueueItem:
+		return &wire.ResolveQueueItem{ItemId: msg.ItemID, ModeratorId: msg.ModeratorID}, nil
+	case *RestoreQueueItem:
+		return &wire.RestoreQueueItem{Item: wire.FromQueueItem(msg.Item)}, nil
+
+	case *EvaluateContent:
+		return &wire.EvaluateContent{
+			ForumId:         msg.ForumID,
+			Rules:           msg.Rules,
+			Kind:            msg.Kind,
+			Text:            msg.Text,
+			AuthorName:      msg.AuthorName,
+			AuthorKarma:     int32(msg.AuthorKarma),
+			AuthorCreatedAt: wire.FromTime(msg.AuthorCreatedAt),
+		}, nil
+
+	case *schemas.Subreddit:
+		return wire.FromSubreddit(msg), nil
+	case []*schemas.Subreddit:
+		forums := make([]*wire.Subreddit, len(msg))
+		for i, forum := range msg {
+			forums[i] = wire.FromSubreddit(forum)
+		}
+		return &wire.SubredditList{Forums: forums}, nil
+	case *schemas.Account:
+		return wire.FromAccount(msg), nil
+	case []*schemas.Account:
+		accounts := make([]*wire.Account, len(msg))
+		for i, account := range msg {
+			accounts[i] = wire.FromAccount(account)
+		}
+		return &wire.AccountList{Accounts: accounts}, nil
+	case *schemas.Post:
+		return wire.FromPost(msg), nil
+	case []*schemas.Post:
+		return &wire.PostList{Posts: wire.FromPosts(msg)}, nil
+	case *PostListing:
+		return &wire.PostListing{Posts: wire.FromPosts(msg.Posts), Total: int32(msg.Total), Offset: int32(msg.Offset), NextCursor: msg.NextCursor}, nil
+	case *schemas.Comment:
+		return wire.FromComment(msg), nil
+	case []*schemas.Comment:
+		return &wire.CommentList{Comments: wire.FromComments(msg)}, nil
+	case *CommentListing:
+		return &wire.CommentListing{Comments: wire.FromComments(msg.Comments), Total: int32(msg.Total), Offset: int32(msg.Offset), NextCursor: msg.NextCursor}, nil
+	case *schemas.Message:
+		return wire.FromMessage(msg), nil
+	case []schemas.Message:
+		messages := make([]*wire.Message, len(msg))
+		for i := range msg {
+			messages[i] = wire.FromMessage(&msg[i])
+		}
+		return &wire.MessageList{Messages: messages}, nil
+	case *schemas.Notification:
+		return wire.FromNotification(msg), nil
+	case []*schemas.Notification:
+		notifications := make([]*wire.Notification, len(msg))
+		for i, notification := range msg {
+			notifications[i] = wire.FromNotification(notification)
+		}
+		return &wire.NotificationList{Notifications: notifications}, nil
+	case *schemas.QueueItem:
+		return wire.FromQueueItem(msg), nil
+	case []*schemas.QueueItem:
+		items := make([]*wire.QueueItem, len(msg))
+		for i, item := range msg {
+			items[i] = wire.FromQueueItem(item)
+		}
+		return &wire.QueueItemList{Items: items}, nil
+	case *automod.Result:
+		return &wire.AutoModResult{Action: msg.Action.String(), Rule: msg.Rule, Reason: msg.Reason, Flair: msg.Flair, Comments: msg.Comments, Matched: msg.Matched}, nil
+	case bool:
+		if !msg {
+			return nil, fmt.Errorf("false is not a reply")
+		}
+		return &wire.Removed{}, nil
+	case int:
+		return &wire.ActiveCount{Count: int32(msg)}, nil
+	case error:
+		return &wire.Error{Code: wire.Error_Code(CodeOf(msg)), Message: msg.Error()}, nil
+	}
+	return nil, fmt.Errorf("%T has no wire form", message)
+}
+
+// DecodeMessage converts a message in package wire back to the manager
+// request or reply it encodes.
+func DecodeMessage(message proto.Message) (interface{}, error) {
+	switch msg := message.(type) {
+	case *wire.AddForum:
+		return &AddForum{Title: msg.Title, Name: msg.Name, CreatorID: msg.CreatorId}, nil
+	case *wire.RetrieveForum:
+		return &RetrieveForum{ForumID: msg.ForumId}, nil
+	case *wire.RetrieveForumByName:
+		return &RetrieveForumByName{Name: msg.Name}, nil
+	case *wire.RetrieveAllForums:
+		return &RetrieveAllForums{}, nil
+	case *wire.RetrieveForums:
+		return &RetrieveForums{ForumIDs: msg.ForumIds}, nil
+	case *wire.RenameForum:
+		return &RenameForum{ForumID: msg.ForumId, Title: msg.Title, ModeratorID: msg.ModeratorId}, nil
+	case *wire.UpdateForum:
+		return &UpdateForum{ForumID: msg.ForumId, Info: schemas.ForumInfo{
+			Description: msg.Description,
+			Sidebar:     msg.Sidebar,
+			Rules:       wire.ForumRulesSchema(msg.Rules),
+			BannerURL:   msg.BannerUrl,
+			IconURL:     msg.IconUrl,
+			NSFW:        msg.Nsfw,
+		}, ModeratorID: msg.ModeratorId}, nil
+	case *wire.RemoveForum:
+		return &RemoveForum{ForumID: msg.ForumId}, nil
+	case *wire.JoinForum:
+		return &JoinForum{ForumID: msg.ForumId, UserID: msg.UserId}, nil
+	case *wire.LeaveForum:
+		return &LeaveForum{ForumID: msg.ForumId, UserID: msg.UserId}, nil
+	case *wire.SetForumType:
+		return &SetForumType{ForumID: msg.ForumId, Type: msg.Type, ModeratorID: msg.ModeratorId}, nil
+	case *wire.ApproveUser:
+		return &ApproveUser{ForumID: msg.ForumId, UserID: msg.UserId, ModeratorID: msg.ModeratorId}, nil
+	case *wire.UnapproveUser:
+		return &UnapproveUser{ForumID: msg.ForumId, UserID: msg.UserId, ModeratorID: msg.ModeratorId}, nil
+	case *wire.RequestJoin:
+		return &RequestJoin{ForumID: msg.ForumId, UserID: msg.UserId}, nil
+	case *wire.ResolveJoinRequest:
+		return &ResolveJoinRequest{ForumID: msg.ForumId, UserID: msg.UserId, Accept: msg.Accept, ModeratorID: msg.ModeratorId}, nil
+	case *wire.AddModerator:
+		return &AddModerator{ForumID: msg.ForumId, UserID: msg.UserId, ModeratorID: msg.ModeratorId}, nil
+	case *wire.SetForumFilters:
+		return &SetForumFilters{ForumID: msg.ForumId, BannedWords: msg.BannedWords, BlockedDomains: msg.BlockedDomains, ModeratorID: msg.ModeratorId}, nil
+	case *wire.SetAutoModerator:
+		return &SetAutoModerator{ForumID: msg.ForumId, Rules: msg.Rules, ModeratorID: msg.ModeratorId}, nil
+
+	case *wire.RegisterUser:
+		return &RegisterUser{DisplayName: msg.DisplayName}, nil
+	case *wire.EnsureUser:
+		return &EnsureUser{Username: msg.Username}, nil
+	case *wire.FetchUser:
+		return &FetchUser{ProfileID: msg.ProfileId}, nil
+	case *wire.FetchUserByName:
+		return &FetchUserByName{Username: msg.Username}, nil
+	case *wire.FetchUsers:
+		return &FetchUsers{ProfileIDs: msg.ProfileIds}, nil
+	case *wire.RemoveUser:
+		return &RemoveUser{ProfileID: msg.ProfileId}, nil
+	case *wire.AdjustKarma:
+		return &AdjustKarma{ProfileID: msg.ProfileId, Delta: int(msg.Delta)}, nil
+	case *wire.UpdateProfile:
+		return &UpdateProfile{ProfileID: msg.ProfileId, Bio: msg.Bio, AvatarURL: msg.AvatarUrl}, nil
+
+	case *wire.AddPost:
+		return &AddPost{ForumID: msg.ForumId, AuthorID: msg.AuthorId, Text: msg.Text, Mentions: wire.MentionsSchema(msg.Mentions), Held: msg.Held, Flair: msg.Flair}, nil
+	case *wire.RetrievePost:
+		return &RetrievePost{ContentID: msg.ContentId}, nil
+	case *wire.RetrieveAllPosts:
+		return &RetrieveAllPosts{HiddenForums: msg.HiddenForums}, nil
+	case *wire.RetrievePosts:
+		return &RetrievePosts{ContentIDs: msg.ContentIds}, nil
+	case *wire.RetrieveForumPosts:
+		return &RetrieveForumPosts{ForumID: msg.ForumId}, nil
+	case *wire.VotePost:
+		return &VotePost{ContentID: msg.ContentId, Upvote: msg.Upvote}, nil
+	case *wire.RetrieveAuthorPosts:
+		return &RetrieveAuthorPosts{AuthorID: msg.AuthorId, Page: DecodePage(msg.Page), HiddenForums: msg.HiddenForums}, nil
+	case *wire.RemovePost:
+		return &RemovePost{ContentID: msg.ContentId}, nil
+	case *wire.ApprovePost:
+		return &ApprovePost{ContentID: msg.ContentId}, nil
+	case *wire.CountActive:
+		return &CountActive{}, nil
+
+	case *wire.SendMessage:
+		return &SendMessage{FromUserID: msg.FromUserId, ToUserID: msg.ToUserId, Body: msg.Body, Held: msg.Held}, nil
+	case *wire.FetchMessages:
+		return &FetchMessages{UserID: msg.UserId}, nil
+	case *wire.RemoveMessage:
+		return &RemoveMessage{MessageID: msg.MessageId}, nil
+	case *wire.DeliverMessage:
+		return &DeliverMessage{MessageID: msg.MessageId}, nil
+
+	case *wire.AddComment:
+		return &AddComment{PostID: msg.PostId, ParentID: msg.ParentId, AuthorID: msg.AuthorId, Content: msg.Content, Mentions: wire.MentionsSchema(msg.Mentions), Held: msg.Held}, nil
+	case *wire.FetchComment:
+		return &FetchComment{CommentID: msg.CommentId}, nil
+	case *wire.RemoveComment:
+		return &RemoveComment{CommentID: msg.CommentId}, nil
+	case *wire.ApproveComment:
+		return &ApproveComment{CommentID: msg.CommentId}, nil
+	case *wire.FetchPostComments:
+		return &FetchPostComments{PostID: msg.PostId}, nil
+	case *wire.FetchThreads:
+		return &FetchThreads{PostIDs: msg.PostIds}, nil
+	case *wire.VoteComment:
+		return &VoteComment{CommentID: msg.CommentId, Upvote: msg.Upvote}, nil
+	case *wire.FetchAuthorComments:
+		return &FetchAuthorComments{AuthorID: msg.AuthorId, Page: DecodePage(msg.Page), HiddenForums: msg.HiddenForums}, nil
+
+	case *wire.PushNotification:
+		return &PushNotification{UserID: msg.UserId, Kind: msg.Kind, SourceID: msg.SourceId, ActorID: msg.ActorId}, nil
+	case *wire.FetchNotifications:
+		return &FetchNotifications{UserID: msg.UserId}, nil
+
+	case *wire.HoldContent:
+		return &HoldContent{Kind: msg.Kind, ContentID: msg.ContentId, ForumID: msg.ForumId, AuthorID: msg.AuthorId, Filter: msg.Filter, Reason: msg.Reason}, nil
+	case *wire.ReportContent:
+		return &ReportContent{Kind: msg.Kind, ContentID: msg.ContentId, ForumID: msg.ForumId, AuthorID: msg.AuthorId, Filter: msg.Filter, Reason: msg.Reason}, nil
+	case *wire.FetchModQueue:
+		return &FetchModQueue{ForumID: msg.ForumId, ModeratorID: msg.ModeratorId}, nil
+	case *wire.ResolveQueueItem:
+		return &ResolveQueueItem{ItemID: msg.ItemId, ModeratorID: msg.ModeratorId}, nil
+	case *wire.RestoreQueueItem:
+		return &RestoreQueueItem{Item: msg.Item.Schema()}, nil
+
+	case *wire.EvaluateContent:
+		return &EvaluateContent{
+			ForumID:         msg.ForumId,
+			Rules:           msg.Rules,
+			Kind:            msg.Kind,
+			Text:            msg.Text,
+			AuthorName:      msg.AuthorName,
+			AuthorKarma:     int(msg.AuthorKarma),
+			AuthorCreatedAt: wire.TimeSchema(msg.AuthorCreatedAt),
+		}, nil
+
+	case *wire.Subreddit:
+		return msg.Schema(), nil
+	case *wire.SubredditList:
+		forums := make([]*schemas.Subreddit, len(msg.Forums))
+		for i, forum := range msg.Forums {
+			forums[i] = forum.Schema()
+		}
+		return forums, nil
+	case *wire.Account:
+		return msg.Schema(), nil
+	case *wire.AccountList:
+		accounts := make([]*schemas.Account, len(msg.Accounts))
+		for i, account := range msg.Accounts {
+			accounts[i] = account.Schema()
+		}
+		return accounts, nil
+	case *wire.Post:
+		return msg.Schema(), nil
+	case *wire.PostList:
+		return wire.PostsSchema(msg.Posts), nil
+	case *wire.PostListing:
+		return &PostListing{Posts: wire.PostsSchema(msg.Posts), Total: int(msg.Total), Offset: int(msg.Offset), NextCursor: msg.NextCursor}, nil
+	case *wire.Comment:
+		return msg.Schema(), nil
+	case *wire.CommentList:
+		return wire.CommentsSchema(msg.Comments), nil
+	case *wire.CommentListing:
+		return &CommentListing{Comments: wire.CommentsSchema(msg.Comments), Total: int(msg.Total), Offset: int(msg.Offset), NextCursor: msg.NextCursor}, nil
+	case *wire.Message:
+		return msg.Schema(), nil
+	case *wire.MessageList:
+		messages := make([]schemas.Message, len(msg.Messages))
+		for i, message := range msg.Messages {
+			messages[i] = *message.Schema()
+		}
+		return messages, nil
+	case *wire.Notification:
+		return msg.Schema(), nil
+	case *wire.NotificationList:
+		notifications := make([]*schemas.Notification, len(msg.Notifications))
+		for i, notification := range msg.Notifications {
+			notifications[i] = notification.Schema()
+		}
+		return notifications, nil
+	case *wire.QueueItem:
+		return msg.Schema(), nil
+	case *wire.QueueItemList:
+		items := make([]*schemas.QueueItem, len(msg.Items))
+		for i, item := range msg.Items {
+			items[i] = item.Schema()
+		}
+		return items, nil
+	case *wire.AutoModResult:
+		action, _ := automod.ParseAction(msg.Action)
+		return &automod.Result{Action: action, Rule: msg.Rule, Reason: msg.Reason, Flair: msg.Flair, Comments: msg.Comments, Matched: msg.Matched}, nil
+	case *wire.Removed:
+		return true, nil
+	case *wire.ActiveCount:
+		return int(msg.Count), nil
+	case *wire.Error:
+		return &Error{Code: Code(msg.Code), Message: msg.Message}, nil
+	}
+	return nil, fmt.Errorf("unknown wire message %T", message)
+}
+
+func encodePage(page Page) *wire.Page {
+	return &wire.Page{Sort: page.Sort, Offset: int32(page.Offset), Limit: int32(page.Limit), After: page.After}
+}
+
+// DecodePage converts a wire page; a missing page is the zero Page.
+func DecodePage(page *wire.Page) Page {
+	return Page{Sort: page.GetSort(), Offset: int(page.GetOffset()), Limit: int(page.GetLimit()), After: page.GetAfter()}
+}
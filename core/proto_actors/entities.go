@@ -0,0 +1,262 @@
+package proto_actor
+
+import (
+	"reddit-clone/core/clock"
+	"reddit-clone/core/ids"
+	"reddit-clone/schemas"
+	"sort"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// postActor owns a single post while it is activated. It loads the post from
+// the store when started and writes every change back before replying, so
+// a later activation, or a listing served by the manager, sees it.
+type postActor struct {
+	id    string
+	store *postStore
+	clock clock.Clock
+	idle  idleTracker
+	post  *schemas.Post
+}
+
+func newPostActor(id string, store *postStore, clock clock.Clock, timeout time.Duration) *postActor {
+	return &postActor{id: id, store: store, clock: clock, idle: idleTracker{key: id, timeout: timeout}}
+}
+
+func postProps(id string, config managerConfig) *actor.Props {
+	return config.props(PostKind, func() actor.Actor {
+		return newPostActor(id, config.storage.posts, config.clock, config.passivation)
+	})
+}
+
+func (pa *postActor) Receive(ctx actor.Context) {
+	if pa.idle.track(ctx) {
+		return
+	}
+
+	switch msg := ctx.Message().(type) {
+	case *actor.Started:
+		pa.post, _ = pa.store.get(pa.id)
+
+	case *RetrievePost:
+		if pa.post == nil {
+			ctx.Respond(ErrPostNotFound)
+			return
+		}
+		ctx.Respond(pa.post.Clone())
+
+	case *VotePost:
+		if pa.post == nil {
+			ctx.Respond(ErrPostNotFound)
+			return
+		}
+		if msg.Upvote {
+			pa.post.AddUpvote(pa.clock.Now())
+		} else {
+			pa.post.AddDownvote(pa.clock.Now())
+		}
+		pa.store.put(pa.post)
+		ctx.Respond(pa.post.Clone())
+
+	case *RemovePost:
+		if pa.post == nil {
+			ctx.Respond(ErrPostNotFound)
+			return
+		}
+		pa.store.remove(pa.id)
+		pa.post = nil
+		ctx.Respond(true)
+		pa.idle.passivate(ctx)
+
+	case *ApprovePost:
+		if pa.post == nil {
+			ctx.Respond(ErrPostNotFound)
+			return
+		}
+		if pa.post.Held {
+			pa.post.Held = false
+			pa.post.UpdatedAt = pa.clock.Now()
+			pa.store.put(pa.post)
+		}
+		ctx.Respond(pa.post.Clone())
+	}
+}
+
+// threadActor owns the comment tree of one post while it is activated.
+type threadActor struct {
+	postID   string
+	store    *commentStore
+	clock    clock.Clock
+	ids      ids.Generator
+	idle     idleTracker
+	comments map[string]*schemas.Comment
+	roots    []*schemas.Comment
+}
+
+func newThreadActor(postID string, store *commentStore, clock clock.Clock, ids ids.Generator, timeout time.Duration) *threadActor {
+	return &threadActor{postID: postID, store: store, clock: clock, ids: ids, idle: idleTracker{key: postID, timeout: timeout}}
+}
+
+func threadProps(postID string, config managerConfig) *actor.Props {
+	return config.props(ThreadKind, func() actor.Actor {
+		return newThreadActor(postID, config.storage.comments, config.clock, config.ids, config.passivation)
+	})
+}
+
+func (ta *threadActor) Receive(ctx actor.Context) {
+	if ta.idle.track(ctx) {
+		return
+	}
+
+	switch msg := ctx.Message().(type) {
+	case *actor.Started:
+		ta.load()
+
+	case *AddComment:
+		ta.add(ctx, msg)
+
+	case *FetchComment:
+		comment, exists := ta.comments[msg.CommentID]
+		if !exists {
+			ctx.Respond(ErrCommentNotFound)
+			return
+		}
+		ctx.Respond(comment.Clone())
+
+	case *VoteComment:
+		comment, exists := ta.comments[msg.CommentID]
+		if !exists {
+			ctx.Respond(ErrCommentNotFound)
+			return
+		}
+		if msg.Upvote {
+			comment.AddUpvote(ta.clock.Now())
+		} else {
+			comment.AddDownvote(ta.clock.Now())
+		}
+		ta.store.put(comment)
+		ctx.Respond(comment.Clone())
+
+	case *RemoveComment:
+		ta.remove(ctx, msg.CommentID)
+
+	case *ApproveComment:
+		ta.approve(ctx, msg.CommentID)
+
+	case *FetchPostComments:
+		// Top-level comments, oldest first. Replies are reachable through
+		// each comment's Replies.
+		roots := make([]*schemas.Comment, len(ta.roots))
+		for i, root := range ta.roots {
+			roots[i] = root.Clone()
+		}
+		ctx.Respond(roots)
+	}
+}
+
+// load rebuilds the reply tree from the flat stored comments. Replies whose
+// parent has been removed, and held comments, stay fetchable by ID but are
+// not linked.
+func (ta *threadActor) load() {
+	stored := ta.store.thread(ta.postID)
+	sort.Slice(stored, func(i, j int) bool { return stored[i].ID < stored[j].ID })
+
+	ta.comments = make(map[string]*schemas.Comment, len(stored))
+	ta.roots = nil
+	for _, comment := range stored {
+		ta.comments[comment.ID] = comment
+	}
+	for _, comment := range stored {
+		if comment.Held {
+			continue
+		}
+		if comment.ParentID == "" {
+			ta.roots = append(ta.roots, comment)
+		} else if parent, exists := ta.comments[comment.ParentID]; exists {
+			parent.Replies = append(parent.Replies, comment)
+		}
+	}
+}
+
+func (ta *threadActor) add(ctx actor.Context, msg *AddComment) {
+	comment := schemas.NewComment(ta.ids, msg.AuthorID, msg.Content, ta.clock.Now())
+	comment.Mentions = msg.Mentions
+	comment.PostID = ta.postID
+	comment.ParentID = msg.ParentID
+	comment.Held = msg.Held
+
+	if msg.ParentID != "" {
+		if _, exists := ta.comments[msg.ParentID]; !exists {
+			ctx.Respond(ErrParentNotFound)
+			return
+		}
+	}
+	if !comment.Held {
+		ta.link(comment)
+	}
+	ta.comments[comment.ID] = comment
+	ta.store.put(comment)
+
+	ctx.Respond(comment.Clone())
+}
+
+// link adds comment to the replies of its parent, or to the roots. Since
+// an approved comment may be older than its siblings, it is inserted in
+// creation order. A reply whose parent has been removed is left unlinked,
+// as in load.
+func (ta *threadActor) link(comment *schemas.Comment) {
+	siblings := &ta.roots
+	if comment.ParentID != "" {
+		parent, exists := ta.comments[comment.ParentID]
+		if !exists {
+			return
+		}
+		parent.UpdatedAt = ta.clock.Now()
+		ta.store.put(parent)
+		siblings = &parent.Replies
+	}
+	i := sort.Search(len(*siblings), func(i int) bool { return (*siblings)[i].ID > comment.ID })
+	*siblings = append(*siblings, nil)
+	copy((*siblings)[i+1:], (*siblings)[i:])
+	(*siblings)[i] = comment
+}
+
+func (ta *threadActor) approve(ctx actor.Context, commentID string) {
+	comment, exists := ta.comments[commentID]
+	if !exists {
+		ctx.Respond(ErrCommentNotFound)
+		return
+	}
+	if comment.Held {
+		comment.Held = false
+		comment.UpdatedAt = ta.clock.Now()
+		ta.link(comment)
+		ta.store.put(comment)
+	}
+	ctx.Respond(comment.Clone())
+}
+
+func (ta *threadActor) remove(ctx actor.Context, commentID string) {
+	removed, exists := ta.comments[commentID]
+	if !exists {
+		ctx.Respond(ErrCommentNotFound)
+		return
+	}
+
+	siblings := &ta.roots
+	if parent, exists := ta.comments[removed.ParentID]; exists {
+		siblings = &parent.Replies
+	}
+	for i, sibling := range *siblings {
+		if sibling.ID == commentID {
+			*siblings = append((*siblings)[:i], (*siblings)[i+1:]...)
+			break
+		}
+	}
+
+	delete(ta.comments, commentID)
+	ta.store.remove(commentID)
+	ctx.Respond(true)
+}
@@ -0,0 +1,50 @@
+package proto_actor
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrForumNameInvalid  = Errorf(Invalid, "forum name must be 2-21 characters of letters, digits, '_' or '-'")
+	ErrForumNameReserved = Errorf(Invalid, "forum name is reserved")
+	ErrForumNameTaken    = Errorf(Conflict, "forum name is already taken")
+	ErrCreatorRequired   = Errorf(Invalid, "a forum needs a creator to be its first moderator")
+)
+
+var (
+	forumNamePattern    = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,20}$`)
+	forumNameSeparators = regexp.MustCompile(`[\s_]+`)
+	forumNameStrip      = regexp.MustCompile(`[^a-z0-9_-]`)
+	forumNameRepeats    = regexp.MustCompile(`_{2,}`)
+)
+
+// reservedForumNames collide with listing pages or look like generated
+// forum IDs.
+var reservedForumNames = map[string]bool{
+	"all":     true,
+	"by-name": true,
+	"friends": true,
+	"mod":     true,
+	"popular": true,
+	"random":  true,
+}
+
+// NormalizeForumName returns the canonical name for a forum. When name is
+// empty it is derived from the display title by lower-casing it, joining words
+// with underscores and dropping other punctuation.
+func NormalizeForumName(name, title string) (string, error) {
+	if name == "" {
+		name = forumNameSeparators.ReplaceAllString(strings.TrimSpace(title), "_")
+		name = forumNameStrip.ReplaceAllString(strings.ToLower(name), "")
+		name = strings.Trim(forumNameRepeats.ReplaceAllString(name, "_"), "_-")
+	}
+	name = strings.ToLower(name)
+	if !forumNamePattern.MatchString(name) {
+		return "", ErrForumNameInvalid
+	}
+	if reservedForumNames[name] || strings.HasPrefix(name, "subreddit_") {
+		return "", ErrForumNameReserved
+	}
+	return name, nil
+}
@@ -0,0 +1,214 @@
+package proto_actor
+
+import (
+	"reddit-clone/schemas"
+	"sort"
+)
+
+const (
+	DefaultPageLimit = 25
+	MaxPageLimit     = 100
+)
+
+// Listing sort orders accepted by the author history messages.
+const (
+	SortNew = "new"
+	SortOld = "old"
+	SortTop = "top"
+)
+
+// Page selects a window of a listing. A zero Limit means DefaultPageLimit.
+// After is a cursor: the ID of the last item already seen. When set for the
+// new and old orders it takes precedence over Offset, so pages stay stable
+// while items are added; the top order always pages by Offset.
+type Page struct {
+	Sort   string
+	Offset int
+	Limit  int
+	After  string
+}
+
+// EffectiveLimit is the page size actually applied after defaults and caps.
+func (p Page) EffectiveLimit() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultPageLimit
+	case p.Limit > MaxPageLimit:
+		return MaxPageLimit
+	}
+	return p.Limit
+}
+
+// cursored reports whether the page is selected by the After cursor.
+func (p Page) cursored() bool {
+	return p.After != "" && p.Sort != SortTop
+}
+
+// bounds returns the slice window of a listing already sorted by p.Sort.
+// idAt returns the ID of the item at an index and is only used for cursors.
+func (p Page) bounds(total int, idAt func(int) string) (int, int) {
+	limit := p.EffectiveLimit()
+	start := p.Offset
+	if p.cursored() {
+		// IDs are fixed-width and creation ordered, so the listing is
+		// monotonic in ID and the cursor position can be binary searched.
+		start = sort.Search(total, func(i int) bool {
+			if p.Sort == SortOld {
+				return idAt(i) > p.After
+			}
+			return idAt(i) < p.After
+		})
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// nextCursor is the After value for the page following [start, end), or
+// empty when there is none or the order does not support cursors.
+func (p Page) nextCursor(total, end int, idAt func(int) string) string {
+	if p.Sort == SortTop || end >= total || end == 0 {
+		return ""
+	}
+	return idAt(end - 1)
+}
+
+type PostListing struct {
+	Posts      []*schemas.Post
+	Total      int
+	Offset     int
+	NextCursor string
+}
+
+type CommentListing struct {
+	Comments   []*schemas.Comment
+	Total      int
+	Offset     int
+	NextCursor string
+}
+
+// listed reports whether post appears in listings: held posts are only
+// reachable by ID until they are approved.
+func listed(post *schemas.Post) bool {
+	return !post.Held
+}
+
+func listedPosts(posts []*schemas.Post) []*schemas.Post {
+	kept := posts[:0]
+	for _, post := range posts {
+		if listed(post) {
+			kept = append(kept, post)
+		}
+	}
+	return kept
+}
+
+// listedComments drops held comments, as listedPosts does posts.
+func listedComments(comments []*schemas.Comment) []*schemas.Comment {
+	kept := comments[:0]
+	for _, comment := range comments {
+		if !comment.Held {
+			kept = append(kept, comment)
+		}
+	}
+	return kept
+}
+
+// forumSet is the set of forums a listing leaves out, for the private
+// forums its viewer may not see.
+type forumSet map[string]bool
+
+func newForumSet(forumIDs []string) forumSet {
+	set := make(forumSet, len(forumIDs))
+	for _, id := range forumIDs {
+		set[id] = true
+	}
+	return set
+}
+
+// postsOutside drops the posts in hidden.
+func postsOutside(posts []*schemas.Post, hidden forumSet) []*schemas.Post {
+	if len(hidden) == 0 {
+		return posts
+	}
+	kept := posts[:0]
+	for _, post := range posts {
+		if !hidden[post.SubredditID] {
+			kept = append(kept, post)
+		}
+	}
+	return kept
+}
+
+func postListing(posts []*schemas.Post, page Page) *PostListing {
+	sortPosts(posts, page.Sort)
+	idAt := func(i int) string { return posts[i].ID }
+	start, end := page.bounds(len(posts), idAt)
+	return &PostListing{
+		Posts:      posts[start:end],
+		Total:      len(posts),
+		Offset:     start,
+		NextCursor: page.nextCursor(len(posts), end, idAt),
+	}
+}
+
+func commentListing(comments []*schemas.Comment, page Page) *CommentListing {
+	sortComments(comments, page.Sort)
+	idAt := func(i int) string { return comments[i].ID }
+	start, end := page.bounds(len(comments), idAt)
+	return &CommentListing{
+		Comments:   comments[start:end],
+		Total:      len(comments),
+		Offset:     start,
+		NextCursor: page.nextCursor(len(comments), end, idAt),
+	}
+}
+
+// sortPosts orders posts in place. Creation order is taken from the IDs,
+// which unlike CreatedAt never tie.
+func sortPosts(posts []*schemas.Post, order string) {
+	sort.SliceStable(posts, func(i, j int) bool {
+		switch order {
+		case SortOld:
+			return posts[i].ID < posts[j].ID
+		case SortTop:
+			si, sj := posts[i].Upvotes-posts[i].Downvotes, posts[j].Upvotes-posts[j].Downvotes
+			if si != sj {
+				return si > sj
+			}
+		}
+		return posts[i].ID > posts[j].ID
+	})
+}
+
+func sortComments(comments []*schemas.Comment, order string) {
+	sort.SliceStable(comments, func(i, j int) bool {
+		switch order {
+		case SortOld:
+			return comments[i].ID < comments[j].ID
+		case SortTop:
+			si, sj := comments[i].Upvotes-comments[i].Downvotes, comments[j].Upvotes-comments[j].Downvotes
+			if si != sj {
+				return si > sj
+			}
+		}
+		return comments[i].ID > comments[j].ID
+	})
+}
+
+func removeID(ids []string, id string) []string {
+	for i, existing := range ids {
+		if existing == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
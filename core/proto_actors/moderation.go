@@ -0,0 +1,181 @@
+package proto_actor
+
+import (
+	"reddit-clone/core/clock"
+	"reddit-clone/core/ids"
+	"reddit-clone/schemas"
+	"sort"
+	"strings"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+var (
+	ErrQueueItemNotFound   = &Error{Code: NotFound, Message: "modqueue item not found"}
+	ErrNotMessageModerator = &Error{Code: Forbidden, Message: "only the message moderators may review private messages"}
+)
+
+// ModerationManager keeps the modqueue: the content that the content filters
+// or AutoModerator held for review, and the content AutoModerator reported.
+// It only records what is queued; releasing or removing the content itself
+// is up to the manager that owns it. The items of a forum are reviewed by
+// its moderators, and held private messages, which belong to no forum, by
+// the message moderators.
+type ModerationManager struct {
+	*modqueueState
+	forums            *forumState
+	members           *memberState
+	messageModerators map[string]bool
+	clock             clock.Clock
+	ids               ids.Generator
+}
+
+func NewModerationManager(options ...Option) *ModerationManager {
+	config := newManagerConfig(options)
+	messageModerators := make(map[string]bool)
+	for _, username := range config.messageModerators {
+		messageModerators[strings.ToLower(username)] = true
+	}
+	return &ModerationManager{
+		clock:             config.clock,
+		ids:               config.ids,
+		modqueueState:     config.storage.modqueue,
+		forums:            config.storage.forums,
+		members:           config.storage.members,
+		messageModerators: messageModerators,
+	}
+}
+
+// HoldContent queues content that Filter held, for Reason, for review.
+type HoldContent struct {
+	Kind      string
+	ContentID string
+	ForumID   string
+	AuthorID  string
+	Filter    string
+	Reason    string
+}
+
+// ReportContent queues content for review without holding it. Filter names
+// what reported it.
+type ReportContent struct {
+	Kind      string
+	ContentID string
+	ForumID   string
+	AuthorID  string
+	Filter    string
+	Reason    string
+}
+
+// FetchModQueue lists the queued items of a forum, or the held private
+// messages when ForumID is empty, oldest first. It is refused as Forbidden
+// unless ModeratorID may review them.
+type FetchModQueue struct {
+	ForumID     string
+	ModeratorID string
+}
+
+// ResolveQueueItem takes an item off the queue and replies with it, if
+// ModeratorID may review it. Only one of several concurrent resolutions of
+// an item succeeds.
+type ResolveQueueItem struct {
+	ItemID      string
+	ModeratorID string
+}
+
+// RestoreQueueItem puts back an item taken off the queue by ResolveQueueItem
+// whose resolution failed, so that it can be resolved again.
+type RestoreQueueItem struct {
+	Item *schemas.QueueItem
+}
+
+func (mm *ModerationManager) Receive(ctx actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case *HoldContent:
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		item := schemas.NewQueueItem(mm.ids, msg.Kind, msg.ContentID, msg.ForumID, msg.AuthorID, msg.Filter, msg.Reason, mm.clock.Now())
+		item.Held = true
+		mm.items[item.ID] = item
+		respondIfAsked(ctx, item)
+
+	case *ReportContent:
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		item := schemas.NewQueueItem(mm.ids, msg.Kind, msg.ContentID, msg.ForumID, msg.AuthorID, msg.Filter, msg.Reason, mm.clock.Now())
+		mm.items[item.ID] = item
+		respondIfAsked(ctx, item)
+
+	case *FetchModQueue:
+		if err := mm.checkReviewer(msg.ForumID, msg.ModeratorID); err != nil {
+			ctx.Respond(err)
+			return
+		}
+
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		items := []*schemas.QueueItem{}
+		for _, item := range mm.items {
+			if item.ForumID == msg.ForumID {
+				queued := *item
+				items = append(items, &queued)
+			}
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+		ctx.Respond(items)
+
+	case *ResolveQueueItem:
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		item, exists := mm.items[msg.ItemID]
+		if !exists {
+			ctx.Respond(ErrQueueItemNotFound)
+			return
+		}
+		if err := mm.checkReviewer(item.ForumID, msg.ModeratorID); err != nil {
+			ctx.Respond(err)
+			return
+		}
+		delete(mm.items, msg.ItemID)
+		ctx.Respond(item)
+
+	case *RestoreQueueItem:
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		item := *msg.Item
+		mm.items[item.ID] = &item
+		respondIfAsked(ctx, &item)
+	}
+}
+
+// checkReviewer refuses with an *Error unless moderatorID moderates the
+// forum forumID, or is a message moderator when forumID is empty.
+func (mm *ModerationManager) checkReviewer(forumID, moderatorID string) error {
+	if forumID == "" {
+		mm.members.lock.Lock()
+		defer mm.members.lock.Unlock()
+
+		profile, exists := mm.members.profiles[moderatorID]
+		if !exists || !mm.messageModerators[strings.ToLower(profile.Username)] {
+			return ErrNotMessageModerator
+		}
+		return nil
+	}
+
+	mm.forums.lock.Lock()
+	defer mm.forums.lock.Unlock()
+
+	forum, exists := mm.forums.forums[forumID]
+	if !exists {
+		return ErrForumNotFound
+	}
+	if !forum.IsModerator(moderatorID) {
+		return ErrNotModerator
+	}
+	return nil
+}
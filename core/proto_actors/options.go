@@ -0,0 +1,186 @@
+package proto_actor
+
+import (
+	"reddit-clone/core/clock"
+	"reddit-clone/core/ids"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/cluster"
+)
+
+// DefaultPassivationTimeout is how long a per-entity actor stays activated
+// without receiving a message.
+const DefaultPassivationTimeout = 2 * time.Minute
+
+// DefaultRequestTimeout is how long an actor waits for the reply of another
+// actor it asks on behalf of a request.
+const DefaultRequestTimeout = 5 * time.Second
+
+// defaultIDs is shared by every manager built without WithIDGenerator, so
+// that the entities they create cannot get the same ID.
+var defaultIDs ids.Generator = func() ids.Generator {
+	generator, err := ids.NewSnowflake(0)
+	if err != nil {
+		panic(err)
+	}
+	return generator
+}()
+
+// Option configures a manager at construction time.
+type Option func(*managerConfig)
+
+type managerConfig struct {
+	clock       clock.Clock
+	ids         ids.Generator
+	passivation time.Duration
+	timeout     time.Duration
+	storage     *Storage
+	cluster     *cluster.Cluster
+	restart     RestartPolicy
+	middleware  []actor.ReceiverMiddleware
+	instruments []Instrumentation
+	// messageModerators are the usernames of the users who review held
+	// private messages.
+	messageModerators []string
+}
+
+// WithClock makes a manager read the current time from c instead of the
+// wall clock, so tests can control timestamps.
+func WithClock(c clock.Clock) Option {
+	return func(config *managerConfig) {
+		config.clock = c
+	}
+}
+
+// WithIDGenerator makes a manager, and the entities it creates, take their
+// IDs from g. Every process sharing a data set needs a generator of its own
+// node ID.
+func WithIDGenerator(g ids.Generator) Option {
+	return func(config *managerConfig) {
+		config.ids = g
+	}
+}
+
+// WithPassivationTimeout sets how long the per-entity actors of PostManager
+// and CommentService stay activated while idle.
+func WithPassivationTimeout(d time.Duration) Option {
+	return func(config *managerConfig) {
+		config.passivation = d
+	}
+}
+
+// WithRequestTimeout sets how long a cluster grain waits for its entity
+// actor, and SpawnManagers for the managers to start.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(config *managerConfig) {
+		config.timeout = d
+	}
+}
+
+// WithStorage makes PostManager and CommentService keep their state in s
+// instead of a private Storage.
+func WithStorage(s *Storage) Option {
+	return func(config *managerConfig) {
+		config.storage = s
+	}
+}
+
+// WithCluster makes PostManager and CommentService route per-entity messages
+// to grains of c, which may live on any node, instead of to local children.
+// The kinds from ClusterKinds must be registered on every member.
+func WithCluster(c *cluster.Cluster) Option {
+	return func(config *managerConfig) {
+		config.cluster = c
+	}
+}
+
+// WithMessageModerators names the users, by username, who may review the
+// private messages held in the modqueue. Nobody may by default.
+func WithMessageModerators(usernames ...string) Option {
+	return func(config *managerConfig) {
+		config.messageModerators = append(config.messageModerators, usernames...)
+	}
+}
+
+// WithRestartPolicy sets how crashed managers and per-entity actors are
+// restarted.
+func WithRestartPolicy(p RestartPolicy) Option {
+	return func(config *managerConfig) {
+		config.restart = p
+	}
+}
+
+// WithMiddleware runs every message received by managers spawned with
+// SpawnManagers and by per-entity actors through middleware.
+func WithMiddleware(middleware ...actor.ReceiverMiddleware) Option {
+	return func(config *managerConfig) {
+		config.middleware = append(config.middleware, middleware...)
+	}
+}
+
+// Instrumentation observes the actors spawned with SpawnManagers or
+// ClusterKinds. It is asked for the middleware of each kind of actor: the
+// "managers" guardian, each manager by its name such as "posts", and
+// PostKind or ThreadKind for per-entity actors and their grains. It returns
+// nil for the middleware it does not need.
+type Instrumentation interface {
+	Receiver(kind string) actor.ReceiverMiddleware
+	Sender(kind string) actor.SenderMiddleware
+	Mailbox(kind string) actor.MailboxMiddleware
+}
+
+// WithInstrumentation makes every manager and per-entity actor report to
+// each of instruments, in addition to any WithMiddleware.
+func WithInstrumentation(instruments ...Instrumentation) Option {
+	return func(config *managerConfig) {
+		config.instruments = append(config.instruments, instruments...)
+	}
+}
+
+// props builds the props of a manager or per-entity actor of kind: its
+// children are supervised by the restart policy and its messages pass the
+// middleware.
+func (config managerConfig) props(kind string, producer actor.Producer) *actor.Props {
+	var receivers []actor.ReceiverMiddleware
+	var senders []actor.SenderMiddleware
+	var mailboxes []actor.MailboxMiddleware
+	for _, i := range config.instruments {
+		if receiver := i.Receiver(kind); receiver != nil {
+			receivers = append(receivers, receiver)
+		}
+		if sender := i.Sender(kind); sender != nil {
+			senders = append(senders, sender)
+		}
+		if mailbox := i.Mailbox(kind); mailbox != nil {
+			mailboxes = append(mailboxes, mailbox)
+		}
+	}
+	return actor.PropsFromProducer(producer,
+		actor.WithSupervisor(config.restart),
+		actor.WithReceiverMiddleware(append(receivers, config.middleware...)...),
+		actor.WithSenderMiddleware(senders...),
+		actor.WithMailbox(actor.Unbounded(mailboxes...)))
+}
+
+// entityRouter builds the router for a manager's per-entity actors: grains of
+// kind when clustered, otherwise local children made by props.
+func (config managerConfig) entityRouter(kind string, props func(key string, config managerConfig) *actor.Props) entityRouter {
+	if config.cluster != nil {
+		return newClusterRouter(config.cluster, kind)
+	}
+	return newActivations(func(key string) *actor.Props {
+		return props(key, config)
+	})
+}
+
+func newManagerConfig(options []Option) managerConfig {
+	config := managerConfig{clock: clock.Real, ids: defaultIDs, passivation: DefaultPassivationTimeout, timeout: DefaultRequestTimeout, restart: DefaultRestartPolicy}
+	for _, option := range options {
+		option(&config)
+	}
+	if config.storage == nil {
+		config.storage = NewStorage()
+	}
+	return config
+}
@@ -0,0 +1,199 @@
+package proto_actor
+
+import (
+	"errors"
+	"fmt"
+	"reddit-clone/core/automod"
+	"reddit-clone/schemas"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// Every request a manager accepts replies with exactly one value of the type
+// its Request declares, or with an *Error. Managers never reply with nil or
+// with false to signal a missing entity.
+
+// Request is a message whose successful reply has type T.
+type Request[T any] interface {
+	reply(T)
+}
+
+// Code classifies why a request failed.
+type Code int
+
+const (
+	// Internal is an unexpected failure, including errors that are not an
+	// *Error.
+	Internal Code = iota
+	NotFound
+	Conflict
+	Forbidden
+	Invalid
+	// Unavailable means the manager did not answer in time.
+	Unavailable
+	// RateLimited means the client must wait before trying again. The API
+	// front ends return it, never a manager, so it has no wire.Error code.
+	RateLimited
+)
+
+var codeNames = map[Code]string{
+	Internal:    "internal",
+	NotFound:    "not_found",
+	Conflict:    "conflict",
+	Forbidden:   "forbidden",
+	Invalid:     "invalid",
+	Unavailable: "unavailable",
+	RateLimited: "rate_limited",
+}
+
+func (c Code) String() string {
+	if name, known := codeNames[c]; known {
+		return name
+	}
+	return fmt.Sprintf("code(%d)", int(c))
+}
+
+func (c Code) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Code) UnmarshalText(text []byte) error {
+	for code, name := range codeNames {
+		if name == string(text) {
+			*c = code
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown error code %q", text)
+}
+
+// Error is the error reply of every manager.
+type Error struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func Errorf(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// CodeOf returns the code of err, or Internal when err is not an *Error.
+func CodeOf(err error) Code {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.Code
+	}
+	return Internal
+}
+
+var (
+	ErrPostNotFound    = &Error{Code: NotFound, Message: "post not found"}
+	ErrCommentNotFound = &Error{Code: NotFound, Message: "comment not found"}
+	ErrParentNotFound  = &Error{Code: NotFound, Message: "parent comment not found"}
+	ErrForumNotFound   = &Error{Code: NotFound, Message: "forum not found"}
+	ErrUserNotFound    = &Error{Code: NotFound, Message: "user profile not found"}
+	ErrMessageNotFound = &Error{Code: NotFound, Message: "message not found"}
+	ErrUnknownMessage  = &Error{Code: Invalid, Message: "unknown message"}
+
+	ErrForumPrivate        = &Error{Code: Forbidden, Message: "forum is private"}
+	ErrForumRestricted     = &Error{Code: Forbidden, Message: "only approved users may post in this forum"}
+	ErrJoinRequestNotFound = &Error{Code: NotFound, Message: "join request not found"}
+	ErrNotModerator        = &Error{Code: Forbidden, Message: "only the forum's moderators may do this"}
+)
+
+// respondIfAsked replies to requests but not to messages that were sent
+// without a sender, whose replies would only end up as dead letters.
+func respondIfAsked(ctx actor.Context, reply interface{}) {
+	if ctx.Sender() != nil {
+		ctx.Respond(reply)
+	}
+}
+
+// AskTimeout is published on the actor system's event stream whenever Ask
+// gives up waiting for a reply.
+type AskTimeout struct {
+	Target  *actor.PID
+	Request interface{}
+}
+
+// Ask sends request to pid and waits up to timeout for its reply. A timeout
+// is reported as Unavailable, and a reply of the wrong type as Internal.
+func Ask[T any](sender actor.SenderContext, pid *actor.PID, request Request[T], timeout time.Duration) (T, error) {
+	var zero T
+	res, err := sender.RequestFuture(pid, request, timeout).Result()
+	if err != nil {
+		if errors.Is(err, actor.ErrTimeout) {
+			sender.ActorSystem().EventStream.Publish(&AskTimeout{Target: pid, Request: request})
+		}
+		return zero, Errorf(Unavailable, "%T: %v", request, err)
+	}
+	switch reply := res.(type) {
+	case T:
+		return reply, nil
+	case error:
+		return zero, reply
+	}
+	return zero, Errorf(Internal, "unexpected reply %T to %T", res, request)
+}
+
+func (*AddForum) reply(*schemas.Subreddit)                {}
+func (*RetrieveForum) reply(*schemas.Subreddit)           {}
+func (*RetrieveForumByName) reply(*schemas.Subreddit)     {}
+func (*RetrieveAllForums) reply([]*schemas.Subreddit)     {}
+func (*RetrieveForums) reply([]*schemas.Subreddit)        {}
+func (*RenameForum) reply(*schemas.Subreddit)             {}
+func (*UpdateForum) reply(*schemas.Subreddit)             {}
+func (*RemoveForum) reply(bool)                           {}
+func (*JoinForum) reply(*schemas.Subreddit)               {}
+func (*LeaveForum) reply(*schemas.Subreddit)              {}
+func (*SetForumType) reply(*schemas.Subreddit)            {}
+func (*ApproveUser) reply(*schemas.Subreddit)             {}
+func (*UnapproveUser) reply(*schemas.Subreddit)           {}
+func (*RequestJoin) reply(*schemas.Subreddit)             {}
+func (*ResolveJoinRequest) reply(*schemas.Subreddit)      {}
+func (*AddModerator) reply(*schemas.Subreddit)            {}
+func (*SetForumFilters) reply(*schemas.Subreddit)         {}
+func (*SetAutoModerator) reply(*schemas.Subreddit)        {}
+func (*RegisterUser) reply(*schemas.Account)              {}
+func (*EnsureUser) reply(*schemas.Account)                {}
+func (*FetchUser) reply(*schemas.Account)                 {}
+func (*FetchUserByName) reply(*schemas.Account)           {}
+func (*FetchUsers) reply([]*schemas.Account)              {}
+func (*RemoveUser) reply(bool)                            {}
+func (*AdjustKarma) reply(*schemas.Account)               {}
+func (*UpdateProfile) reply(*schemas.Account)             {}
+func (*AddPost) reply(*schemas.Post)                      {}
+func (*RetrievePost) reply(*schemas.Post)                 {}
+func (*RetrieveAllPosts) reply([]*schemas.Post)           {}
+func (*RetrievePosts) reply([]*schemas.Post)              {}
+func (*RetrieveForumPosts) reply([]*schemas.Post)         {}
+func (*VotePost) reply(*schemas.Post)                     {}
+func (*RetrieveAuthorPosts) reply(*PostListing)           {}
+func (*RemovePost) reply(bool)                            {}
+func (*ApprovePost) reply(*schemas.Post)                  {}
+func (*CountActive) reply(int)                            {}
+func (*SendMessage) reply(*schemas.Message)               {}
+func (*FetchMessages) reply([]schemas.Message)            {}
+func (*RemoveMessage) reply(bool)                         {}
+func (*DeliverMessage) reply(*schemas.Message)            {}
+func (*AddComment) reply(*schemas.Comment)                {}
+func (*FetchComment) reply(*schemas.Comment)              {}
+func (*RemoveComment) reply(bool)                         {}
+func (*ApproveComment) reply(*schemas.Comment)            {}
+func (*FetchPostComments) reply([]*schemas.Comment)       {}
+func (*FetchThreads) reply([]*schemas.Comment)            {}
+func (*VoteComment) reply(*schemas.Comment)               {}
+func (*FetchAuthorComments) reply(*CommentListing)        {}
+func (*PushNotification) reply(*schemas.Notification)     {}
+func (*FetchNotifications) reply([]*schemas.Notification) {}
+func (*HoldContent) reply(*schemas.QueueItem)             {}
+func (*ReportContent) reply(*schemas.QueueItem)           {}
+func (*FetchModQueue) reply([]*schemas.QueueItem)         {}
+func (*ResolveQueueItem) reply(*schemas.QueueItem)        {}
+func (*RestoreQueueItem) reply(*schemas.QueueItem)        {}
+func (*EvaluateContent) reply(*automod.Result)            {}
@@ -1,49 +1,189 @@
 package proto_actor
 
 import (
-	"errors"
+	"fmt"
+	"reddit-clone/core/automod"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/ids"
+	"reddit-clone/core/logging"
 	"reddit-clone/schemas"
-	"sync"
-	"log"
+	"strings"
+	"log/slog"
 	"github.com/asynkron/protoactor-go/actor"
 )
 
 
 
 type ForumManager struct {
-	forums map[string]*schemas.Subreddit
-	lock   sync.Mutex
+	*forumState
+	clock clock.Clock
+	ids   ids.Generator
 }
 
-func NewForumManager() *ForumManager {
+func NewForumManager(options ...Option) *ForumManager {
+	config := newManagerConfig(options)
 	return &ForumManager{
-		forums: make(map[string]*schemas.Subreddit),
+		clock:      config.clock,
+		ids:        config.ids,
+		forumState: config.storage.forums,
 	}
 }
 
 
+// AddForum creates a forum. Name is the canonical, URL-safe name; when it is
+// empty it is derived from Title. CreatorID is required: it is recorded as
+// the forum's creator and first moderator, so no forum is left unmoderated.
 type AddForum struct {
-	Title string
+	Title     string
+	Name      string
+	CreatorID string
 }
 
 type RetrieveForum struct {
 	ForumID string
 }
 
+type RetrieveForumByName struct {
+	Name string
+}
+
+type RetrieveAllForums struct{}
+
+// RetrieveForums looks up several forums in one round trip. The reply holds
+// the forums that exist, in the order of ForumIDs.
+type RetrieveForums struct {
+	ForumIDs []string
+}
+
+// RenameForum changes the forum's title. It is refused as Forbidden unless
+// ModeratorID moderates the forum.
+type RenameForum struct {
+	ForumID     string
+	Title       string
+	ModeratorID string
+}
+
+// UpdateForum replaces the description, sidebar, rules, images and NSFW flag
+// of the forum. It is refused as Forbidden unless ModeratorID moderates it.
+type UpdateForum struct {
+	ForumID     string
+	Info        schemas.ForumInfo
+	ModeratorID string
+}
+
 type RemoveForum struct {
 	ForumID string
 }
 
+// JoinForum makes UserID a member of the forum. Joining twice is harmless.
+// Only approved users may join a private forum directly; others ask with
+// RequestJoin.
+type JoinForum struct {
+	ForumID string
+	UserID  string
+}
+
+// LeaveForum removes UserID from the forum's members, if it is one.
+type LeaveForum struct {
+	ForumID string
+	UserID  string
+}
+
+// SetForumType makes the forum public, restricted or private. This and the
+// other changes to who may use a forum are refused as Forbidden unless
+// ModeratorID moderates it.
+type SetForumType struct {
+	ForumID     string
+	Type        string
+	ModeratorID string
+}
+
+// ApproveUser lets UserID post in the forum while it is restricted and view
+// it while it is private. Approving twice is harmless.
+type ApproveUser struct {
+	ForumID     string
+	UserID      string
+	ModeratorID string
+}
+
+// UnapproveUser takes UserID off the forum's approved users, if it is one.
+type UnapproveUser struct {
+	ForumID     string
+	UserID      string
+	ModeratorID string
+}
+
+// RequestJoin asks the moderators of a private forum to let UserID join.
+type RequestJoin struct {
+	ForumID string
+	UserID  string
+}
+
+// ResolveJoinRequest accepts or declines the request of UserID to join the
+// forum.
+type ResolveJoinRequest struct {
+	ForumID     string
+	UserID      string
+	Accept      bool
+	ModeratorID string
+}
+
+// AddModerator makes UserID a moderator of the forum. Adding twice is
+// harmless.
+type AddModerator struct {
+	ForumID     string
+	UserID      string
+	ModeratorID string
+}
+
+// SetForumFilters replaces the banned words and blocked domains the content
+// filters apply to the forum, on top of the site-wide lists. It is refused
+// as Forbidden unless ModeratorID moderates the forum.
+type SetForumFilters struct {
+	ForumID        string
+	BannedWords    []string
+	BlockedDomains []string
+	ModeratorID    string
+}
+
+// SetAutoModerator replaces the source of the forum's AutoModerator rules.
+// Rules that do not parse are refused as Invalid, and the change as
+// Forbidden unless ModeratorID moderates the forum.
+type SetAutoModerator struct {
+	ForumID     string
+	Rules       string
+	ModeratorID string
+}
+
 func (fm *ForumManager) Receive(ctx actor.Context) {
 	switch msg := ctx.Message().(type) {
 	case *AddForum:
 		fm.lock.Lock()
 		defer fm.lock.Unlock()
 
-		
-		forum := schemas.NewSubreddit(msg.Title)
+		if msg.CreatorID == "" {
+			ctx.Respond(ErrCreatorRequired)
+			return
+		}
+		name, err := NormalizeForumName(msg.Name, msg.Title)
+		if err != nil {
+			ctx.Respond(err)
+			return
+		}
+		if _, taken := fm.byName[name]; taken {
+			ctx.Respond(ErrForumNameTaken)
+			return
+		}
+
+		forum := schemas.NewSubreddit(fm.ids, name, fm.clock.Now())
+		if title := strings.TrimSpace(msg.Title); title != "" {
+			forum.Title = title
+		}
+		forum.CreatedBy = msg.CreatorID
+		forum.AddModerator(msg.CreatorID, forum.CreatedAt)
 		fm.forums[forum.ID] = forum
-		ctx.Respond(forum)
+		fm.byName[name] = forum.ID
+		ctx.Respond(forum.Clone())
 
 	case *RetrieveForum:
 		fm.lock.Lock()
@@ -52,22 +192,264 @@ func (fm *ForumManager) Receive(ctx actor.Context) {
 	
 		forum, exists := fm.forums[msg.ForumID]
 		if !exists {
-			ctx.Respond(errors.New("forum not found"))
+			ctx.Respond(ErrForumNotFound)
 		} else {
-			ctx.Respond(forum)
+			ctx.Respond(forum.Clone())
+		}
+
+	case *RetrieveForumByName:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forumID, exists := fm.byName[strings.ToLower(msg.Name)]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		ctx.Respond(fm.forums[forumID].Clone())
+
+	case *RetrieveAllForums:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		allForums := []*schemas.Subreddit{}
+		for _, forum := range fm.forums {
+			allForums = append(allForums, forum.Clone())
+		}
+		ctx.Respond(allForums)
+
+	case *RetrieveForums:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forums := []*schemas.Subreddit{}
+		for _, id := range msg.ForumIDs {
+			if forum, exists := fm.forums[id]; exists {
+				forums = append(forums, forum.Clone())
+			}
+		}
+		ctx.Respond(forums)
+
+	case *RenameForum:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		if !forum.IsModerator(msg.ModeratorID) {
+			ctx.Respond(ErrNotModerator)
+			return
+		}
+		forum.Rename(msg.Title, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *UpdateForum:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		if !forum.IsModerator(msg.ModeratorID) {
+			ctx.Respond(ErrNotModerator)
+			return
+		}
+		forum.SetInfo(msg.Info, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *JoinForum:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		if forum.Type == schemas.ForumPrivate && !forum.Members[msg.UserID] && !forum.ApprovedUsers[msg.UserID] {
+			ctx.Respond(ErrForumPrivate)
+			return
+		}
+		forum.AddMember(msg.UserID, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *LeaveForum:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		forum.RemoveMember(msg.UserID, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *SetForumType:
+		switch msg.Type {
+		case schemas.ForumPublic, schemas.ForumRestricted, schemas.ForumPrivate:
+		default:
+			ctx.Respond(Errorf(Invalid, "forum type must be public, restricted or private, not %q", msg.Type))
+			return
+		}
+
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		if !forum.IsModerator(msg.ModeratorID) {
+			ctx.Respond(ErrNotModerator)
+			return
+		}
+		forum.SetType(msg.Type, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *ApproveUser:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		if !forum.IsModerator(msg.ModeratorID) {
+			ctx.Respond(ErrNotModerator)
+			return
+		}
+		forum.Approve(msg.UserID, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *UnapproveUser:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		if !forum.IsModerator(msg.ModeratorID) {
+			ctx.Respond(ErrNotModerator)
+			return
+		}
+		forum.Unapprove(msg.UserID, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *RequestJoin:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		if forum.Type != schemas.ForumPrivate {
+			ctx.Respond(Errorf(Invalid, "only private forums take join requests; join r/%s directly", forum.Name))
+			return
+		}
+		if forum.Members[msg.UserID] {
+			ctx.Respond(Errorf(Conflict, "already a member of r/%s", forum.Name))
+			return
+		}
+		forum.RequestJoin(msg.UserID, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *ResolveJoinRequest:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		if !forum.IsModerator(msg.ModeratorID) {
+			ctx.Respond(ErrNotModerator)
+			return
+		}
+		if !forum.HasJoinRequest(msg.UserID) {
+			ctx.Respond(ErrJoinRequestNotFound)
+			return
+		}
+		forum.ResolveJoinRequest(msg.UserID, msg.Accept, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *AddModerator:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		if !forum.IsModerator(msg.ModeratorID) {
+			ctx.Respond(ErrNotModerator)
+			return
+		}
+		forum.AddModerator(msg.UserID, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *SetForumFilters:
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
+		}
+		if !forum.IsModerator(msg.ModeratorID) {
+			ctx.Respond(ErrNotModerator)
+			return
+		}
+		forum.SetFilters(msg.BannedWords, msg.BlockedDomains, fm.clock.Now())
+		ctx.Respond(forum.Clone())
+
+	case *SetAutoModerator:
+		if _, err := automod.Parse(msg.Rules); err != nil {
+			ctx.Respond(Errorf(Invalid, "%v", err))
+			return
+		}
+
+		fm.lock.Lock()
+		defer fm.lock.Unlock()
+
+		forum, exists := fm.forums[msg.ForumID]
+		if !exists {
+			ctx.Respond(ErrForumNotFound)
+			return
 		}
+		if !forum.IsModerator(msg.ModeratorID) {
+			ctx.Respond(ErrNotModerator)
+			return
+		}
+		forum.SetAutoModerator(msg.Rules, fm.clock.Now())
+		ctx.Respond(forum.Clone())
 
 	case *RemoveForum:
 		fm.lock.Lock()
 		defer fm.lock.Unlock()
 
 	
-		_, exists := fm.forums[msg.ForumID]
+		forum, exists := fm.forums[msg.ForumID]
 		if exists {
+			delete(fm.byName, forum.Name)
 			delete(fm.forums, msg.ForumID)
 			ctx.Respond(true)
 		} else {
-			ctx.Respond(false)
+			ctx.Respond(ErrForumNotFound)
 		}
 	}
 }
@@ -75,13 +457,17 @@ func (fm *ForumManager) Receive(ctx actor.Context) {
 
 
 type MemberManager struct {
-	profiles map[string]*schemas.Account
-	lock     sync.Mutex
+	*memberState
+	clock clock.Clock
+	ids   ids.Generator
 }
 
-func NewMemberManager() *MemberManager {
+func NewMemberManager(options ...Option) *MemberManager {
+	config := newManagerConfig(options)
 	return &MemberManager{
-		profiles: make(map[string]*schemas.Account),
+		clock:       config.clock,
+		ids:         config.ids,
+		memberState: config.storage.members,
 	}
 }
 
@@ -90,14 +476,42 @@ type RegisterUser struct {
 	DisplayName string
 }
 
+// EnsureUser registers the account of a part of the site that acts as a
+// user, such as AutoModerator, whose name is reserved, or replies with the
+// account if it exists.
+type EnsureUser struct {
+	Username string
+}
+
 type FetchUser struct {
 	ProfileID string
 }
 
+type FetchUserByName struct {
+	Username string
+}
+
+// FetchUsers looks up several profiles in one round trip. The reply holds
+// the profiles that exist, in the order of ProfileIDs.
+type FetchUsers struct {
+	ProfileIDs []string
+}
+
 type RemoveUser struct {
 	ProfileID string
 }
 
+type AdjustKarma struct {
+	ProfileID string
+	Delta     int
+}
+
+type UpdateProfile struct {
+	ProfileID string
+	Bio       string
+	AvatarURL string
+}
+
 
 func (mm *MemberManager) Receive(ctx actor.Context) {
 	switch msg := ctx.Message().(type) {
@@ -105,9 +519,39 @@ func (mm *MemberManager) Receive(ctx actor.Context) {
 		mm.lock.Lock()
 		defer mm.lock.Unlock()
 
-		profile := schemas.NewAccount(msg.DisplayName)
+		key, err := NormalizeUsername(msg.DisplayName)
+		if err != nil {
+			ctx.Respond(err)
+			return
+		}
+		if _, taken := mm.byName[key]; taken {
+			ctx.Respond(ErrUsernameTaken)
+			return
+		}
+
+		profile := schemas.NewAccount(mm.ids, msg.DisplayName, mm.clock.Now())
+		mm.profiles[profile.ID] = profile
+		mm.byName[key] = profile.ID
+		ctx.Respond(profile.Clone())
+
+	case *EnsureUser:
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		key := strings.ToLower(msg.Username)
+		if id, exists := mm.byName[key]; exists {
+			ctx.Respond(mm.profiles[id].Clone())
+			return
+		}
+		if !usernamePattern.MatchString(msg.Username) {
+			ctx.Respond(ErrUsernameInvalid)
+			return
+		}
+
+		profile := schemas.NewAccount(mm.ids, msg.Username, mm.clock.Now())
 		mm.profiles[profile.ID] = profile
-		ctx.Respond(profile)
+		mm.byName[key] = profile.ID
+		ctx.Respond(profile.Clone())
 
 	case *FetchUser:
 		mm.lock.Lock()
@@ -115,17 +559,75 @@ func (mm *MemberManager) Receive(ctx actor.Context) {
 
 		profile, exists := mm.profiles[msg.ProfileID]
 		if !exists {
-			ctx.Respond(errors.New("user profile not found"))
+			ctx.Respond(ErrUserNotFound)
 		} else {
-			ctx.Respond(profile)
+			ctx.Respond(profile.Clone())
 		}
 
+	case *FetchUserByName:
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		profileID, exists := mm.byName[strings.ToLower(msg.Username)]
+		if !exists {
+			ctx.Respond(ErrUserNotFound)
+			return
+		}
+		ctx.Respond(mm.profiles[profileID].Clone())
+
+	case *FetchUsers:
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		profiles := []*schemas.Account{}
+		for _, id := range msg.ProfileIDs {
+			if profile, exists := mm.profiles[id]; exists {
+				profiles = append(profiles, profile.Clone())
+			}
+		}
+		ctx.Respond(profiles)
+
 	case *RemoveUser:
 		mm.lock.Lock()
 		defer mm.lock.Unlock()
 
+		profile, exists := mm.profiles[msg.ProfileID]
+		if !exists {
+			ctx.Respond(ErrUserNotFound)
+			return
+		}
+		delete(mm.byName, strings.ToLower(profile.Username))
 		delete(mm.profiles, msg.ProfileID)
 		ctx.Respond(true)
+
+	case *AdjustKarma:
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		// Karma is usually adjusted with Send; only requests get a reply.
+		profile, exists := mm.profiles[msg.ProfileID]
+		if !exists {
+			respondIfAsked(ctx, ErrUserNotFound)
+			return
+		}
+		if msg.Delta >= 0 {
+			profile.IncrementKarma(msg.Delta, mm.clock.Now())
+		} else {
+			profile.DecrementKarma(-msg.Delta, mm.clock.Now())
+		}
+		respondIfAsked(ctx, profile.Clone())
+
+	case *UpdateProfile:
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		profile, exists := mm.profiles[msg.ProfileID]
+		if !exists {
+			ctx.Respond(ErrUserNotFound)
+			return
+		}
+		profile.UpdateProfile(msg.Bio, msg.AvatarURL, mm.clock.Now())
+		ctx.Respond(profile.Clone())
 	}
 }
 
@@ -134,104 +636,201 @@ func (mm *MemberManager) Receive(ctx actor.Context) {
 
 
 
+// PostManager routes post messages. Each post is served by its own child
+// actor, activated on demand and passivated when idle, so operations on
+// different posts run concurrently; the manager itself only creates posts
+// and answers listings from the shared store.
 type PostManager struct {
-	posts map[string]*schemas.Post
-	mutex sync.Mutex
+	store  *postStore
+	router entityRouter
+	clock  clock.Clock
+	ids    ids.Generator
 }
 
-func NewPostManager() *PostManager {
+func NewPostManager(options ...Option) *PostManager {
+	config := newManagerConfig(options)
 	return &PostManager{
-		posts: make(map[string]*schemas.Post),
+		clock:  config.clock,
+		ids:    config.ids,
+		store:  config.storage.posts,
+		router: config.entityRouter(PostKind, postProps),
 	}
 }
 
 
+// AddPost creates a post. A Held post waits in the modqueue and stays out of
+// the listings until it is approved. Flair is the label AutoModerator gave it.
 type AddPost struct {
 	ForumID  string
 	AuthorID string
 	Text     string
+	Mentions []schemas.Mention
+	Held     bool
+	Flair    string
 }
 
 type RetrievePost struct {
 	ContentID string
 }
 
-type RetrieveAllPosts struct{} 
+// RetrieveAllPosts lists the posts of every forum but those in
+// HiddenForums.
+type RetrieveAllPosts struct {
+	HiddenForums []string
+}
+
+// RetrievePosts looks up several posts in one round trip, from the store
+// rather than through each post's actor. The reply holds the posts that
+// exist, in the order of ContentIDs.
+type RetrievePosts struct {
+	ContentIDs []string
+}
+
+type RetrieveForumPosts struct {
+	ForumID string
+}
+
+type VotePost struct {
+	ContentID string
+	Upvote    bool
+}
+
+// RetrieveAuthorPosts lists the posts of AuthorID, leaving out those in
+// HiddenForums.
+type RetrieveAuthorPosts struct {
+	AuthorID     string
+	Page         Page
+	HiddenForums []string
+}
 
 type RemovePost struct {
 	ContentID string
 }
 
+// ApprovePost releases a held post into the listings. Approving a post that
+// is not held changes nothing.
+type ApprovePost struct {
+	ContentID string
+}
+
+// CountActive asks PostManager or CommentService how many per-entity actors
+// are currently activated.
+type CountActive struct{}
+
 func (pm *PostManager) Receive(ctx actor.Context) {
+	if pm.router.handle(ctx) {
+		return
+	}
+
 	switch msg := ctx.Message().(type) {
 	case *AddPost:
-		log.Printf("Received AddPost message: %+v\n", msg)
-		pm.mutex.Lock()
-		defer pm.mutex.Unlock()
-
-		// Create a new post
-		post := schemas.NewPost(msg.AuthorID, msg.ForumID, msg.Text)
-		pm.posts[post.ID] = post
-		log.Printf("Post added: %+v\n", post)
+		post := schemas.NewPost(pm.ids, msg.AuthorID, msg.ForumID, msg.Text, pm.clock.Now())
+		post.Mentions = msg.Mentions
+		post.Held = msg.Held
+		post.Flair = msg.Flair
+		pm.store.put(post)
 		ctx.Respond(post)
 
 	case *RetrievePost:
-		pm.mutex.Lock()
-		defer pm.mutex.Unlock()
-
-		post, exists := pm.posts[msg.ContentID]
-		if !exists {
-			ctx.Respond(nil)
-		} else {
-			ctx.Respond(post)
+		if !pm.exists(msg.ContentID) {
+			ctx.Respond(ErrPostNotFound)
+			return
 		}
+		pm.router.forward(ctx, msg.ContentID, msg)
 
 	case *RetrieveAllPosts:
-		pm.mutex.Lock()
-		defer pm.mutex.Unlock()
+		hidden := newForumSet(msg.HiddenForums)
+		ctx.Respond(pm.store.all(func(post *schemas.Post) bool {
+			return listed(post) && !hidden[post.SubredditID]
+		}))
+
+	case *RetrievePosts:
+		posts := []*schemas.Post{}
+		for _, id := range msg.ContentIDs {
+			if post, exists := pm.store.get(id); exists {
+				posts = append(posts, post)
+			}
+		}
+		ctx.Respond(posts)
 
-		var allPosts []*schemas.Post
-		for _, post := range pm.posts {
-			allPosts = append(allPosts, post)
+	case *RetrieveForumPosts:
+		ctx.Respond(pm.store.all(func(post *schemas.Post) bool {
+			return post.SubredditID == msg.ForumID && listed(post)
+		}))
+
+	case *VotePost:
+		if !pm.exists(msg.ContentID) {
+			ctx.Respond(ErrPostNotFound)
+			return
 		}
-		ctx.Respond(allPosts)
+		pm.router.forward(ctx, msg.ContentID, msg)
+
+	case *RetrieveAuthorPosts:
+		posts := postsOutside(listedPosts(pm.store.byAuthorID(msg.AuthorID)), newForumSet(msg.HiddenForums))
+		ctx.Respond(postListing(posts, msg.Page))
 
 	case *RemovePost:
-		pm.mutex.Lock()
-		defer pm.mutex.Unlock()
+		if !pm.exists(msg.ContentID) {
+			ctx.Respond(ErrPostNotFound)
+			return
+		}
+		pm.router.forward(ctx, msg.ContentID, msg)
 
-		_, exists := pm.posts[msg.ContentID]
-		if exists {
-			delete(pm.posts, msg.ContentID)
-			ctx.Respond(true)
-		} else {
-			ctx.Respond(false)
+	case *ApprovePost:
+		if !pm.exists(msg.ContentID) {
+			ctx.Respond(ErrPostNotFound)
+			return
 		}
+		pm.router.forward(ctx, msg.ContentID, msg)
+
+	case *CountActive:
+		ctx.Respond(pm.router.count())
+
+	case *actor.Started, *actor.Stopping, *actor.Stopped, *actor.Restarting:
+
 	default:
-		log.Printf("Unknown message type received: %+v\n", msg)
-		ctx.Respond(errors.New("unknown message"))
+		logging.ForMessage(slog.Default(), ctx).Warn("unknown message", "type", fmt.Sprintf("%T", msg))
+		ctx.Respond(ErrUnknownMessage)
 	}
 }
 
+// exists avoids activating an actor for a post that was never created. An
+// activated post is always routed to, since only it knows whether a removal
+// is still in flight.
+func (pm *PostManager) exists(id string) bool {
+	if pm.router.isActive(id) {
+		return true
+	}
+	_, exists := pm.store.get(id)
+	return exists
+}
+
 
 
 
 type MessageManager struct {
-	messageStore map[string][]schemas.Message
-	lock         sync.Mutex
+	*messageState
+	clock clock.Clock
+	ids   ids.Generator
 }
 
-func NewMessageManager() *MessageManager {
+func NewMessageManager(options ...Option) *MessageManager {
+	config := newManagerConfig(options)
 	return &MessageManager{
-		messageStore: make(map[string][]schemas.Message),
+		clock:        config.clock,
+		ids:          config.ids,
+		messageState: config.storage.messages,
 	}
 }
 
 
+// SendMessage delivers a message to ToUserID, unless it is Held: a held
+// message is kept only in its sender's inbox until DeliverMessage.
 type SendMessage struct {
 	FromUserID string
 	ToUserID   string
 	Body       string
+	Held       bool
 }
 
 type FetchMessages struct {
@@ -242,17 +841,26 @@ type RemoveMessage struct {
 	MessageID string
 }
 
+// DeliverMessage releases a held message to its receiver. Delivering a
+// message that is not held changes nothing.
+type DeliverMessage struct {
+	MessageID string
+}
+
 func (mm *MessageManager) Receive(ctx actor.Context) {
 	switch msg := ctx.Message().(type) {
 	case *SendMessage:
 		mm.lock.Lock()
 		defer mm.lock.Unlock()
 
-		message := schemas.NewMessage(msg.FromUserID, msg.ToUserID, msg.Body)
+		message := schemas.NewMessage(mm.ids, msg.FromUserID, msg.ToUserID, msg.Body, mm.clock.Now())
+		message.Held = msg.Held
 
 		
 		mm.messageStore[msg.FromUserID] = append(mm.messageStore[msg.FromUserID], *message)
-		mm.messageStore[msg.ToUserID] = append(mm.messageStore[msg.ToUserID], *message)
+		if !message.Held {
+			mm.messageStore[msg.ToUserID] = append(mm.messageStore[msg.ToUserID], *message)
+		}
 
 		ctx.Respond(message)
 
@@ -266,44 +874,87 @@ func (mm *MessageManager) Receive(ctx actor.Context) {
 			
 			ctx.Respond([]schemas.Message{})
 		} else {
-			ctx.Respond(userMessages)
+			// DeliverMessage and RemoveMessage change the stored slice in
+			// place, so the reply gets its own copy.
+			ctx.Respond(append([]schemas.Message{}, userMessages...))
 		}
 
 	case *RemoveMessage:
 		mm.lock.Lock()
 		defer mm.lock.Unlock()
 
-		
+		removed := false
 		for user, messages := range mm.messageStore {
 			for i, message := range messages {
 				if message.ID == msg.MessageID {
 			
 					mm.messageStore[user] = append(messages[:i], messages[i+1:]...)
+					removed = true
 					break
 				}
 			}
 		}
+		if !removed {
+			ctx.Respond(ErrMessageNotFound)
+			return
+		}
 		ctx.Respond(true)
+
+	case *DeliverMessage:
+		mm.lock.Lock()
+		defer mm.lock.Unlock()
+
+		// A held message is only in its sender's inbox.
+		for _, messages := range mm.messageStore {
+			for i := range messages {
+				message := &messages[i]
+				if message.ID != msg.MessageID {
+					continue
+				}
+				if message.Held {
+					message.Held = false
+					message.UpdatedAt = mm.clock.Now()
+					mm.messageStore[message.ReceiverID] = append(mm.messageStore[message.ReceiverID], *message)
+				}
+				delivered := *message
+				ctx.Respond(&delivered)
+				return
+			}
+		}
+		ctx.Respond(ErrMessageNotFound)
 	}
 }
 
 
 
+// CommentService routes comment messages to one child actor per thread,
+// i.e. per post, which owns that post's reply tree. Threads are activated
+// on demand and passivated when idle. It reads the forums of posts from the
+// post store to leave out the comments in hidden forums.
 type CommentService struct {
-	comments map[string]*schemas.Comment
-	mutex    sync.Mutex
+	store  *commentStore
+	posts  *postStore
+	router entityRouter
 }
 
-func NewCommentService() *CommentService {
+func NewCommentService(options ...Option) *CommentService {
+	config := newManagerConfig(options)
 	return &CommentService{
-		comments: make(map[string]*schemas.Comment),
+		store:  config.storage.comments,
+		posts:  config.storage.posts,
+		router: config.entityRouter(ThreadKind, threadProps),
 	}
 }
 
+// AddComment adds a comment to a thread. A Held comment is not linked into
+// the thread until it is approved.
 type AddComment struct {
+	PostID   string
 	ParentID string
 	AuthorID string
 	Content  string
+	Mentions []schemas.Mention
+	Held     bool
 }
 
 type FetchComment struct {
@@ -314,75 +965,173 @@ type RemoveComment struct {
 	CommentID string
 }
 
+// ApproveComment links a held comment into its thread. Approving a comment
+// that is not held changes nothing.
+type ApproveComment struct {
+	CommentID string
+}
+
+type FetchPostComments struct {
+	PostID string
+}
+
+// FetchThreads returns the comments on several posts in one round trip, from
+// the store rather than through each thread's actor. The comments are flat,
+// without Replies, grouped by post in the order of PostIDs and oldest first
+// within a post.
+type FetchThreads struct {
+	PostIDs []string
+}
+
+type VoteComment struct {
+	CommentID string
+	Upvote    bool
+}
+
+// FetchAuthorComments lists the comments of AuthorID, leaving out those on
+// posts in HiddenForums.
+type FetchAuthorComments struct {
+	AuthorID     string
+	Page         Page
+	HiddenForums []string
+}
+
 func (cs *CommentService) Receive(ctx actor.Context) {
+	if cs.router.handle(ctx) {
+		return
+	}
+
 	switch msg := ctx.Message().(type) {
 
 	case *AddComment:
 		cs.handleAddComment(ctx, msg)
 
 	case *FetchComment:
-		cs.handleFetchComment(ctx, msg)
+		cs.routeByComment(ctx, msg.CommentID)
 
 	case *RemoveComment:
-		cs.handleRemoveComment(ctx, msg)
+		cs.routeByComment(ctx, msg.CommentID)
+
+	case *ApproveComment:
+		cs.routeByComment(ctx, msg.CommentID)
+
+	case *FetchPostComments:
+		cs.router.forward(ctx, msg.PostID, msg)
+
+	case *FetchThreads:
+		comments := []*schemas.Comment{}
+		for _, postID := range msg.PostIDs {
+			comments = append(comments, listedComments(cs.store.thread(postID))...)
+		}
+		ctx.Respond(comments)
+
+	case *VoteComment:
+		cs.routeByComment(ctx, msg.CommentID)
+
+	case *FetchAuthorComments:
+		comments := cs.outside(listedComments(cs.store.byAuthorID(msg.AuthorID)), newForumSet(msg.HiddenForums))
+		ctx.Respond(commentListing(comments, msg.Page))
+
+	case *CountActive:
+		ctx.Respond(cs.router.count())
 	}
 }
 
 
+// handleAddComment routes a new comment to its thread. A reply may omit
+// PostID, in which case it joins its parent's thread.
 func (cs *CommentService) handleAddComment(ctx actor.Context, msg *AddComment) {
-	cs.mutex.Lock()
-	defer cs.mutex.Unlock()
-
-	comment := schemas.NewComment(msg.AuthorID, msg.Content)
-
-	if msg.ParentID != "" {
-		parent, exists := cs.comments[msg.ParentID]
+	if msg.ParentID != "" && msg.PostID == "" {
+		parent, exists := cs.store.get(msg.ParentID)
 		if !exists {
-			ctx.Respond(errors.New("parent comment not found"))
+			ctx.Respond(ErrParentNotFound)
 			return
 		}
-		parent.AddReply(comment)
-		cs.comments[comment.ID] = comment
-	} else {
-		cs.comments[comment.ID] = comment
+		routed := *msg
+		routed.PostID = parent.PostID
+		msg = &routed
 	}
-
-	ctx.Respond(comment)
+	cs.router.forward(ctx, msg.PostID, msg)
 }
 
 
-func (cs *CommentService) handleFetchComment(ctx actor.Context, msg *FetchComment) {
-	cs.mutex.Lock()
-	defer cs.mutex.Unlock()
+// outside drops the comments on posts in hidden.
+func (cs *CommentService) outside(comments []*schemas.Comment, hidden forumSet) []*schemas.Comment {
+	if len(hidden) == 0 {
+		return comments
+	}
+	kept := comments[:0]
+	for _, comment := range comments {
+		if post, exists := cs.posts.get(comment.PostID); exists && hidden[post.SubredditID] {
+			continue
+		}
+		kept = append(kept, comment)
+	}
+	return kept
+}
 
-	comment, exists := cs.comments[msg.CommentID]
+// routeByComment forwards the current message to the thread holding
+// commentID.
+func (cs *CommentService) routeByComment(ctx actor.Context, commentID string) {
+	comment, exists := cs.store.get(commentID)
 	if !exists {
-		ctx.Respond(errors.New("comment not found"))
+		ctx.Respond(ErrCommentNotFound)
 		return
 	}
-	ctx.Respond(comment)
+	cs.router.forward(ctx, comment.PostID, ctx.Message())
 }
 
 
-func (cs *CommentService) handleRemoveComment(ctx actor.Context, msg *RemoveComment) {
-	cs.mutex.Lock()
-	defer cs.mutex.Unlock()
 
-	_, exists := cs.comments[msg.CommentID]
-	if !exists {
-		ctx.Respond(false)
-		return
-	}
+type NotificationManager struct {
+	*notificationState
+	clock clock.Clock
+	ids   ids.Generator
+}
 
-	for _, c := range cs.comments {
-		for i, reply := range c.Replies {
-			if reply.ID == msg.CommentID {
-				c.Replies = append(c.Replies[:i], c.Replies[i+1:]...)
-				break
-			}
-		}
+func NewNotificationManager(options ...Option) *NotificationManager {
+	config := newManagerConfig(options)
+	return &NotificationManager{
+		clock:             config.clock,
+		ids:               config.ids,
+		notificationState: config.storage.notifications,
 	}
+}
+
+
+type PushNotification struct {
+	UserID   string
+	Kind     string
+	SourceID string
+	ActorID  string
+}
+
+type FetchNotifications struct {
+	UserID string
+}
 
-	delete(cs.comments, msg.CommentID)
-	ctx.Respond(true)
+func (nm *NotificationManager) Receive(ctx actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case *PushNotification:
+		nm.lock.Lock()
+		defer nm.lock.Unlock()
+
+		notification := schemas.NewNotification(nm.ids, msg.UserID, msg.Kind, msg.SourceID, msg.ActorID, nm.clock.Now())
+		nm.inbox[msg.UserID] = append(nm.inbox[msg.UserID], notification)
+		respondIfAsked(ctx, notification)
+
+	case *FetchNotifications:
+		nm.lock.Lock()
+		defer nm.lock.Unlock()
+
+		// PushNotification appends to the stored slice, so the reply gets
+		// its own copy.
+		ctx.Respond(append([]*schemas.Notification{}, nm.inbox[msg.UserID]...))
+	}
 }
+
+
+
+
+
+
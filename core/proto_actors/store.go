@@ -0,0 +1,278 @@
+package proto_actor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"reddit-clone/schemas"
+	"sync"
+)
+
+// Storage is the state of every manager and per-entity actor. It outlives
+// the actors themselves: an entity loads its state on activation, and a
+// manager restarted by its supervisor picks up where it crashed. Managers
+// create their own by default; nodes of a cluster must share one so that an
+// entity reactivated on another node after a failover finds its state.
+type Storage struct {
+	// id tells Storages apart, so that cluster members can check that they
+	// share one.
+	id            string
+	posts         *postStore
+	comments      *commentStore
+	forums        *forumState
+	members       *memberState
+	messages      *messageState
+	notifications *notificationState
+	modqueue      *modqueueState
+}
+
+func NewStorage() *Storage {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return &Storage{
+		id:       hex.EncodeToString(id),
+		posts:    newPostStore(),
+		comments: newCommentStore(),
+		forums: &forumState{
+			forums: make(map[string]*schemas.Subreddit),
+			byName: make(map[string]string),
+		},
+		members: &memberState{
+			profiles: make(map[string]*schemas.Account),
+			byName:   make(map[string]string),
+		},
+		messages:      &messageState{messageStore: make(map[string][]schemas.Message)},
+		notifications: &notificationState{inbox: make(map[string][]*schemas.Notification)},
+		modqueue:      &modqueueState{items: make(map[string]*schemas.QueueItem)},
+	}
+}
+
+// Counts is the number of entities of each kind in a Storage.
+type Counts struct {
+	Users    int
+	Forums   int
+	Posts    int
+	Comments int
+	Messages int
+}
+
+// Counts reports how many entities s holds. Each kind is counted under its
+// own lock, so the counts may be from slightly different moments.
+func (s *Storage) Counts() Counts {
+	var counts Counts
+
+	s.members.lock.Lock()
+	counts.Users = len(s.members.profiles)
+	s.members.lock.Unlock()
+
+	s.forums.lock.Lock()
+	counts.Forums = len(s.forums.forums)
+	s.forums.lock.Unlock()
+
+	s.posts.mutex.RLock()
+	counts.Posts = len(s.posts.posts)
+	s.posts.mutex.RUnlock()
+
+	s.comments.mutex.RLock()
+	counts.Comments = len(s.comments.comments)
+	s.comments.mutex.RUnlock()
+
+	// Every message is kept in the inbox of both its sender and receiver.
+	s.messages.lock.Lock()
+	seen := make(map[string]bool)
+	for _, messages := range s.messages.messageStore {
+		for _, message := range messages {
+			seen[message.ID] = true
+		}
+	}
+	counts.Messages = len(seen)
+	s.messages.lock.Unlock()
+
+	return counts
+}
+
+// The states below are owned by one manager each, which embeds its state and
+// holds the lock while handling a message.
+
+type forumState struct {
+	forums map[string]*schemas.Subreddit
+	byName map[string]string
+	lock   sync.Mutex
+}
+
+type memberState struct {
+	profiles map[string]*schemas.Account
+	byName   map[string]string
+	lock     sync.Mutex
+}
+
+type messageState struct {
+	messageStore map[string][]schemas.Message
+	lock         sync.Mutex
+}
+
+type notificationState struct {
+	inbox map[string][]*schemas.Notification
+	lock  sync.Mutex
+}
+
+type modqueueState struct {
+	items map[string]*schemas.QueueItem
+	lock  sync.Mutex
+}
+
+// postStore is the backing storage that post actors load from on activation
+// and write through to on every change. It only ever hands out copies, so
+// callers can read results while the owning actor keeps mutating its post.
+type postStore struct {
+	mutex    sync.RWMutex
+	posts    map[string]*schemas.Post
+	byAuthor map[string][]string
+}
+
+func newPostStore() *postStore {
+	return &postStore{
+		posts:    make(map[string]*schemas.Post),
+		byAuthor: make(map[string][]string),
+	}
+}
+
+func (s *postStore) get(id string) (*schemas.Post, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	post, exists := s.posts[id]
+	if !exists {
+		return nil, false
+	}
+	return post.Clone(), true
+}
+
+func (s *postStore) put(post *schemas.Post) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.posts[post.ID]; !exists {
+		s.byAuthor[post.AuthorID] = append(s.byAuthor[post.AuthorID], post.ID)
+	}
+	s.posts[post.ID] = post.Clone()
+}
+
+func (s *postStore) remove(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	post, exists := s.posts[id]
+	if !exists {
+		return false
+	}
+	s.byAuthor[post.AuthorID] = removeID(s.byAuthor[post.AuthorID], id)
+	delete(s.posts, id)
+	return true
+}
+
+// all returns copies of the posts accepted by keep, or of every post when
+// keep is nil.
+func (s *postStore) all(keep func(*schemas.Post) bool) []*schemas.Post {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	posts := []*schemas.Post{}
+	for _, post := range s.posts {
+		if keep == nil || keep(post) {
+			posts = append(posts, post.Clone())
+		}
+	}
+	return posts
+}
+
+func (s *postStore) byAuthorID(authorID string) []*schemas.Post {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ids := s.byAuthor[authorID]
+	posts := make([]*schemas.Post, 0, len(ids))
+	for _, id := range ids {
+		posts = append(posts, s.posts[id].Clone())
+	}
+	return posts
+}
+
+// commentStore holds comments flat, without their Replies; thread actors
+// rebuild the reply tree from ParentID when they activate.
+type commentStore struct {
+	mutex    sync.RWMutex
+	comments map[string]*schemas.Comment
+	byPost   map[string][]string
+	byAuthor map[string][]string
+}
+
+func newCommentStore() *commentStore {
+	return &commentStore{
+		comments: make(map[string]*schemas.Comment),
+		byPost:   make(map[string][]string),
+		byAuthor: make(map[string][]string),
+	}
+}
+
+func flatComment(comment *schemas.Comment) *schemas.Comment {
+	flat := *comment
+	flat.Mentions = append([]schemas.Mention(nil), comment.Mentions...)
+	flat.Replies = []*schemas.Comment{}
+	return &flat
+}
+
+func (s *commentStore) get(id string) (*schemas.Comment, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	comment, exists := s.comments[id]
+	if !exists {
+		return nil, false
+	}
+	return flatComment(comment), true
+}
+
+func (s *commentStore) put(comment *schemas.Comment) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.comments[comment.ID]; !exists {
+		s.byPost[comment.PostID] = append(s.byPost[comment.PostID], comment.ID)
+		s.byAuthor[comment.AuthorID] = append(s.byAuthor[comment.AuthorID], comment.ID)
+	}
+	s.comments[comment.ID] = flatComment(comment)
+}
+
+func (s *commentStore) remove(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	comment, exists := s.comments[id]
+	if !exists {
+		return false
+	}
+	s.byPost[comment.PostID] = removeID(s.byPost[comment.PostID], id)
+	s.byAuthor[comment.AuthorID] = removeID(s.byAuthor[comment.AuthorID], id)
+	delete(s.comments, id)
+	return true
+}
+
+func (s *commentStore) thread(postID string) []*schemas.Comment {
+	return s.list(postID, s.byPost)
+}
+
+func (s *commentStore) byAuthorID(authorID string) []*schemas.Comment {
+	return s.list(authorID, s.byAuthor)
+}
+
+func (s *commentStore) list(key string, index map[string][]string) []*schemas.Comment {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ids := index[key]
+	comments := make([]*schemas.Comment, 0, len(ids))
+	for _, id := range ids {
+		comments = append(comments, flatComment(s.comments[id]))
+	}
+	return comments
+}
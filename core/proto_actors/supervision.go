@@ -0,0 +1,208 @@
+package proto_actor
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/eventstream"
+)
+
+// RestartPolicy is the supervisor strategy for managers and per-entity
+// actors. A crashed actor is restarted after a backoff that doubles with
+// every failure inside Window, from InitialBackoff up to MaxBackoff. The
+// message it crashed on is dropped; its state is reloaded from the Storage.
+//
+// An actor that fails more than MaxRestarts times inside Window is stopped
+// instead. A per-entity actor is then activated afresh on its next message,
+// and the guardian started by SpawnManagers respawns a stopped manager under
+// the same PID. Zero MaxRestarts never stops the actor.
+type RestartPolicy struct {
+	MaxRestarts    int
+	Window         time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRestartPolicy is used unless WithRestartPolicy says otherwise.
+var DefaultRestartPolicy = RestartPolicy{
+	MaxRestarts:    10,
+	Window:         time.Minute,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// Backoff is the delay before the restart following the given number of
+// consecutive failures.
+func (p RestartPolicy) Backoff(failures int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < failures && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// HandleFailure implements actor.SupervisorStrategy.
+func (p RestartPolicy) HandleFailure(system *actor.ActorSystem, supervisor actor.Supervisor, child *actor.PID, rs *actor.RestartStatistics, reason interface{}, message interface{}) {
+	if rs.NumberOfFailures(p.Window) == 0 {
+		rs.Reset()
+	}
+	rs.Fail()
+
+	failures := rs.FailureCount()
+	if p.MaxRestarts > 0 && failures > p.MaxRestarts {
+		slog.Error("stopping actor", "pid", child.Id, "failures", failures, "reason", reason)
+		publishFailure(system, child, reason, actor.StopDirective)
+		supervisor.StopChildren(child)
+		return
+	}
+
+	backoff := p.Backoff(failures)
+	slog.Warn("restarting actor", "pid", child.Id, "backoff", backoff, "failures", failures, "message", fmt.Sprintf("%T", message), "reason", reason)
+	publishFailure(system, child, reason, actor.RestartDirective)
+	time.AfterFunc(backoff, func() {
+		supervisor.RestartChildren(child)
+	})
+}
+
+func publishFailure(system *actor.ActorSystem, child *actor.PID, reason interface{}, directive actor.Directive) {
+	system.EventStream.Publish(&actor.SupervisorEvent{Child: child, Reason: reason, Directive: directive})
+}
+
+// Managers are the PIDs of the manager actors started by SpawnManagers.
+type Managers struct {
+	Members       *actor.PID
+	Forums        *actor.PID
+	Posts         *actor.PID
+	Comments      *actor.PID
+	Messages      *actor.PID
+	Notifications *actor.PID
+	Moderation    *actor.PID
+	AutoModerator *actor.PID
+}
+
+// SpawnManagers starts every manager as a child of one guardian actor, which
+// supervises them with the configured RestartPolicy. All managers share one
+// Storage, the one given with WithStorage or else a new one, so a restarted
+// manager recovers the state its predecessor wrote.
+func SpawnManagers(root *actor.RootContext, options ...Option) (*Managers, error) {
+	config := newManagerConfig(options)
+	options = append(options, WithStorage(config.storage))
+
+	producers := map[string]actor.Producer{
+		"members":       func() actor.Actor { return NewMemberManager(options...) },
+		"forums":        func() actor.Actor { return NewForumManager(options...) },
+		"posts":         func() actor.Actor { return NewPostManager(options...) },
+		"comments":      func() actor.Actor { return NewCommentService(options...) },
+		"messages":      func() actor.Actor { return NewMessageManager(options...) },
+		"notifications": func() actor.Actor { return NewNotificationManager(options...) },
+		"moderation":    func() actor.Actor { return NewModerationManager(options...) },
+		"automoderator": func() actor.Actor { return NewAutoModerator(options...) },
+	}
+	guardian := root.SpawnPrefix(config.props("managers", func() actor.Actor {
+		return &guardian{config: config, producers: producers, children: make(map[string]*actor.PID)}
+	}), "managers")
+
+	res, err := root.RequestFuture(guardian, &fetchManagers{}, config.timeout).Result()
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Managers), nil
+}
+
+type fetchManagers struct{}
+
+// guardian spawns the managers under fixed names and respawns any that its
+// RestartPolicy gave up on. Since the names do not change, neither do the
+// PIDs callers hold.
+type guardian struct {
+	config    managerConfig
+	producers map[string]actor.Producer
+	children  map[string]*actor.PID
+	stopping  bool
+}
+
+func (g *guardian) Receive(ctx actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case *actor.Started:
+		for name := range g.producers {
+			g.spawn(ctx, name)
+		}
+
+	case *actor.Stopping:
+		g.stopping = true
+
+	case *actor.Terminated:
+		if g.stopping {
+			return
+		}
+		for name, pid := range g.children {
+			if pid.Equal(msg.Who) {
+				slog.Warn("respawning manager", "manager", name)
+				g.spawn(ctx, name)
+			}
+		}
+
+	case *fetchManagers:
+		ctx.Respond(&Managers{
+			Members:       g.children["members"],
+			Forums:        g.children["forums"],
+			Posts:         g.children["posts"],
+			Comments:      g.children["comments"],
+			Messages:      g.children["messages"],
+			Notifications: g.children["notifications"],
+			Moderation:    g.children["moderation"],
+			AutoModerator: g.children["automoderator"],
+		})
+	}
+}
+
+func (g *guardian) spawn(ctx actor.Context, name string) {
+	pid, err := ctx.SpawnNamed(g.config.props(name, g.producers[name]), name)
+	if err != nil {
+		slog.Error("spawning manager failed", "manager", name, "error", err)
+		return
+	}
+	g.children[name] = pid
+}
+
+// DeadLetterMonitor counts and logs messages sent to actors that no longer
+// exist, such as replies to requests that timed out or messages to a
+// manager that was stopped.
+type DeadLetterMonitor struct {
+	system       *actor.ActorSystem
+	subscription *eventstream.Subscription
+	count        atomic.Int64
+}
+
+// MonitorDeadLetters subscribes a monitor to the dead letters of system.
+func MonitorDeadLetters(system *actor.ActorSystem) *DeadLetterMonitor {
+	monitor := &DeadLetterMonitor{system: system}
+	monitor.subscription = system.EventStream.Subscribe(func(event interface{}) {
+		deadLetter, ok := event.(*actor.DeadLetterEvent)
+		if !ok {
+			return
+		}
+		// Idle checks routinely outlive the actor they were scheduled for.
+		if _, idle := deadLetter.Message.(*idleCheck); idle {
+			return
+		}
+		monitor.count.Add(1)
+		slog.Info("dead letter", "message", fmt.Sprintf("%T", deadLetter.Message), "pid", deadLetter.PID.String())
+	})
+	return monitor
+}
+
+// Count is the number of dead letters seen so far.
+func (m *DeadLetterMonitor) Count() int64 {
+	return m.count.Load()
+}
+
+func (m *DeadLetterMonitor) Stop() {
+	m.system.EventStream.Unsubscribe(m.subscription)
+}
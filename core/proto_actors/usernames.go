@@ -0,0 +1,47 @@
+package proto_actor
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrUsernameInvalid  = Errorf(Invalid, "username must be 3-20 characters of letters, digits, '_' or '-'")
+	ErrUsernameReserved = Errorf(Invalid, "username is reserved")
+	ErrUsernameTaken    = Errorf(Conflict, "username is already taken")
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,20}$`)
+
+// reservedUsernames cannot be registered by anyone. Names that look like
+// generated account IDs are also refused so a path segment or request field
+// can always be told apart as an ID or a username.
+var reservedUsernames = map[string]bool{
+	"admin":         true,
+	"administrator": true,
+	"api":           true,
+	"automoderator": true,
+	"by-name":       true,
+	"deleted":       true,
+	"me":            true,
+	"mod":           true,
+	"moderator":     true,
+	"null":          true,
+	"reddit":        true,
+	"removed":       true,
+	"root":          true,
+	"system":        true,
+}
+
+// NormalizeUsername validates a requested username and returns the
+// case-folded key used for uniqueness checks and lookups.
+func NormalizeUsername(name string) (string, error) {
+	if !usernamePattern.MatchString(name) {
+		return "", ErrUsernameInvalid
+	}
+	key := strings.ToLower(name)
+	if reservedUsernames[key] || strings.HasPrefix(key, "user_") {
+		return "", ErrUsernameReserved
+	}
+	return key, nil
+}
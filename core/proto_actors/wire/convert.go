@@ -0,0 +1,396 @@
+package wire
+
+import (
+	"reddit-clone/schemas"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The From functions convert schemas entities to their wire form and the
+// Schema methods convert back. Both map nil to nil.
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timeOf(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// FromTime converts a time carried by a request, keeping the zero time zero
+// as TimeSchema reads it back.
+func FromTime(t time.Time) *timestamppb.Timestamp {
+	return timestamp(t)
+}
+
+func TimeSchema(ts *timestamppb.Timestamp) time.Time {
+	return timeOf(ts)
+}
+
+func FromMentions(mentions []schemas.Mention) []*Mention {
+	if mentions == nil {
+		return nil
+	}
+	converted := make([]*Mention, len(mentions))
+	for i, m := range mentions {
+		converted[i] = &Mention{
+			Kind:     m.Kind,
+			Name:     m.Name,
+			TargetId: m.TargetID,
+			Start:    int32(m.Start),
+			End:      int32(m.End),
+		}
+	}
+	return converted
+}
+
+func MentionsSchema(mentions []*Mention) []schemas.Mention {
+	if mentions == nil {
+		return nil
+	}
+	converted := make([]schemas.Mention, len(mentions))
+	for i, m := range mentions {
+		converted[i] = schemas.Mention{
+			Kind:     m.GetKind(),
+			Name:     m.GetName(),
+			TargetID: m.GetTargetId(),
+			Start:    int(m.GetStart()),
+			End:      int(m.GetEnd()),
+		}
+	}
+	return converted
+}
+
+func FromPost(p *schemas.Post) *Post {
+	if p == nil {
+		return nil
+	}
+	return &Post{
+		Id:          p.ID,
+		Content:     p.Content,
+		AuthorId:    p.AuthorID,
+		SubredditId: p.SubredditID,
+		Upvotes:     int32(p.Upvotes),
+		Downvotes:   int32(p.Downvotes),
+		Comments:    FromComments(p.Comments),
+		Mentions:    FromMentions(p.Mentions),
+		CreatedAt:   timestamp(p.CreatedAt),
+		UpdatedAt:   timestamp(p.UpdatedAt),
+		Held:        p.Held,
+		Flair:       p.Flair,
+	}
+}
+
+func (x *Post) Schema() *schemas.Post {
+	if x == nil {
+		return nil
+	}
+	return &schemas.Post{
+		ID:          x.Id,
+		Content:     x.Content,
+		AuthorID:    x.AuthorId,
+		SubredditID: x.SubredditId,
+		Upvotes:     int(x.Upvotes),
+		Downvotes:   int(x.Downvotes),
+		Comments:    CommentsSchema(x.Comments),
+		Mentions:    MentionsSchema(x.Mentions),
+		CreatedAt:   timeOf(x.CreatedAt),
+		UpdatedAt:   timeOf(x.UpdatedAt),
+		Held:        x.Held,
+		Flair:       x.Flair,
+	}
+}
+
+func FromPosts(posts []*schemas.Post) []*Post {
+	converted := make([]*Post, len(posts))
+	for i, p := range posts {
+		converted[i] = FromPost(p)
+	}
+	return converted
+}
+
+func PostsSchema(posts []*Post) []*schemas.Post {
+	converted := make([]*schemas.Post, len(posts))
+	for i, p := range posts {
+		converted[i] = p.Schema()
+	}
+	return converted
+}
+
+func FromComment(c *schemas.Comment) *Comment {
+	if c == nil {
+		return nil
+	}
+	return &Comment{
+		Id:        c.ID,
+		Content:   c.Content,
+		AuthorId:  c.AuthorID,
+		PostId:    c.PostID,
+		ParentId:  c.ParentID,
+		Upvotes:   int32(c.Upvotes),
+		Downvotes: int32(c.Downvotes),
+		Replies:   FromComments(c.Replies),
+		Mentions:  FromMentions(c.Mentions),
+		CreatedAt: timestamp(c.CreatedAt),
+		UpdatedAt: timestamp(c.UpdatedAt),
+		Held:      c.Held,
+	}
+}
+
+func (x *Comment) Schema() *schemas.Comment {
+	if x == nil {
+		return nil
+	}
+	return &schemas.Comment{
+		ID:        x.Id,
+		Content:   x.Content,
+		AuthorID:  x.AuthorId,
+		PostID:    x.PostId,
+		ParentID:  x.ParentId,
+		Upvotes:   int(x.Upvotes),
+		Downvotes: int(x.Downvotes),
+		Replies:   CommentsSchema(x.Replies),
+		Mentions:  MentionsSchema(x.Mentions),
+		CreatedAt: timeOf(x.CreatedAt),
+		UpdatedAt: timeOf(x.UpdatedAt),
+		Held:      x.Held,
+	}
+}
+
+func FromComments(comments []*schemas.Comment) []*Comment {
+	converted := make([]*Comment, len(comments))
+	for i, c := range comments {
+		converted[i] = FromComment(c)
+	}
+	return converted
+}
+
+func CommentsSchema(comments []*Comment) []*schemas.Comment {
+	converted := make([]*schemas.Comment, len(comments))
+	for i, c := range comments {
+		converted[i] = c.Schema()
+	}
+	return converted
+}
+
+func FromSubreddit(s *schemas.Subreddit) *Subreddit {
+	if s == nil {
+		return nil
+	}
+	return &Subreddit{
+		Id:             s.ID,
+		Name:           s.Name,
+		Title:          s.Title,
+		Members:        s.Members,
+		Posts:          FromPosts(s.Posts),
+		CreatedAt:      timestamp(s.CreatedAt),
+		UpdatedAt:      timestamp(s.UpdatedAt),
+		BannedWords:    s.BannedWords,
+		BlockedDomains: s.BlockedDomains,
+		Automoderator:  s.AutoModerator,
+		Type:           s.Type,
+		ApprovedUsers:  s.ApprovedUsers,
+		JoinRequests:   s.JoinRequests,
+		CreatedBy:      s.CreatedBy,
+		Moderators:     s.Moderators,
+		Description:    s.Description,
+		Sidebar:        s.Sidebar,
+		Rules:          FromForumRules(s.Rules),
+		BannerUrl:      s.BannerURL,
+		IconUrl:        s.IconURL,
+		Nsfw:           s.NSFW,
+	}
+}
+
+func (x *Subreddit) Schema() *schemas.Subreddit {
+	if x == nil {
+		return nil
+	}
+	members := make(map[string]bool, len(x.Members))
+	for id, member := range x.Members {
+		members[id] = member
+	}
+	var approved map[string]bool
+	for id, ok := range x.ApprovedUsers {
+		if approved == nil {
+			approved = make(map[string]bool, len(x.ApprovedUsers))
+		}
+		approved[id] = ok
+	}
+	return &schemas.Subreddit{
+		ID:             x.Id,
+		Name:           x.Name,
+		Title:          x.Title,
+		Members:        members,
+		Posts:          PostsSchema(x.Posts),
+		CreatedAt:      timeOf(x.CreatedAt),
+		UpdatedAt:      timeOf(x.UpdatedAt),
+		BannedWords:    x.BannedWords,
+		BlockedDomains: x.BlockedDomains,
+		AutoModerator:  x.Automoderator,
+		Type:           x.Type,
+		ApprovedUsers:  approved,
+		JoinRequests:   x.JoinRequests,
+		CreatedBy:      x.CreatedBy,
+		Moderators:     x.Moderators,
+		ForumInfo: schemas.ForumInfo{
+			Description: x.Description,
+			Sidebar:     x.Sidebar,
+			Rules:       ForumRulesSchema(x.Rules),
+			BannerURL:   x.BannerUrl,
+			IconURL:     x.IconUrl,
+			NSFW:        x.Nsfw,
+		},
+	}
+}
+
+func FromForumRules(rules []schemas.ForumRule) []*ForumRule {
+	if rules == nil {
+		return nil
+	}
+	converted := make([]*ForumRule, len(rules))
+	for i, r := range rules {
+		converted[i] = &ForumRule{Title: r.Title, Description: r.Description}
+	}
+	return converted
+}
+
+func ForumRulesSchema(rules []*ForumRule) []schemas.ForumRule {
+	if rules == nil {
+		return nil
+	}
+	converted := make([]schemas.ForumRule, len(rules))
+	for i, r := range rules {
+		converted[i] = schemas.ForumRule{Title: r.GetTitle(), Description: r.GetDescription()}
+	}
+	return converted
+}
+
+func FromAccount(a *schemas.Account) *Account {
+	if a == nil {
+		return nil
+	}
+	return &Account{
+		Id:        a.ID,
+		Username:  a.Username,
+		Karma:     int32(a.Karma),
+		Bio:       a.Bio,
+		AvatarUrl: a.AvatarURL,
+		CreatedAt: timestamp(a.CreatedAt),
+		UpdatedAt: timestamp(a.UpdatedAt),
+	}
+}
+
+func (x *Account) Schema() *schemas.Account {
+	if x == nil {
+		return nil
+	}
+	return &schemas.Account{
+		ID:        x.Id,
+		Username:  x.Username,
+		Karma:     int(x.Karma),
+		Bio:       x.Bio,
+		AvatarURL: x.AvatarUrl,
+		CreatedAt: timeOf(x.CreatedAt),
+		UpdatedAt: timeOf(x.UpdatedAt),
+	}
+}
+
+func FromMessage(m *schemas.Message) *Message {
+	if m == nil {
+		return nil
+	}
+	return &Message{
+		Id:         m.ID,
+		SenderId:   m.SenderID,
+		ReceiverId: m.ReceiverID,
+		Content:    m.Content,
+		CreatedAt:  timestamp(m.CreatedAt),
+		UpdatedAt:  timestamp(m.UpdatedAt),
+		Held:       m.Held,
+	}
+}
+
+func (x *Message) Schema() *schemas.Message {
+	if x == nil {
+		return nil
+	}
+	return &schemas.Message{
+		ID:         x.Id,
+		SenderID:   x.SenderId,
+		ReceiverID: x.ReceiverId,
+		Content:    x.Content,
+		CreatedAt:  timeOf(x.CreatedAt),
+		UpdatedAt:  timeOf(x.UpdatedAt),
+		Held:       x.Held,
+	}
+}
+
+func FromNotification(n *schemas.Notification) *Notification {
+	if n == nil {
+		return nil
+	}
+	return &Notification{
+		Id:        n.ID,
+		UserId:    n.UserID,
+		Kind:      n.Kind,
+		SourceId:  n.SourceID,
+		ActorId:   n.ActorID,
+		CreatedAt: timestamp(n.CreatedAt),
+	}
+}
+
+func (x *Notification) Schema() *schemas.Notification {
+	if x == nil {
+		return nil
+	}
+	return &schemas.Notification{
+		ID:        x.Id,
+		UserID:    x.UserId,
+		Kind:      x.Kind,
+		SourceID:  x.SourceId,
+		ActorID:   x.ActorId,
+		CreatedAt: timeOf(x.CreatedAt),
+	}
+}
+
+func FromQueueItem(q *schemas.QueueItem) *QueueItem {
+	if q == nil {
+		return nil
+	}
+	return &QueueItem{
+		Id:        q.ID,
+		Kind:      q.Kind,
+		ContentId: q.ContentID,
+		ForumId:   q.ForumID,
+		AuthorId:  q.AuthorID,
+		Filter:    q.Filter,
+		Reason:    q.Reason,
+		Held:      q.Held,
+		CreatedAt: timestamp(q.CreatedAt),
+	}
+}
+
+func (x *QueueItem) Schema() *schemas.QueueItem {
+	if x == nil {
+		return nil
+	}
+	return &schemas.QueueItem{
+		ID:        x.Id,
+		Kind:      x.Kind,
+		ContentID: x.ContentId,
+		ForumID:   x.ForumId,
+		AuthorID:  x.AuthorId,
+		Filter:    x.Filter,
+		Reason:    x.Reason,
+		Held:      x.Held,
+		CreatedAt: timeOf(x.CreatedAt),
+	}
+}
@@ -0,0 +1,7 @@
+// Package wire holds the protobuf versions of the domain entities and
+// manager messages, which are exchanged between cluster nodes, and the gRPC
+// service built on them.
+package wire
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative reddit.proto service.proto
+//go:generate protoc --go-grpc_out=. --go-grpc_opt=paths=source_relative service.proto
@@ -0,0 +1,233 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        (unknown)
+// source: service.proto
+
+package wire
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_service_proto protoreflect.FileDescriptor
+
+var file_service_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x04, 0x77, 0x69, 0x72, 0x65, 0x1a, 0x0c, 0x72, 0x65, 0x64, 0x64, 0x69, 0x74, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x32, 0x91, 0x0a, 0x0a, 0x06, 0x52, 0x65, 0x64, 0x64, 0x69, 0x74, 0x12, 0x33,
+	0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x12, 0x16, 0x2e, 0x77, 0x69,
+	0x72, 0x65, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x41, 0x6c, 0x6c, 0x50, 0x6f,
+	0x73, 0x74, 0x73, 0x1a, 0x0e, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x4c,
+	0x69, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0a, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x50, 0x6f, 0x73,
+	0x74, 0x12, 0x0d, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x41, 0x64, 0x64, 0x50, 0x6f, 0x73, 0x74,
+	0x1a, 0x0a, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x07,
+	0x47, 0x65, 0x74, 0x50, 0x6f, 0x73, 0x74, 0x12, 0x12, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52,
+	0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x50, 0x6f, 0x73, 0x74, 0x1a, 0x0a, 0x2e, 0x77, 0x69,
+	0x72, 0x65, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x50, 0x6f, 0x73, 0x74, 0x12, 0x10, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x6d,
+	0x6f, 0x76, 0x65, 0x50, 0x6f, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52,
+	0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x26, 0x0a, 0x08, 0x56, 0x6f, 0x74, 0x65, 0x50, 0x6f,
+	0x73, 0x74, 0x12, 0x0e, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x50, 0x6f,
+	0x73, 0x74, 0x1a, 0x0a, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x12, 0x2d,
+	0x0a, 0x0a, 0x41, 0x64, 0x64, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x10, 0x2e, 0x77,
+	0x69, 0x72, 0x65, 0x2e, 0x41, 0x64, 0x64, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x1a, 0x0d,
+	0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x2f, 0x0a,
+	0x0a, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x2e, 0x77, 0x69,
+	0x72, 0x65, 0x2e, 0x46, 0x65, 0x74, 0x63, 0x68, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x1a,
+	0x0d, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x33,
+	0x0a, 0x0d, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x12,
+	0x13, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x43, 0x6f, 0x6d,
+	0x6d, 0x65, 0x6e, 0x74, 0x1a, 0x0d, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x6d, 0x6f,
+	0x76, 0x65, 0x64, 0x12, 0x2f, 0x0a, 0x0b, 0x56, 0x6f, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x65,
+	0x6e, 0x74, 0x12, 0x11, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x43, 0x6f,
+	0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x1a, 0x0d, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x43, 0x6f, 0x6d,
+	0x6d, 0x65, 0x6e, 0x74, 0x12, 0x36, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x73, 0x12, 0x13, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x46, 0x65, 0x74, 0x63,
+	0x68, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x1a, 0x11, 0x2e, 0x77, 0x69, 0x72, 0x65,
+	0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x2f, 0x0a, 0x0b,
+	0x53, 0x65, 0x6e, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x11, 0x2e, 0x77, 0x69,
+	0x72, 0x65, 0x2e, 0x53, 0x65, 0x6e, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x0d,
+	0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x33, 0x0a,
+	0x0d, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x13,
+	0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x1a, 0x0d, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76,
+	0x65, 0x64, 0x12, 0x2b, 0x0a, 0x08, 0x41, 0x64, 0x64, 0x46, 0x6f, 0x72, 0x75, 0x6d, 0x12, 0x0e,
+	0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x41, 0x64, 0x64, 0x46, 0x6f, 0x72, 0x75, 0x6d, 0x1a, 0x0f,
+	0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x53, 0x75, 0x62, 0x72, 0x65, 0x64, 0x64, 0x69, 0x74, 0x12,
+	0x30, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x46, 0x6f, 0x72, 0x75, 0x6d, 0x12, 0x13, 0x2e, 0x77, 0x69,
+	0x72, 0x65, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x46, 0x6f, 0x72, 0x75, 0x6d,
+	0x1a, 0x0f, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x53, 0x75, 0x62, 0x72, 0x65, 0x64, 0x64, 0x69,
+	0x74, 0x12, 0x3c, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x46, 0x6f, 0x72, 0x75, 0x6d, 0x42, 0x79, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x19, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x69,
+	0x65, 0x76, 0x65, 0x46, 0x6f, 0x72, 0x75, 0x6d, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x1a, 0x0f,
+	0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x53, 0x75, 0x62, 0x72, 0x65, 0x64, 0x64, 0x69, 0x74, 0x12,
+	0x31, 0x0a, 0x0b, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x46, 0x6f, 0x72, 0x75, 0x6d, 0x12, 0x11,
+	0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x46, 0x6f, 0x72, 0x75,
+	0x6d, 0x1a, 0x0f, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x53, 0x75, 0x62, 0x72, 0x65, 0x64, 0x64,
+	0x69, 0x74, 0x12, 0x2f, 0x0a, 0x0b, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x46, 0x6f, 0x72, 0x75,
+	0x6d, 0x12, 0x11, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x46,
+	0x6f, 0x72, 0x75, 0x6d, 0x1a, 0x0d, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x6d, 0x6f,
+	0x76, 0x65, 0x64, 0x12, 0x31, 0x0a, 0x0c, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x55,
+	0x73, 0x65, 0x72, 0x12, 0x12, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x65, 0x72, 0x55, 0x73, 0x65, 0x72, 0x1a, 0x0d, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x41,
+	0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x29, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x55, 0x73, 0x65,
+	0x72, 0x12, 0x0f, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x46, 0x65, 0x74, 0x63, 0x68, 0x55, 0x73,
+	0x65, 0x72, 0x1a, 0x0d, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x35, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x55, 0x73, 0x65, 0x72, 0x42, 0x79, 0x4e, 0x61,
+	0x6d, 0x65, 0x12, 0x15, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x46, 0x65, 0x74, 0x63, 0x68, 0x55,
+	0x73, 0x65, 0x72, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x1a, 0x0d, 0x2e, 0x77, 0x69, 0x72, 0x65,
+	0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x33, 0x0a, 0x0d, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x13, 0x2e, 0x77, 0x69, 0x72, 0x65,
+	0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x1a, 0x0d,
+	0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2d, 0x0a,
+	0x0a, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x10, 0x2e, 0x77, 0x69,
+	0x72, 0x65, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x1a, 0x0d, 0x2e,
+	0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x3d, 0x0a, 0x0d,
+	0x4c, 0x69, 0x73, 0x74, 0x55, 0x73, 0x65, 0x72, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x12, 0x19, 0x2e,
+	0x77, 0x69, 0x72, 0x65, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x41, 0x75, 0x74,
+	0x68, 0x6f, 0x72, 0x50, 0x6f, 0x73, 0x74, 0x73, 0x1a, 0x11, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e,
+	0x50, 0x6f, 0x73, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x43, 0x0a, 0x10, 0x4c,
+	0x69, 0x73, 0x74, 0x55, 0x73, 0x65, 0x72, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12,
+	0x19, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x46, 0x65, 0x74, 0x63, 0x68, 0x41, 0x75, 0x74, 0x68,
+	0x6f, 0x72, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x1a, 0x14, 0x2e, 0x77, 0x69, 0x72,
+	0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x67,
+	0x12, 0x45, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x46, 0x65, 0x74,
+	0x63, 0x68, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x1a,
+	0x16, 0x2e, 0x77, 0x69, 0x72, 0x65, 0x2e, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x25, 0x5a, 0x23, 0x72, 0x65, 0x64, 0x64, 0x69,
+	0x74, 0x2d, 0x63, 0x6c, 0x6f, 0x6e, 0x65, 0x2f, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x5f, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2f, 0x77, 0x69, 0x72, 0x65, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var file_service_proto_goTypes = []interface{}{
+	(*RetrieveAllPosts)(nil),    // 0: wire.RetrieveAllPosts
+	(*AddPost)(nil),             // 1: wire.AddPost
+	(*RetrievePost)(nil),        // 2: wire.RetrievePost
+	(*RemovePost)(nil),          // 3: wire.RemovePost
+	(*VotePost)(nil),            // 4: wire.VotePost
+	(*AddComment)(nil),          // 5: wire.AddComment
+	(*FetchComment)(nil),        // 6: wire.FetchComment
+	(*RemoveComment)(nil),       // 7: wire.RemoveComment
+	(*VoteComment)(nil),         // 8: wire.VoteComment
+	(*FetchMessages)(nil),       // 9: wire.FetchMessages
+	(*SendMessage)(nil),         // 10: wire.SendMessage
+	(*RemoveMessage)(nil),       // 11: wire.RemoveMessage
+	(*AddForum)(nil),            // 12: wire.AddForum
+	(*RetrieveForum)(nil),       // 13: wire.RetrieveForum
+	(*RetrieveForumByName)(nil), // 14: wire.RetrieveForumByName
+	(*RenameForum)(nil),         // 15: wire.RenameForum
+	(*RemoveForum)(nil),         // 16: wire.RemoveForum
+	(*RegisterUser)(nil),        // 17: wire.RegisterUser
+	(*FetchUser)(nil),           // 18: wire.FetchUser
+	(*FetchUserByName)(nil),     // 19: wire.FetchUserByName
+	(*UpdateProfile)(nil),       // 20: wire.UpdateProfile
+	(*RemoveUser)(nil),          // 21: wire.RemoveUser
+	(*RetrieveAuthorPosts)(nil), // 22: wire.RetrieveAuthorPosts
+	(*FetchAuthorComments)(nil), // 23: wire.FetchAuthorComments
+	(*FetchNotifications)(nil),  // 24: wire.FetchNotifications
+	(*PostList)(nil),            // 25: wire.PostList
+	(*Post)(nil),                // 26: wire.Post
+	(*Removed)(nil),             // 27: wire.Removed
+	(*Comment)(nil),             // 28: wire.Comment
+	(*MessageList)(nil),         // 29: wire.MessageList
+	(*Message)(nil),             // 30: wire.Message
+	(*Subreddit)(nil),           // 31: wire.Subreddit
+	(*Account)(nil),             // 32: wire.Account
+	(*PostListing)(nil),         // 33: wire.PostListing
+	(*CommentListing)(nil),      // 34: wire.CommentListing
+	(*NotificationList)(nil),    // 35: wire.NotificationList
+}
+var file_service_proto_depIdxs = []int32{
+	0,  // 0: wire.Reddit.ListPosts:input_type -> wire.RetrieveAllPosts
+	1,  // 1: wire.Reddit.SubmitPost:input_type -> wire.AddPost
+	2,  // 2: wire.Reddit.GetPost:input_type -> wire.RetrievePost
+	3,  // 3: wire.Reddit.DeletePost:input_type -> wire.RemovePost
+	4,  // 4: wire.Reddit.VotePost:input_type -> wire.VotePost
+	5,  // 5: wire.Reddit.AddComment:input_type -> wire.AddComment
+	6,  // 6: wire.Reddit.GetComment:input_type -> wire.FetchComment
+	7,  // 7: wire.Reddit.DeleteComment:input_type -> wire.RemoveComment
+	8,  // 8: wire.Reddit.VoteComment:input_type -> wire.VoteComment
+	9,  // 9: wire.Reddit.ListMessages:input_type -> wire.FetchMessages
+	10, // 10: wire.Reddit.SendMessage:input_type -> wire.SendMessage
+	11, // 11: wire.Reddit.DeleteMessage:input_type -> wire.RemoveMessage
+	12, // 12: wire.Reddit.AddForum:input_type -> wire.AddForum
+	13, // 13: wire.Reddit.GetForum:input_type -> wire.RetrieveForum
+	14, // 14: wire.Reddit.GetForumByName:input_type -> wire.RetrieveForumByName
+	15, // 15: wire.Reddit.RenameForum:input_type -> wire.RenameForum
+	16, // 16: wire.Reddit.DeleteForum:input_type -> wire.RemoveForum
+	17, // 17: wire.Reddit.RegisterUser:input_type -> wire.RegisterUser
+	18, // 18: wire.Reddit.GetUser:input_type -> wire.FetchUser
+	19, // 19: wire.Reddit.GetUserByName:input_type -> wire.FetchUserByName
+	20, // 20: wire.Reddit.UpdateProfile:input_type -> wire.UpdateProfile
+	21, // 21: wire.Reddit.DeleteUser:input_type -> wire.RemoveUser
+	22, // 22: wire.Reddit.ListUserPosts:input_type -> wire.RetrieveAuthorPosts
+	23, // 23: wire.Reddit.ListUserComments:input_type -> wire.FetchAuthorComments
+	24, // 24: wire.Reddit.ListNotifications:input_type -> wire.FetchNotifications
+	25, // 25: wire.Reddit.ListPosts:output_type -> wire.PostList
+	26, // 26: wire.Reddit.SubmitPost:output_type -> wire.Post
+	26, // 27: wire.Reddit.GetPost:output_type -> wire.Post
+	27, // 28: wire.Reddit.DeletePost:output_type -> wire.Removed
+	26, // 29: wire.Reddit.VotePost:output_type -> wire.Post
+	28, // 30: wire.Reddit.AddComment:output_type -> wire.Comment
+	28, // 31: wire.Reddit.GetComment:output_type -> wire.Comment
+	27, // 32: wire.Reddit.DeleteComment:output_type -> wire.Removed
+	28, // 33: wire.Reddit.VoteComment:output_type -> wire.Comment
+	29, // 34: wire.Reddit.ListMessages:output_type -> wire.MessageList
+	30, // 35: wire.Reddit.SendMessage:output_type -> wire.Message
+	27, // 36: wire.Reddit.DeleteMessage:output_type -> wire.Removed
+	31, // 37: wire.Reddit.AddForum:output_type -> wire.Subreddit
+	31, // 38: wire.Reddit.GetForum:output_type -> wire.Subreddit
+	31, // 39: wire.Reddit.GetForumByName:output_type -> wire.Subreddit
+	31, // 40: wire.Reddit.RenameForum:output_type -> wire.Subreddit
+	27, // 41: wire.Reddit.DeleteForum:output_type -> wire.Removed
+	32, // 42: wire.Reddit.RegisterUser:output_type -> wire.Account
+	32, // 43: wire.Reddit.GetUser:output_type -> wire.Account
+	32, // 44: wire.Reddit.GetUserByName:output_type -> wire.Account
+	32, // 45: wire.Reddit.UpdateProfile:output_type -> wire.Account
+	27, // 46: wire.Reddit.DeleteUser:output_type -> wire.Removed
+	33, // 47: wire.Reddit.ListUserPosts:output_type -> wire.PostListing
+	34, // 48: wire.Reddit.ListUserComments:output_type -> wire.CommentListing
+	35, // 49: wire.Reddit.ListNotifications:output_type -> wire.NotificationList
+	25, // [25:50] is the sub-list for method output_type
+	0,  // [0:25] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_service_proto_init() }
+func file_service_proto_init() {
+	if File_service_proto != nil {
+		return
+	}
+	file_reddit_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_service_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_service_proto_goTypes,
+		DependencyIndexes: file_service_proto_depIdxs,
+	}.Build()
+	File_service_proto = out.File
+	file_service_proto_rawDesc = nil
+	file_service_proto_goTypes = nil
+	file_service_proto_depIdxs = nil
+}
@@ -0,0 +1,997 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: service.proto
+
+package wire
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Reddit_ListPosts_FullMethodName         = "/wire.Reddit/ListPosts"
+	Reddit_SubmitPost_FullMethodName        = "/wire.Reddit/SubmitPost"
+	Reddit_GetPost_FullMethodName           = "/wire.Reddit/GetPost"
+	Reddit_DeletePost_FullMethodName        = "/wire.Reddit/DeletePost"
+	Reddit_VotePost_FullMethodName          = "/wire.Reddit/VotePost"
+	Reddit_AddComment_FullMethodName        = "/wire.Reddit/AddComment"
+	Reddit_GetComment_FullMethodName        = "/wire.Reddit/GetComment"
+	Reddit_DeleteComment_FullMethodName     = "/wire.Reddit/DeleteComment"
+	Reddit_VoteComment_FullMethodName       = "/wire.Reddit/VoteComment"
+	Reddit_ListMessages_FullMethodName      = "/wire.Reddit/ListMessages"
+	Reddit_SendMessage_FullMethodName       = "/wire.Reddit/SendMessage"
+	Reddit_DeleteMessage_FullMethodName     = "/wire.Reddit/DeleteMessage"
+	Reddit_AddForum_FullMethodName          = "/wire.Reddit/AddForum"
+	Reddit_GetForum_FullMethodName          = "/wire.Reddit/GetForum"
+	Reddit_GetForumByName_FullMethodName    = "/wire.Reddit/GetForumByName"
+	Reddit_RenameForum_FullMethodName       = "/wire.Reddit/RenameForum"
+	Reddit_DeleteForum_FullMethodName       = "/wire.Reddit/DeleteForum"
+	Reddit_RegisterUser_FullMethodName      = "/wire.Reddit/RegisterUser"
+	Reddit_GetUser_FullMethodName           = "/wire.Reddit/GetUser"
+	Reddit_GetUserByName_FullMethodName     = "/wire.Reddit/GetUserByName"
+	Reddit_UpdateProfile_FullMethodName     = "/wire.Reddit/UpdateProfile"
+	Reddit_DeleteUser_FullMethodName        = "/wire.Reddit/DeleteUser"
+	Reddit_ListUserPosts_FullMethodName     = "/wire.Reddit/ListUserPosts"
+	Reddit_ListUserComments_FullMethodName  = "/wire.Reddit/ListUserComments"
+	Reddit_ListNotifications_FullMethodName = "/wire.Reddit/ListNotifications"
+)
+
+// RedditClient is the client API for Reddit service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RedditClient interface {
+	ListPosts(ctx context.Context, in *RetrieveAllPosts, opts ...grpc.CallOption) (*PostList, error)
+	SubmitPost(ctx context.Context, in *AddPost, opts ...grpc.CallOption) (*Post, error)
+	GetPost(ctx context.Context, in *RetrievePost, opts ...grpc.CallOption) (*Post, error)
+	DeletePost(ctx context.Context, in *RemovePost, opts ...grpc.CallOption) (*Removed, error)
+	VotePost(ctx context.Context, in *VotePost, opts ...grpc.CallOption) (*Post, error)
+	AddComment(ctx context.Context, in *AddComment, opts ...grpc.CallOption) (*Comment, error)
+	GetComment(ctx context.Context, in *FetchComment, opts ...grpc.CallOption) (*Comment, error)
+	DeleteComment(ctx context.Context, in *RemoveComment, opts ...grpc.CallOption) (*Removed, error)
+	VoteComment(ctx context.Context, in *VoteComment, opts ...grpc.CallOption) (*Comment, error)
+	ListMessages(ctx context.Context, in *FetchMessages, opts ...grpc.CallOption) (*MessageList, error)
+	SendMessage(ctx context.Context, in *SendMessage, opts ...grpc.CallOption) (*Message, error)
+	DeleteMessage(ctx context.Context, in *RemoveMessage, opts ...grpc.CallOption) (*Removed, error)
+	AddForum(ctx context.Context, in *AddForum, opts ...grpc.CallOption) (*Subreddit, error)
+	GetForum(ctx context.Context, in *RetrieveForum, opts ...grpc.CallOption) (*Subreddit, error)
+	GetForumByName(ctx context.Context, in *RetrieveForumByName, opts ...grpc.CallOption) (*Subreddit, error)
+	RenameForum(ctx context.Context, in *RenameForum, opts ...grpc.CallOption) (*Subreddit, error)
+	DeleteForum(ctx context.Context, in *RemoveForum, opts ...grpc.CallOption) (*Removed, error)
+	RegisterUser(ctx context.Context, in *RegisterUser, opts ...grpc.CallOption) (*Account, error)
+	GetUser(ctx context.Context, in *FetchUser, opts ...grpc.CallOption) (*Account, error)
+	GetUserByName(ctx context.Context, in *FetchUserByName, opts ...grpc.CallOption) (*Account, error)
+	UpdateProfile(ctx context.Context, in *UpdateProfile, opts ...grpc.CallOption) (*Account, error)
+	DeleteUser(ctx context.Context, in *RemoveUser, opts ...grpc.CallOption) (*Removed, error)
+	ListUserPosts(ctx context.Context, in *RetrieveAuthorPosts, opts ...grpc.CallOption) (*PostListing, error)
+	ListUserComments(ctx context.Context, in *FetchAuthorComments, opts ...grpc.CallOption) (*CommentListing, error)
+	ListNotifications(ctx context.Context, in *FetchNotifications, opts ...grpc.CallOption) (*NotificationList, error)
+}
+
+type redditClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRedditClient(cc grpc.ClientConnInterface) RedditClient {
+	return &redditClient{cc}
+}
+
+func (c *redditClient) ListPosts(ctx context.Context, in *RetrieveAllPosts, opts ...grpc.CallOption) (*PostList, error) {
+	out := new(PostList)
+	err := c.cc.Invoke(ctx, Reddit_ListPosts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) SubmitPost(ctx context.Context, in *AddPost, opts ...grpc.CallOption) (*Post, error) {
+	out := new(Post)
+	err := c.cc.Invoke(ctx, Reddit_SubmitPost_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) GetPost(ctx context.Context, in *RetrievePost, opts ...grpc.CallOption) (*Post, error) {
+	out := new(Post)
+	err := c.cc.Invoke(ctx, Reddit_GetPost_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) DeletePost(ctx context.Context, in *RemovePost, opts ...grpc.CallOption) (*Removed, error) {
+	out := new(Removed)
+	err := c.cc.Invoke(ctx, Reddit_DeletePost_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) VotePost(ctx context.Context, in *VotePost, opts ...grpc.CallOption) (*Post, error) {
+	out := new(Post)
+	err := c.cc.Invoke(ctx, Reddit_VotePost_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) AddComment(ctx context.Context, in *AddComment, opts ...grpc.CallOption) (*Comment, error) {
+	out := new(Comment)
+	err := c.cc.Invoke(ctx, Reddit_AddComment_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) GetComment(ctx context.Context, in *FetchComment, opts ...grpc.CallOption) (*Comment, error) {
+	out := new(Comment)
+	err := c.cc.Invoke(ctx, Reddit_GetComment_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) DeleteComment(ctx context.Context, in *RemoveComment, opts ...grpc.CallOption) (*Removed, error) {
+	out := new(Removed)
+	err := c.cc.Invoke(ctx, Reddit_DeleteComment_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) VoteComment(ctx context.Context, in *VoteComment, opts ...grpc.CallOption) (*Comment, error) {
+	out := new(Comment)
+	err := c.cc.Invoke(ctx, Reddit_VoteComment_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) ListMessages(ctx context.Context, in *FetchMessages, opts ...grpc.CallOption) (*MessageList, error) {
+	out := new(MessageList)
+	err := c.cc.Invoke(ctx, Reddit_ListMessages_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) SendMessage(ctx context.Context, in *SendMessage, opts ...grpc.CallOption) (*Message, error) {
+	out := new(Message)
+	err := c.cc.Invoke(ctx, Reddit_SendMessage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) DeleteMessage(ctx context.Context, in *RemoveMessage, opts ...grpc.CallOption) (*Removed, error) {
+	out := new(Removed)
+	err := c.cc.Invoke(ctx, Reddit_DeleteMessage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) AddForum(ctx context.Context, in *AddForum, opts ...grpc.CallOption) (*Subreddit, error) {
+	out := new(Subreddit)
+	err := c.cc.Invoke(ctx, Reddit_AddForum_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) GetForum(ctx context.Context, in *RetrieveForum, opts ...grpc.CallOption) (*Subreddit, error) {
+	out := new(Subreddit)
+	err := c.cc.Invoke(ctx, Reddit_GetForum_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) GetForumByName(ctx context.Context, in *RetrieveForumByName, opts ...grpc.CallOption) (*Subreddit, error) {
+	out := new(Subreddit)
+	err := c.cc.Invoke(ctx, Reddit_GetForumByName_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) RenameForum(ctx context.Context, in *RenameForum, opts ...grpc.CallOption) (*Subreddit, error) {
+	out := new(Subreddit)
+	err := c.cc.Invoke(ctx, Reddit_RenameForum_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) DeleteForum(ctx context.Context, in *RemoveForum, opts ...grpc.CallOption) (*Removed, error) {
+	out := new(Removed)
+	err := c.cc.Invoke(ctx, Reddit_DeleteForum_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) RegisterUser(ctx context.Context, in *RegisterUser, opts ...grpc.CallOption) (*Account, error) {
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Reddit_RegisterUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) GetUser(ctx context.Context, in *FetchUser, opts ...grpc.CallOption) (*Account, error) {
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Reddit_GetUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) GetUserByName(ctx context.Context, in *FetchUserByName, opts ...grpc.CallOption) (*Account, error) {
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Reddit_GetUserByName_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) UpdateProfile(ctx context.Context, in *UpdateProfile, opts ...grpc.CallOption) (*Account, error) {
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Reddit_UpdateProfile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) DeleteUser(ctx context.Context, in *RemoveUser, opts ...grpc.CallOption) (*Removed, error) {
+	out := new(Removed)
+	err := c.cc.Invoke(ctx, Reddit_DeleteUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) ListUserPosts(ctx context.Context, in *RetrieveAuthorPosts, opts ...grpc.CallOption) (*PostListing, error) {
+	out := new(PostListing)
+	err := c.cc.Invoke(ctx, Reddit_ListUserPosts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) ListUserComments(ctx context.Context, in *FetchAuthorComments, opts ...grpc.CallOption) (*CommentListing, error) {
+	out := new(CommentListing)
+	err := c.cc.Invoke(ctx, Reddit_ListUserComments_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redditClient) ListNotifications(ctx context.Context, in *FetchNotifications, opts ...grpc.CallOption) (*NotificationList, error) {
+	out := new(NotificationList)
+	err := c.cc.Invoke(ctx, Reddit_ListNotifications_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RedditServer is the server API for Reddit service.
+// All implementations must embed UnimplementedRedditServer
+// for forward compatibility
+type RedditServer interface {
+	ListPosts(context.Context, *RetrieveAllPosts) (*PostList, error)
+	SubmitPost(context.Context, *AddPost) (*Post, error)
+	GetPost(context.Context, *RetrievePost) (*Post, error)
+	DeletePost(context.Context, *RemovePost) (*Removed, error)
+	VotePost(context.Context, *VotePost) (*Post, error)
+	AddComment(context.Context, *AddComment) (*Comment, error)
+	GetComment(context.Context, *FetchComment) (*Comment, error)
+	DeleteComment(context.Context, *RemoveComment) (*Removed, error)
+	VoteComment(context.Context, *VoteComment) (*Comment, error)
+	ListMessages(context.Context, *FetchMessages) (*MessageList, error)
+	SendMessage(context.Context, *SendMessage) (*Message, error)
+	DeleteMessage(context.Context, *RemoveMessage) (*Removed, error)
+	AddForum(context.Context, *AddForum) (*Subreddit, error)
+	GetForum(context.Context, *RetrieveForum) (*Subreddit, error)
+	GetForumByName(context.Context, *RetrieveForumByName) (*Subreddit, error)
+	RenameForum(context.Context, *RenameForum) (*Subreddit, error)
+	DeleteForum(context.Context, *RemoveForum) (*Removed, error)
+	RegisterUser(context.Context, *RegisterUser) (*Account, error)
+	GetUser(context.Context, *FetchUser) (*Account, error)
+	GetUserByName(context.Context, *FetchUserByName) (*Account, error)
+	UpdateProfile(context.Context, *UpdateProfile) (*Account, error)
+	DeleteUser(context.Context, *RemoveUser) (*Removed, error)
+	ListUserPosts(context.Context, *RetrieveAuthorPosts) (*PostListing, error)
+	ListUserComments(context.Context, *FetchAuthorComments) (*CommentListing, error)
+	ListNotifications(context.Context, *FetchNotifications) (*NotificationList, error)
+	mustEmbedUnimplementedRedditServer()
+}
+
+// UnimplementedRedditServer must be embedded to have forward compatible implementations.
+type UnimplementedRedditServer struct {
+}
+
+func (UnimplementedRedditServer) ListPosts(context.Context, *RetrieveAllPosts) (*PostList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPosts not implemented")
+}
+func (UnimplementedRedditServer) SubmitPost(context.Context, *AddPost) (*Post, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitPost not implemented")
+}
+func (UnimplementedRedditServer) GetPost(context.Context, *RetrievePost) (*Post, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPost not implemented")
+}
+func (UnimplementedRedditServer) DeletePost(context.Context, *RemovePost) (*Removed, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePost not implemented")
+}
+func (UnimplementedRedditServer) VotePost(context.Context, *VotePost) (*Post, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VotePost not implemented")
+}
+func (UnimplementedRedditServer) AddComment(context.Context, *AddComment) (*Comment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddComment not implemented")
+}
+func (UnimplementedRedditServer) GetComment(context.Context, *FetchComment) (*Comment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetComment not implemented")
+}
+func (UnimplementedRedditServer) DeleteComment(context.Context, *RemoveComment) (*Removed, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteComment not implemented")
+}
+func (UnimplementedRedditServer) VoteComment(context.Context, *VoteComment) (*Comment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VoteComment not implemented")
+}
+func (UnimplementedRedditServer) ListMessages(context.Context, *FetchMessages) (*MessageList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMessages not implemented")
+}
+func (UnimplementedRedditServer) SendMessage(context.Context, *SendMessage) (*Message, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendMessage not implemented")
+}
+func (UnimplementedRedditServer) DeleteMessage(context.Context, *RemoveMessage) (*Removed, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMessage not implemented")
+}
+func (UnimplementedRedditServer) AddForum(context.Context, *AddForum) (*Subreddit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddForum not implemented")
+}
+func (UnimplementedRedditServer) GetForum(context.Context, *RetrieveForum) (*Subreddit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetForum not implemented")
+}
+func (UnimplementedRedditServer) GetForumByName(context.Context, *RetrieveForumByName) (*Subreddit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetForumByName not implemented")
+}
+func (UnimplementedRedditServer) RenameForum(context.Context, *RenameForum) (*Subreddit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameForum not implemented")
+}
+func (UnimplementedRedditServer) DeleteForum(context.Context, *RemoveForum) (*Removed, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteForum not implemented")
+}
+func (UnimplementedRedditServer) RegisterUser(context.Context, *RegisterUser) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterUser not implemented")
+}
+func (UnimplementedRedditServer) GetUser(context.Context, *FetchUser) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedRedditServer) GetUserByName(context.Context, *FetchUserByName) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserByName not implemented")
+}
+func (UnimplementedRedditServer) UpdateProfile(context.Context, *UpdateProfile) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProfile not implemented")
+}
+func (UnimplementedRedditServer) DeleteUser(context.Context, *RemoveUser) (*Removed, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (UnimplementedRedditServer) ListUserPosts(context.Context, *RetrieveAuthorPosts) (*PostListing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUserPosts not implemented")
+}
+func (UnimplementedRedditServer) ListUserComments(context.Context, *FetchAuthorComments) (*CommentListing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUserComments not implemented")
+}
+func (UnimplementedRedditServer) ListNotifications(context.Context, *FetchNotifications) (*NotificationList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNotifications not implemented")
+}
+func (UnimplementedRedditServer) mustEmbedUnimplementedRedditServer() {}
+
+// UnsafeRedditServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RedditServer will
+// result in compilation errors.
+type UnsafeRedditServer interface {
+	mustEmbedUnimplementedRedditServer()
+}
+
+func RegisterRedditServer(s grpc.ServiceRegistrar, srv RedditServer) {
+	s.RegisterService(&Reddit_ServiceDesc, srv)
+}
+
+func _Reddit_ListPosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetrieveAllPosts)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).ListPosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_ListPosts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).ListPosts(ctx, req.(*RetrieveAllPosts))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_SubmitPost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddPost)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).SubmitPost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_SubmitPost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).SubmitPost(ctx, req.(*AddPost))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_GetPost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetrievePost)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).GetPost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_GetPost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).GetPost(ctx, req.(*RetrievePost))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_DeletePost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemovePost)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).DeletePost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_DeletePost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).DeletePost(ctx, req.(*RemovePost))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_VotePost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VotePost)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).VotePost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_VotePost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).VotePost(ctx, req.(*VotePost))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_AddComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddComment)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).AddComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_AddComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).AddComment(ctx, req.(*AddComment))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_GetComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchComment)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).GetComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_GetComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).GetComment(ctx, req.(*FetchComment))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_DeleteComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveComment)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).DeleteComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_DeleteComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).DeleteComment(ctx, req.(*RemoveComment))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_VoteComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoteComment)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).VoteComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_VoteComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).VoteComment(ctx, req.(*VoteComment))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_ListMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchMessages)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).ListMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_ListMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).ListMessages(ctx, req.(*FetchMessages))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_SendMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).SendMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_SendMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).SendMessage(ctx, req.(*SendMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_DeleteMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).DeleteMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_DeleteMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).DeleteMessage(ctx, req.(*RemoveMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_AddForum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddForum)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).AddForum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_AddForum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).AddForum(ctx, req.(*AddForum))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_GetForum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetrieveForum)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).GetForum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_GetForum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).GetForum(ctx, req.(*RetrieveForum))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_GetForumByName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetrieveForumByName)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).GetForumByName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_GetForumByName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).GetForumByName(ctx, req.(*RetrieveForumByName))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_RenameForum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameForum)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).RenameForum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_RenameForum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).RenameForum(ctx, req.(*RenameForum))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_DeleteForum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveForum)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).DeleteForum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_DeleteForum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).DeleteForum(ctx, req.(*RemoveForum))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_RegisterUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterUser)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).RegisterUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_RegisterUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).RegisterUser(ctx, req.(*RegisterUser))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchUser)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_GetUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).GetUser(ctx, req.(*FetchUser))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_GetUserByName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchUserByName)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).GetUserByName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_GetUserByName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).GetUserByName(ctx, req.(*FetchUserByName))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_UpdateProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProfile)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).UpdateProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_UpdateProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).UpdateProfile(ctx, req.(*UpdateProfile))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveUser)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_DeleteUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).DeleteUser(ctx, req.(*RemoveUser))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_ListUserPosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetrieveAuthorPosts)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).ListUserPosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_ListUserPosts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).ListUserPosts(ctx, req.(*RetrieveAuthorPosts))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_ListUserComments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchAuthorComments)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).ListUserComments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_ListUserComments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).ListUserComments(ctx, req.(*FetchAuthorComments))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reddit_ListNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchNotifications)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedditServer).ListNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reddit_ListNotifications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedditServer).ListNotifications(ctx, req.(*FetchNotifications))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Reddit_ServiceDesc is the grpc.ServiceDesc for Reddit service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Reddit_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wire.Reddit",
+	HandlerType: (*RedditServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListPosts",
+			Handler:    _Reddit_ListPosts_Handler,
+		},
+		{
+			MethodName: "SubmitPost",
+			Handler:    _Reddit_SubmitPost_Handler,
+		},
+		{
+			MethodName: "GetPost",
+			Handler:    _Reddit_GetPost_Handler,
+		},
+		{
+			MethodName: "DeletePost",
+			Handler:    _Reddit_DeletePost_Handler,
+		},
+		{
+			MethodName: "VotePost",
+			Handler:    _Reddit_VotePost_Handler,
+		},
+		{
+			MethodName: "AddComment",
+			Handler:    _Reddit_AddComment_Handler,
+		},
+		{
+			MethodName: "GetComment",
+			Handler:    _Reddit_GetComment_Handler,
+		},
+		{
+			MethodName: "DeleteComment",
+			Handler:    _Reddit_DeleteComment_Handler,
+		},
+		{
+			MethodName: "VoteComment",
+			Handler:    _Reddit_VoteComment_Handler,
+		},
+		{
+			MethodName: "ListMessages",
+			Handler:    _Reddit_ListMessages_Handler,
+		},
+		{
+			MethodName: "SendMessage",
+			Handler:    _Reddit_SendMessage_Handler,
+		},
+		{
+			MethodName: "DeleteMessage",
+			Handler:    _Reddit_DeleteMessage_Handler,
+		},
+		{
+			MethodName: "AddForum",
+			Handler:    _Reddit_AddForum_Handler,
+		},
+		{
+			MethodName: "GetForum",
+			Handler:    _Reddit_GetForum_Handler,
+		},
+		{
+			MethodName: "GetForumByName",
+			Handler:    _Reddit_GetForumByName_Handler,
+		},
+		{
+			MethodName: "RenameForum",
+			Handler:    _Reddit_RenameForum_Handler,
+		},
+		{
+			MethodName: "DeleteForum",
+			Handler:    _Reddit_DeleteForum_Handler,
+		},
+		{
+			MethodName: "RegisterUser",
+			Handler:    _Reddit_RegisterUser_Handler,
+		},
+		{
+			MethodName: "GetUser",
+			Handler:    _Reddit_GetUser_Handler,
+		},
+		{
+			MethodName: "GetUserByName",
+			Handler:    _Reddit_GetUserByName_Handler,
+		},
+		{
+			MethodName: "UpdateProfile",
+			Handler:    _Reddit_UpdateProfile_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _Reddit_DeleteUser_Handler,
+		},
+		{
+			MethodName: "ListUserPosts",
+			Handler:    _Reddit_ListUserPosts_Handler,
+		},
+		{
+			MethodName: "ListUserComments",
+			Handler:    _Reddit_ListUserComments_Handler,
+		},
+		{
+			MethodName: "ListNotifications",
+			Handler:    _Reddit_ListNotifications_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "service.proto",
+}
@@ -0,0 +1,227 @@
+// Package ratelimit bounds how often clients may act with token buckets. Each
+// bucket holds up to a burst of tokens and earns one back at a steady rate;
+// an action takes a token from every bucket it is counted against, and is
+// refused while any of them is empty.
+package ratelimit
+
+import (
+	"math"
+	"reddit-clone/core/clock"
+	"sync"
+	"time"
+)
+
+// Rate lets Burst actions through at once, then one more every Every.
+type Rate struct {
+	Every time.Duration
+	Burst int
+}
+
+// Action names what a bucket counts.
+type Action string
+
+const (
+	// IP counts every request from a client address.
+	IP Action = "ip"
+	// User counts every write of a user.
+	User    Action = "user"
+	Post    Action = "post"
+	Comment Action = "comment"
+	Vote    Action = "vote"
+	Message Action = "message"
+	// Forum counts the posts submitted to a forum, by anyone.
+	Forum Action = "forum"
+)
+
+var nouns = map[Action]string{
+	IP:      "requests",
+	User:    "requests",
+	Post:    "posts",
+	Comment: "comments",
+	Vote:    "votes",
+	Message: "messages",
+	Forum:   "posts in this forum",
+}
+
+// Noun describes what the bucket of a counts, such as "posts".
+func (a Action) Noun() string {
+	if noun, known := nouns[a]; known {
+		return noun
+	}
+	return "requests"
+}
+
+// personal reports whether the buckets of a belong to the acting user, whose
+// karma may relax them.
+func (a Action) personal() bool {
+	return a != IP && a != Forum
+}
+
+// Key names one bucket: the bucket of Action for Subject, such as the post
+// bucket of a user ID or the forum bucket of a forum ID.
+type Key struct {
+	Action  Action
+	Subject string
+}
+
+// Policy is the Rate of each action, and the relaxation granted to trusted
+// users: from TrustedKarma karma, their own buckets hold TrustedFactor times
+// the burst and refill TrustedFactor times as fast. Actions without a Rate
+// are not limited.
+type Policy struct {
+	Rates         map[Action]Rate
+	TrustedKarma  int
+	TrustedFactor float64
+}
+
+// Decision is the outcome of Allow. It describes the bucket that refused the
+// action or, when it was allowed, the one with the fewest tokens left.
+type Decision struct {
+	Allowed bool
+	Key     Key
+	// Limit is the number of tokens the bucket holds when full.
+	Limit int
+	// Remaining is the number of whole tokens left in it.
+	Remaining int
+	// RetryAfter is how long until the bucket has a token again; zero when
+	// the action was allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// sweepEvery is how many calls to Allow pass between sweeps of full buckets.
+const sweepEvery = 1024
+
+// Limiter keeps the buckets of a Policy. It is safe for concurrent use.
+type Limiter struct {
+	policy Policy
+	clock  clock.Clock
+
+	mutex   sync.Mutex
+	buckets map[Key]*bucket
+	calls   int
+}
+
+// bucket is refilled lazily: tokens is its content at updated.
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	capacity float64
+	interval time.Duration
+}
+
+// New returns a Limiter whose buckets start full and refill by the time c
+// reports.
+func New(policy Policy, c clock.Clock) *Limiter {
+	return &Limiter{policy: policy, clock: c, buckets: make(map[Key]*bucket)}
+}
+
+// Trusted reports whether a user with karma has relaxed limits.
+func (l *Limiter) Trusted(karma int) bool {
+	return l.policy.TrustedFactor > 1 && karma >= l.policy.TrustedKarma
+}
+
+// Allow takes one token from each bucket named by keys, or from none of
+// them if any is empty. trusted relaxes the buckets of the acting user.
+func (l *Limiter) Allow(trusted bool, keys ...Key) Decision {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.clock.Now()
+	l.calls++
+	if l.calls%sweepEvery == 0 {
+		l.sweep(now)
+	}
+
+	decision := Decision{Allowed: true, Remaining: math.MaxInt}
+	var taken []*bucket
+	for _, key := range keys {
+		rate, limited := l.policy.Rates[key.Action]
+		if !limited || rate.Every <= 0 || rate.Burst <= 0 {
+			continue
+		}
+		factor := 1.0
+		if trusted && key.Action.personal() {
+			factor = l.policy.TrustedFactor
+		}
+		b := l.refill(key, rate, factor, now)
+		if b.tokens < 1 {
+			retry := b.wait(1)
+			if decision.Allowed || retry > decision.RetryAfter {
+				decision = b.decision(key, false)
+				decision.RetryAfter = retry
+			}
+			continue
+		}
+		taken = append(taken, b)
+		if decision.Allowed && int(b.tokens-1) < decision.Remaining {
+			decision = b.decision(key, true)
+			decision.Remaining--
+			decision.Reset = b.wait(b.capacity + 1)
+		}
+	}
+	if !decision.Allowed {
+		return decision
+	}
+	for _, b := range taken {
+		b.tokens--
+	}
+	if decision.Remaining == math.MaxInt {
+		decision.Remaining = 0
+	}
+	return decision
+}
+
+// refill returns the bucket of key brought up to now, creating it full.
+func (l *Limiter) refill(key Key, rate Rate, factor float64, now time.Time) *bucket {
+	capacity := float64(rate.Burst) * factor
+	interval := time.Duration(float64(rate.Every) / factor)
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: capacity, updated: now}
+		l.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(interval)
+		b.updated = now
+	}
+	b.capacity, b.interval = capacity, interval
+	b.tokens = math.Min(b.tokens, capacity)
+	return b
+}
+
+// wait returns how long until the bucket holds tokens.
+func (b *bucket) wait(tokens float64) time.Duration {
+	if tokens <= b.tokens {
+		return 0
+	}
+	return time.Duration(math.Ceil((tokens - b.tokens) * float64(b.interval)))
+}
+
+func (b *bucket) decision(key Key, allowed bool) Decision {
+	return Decision{
+		Allowed:   allowed,
+		Key:       key,
+		Limit:     int(b.capacity),
+		Remaining: int(b.tokens),
+		Reset:     b.wait(b.capacity),
+	}
+}
+
+// sweep forgets the buckets that have refilled, which behave as new ones.
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if !b.updated.Add(b.wait(b.capacity)).After(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of buckets kept.
+func (l *Limiter) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return len(l.buckets)
+}
@@ -0,0 +1,141 @@
+package simulator
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// The operations a simulated user performs, in the order they are reported.
+const (
+	OpRegister = "register"
+	OpJoin     = "join"
+	OpPost     = "post"
+	OpComment  = "comment"
+	OpVote     = "vote"
+	OpMessage  = "message"
+	// OpInbox is the catch-up read of messages and notifications a user
+	// does when it comes back online.
+	OpInbox = "inbox"
+)
+
+var operations = []string{OpRegister, OpJoin, OpPost, OpComment, OpVote, OpMessage, OpInbox}
+
+// stats collects the latency of every request the users make.
+type stats struct {
+	lock      sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+}
+
+func newStats() *stats {
+	return &stats{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+	}
+}
+
+// record adds one request of op that took latency. Failed requests are
+// counted but left out of the percentiles.
+func (s *stats) record(op string, latency time.Duration, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err != nil {
+		s.errors[op]++
+		return
+	}
+	s.latencies[op] = append(s.latencies[op], latency)
+}
+
+func (s *stats) report(users int, elapsed time.Duration) *Report {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	report := &Report{Users: users, Elapsed: elapsed}
+	for _, op := range operations {
+		latencies := append([]time.Duration(nil), s.latencies[op]...)
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		stat := OperationStats{Name: op, Count: len(latencies), Errors: s.errors[op]}
+		if len(latencies) > 0 {
+			stat.P50 = percentile(latencies, 50)
+			stat.P90 = percentile(latencies, 90)
+			stat.P99 = percentile(latencies, 99)
+			stat.Max = latencies[len(latencies)-1]
+		}
+		report.Operations = append(report.Operations, stat)
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// OperationStats summarizes the requests of one operation. Count only
+// includes the requests that succeeded, which are the ones the latency
+// percentiles describe.
+type OperationStats struct {
+	Name   string
+	Count  int
+	Errors int
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+// Report is the outcome of a simulation.
+type Report struct {
+	Users      int
+	Elapsed    time.Duration
+	Operations []OperationStats
+}
+
+// Operation returns the stats of the named operation.
+func (r *Report) Operation(name string) OperationStats {
+	for _, op := range r.Operations {
+		if op.Name == name {
+			return op
+		}
+	}
+	return OperationStats{Name: name}
+}
+
+// Total is the number of requests that succeeded and failed.
+func (r *Report) Total() (succeeded, failed int) {
+	for _, op := range r.Operations {
+		succeeded += op.Count
+		failed += op.Errors
+	}
+	return succeeded, failed
+}
+
+// Throughput is the number of successful requests per second.
+func (r *Report) Throughput() float64 {
+	succeeded, _ := r.Total()
+	return float64(succeeded) / r.Elapsed.Seconds()
+}
+
+// Print writes the report as a table.
+func (r *Report) Print(w io.Writer) {
+	succeeded, failed := r.Total()
+	fmt.Fprintf(w, "%d users for %v: %d requests, %d failed, %.1f/s\n\n",
+		r.Users, r.Elapsed.Round(time.Millisecond), succeeded, failed, r.Throughput())
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "operation\tcount\terrors\trate/s\tp50\tp90\tp99\tmax\t")
+	for _, op := range r.Operations {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.1f\t%v\t%v\t%v\t%v\t\n", op.Name, op.Count, op.Errors,
+			float64(op.Count)/r.Elapsed.Seconds(), round(op.P50), round(op.P90), round(op.P99), round(op.Max))
+	}
+	table.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
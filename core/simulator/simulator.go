@@ -0,0 +1,153 @@
+// Package simulator drives the managers with a population of simulated
+// users. Each user is an actor that registers, joins forums, and then posts,
+// comments, votes and sends direct messages at random while it is online,
+// going offline and coming back from time to time. Forum popularity follows
+// a Zipf distribution, so a few forums draw most of the members and traffic.
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// Config describes a simulation. Rates are actions per second of each online
+// user; a rate of zero disables the action.
+type Config struct {
+	Users         int
+	Forums        int
+	ForumsPerUser int
+	// ZipfExponent skews forum popularity. It must be greater than 1; the
+	// larger it is, the more members the most popular forums get.
+	ZipfExponent float64
+
+	PostRate    float64
+	CommentRate float64
+	VoteRate    float64
+	MessageRate float64
+
+	// Users stay online for OnlineTime and then offline for OfflineTime on
+	// average, both exponentially distributed. With OfflineTime zero they
+	// never go offline.
+	OnlineTime  time.Duration
+	OfflineTime time.Duration
+
+	Duration time.Duration
+	// Timeout bounds each request to a manager.
+	Timeout time.Duration
+	Seed    uint64
+}
+
+// DefaultConfig is a moderate load: a thousand users in fifty forums for
+// thirty seconds.
+func DefaultConfig() Config {
+	return Config{
+		Users:         1000,
+		Forums:        50,
+		ForumsPerUser: 3,
+		ZipfExponent:  1.2,
+		PostRate:      0.05,
+		CommentRate:   0.2,
+		VoteRate:      0.5,
+		MessageRate:   0.05,
+		OnlineTime:    10 * time.Second,
+		OfflineTime:   5 * time.Second,
+		Duration:      30 * time.Second,
+		Timeout:       5 * time.Second,
+		Seed:          1,
+	}
+}
+
+// Validate reports the first setting that cannot be simulated.
+func (c Config) Validate() error {
+	switch {
+	case c.Users < 2:
+		return errors.New("users must be at least 2")
+	case c.Forums < 1:
+		return errors.New("forums must be at least 1")
+	case c.ForumsPerUser < 1 || c.ForumsPerUser > c.Forums:
+		return fmt.Errorf("forums per user must be between 1 and %d", c.Forums)
+	case c.ZipfExponent <= 1:
+		return errors.New("zipf exponent must be greater than 1")
+	case c.PostRate < 0 || c.CommentRate < 0 || c.VoteRate < 0 || c.MessageRate < 0:
+		return errors.New("rates cannot be negative")
+	case c.PostRate+c.CommentRate+c.VoteRate+c.MessageRate == 0:
+		return errors.New("at least one rate must be positive")
+	case c.OnlineTime <= 0 || c.OfflineTime < 0:
+		return errors.New("online time must be positive and offline time not negative")
+	case c.Duration <= 0 || c.Timeout <= 0:
+		return errors.New("duration and timeout must be positive")
+	}
+	return nil
+}
+
+// Run creates the forums, spawns the users and lets them act until the
+// configured duration has passed or ctx is done, then stops them and reports
+// what they did. Every user has its own random source derived from Seed, so
+// the same configuration produces the same forum memberships.
+func Run(ctx context.Context, root *actor.RootContext, managers *proto_actor.Managers, config Config) (*Report, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	sim := &simulation{
+		config:   config,
+		managers: managers,
+		world:    newWorld(),
+		stats:    newStats(),
+	}
+	// Every forum needs a creator to moderate it; the simulated users
+	// only post and vote, so one account moderates them all.
+	moderator, err := proto_actor.Ask[*schemas.Account](root, managers.Members, &proto_actor.RegisterUser{
+		DisplayName: "sim_moderator",
+	}, config.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("registering the forum moderator: %w", err)
+	}
+	for i := 0; i < config.Forums; i++ {
+		forum, err := proto_actor.Ask[*schemas.Subreddit](root, managers.Forums, &proto_actor.AddForum{
+			Title:     fmt.Sprintf("Simulated forum %d", i),
+			Name:      fmt.Sprintf("sim%d", i),
+			CreatorID: moderator.ID,
+		}, config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("creating forum %d: %w", i, err)
+		}
+		sim.world.forums = append(sim.world.forums, forum.ID)
+	}
+
+	started := time.Now()
+	users := make([]*actor.PID, config.Users)
+	for i := range users {
+		users[i] = root.Spawn(actor.PropsFromProducer(func() actor.Actor { return newSimUser(sim, i) }))
+	}
+
+	timer := time.NewTimer(config.Duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	// Users finish the request in flight before they stop.
+	stopped := make([]*actor.Future, len(users))
+	for i, pid := range users {
+		stopped[i] = root.PoisonFuture(pid)
+	}
+	for _, future := range stopped {
+		future.Wait()
+	}
+	return sim.stats.report(config.Users, time.Since(started)), nil
+}
+
+type simulation struct {
+	config   Config
+	managers *proto_actor.Managers
+	world    *world
+	stats    *stats
+}
@@ -0,0 +1,232 @@
+package simulator
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"reddit-clone/core/proto_actors"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// Messages a simulated user sends itself from its timers. Each carries the
+// session it was scheduled in, so that an action scheduled before the user
+// went offline is dropped.
+type (
+	act       struct{ session int }
+	goOffline struct{ session int }
+	goOnline  struct{}
+)
+
+// simUser is one simulated user. It makes one request at a time and waits
+// for the reply before scheduling its next action, like a person would.
+type simUser struct {
+	sim    *simulation
+	index  int
+	random *rand.Rand
+
+	id      string
+	forums  []string
+	online  bool
+	session int
+	posts   int
+
+	actionTimer   *time.Timer
+	presenceTimer *time.Timer
+}
+
+func newSimUser(sim *simulation, index int) *simUser {
+	return &simUser{
+		sim:    sim,
+		index:  index,
+		random: rand.New(rand.NewPCG(sim.config.Seed, uint64(index))),
+	}
+}
+
+func (u *simUser) Receive(ctx actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case *actor.Started:
+		if u.register(ctx) {
+			u.comeOnline(ctx)
+		}
+
+	case *act:
+		if u.online && msg.session == u.session {
+			u.perform(ctx)
+			u.scheduleAction(ctx)
+		}
+
+	case *goOffline:
+		if u.online && msg.session == u.session {
+			u.online = false
+			u.presenceTimer = u.after(ctx, u.exponential(u.sim.config.OfflineTime), &goOnline{})
+		}
+
+	case *goOnline:
+		u.catchUp(ctx)
+		u.comeOnline(ctx)
+
+	case *actor.Stopping:
+		for _, timer := range []*time.Timer{u.actionTimer, u.presenceTimer} {
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}
+}
+
+// register creates the user's account and joins ForumsPerUser forums drawn
+// by popularity. A user that could not register or join any forum stays
+// idle.
+func (u *simUser) register(ctx actor.Context) bool {
+	account, ok := call(u, ctx, OpRegister, u.sim.managers.Members, &proto_actor.RegisterUser{
+		DisplayName: fmt.Sprintf("sim_%d", u.index),
+	})
+	if !ok {
+		return false
+	}
+	u.id = account.ID
+	u.sim.world.addUser(u.id)
+
+	forums := u.sim.world.forums
+	zipf := rand.NewZipf(u.random, u.sim.config.ZipfExponent, 1, uint64(len(forums)-1))
+	joined := make(map[string]bool)
+	for len(joined) < u.sim.config.ForumsPerUser {
+		forumID := forums[zipf.Uint64()]
+		if joined[forumID] {
+			continue
+		}
+		joined[forumID] = true
+		if _, ok := call(u, ctx, OpJoin, u.sim.managers.Forums, &proto_actor.JoinForum{ForumID: forumID, UserID: u.id}); ok {
+			u.forums = append(u.forums, forumID)
+		}
+	}
+	return len(u.forums) > 0
+}
+
+func (u *simUser) comeOnline(ctx actor.Context) {
+	u.online = true
+	u.session++
+	u.scheduleAction(ctx)
+	if u.sim.config.OfflineTime > 0 {
+		u.presenceTimer = u.after(ctx, u.exponential(u.sim.config.OnlineTime), &goOffline{session: u.session})
+	}
+}
+
+// scheduleAction waits for the next action as a Poisson process whose rate
+// is the sum of the configured rates.
+func (u *simUser) scheduleAction(ctx actor.Context) {
+	c := u.sim.config
+	rate := c.PostRate + c.CommentRate + c.VoteRate + c.MessageRate
+	u.actionTimer = u.after(ctx, time.Duration(u.random.ExpFloat64()/rate*float64(time.Second)), &act{session: u.session})
+}
+
+// perform picks an action with probability proportional to its rate. An
+// action with nothing to act on yet, such as a comment in a forum without
+// posts, falls back to posting.
+func (u *simUser) perform(ctx actor.Context) {
+	c := u.sim.config
+	forumID := u.forums[u.random.IntN(len(u.forums))]
+	pick := u.random.Float64() * (c.PostRate + c.CommentRate + c.VoteRate + c.MessageRate)
+	switch {
+	case pick < c.PostRate:
+	case pick < c.PostRate+c.CommentRate:
+		if u.comment(ctx, forumID) {
+			return
+		}
+	case pick < c.PostRate+c.CommentRate+c.VoteRate:
+		if u.vote(ctx, forumID) {
+			return
+		}
+	default:
+		if u.message(ctx) {
+			return
+		}
+	}
+	u.post(ctx, forumID)
+}
+
+func (u *simUser) post(ctx actor.Context, forumID string) {
+	u.posts++
+	post, ok := call(u, ctx, OpPost, u.sim.managers.Posts, &proto_actor.AddPost{
+		ForumID:  forumID,
+		AuthorID: u.id,
+		Text:     fmt.Sprintf("Post %d by sim_%d", u.posts, u.index),
+	})
+	if ok {
+		u.sim.world.addPost(forumID, post.ID)
+	}
+}
+
+// comment replies to a recent comment half of the time and otherwise
+// comments on a recent post.
+func (u *simUser) comment(ctx actor.Context, forumID string) bool {
+	request := &proto_actor.AddComment{AuthorID: u.id, Content: fmt.Sprintf("Comment by sim_%d", u.index)}
+	if parentID := u.sim.world.recentComment(u.random, forumID); parentID != "" && u.random.IntN(2) == 0 {
+		request.ParentID = parentID
+	} else if request.PostID = u.sim.world.recentPost(u.random, forumID); request.PostID == "" {
+		return false
+	}
+	if comment, ok := call(u, ctx, OpComment, u.sim.managers.Comments, request); ok {
+		u.sim.world.addComment(forumID, comment.ID)
+	}
+	return true
+}
+
+// vote votes on a recent post or comment, up four times out of five.
+func (u *simUser) vote(ctx actor.Context, forumID string) bool {
+	upvote := u.random.IntN(5) > 0
+	if commentID := u.sim.world.recentComment(u.random, forumID); commentID != "" && u.random.IntN(2) == 0 {
+		call(u, ctx, OpVote, u.sim.managers.Comments, &proto_actor.VoteComment{CommentID: commentID, Upvote: upvote})
+		return true
+	}
+	if postID := u.sim.world.recentPost(u.random, forumID); postID != "" {
+		call(u, ctx, OpVote, u.sim.managers.Posts, &proto_actor.VotePost{ContentID: postID, Upvote: upvote})
+		return true
+	}
+	return false
+}
+
+func (u *simUser) message(ctx actor.Context) bool {
+	to := u.sim.world.otherUser(u.random, u.id)
+	if to == "" {
+		return false
+	}
+	call(u, ctx, OpMessage, u.sim.managers.Messages, &proto_actor.SendMessage{
+		FromUserID: u.id,
+		ToUserID:   to,
+		Body:       fmt.Sprintf("Hello from sim_%d", u.index),
+	})
+	return true
+}
+
+// catchUp reads what arrived while the user was offline.
+func (u *simUser) catchUp(ctx actor.Context) {
+	start := time.Now()
+	_, err := proto_actor.Ask(ctx, u.sim.managers.Messages, &proto_actor.FetchMessages{UserID: u.id}, u.sim.config.Timeout)
+	if err == nil {
+		_, err = proto_actor.Ask(ctx, u.sim.managers.Notifications, &proto_actor.FetchNotifications{UserID: u.id}, u.sim.config.Timeout)
+	}
+	u.sim.stats.record(OpInbox, time.Since(start), err)
+}
+
+func (u *simUser) exponential(mean time.Duration) time.Duration {
+	return time.Duration(u.random.ExpFloat64() * float64(mean))
+}
+
+// after sends message to the user once d has passed, as the managers'
+// timers do.
+func (u *simUser) after(ctx actor.Context, d time.Duration, message interface{}) *time.Timer {
+	root, self := ctx.ActorSystem().Root, ctx.Self()
+	return time.AfterFunc(d, func() {
+		root.Send(self, message)
+	})
+}
+
+// call makes one request of op and records how long it took.
+func call[T any](u *simUser, ctx actor.Context, op string, pid *actor.PID, request proto_actor.Request[T]) (T, bool) {
+	start := time.Now()
+	reply, err := proto_actor.Ask(ctx, pid, request, u.sim.config.Timeout)
+	u.sim.stats.record(op, time.Since(start), err)
+	return reply, err == nil
+}
@@ -0,0 +1,106 @@
+package simulator
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// recentLimit is how many recent posts and comments the world remembers per
+// forum for users to reply to and vote on.
+const recentLimit = 64
+
+// world is what the simulated users know of each other: the forums, the
+// registered users, and the latest content of every forum.
+type world struct {
+	// forums is written before any user starts and only read afterwards.
+	forums []string
+
+	lock     sync.Mutex
+	users    []string
+	posts    map[string]*recent
+	comments map[string]*recent
+}
+
+func newWorld() *world {
+	return &world{
+		posts:    make(map[string]*recent),
+		comments: make(map[string]*recent),
+	}
+}
+
+func (w *world) addUser(id string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.users = append(w.users, id)
+}
+
+// otherUser picks a registered user other than self, or "" when there is
+// none yet.
+func (w *world) otherUser(r *rand.Rand, self string) string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if len(w.users) < 2 {
+		return ""
+	}
+	for {
+		if id := w.users[r.IntN(len(w.users))]; id != self {
+			return id
+		}
+	}
+}
+
+func (w *world) addPost(forumID, postID string) {
+	w.remember(w.posts, forumID, postID)
+}
+
+func (w *world) addComment(forumID, commentID string) {
+	w.remember(w.comments, forumID, commentID)
+}
+
+func (w *world) recentPost(r *rand.Rand, forumID string) string {
+	return w.pick(w.posts, r, forumID)
+}
+
+func (w *world) recentComment(r *rand.Rand, forumID string) string {
+	return w.pick(w.comments, r, forumID)
+}
+
+func (w *world) remember(index map[string]*recent, forumID, id string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if index[forumID] == nil {
+		index[forumID] = &recent{}
+	}
+	index[forumID].add(id)
+}
+
+func (w *world) pick(index map[string]*recent, r *rand.Rand, forumID string) string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if index[forumID] == nil {
+		return ""
+	}
+	return index[forumID].pick(r)
+}
+
+// recent is a ring of the latest recentLimit IDs.
+type recent struct {
+	ids  []string
+	next int
+}
+
+func (r *recent) add(id string) {
+	if len(r.ids) < recentLimit {
+		r.ids = append(r.ids, id)
+		return
+	}
+	r.ids[r.next] = id
+	r.next = (r.next + 1) % recentLimit
+}
+
+func (r *recent) pick(random *rand.Rand) string {
+	if len(r.ids) == 0 {
+		return ""
+	}
+	return r.ids[random.IntN(len(r.ids))]
+}
@@ -0,0 +1,185 @@
+// Package tracing carries OpenTelemetry spans from HTTP and gRPC requests
+// into actor messages and on through the messages actors send each other.
+//
+// The W3C trace context of a span travels in the headers of the message
+// envelope. Instrumentation, passed to proto_actor.WithInstrumentation,
+// starts a span for every message that arrives with one and stamps the
+// messages the actor sends while handling it, so a request to one manager
+// that fans out to an entity actor or another manager forms one trace.
+// Inject and Stamp let request handlers build a root context that stamps
+// their requests the same way.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name is the instrumentation scope of every span this service starts.
+const Name = "reddit-clone"
+
+// Propagator reads and writes the trace context of requests and messages.
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// Tracer returns the tracer of the global tracer provider, which Setup
+// replaces. Until then spans are not recorded.
+func Tracer() trace.Tracer {
+	return otel.Tracer(Name)
+}
+
+// Setup makes exporter receive every span, batched unless it is an
+// in-memory exporter, whose spans tests want to see as soon as they end. It
+// returns the shutdown function that flushes the remaining spans.
+func Setup(exporter sdktrace.SpanExporter) func(context.Context) error {
+	var processor sdktrace.SpanProcessor
+	if _, inMemory := exporter.(*tracetest.InMemoryExporter); inMemory {
+		processor = sdktrace.NewSimpleSpanProcessor(exporter)
+	} else {
+		processor = sdktrace.NewBatchSpanProcessor(exporter)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(Propagator)
+	return provider.Shutdown
+}
+
+// NewExporter returns the exporter named by kind: "stdout" writes each span
+// as JSON to w, "memory" keeps them for inspection, and "" or "none" turns
+// tracing off by returning nil.
+func NewExporter(kind string, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(kind) {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		if w == nil {
+			w = os.Stdout
+		}
+		return stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+	case "memory":
+		return tracetest.NewInMemoryExporter(), nil
+	}
+	return nil, fmt.Errorf("unknown trace exporter %q", kind)
+}
+
+// Inject adds the span of ctx, if it has one, to header, the headers of an
+// actor message. A root context created with header and Stamp sends
+// messages that continue the span.
+func Inject(ctx context.Context, header map[string]string) {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		Propagator.Inject(ctx, propagation.MapCarrier(header))
+	}
+}
+
+// Instrumentation traces the actors it is given to. Messages that arrive
+// without a trace context, such as timers, are not traced.
+type Instrumentation struct{}
+
+// Receiver starts a span named after kind and the message type for each
+// traced message, and makes it the trace context of the messages sent while
+// handling it.
+func (Instrumentation) Receiver(kind string) actor.ReceiverMiddleware {
+	return func(next actor.ReceiverFunc) actor.ReceiverFunc {
+		return func(ctx actor.ReceiverContext, envelope *actor.MessageEnvelope) {
+			parent := Propagator.Extract(context.Background(), envelopeCarrier{envelope})
+			if !trace.SpanContextFromContext(parent).IsValid() {
+				next(ctx, envelope)
+				return
+			}
+
+			message := messageName(envelope.Message)
+			spanContext, span := Tracer().Start(parent, kind+" "+message,
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.String("actor.kind", kind),
+					attribute.String("actor.message", message),
+					attribute.String("actor.pid", ctx.Self().Id),
+				))
+			defer span.End()
+
+			// The envelope is the one ctx.MessageHeader reads, so the
+			// messages sent from here on carry this span.
+			header := make(map[string]string, len(envelope.Header))
+			for key, value := range envelope.Header {
+				header[key] = value
+			}
+			Propagator.Inject(spanContext, propagation.MapCarrier(header))
+			envelope.Header = header
+
+			next(ctx, envelope)
+		}
+	}
+}
+
+// Sender copies the trace context of the message being handled onto every
+// message the actor sends.
+func (Instrumentation) Sender(kind string) actor.SenderMiddleware {
+	return Stamp
+}
+
+// Mailbox is nil: spans start when a message is handled, not queued.
+func (Instrumentation) Mailbox(kind string) actor.MailboxMiddleware {
+	return nil
+}
+
+// Stamp sends a copy of envelope with the trace context of ctx's current
+// message, if it has one. The copy keeps the receiver from sharing headers
+// with the sender, as it would when a message is forwarded.
+func Stamp(next actor.SenderFunc) actor.SenderFunc {
+	return func(ctx actor.SenderContext, target *actor.PID, envelope *actor.MessageEnvelope) {
+		current := ctx.MessageHeader()
+		if current == nil || current.Get("traceparent") == "" {
+			next(ctx, target, envelope)
+			return
+		}
+		stamped := &actor.MessageEnvelope{Message: envelope.Message, Sender: envelope.Sender}
+		for key, value := range envelope.Header {
+			stamped.SetHeader(key, value)
+		}
+		for _, key := range Propagator.Fields() {
+			if value := current.Get(key); value != "" {
+				stamped.SetHeader(key, value)
+			}
+		}
+		next(ctx, target, stamped)
+	}
+}
+
+// envelopeCarrier reads a trace context from the headers of an envelope.
+type envelopeCarrier struct {
+	envelope *actor.MessageEnvelope
+}
+
+func (c envelopeCarrier) Get(key string) string {
+	return c.envelope.GetHeader(key)
+}
+
+func (c envelopeCarrier) Set(key, value string) {
+	c.envelope.SetHeader(key, value)
+}
+
+func (c envelopeCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.envelope.Header))
+	for key := range c.envelope.Header {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// messageName is the type name of message without its package, such as
+// "AddPost".
+func messageName(message interface{}) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", message), "*")
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/templates"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FetchAutoModeratorHandler returns the YAML source of a forum's
+// AutoModerator rules.
+func FetchAutoModeratorHandler(c *gin.Context) {
+	forum, err := resolveForumRef(c, c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates.NewAutoModeratorResponse(forum))
+}
+
+// SetAutoModeratorHandler replaces a forum's AutoModerator rules. Only its
+// moderators may. Rules that do not parse are refused with the rule and line
+// at fault.
+func SetAutoModeratorHandler(c *gin.Context) {
+	var request autoModeratorRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	forumID, ok := bindForumRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	moderatorID, ok := bindUserRef(c, request.ModeratorID, "moderator_id")
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.SetAutoModerator{ForumID: forumID, Rules: request.Rules, ModeratorID: moderatorID}, templates.NewAutoModeratorResponse)
+}
+
+// TestAutoModeratorHandler is a dry run: it tells what the forum's
+// AutoModerator rules, or the rules given instead, would do with a post or
+// comment by author_id, without storing anything or taking any action.
+func TestAutoModeratorHandler(c *gin.Context) {
+	var request autoModeratorTestRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	forum, ok := bindForum(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	author, ok := bindUser(c, request.AuthorID, "author_id")
+	if !ok {
+		return
+	}
+
+	evaluate := &proto_actor.EvaluateContent{
+		ForumID:         forum.ID,
+		Rules:           forum.AutoModerator,
+		Kind:            request.Kind,
+		Text:            request.Text,
+		AuthorName:      author.Username,
+		AuthorKarma:     author.Karma,
+		AuthorCreatedAt: author.CreatedAt,
+	}
+	if request.Rules != nil {
+		evaluate.ForumID, evaluate.Rules = "", *request.Rules
+	}
+	serve(c, AutoModeratorActor, evaluate, templates.NewAutoModResultResponse)
+}
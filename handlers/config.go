@@ -0,0 +1,8 @@
+package handlers
+
+import "reddit-clone/core/config"
+
+// Config holds the request timeout, content limits and features the
+// handlers apply. Replace it with the loaded configuration before
+// RegisterRoutes.
+var Config = config.Default()
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"reddit-clone/core/content"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+
+	"github.com/gin-gonic/gin"
+)
+
+const NotificationMention = "mention"
+
+// resolveMentions parses text for @user and r/forum references and looks each
+// one up in the member and forum managers. References that do not resolve are
+// dropped so they render as plain text.
+func resolveMentions(ctx context.Context, text string) []schemas.Mention {
+	parsed := content.Parse(text)
+	if len(parsed) == 0 {
+		return nil
+	}
+
+	resolved := make(map[string]string)
+	var mentions []schemas.Mention
+	for _, m := range parsed {
+		key := m.Kind + ":" + m.Name
+		targetID, seen := resolved[key]
+		if !seen {
+			targetID = lookupMention(ctx, m)
+			resolved[key] = targetID
+		}
+		if targetID == "" {
+			continue
+		}
+		m.TargetID = targetID
+		mentions = append(mentions, m)
+	}
+	return mentions
+}
+
+func lookupMention(ctx context.Context, m schemas.Mention) string {
+	switch m.Kind {
+	case schemas.MentionUser:
+		if UserActor == nil {
+			return ""
+		}
+		profile, err := ask[*schemas.Account](ctx, UserActor, &proto_actor.FetchUserByName{Username: m.Name})
+		if err == nil {
+			return profile.ID
+		}
+
+	case schemas.MentionForum:
+		if SubredditActor == nil {
+			return ""
+		}
+		forum, err := ask[*schemas.Subreddit](ctx, SubredditActor, &proto_actor.RetrieveForumByName{Name: m.Name})
+		if err == nil {
+			return forum.ID
+		}
+	}
+	return ""
+}
+
+// notifyMentions sends a mention notification to every user referenced in
+// mentions other than the author.
+func notifyMentions(ctx context.Context, mentions []schemas.Mention, sourceID, authorID string) {
+	if NotificationActor == nil {
+		return
+	}
+	for _, userID := range content.MentionedUserIDs(mentions) {
+		if userID == authorID {
+			continue
+		}
+		rootFor(ctx).Send(NotificationActor, &proto_actor.PushNotification{
+			UserID:   userID,
+			Kind:     NotificationMention,
+			SourceID: sourceID,
+			ActorID:  authorID,
+		})
+	}
+}
+
+func FetchNotificationsHandler(c *gin.Context) {
+	userID, ok := bindUserRef(c, c.Query("user_id"), "user_id")
+	if !ok {
+		return
+	}
+
+	serve(c, NotificationActor, &proto_actor.FetchNotifications{UserID: userID}, templates.NewNotificationListResponse)
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reddit-clone/core/automod"
+	"reddit-clone/core/filter"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+)
+
+// ContentFilter screens every post, comment and message when it is set; the
+// AutoModerator rules of a forum are checked on its posts and comments after
+// it. main sets it when Config.Filters is enabled. Rejected content is
+// refused with an Invalid error. Held content is stored but kept out of
+// listings, and of its receiver's inbox for a message, until it is approved
+// from the modqueue; the JSON API and the pages answer its author as if it
+// had been accepted.
+var ContentFilter *filter.Pipeline
+
+// rejectedError is the Invalid error of content a filter rejected or
+// AutoModerator removed.
+type rejectedError struct {
+	err *proto_actor.Error
+}
+
+func (e *rejectedError) Error() string { return e.err.Error() }
+func (e *rejectedError) Unwrap() error { return e.err }
+
+// rejected reports whether err refused content for what it says.
+func rejected(err error) bool {
+	var typed *rejectedError
+	return errors.As(err, &typed)
+}
+
+// screen runs content through ContentFilter. It returns a rejectedError
+// when a filter rejects the content, and otherwise the decision, which
+// allows everything when ContentFilter is not set.
+func screen(ctx context.Context, content *filter.Content) (filter.Decision, error) {
+	if ContentFilter == nil {
+		return filter.Decision{}, nil
+	}
+	decision := ContentFilter.Check(content)
+	if decision.Verdict == filter.Reject {
+		slog.InfoContext(ctx, "content rejected", "kind", content.Kind, "author_id", content.Author.ID, "filter", decision.Filter, "reason", decision.Reason)
+		return decision, &rejectedError{proto_actor.Errorf(proto_actor.Invalid, "the %s %s", content.Kind, decision.Reason)}
+	}
+	return decision, nil
+}
+
+// moderate runs the AutoModerator rules of content's forum on it. It
+// returns a rejectedError when a rule removes the content, and otherwise the
+// result, which is empty when the forum has no rules. The rules travel in
+// the request as text taken from the forum manager's copy of the forum, so
+// a concurrent SetAutoModerator never changes them mid-evaluation.
+func moderate(ctx context.Context, content *filter.Content) (*automod.Result, error) {
+	if content.Forum == nil || AutoModeratorActor == nil {
+		return &automod.Result{}, nil
+	}
+	forumID, rules := content.Forum.ID, content.Forum.AutoModerator
+	if rules == "" {
+		return &automod.Result{}, nil
+	}
+	result, err := ask[*automod.Result](ctx, AutoModeratorActor, &proto_actor.EvaluateContent{
+		ForumID:         forumID,
+		Rules:           rules,
+		Kind:            content.Kind,
+		Text:            content.Text,
+		AuthorName:      content.Author.Username,
+		AuthorKarma:     content.Author.Karma,
+		AuthorCreatedAt: content.Author.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Action == automod.Remove {
+		slog.InfoContext(ctx, "content removed", "kind", content.Kind, "author_id", content.Author.ID, "forum_id", content.Forum.ID, "rule", result.Rule)
+		return nil, &rejectedError{proto_actor.Errorf(proto_actor.Invalid, "the %s was removed by %s: %s", content.Kind, automod.Name, result.Reason)}
+	}
+	return result, nil
+}
+
+// review screens content with the content filters and then AutoModerator,
+// whose hold counts as a filter's.
+func review(ctx context.Context, content *filter.Content) (filter.Decision, *automod.Result, error) {
+	decision, err := screen(ctx, content)
+	if err != nil {
+		return decision, nil, err
+	}
+	result, err := moderate(ctx, content)
+	if err != nil {
+		forget(content)
+		return decision, nil, err
+	}
+	if result.Action == automod.Hold && decision.Verdict == filter.Allow {
+		decision = filter.Decision{Verdict: filter.Hold, Filter: automod.Filter, Reason: result.Reason}
+	}
+	return decision, result, nil
+}
+
+// forget tells the filters that screened content was not stored after
+// all, so they forget it.
+func forget(content *filter.Content) {
+	if ContentFilter != nil {
+		ContentFilter.Release(content)
+	}
+}
+
+// settle records stored content with the filters and, when it was held,
+// queues it for review.
+func settle(ctx context.Context, content *filter.Content, decision filter.Decision, contentID string) {
+	if ContentFilter != nil {
+		ContentFilter.Record(content)
+	}
+	if decision.Verdict != filter.Hold {
+		return
+	}
+
+	var forumID string
+	if content.Forum != nil {
+		forumID = content.Forum.ID
+	}
+	slog.InfoContext(ctx, "content held", "kind", content.Kind, "content_id", contentID, "author_id", content.Author.ID, "filter", decision.Filter, "reason", decision.Reason)
+	if ModerationActor == nil {
+		return
+	}
+	_, err := ask(ctx, ModerationActor, &proto_actor.HoldContent{
+		Kind:      content.Kind,
+		ContentID: contentID,
+		ForumID:   forumID,
+		AuthorID:  content.Author.ID,
+		Filter:    decision.Filter,
+		Reason:    decision.Reason,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "queueing held content failed", "kind", content.Kind, "content_id", contentID, "error", err)
+	}
+}
+
+// enforce takes the actions of AutoModerator on stored content besides
+// holding and flairing it: it posts the replies of the matching rules, as
+// replies to commentID or else on postID, and reports the content unless it
+// is held anyway. Failures are logged, as the content is already stored.
+func enforce(ctx context.Context, content *filter.Content, result *automod.Result, held bool, contentID, postID, commentID string) {
+	if result.Action == automod.Report && !held && ModerationActor != nil {
+		_, err := ask(ctx, ModerationActor, &proto_actor.ReportContent{
+			Kind:      content.Kind,
+			ContentID: contentID,
+			ForumID:   content.Forum.ID,
+			AuthorID:  content.Author.ID,
+			Filter:    automod.Filter,
+			Reason:    result.Reason,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "reporting content failed", "kind", content.Kind, "content_id", contentID, "error", err)
+		}
+	}
+	if len(result.Comments) == 0 {
+		return
+	}
+
+	bot, err := ask[*schemas.Account](ctx, UserActor, &proto_actor.EnsureUser{Username: automod.Name})
+	if err != nil {
+		slog.ErrorContext(ctx, "fetching the AutoModerator account failed", "error", err)
+		return
+	}
+	for _, text := range result.Comments {
+		reply, err := ask[*schemas.Comment](ctx, CommentActor, &proto_actor.AddComment{
+			PostID:   postID,
+			ParentID: commentID,
+			AuthorID: bot.ID,
+			Content:  text,
+			Mentions: resolveMentions(ctx, text),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "replying as AutoModerator failed", "kind", content.Kind, "content_id", contentID, "error", err)
+			continue
+		}
+		notifyMentions(ctx, reply.Mentions, reply.ID, reply.AuthorID)
+	}
+}
+
+// submitPost screens and stores a post by author in forum, and notifies the
+// users it mentions unless it is held. Every API submits posts through it,
+// so it refuses authors who may not post in forum.
+func submitPost(ctx context.Context, author *schemas.Account, forum *schemas.Subreddit, text string) (*schemas.Post, error) {
+	if err := checkPost(forum, author.ID); err != nil {
+		return nil, err
+	}
+	content := &filter.Content{Kind: schemas.KindPost, Author: author, Forum: forum, Text: text}
+	decision, result, err := review(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := ask[*schemas.Post](ctx, PostActor, &proto_actor.AddPost{
+		ForumID:  forum.ID,
+		AuthorID: author.ID,
+		Text:     text,
+		Mentions: resolveMentions(ctx, text),
+		Held:     decision.Verdict == filter.Hold,
+		Flair:    result.Flair,
+	})
+	if err != nil {
+		forget(content)
+		return nil, err
+	}
+
+	settle(ctx, content, decision, post.ID)
+	if !post.Held {
+		notifyMentions(ctx, post.Mentions, post.ID, post.AuthorID)
+	}
+	enforce(ctx, content, result, post.Held, post.ID, post.ID, "")
+	return post, nil
+}
+
+// submitComment is submitPost for a comment on postID, or a reply to
+// parentID. The comment is screened with the lists and AutoModerator rules
+// of the post's forum, and refused when author may not view the forum.
+func submitComment(ctx context.Context, author *schemas.Account, postID, parentID, text string) (*schemas.Comment, error) {
+	forum, err := commentForum(ctx, postID, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkView(forum, author.ID); err != nil {
+		return nil, err
+	}
+	content := &filter.Content{Kind: schemas.KindComment, Author: author, Forum: forum, Text: text}
+	decision, result, err := review(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := ask[*schemas.Comment](ctx, CommentActor, &proto_actor.AddComment{
+		PostID:   postID,
+		ParentID: parentID,
+		AuthorID: author.ID,
+		Content:  text,
+		Mentions: resolveMentions(ctx, text),
+		Held:     decision.Verdict == filter.Hold,
+	})
+	if err != nil {
+		forget(content)
+		return nil, err
+	}
+
+	settle(ctx, content, decision, comment.ID)
+	if !comment.Held {
+		notifyMentions(ctx, comment.Mentions, comment.ID, comment.AuthorID)
+	}
+	enforce(ctx, content, result, comment.Held, comment.ID, comment.PostID, comment.ID)
+	return comment, nil
+}
+
+// commentForum returns the forum of the post a comment goes to: postID, or
+// the post of the parentID comment when postID is empty.
+func commentForum(ctx context.Context, postID, parentID string) (*schemas.Subreddit, error) {
+	if postID == "" {
+		parent, err := ask[*schemas.Comment](ctx, CommentActor, &proto_actor.FetchComment{CommentID: parentID})
+		if err != nil {
+			if proto_actor.CodeOf(err) == proto_actor.NotFound {
+				return nil, proto_actor.ErrParentNotFound
+			}
+			return nil, err
+		}
+		postID = parent.PostID
+	}
+	post, err := ask[*schemas.Post](ctx, PostActor, &proto_actor.RetrievePost{ContentID: postID})
+	if err != nil {
+		return nil, err
+	}
+	return ask[*schemas.Subreddit](ctx, SubredditActor, &proto_actor.RetrieveForum{ForumID: post.SubredditID})
+}
+
+// sendMessage is submitPost for a private message. A held message reaches
+// its receiver only once it is approved.
+func sendMessage(ctx context.Context, from *schemas.Account, toUserID, body string) (*schemas.Message, error) {
+	content := &filter.Content{Kind: schemas.KindMessage, Author: from, Text: body}
+	decision, err := screen(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := ask[*schemas.Message](ctx, MessageActor, &proto_actor.SendMessage{
+		FromUserID: from.ID,
+		ToUserID:   toUserID,
+		Body:       body,
+		Held:       decision.Verdict == filter.Hold,
+	})
+	if err != nil {
+		forget(content)
+		return nil, err
+	}
+
+	settle(ctx, content, decision, message.ID)
+	return message, nil
+}
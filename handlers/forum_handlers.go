@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resolveForumRef accepts either a generated forum ID or a canonical forum
+// name and returns the matching forum.
+func resolveForumRef(ctx context.Context, ref string) (*schemas.Subreddit, error) {
+	if ref == "" {
+		return nil, proto_actor.ErrForumNotFound
+	}
+	if strings.HasPrefix(ref, "subreddit_") {
+		return ask[*schemas.Subreddit](ctx, SubredditActor, &proto_actor.RetrieveForum{ForumID: ref})
+	}
+	return ask[*schemas.Subreddit](ctx, SubredditActor, &proto_actor.RetrieveForumByName{Name: ref})
+}
+
+// bindForumRef resolves ref to a forum ID for a JSON handler. It writes the
+// error, naming field, and returns false when no such forum exists.
+func bindForumRef(c *gin.Context, ref, field string) (string, bool) {
+	forum, ok := bindForum(c, ref, field)
+	if !ok {
+		return "", false
+	}
+	return forum.ID, true
+}
+
+// bindForum is bindForumRef for handlers that need the forum itself.
+func bindForum(c *gin.Context, ref, field string) (*schemas.Subreddit, bool) {
+	forum, err := resolveForumRef(c, ref)
+	if err != nil {
+		writeFieldError(c, err, field)
+		return nil, false
+	}
+	return forum, true
+}
+
+func FetchForumByNameHandler(c *gin.Context) {
+	serve(c, SubredditActor, &proto_actor.RetrieveForumByName{Name: c.Param("name")}, templates.NewSubredditResponse)
+}
+
+// RenameForumHandler changes a forum's display title. The canonical name is
+// fixed at creation so links and r/ references keep working. Only its
+// moderators may rename it.
+func RenameForumHandler(c *gin.Context) {
+	var request renameForumRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	forumID, ok := bindForumRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	moderatorID, ok := bindUserRef(c, request.ModeratorID, "moderator_id")
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.RenameForum{
+		ForumID:     forumID,
+		Title:       strings.TrimSpace(request.Title),
+		ModeratorID: moderatorID,
+	}, templates.NewSubredditResponse)
+}
+
+// UpdateForumHandler replaces a forum's description, sidebar, rules, banner
+// and icon, and NSFW flag. Only its moderators may.
+func UpdateForumHandler(c *gin.Context) {
+	var request forumInfoRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	forumID, ok := bindForumRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	moderatorID, ok := bindUserRef(c, request.ModeratorID, "moderator_id")
+	if !ok {
+		return
+	}
+
+	var rules []schemas.ForumRule
+	for _, rule := range request.Rules {
+		rules = append(rules, schemas.ForumRule{
+			Title:       strings.TrimSpace(rule.Title),
+			Description: strings.TrimSpace(rule.Description),
+		})
+	}
+	serve(c, SubredditActor, &proto_actor.UpdateForum{ForumID: forumID, Info: schemas.ForumInfo{
+		Description: strings.TrimSpace(request.Description),
+		Sidebar:     request.Sidebar,
+		Rules:       rules,
+		BannerURL:   request.BannerURL,
+		IconURL:     request.IconURL,
+		NSFW:        request.NSFW,
+	}, ModeratorID: moderatorID}, templates.NewSubredditResponse)
+}
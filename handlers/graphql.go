@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"reddit-clone/core/proto_actors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// A GraphQL query is checked against the depth and complexity limits of
+// Config.Limits before it runs. Depth counts nested selection sets.
+// Complexity counts every selected field once, with the selections under a
+// list multiplied by the list's first argument, or by UnboundedListCost for
+// lists without one such as replies. Introspection fields are not counted.
+const UnboundedListCost = 10
+
+type graphqlRequest struct {
+	Query         string                 `json:"query" form:"query" binding:"required"`
+	OperationName string                 `json:"operationName" form:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// codedError is a manager error as reported in a GraphQL response, with its
+// code in the error's extensions.
+type codedError struct {
+	message string
+	code    proto_actor.Code
+}
+
+func (e *codedError) Error() string {
+	return e.message
+}
+
+func (e *codedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code.String()}
+}
+
+// graphqlError reports err to the client. As with writeError, internal
+// errors are not described.
+func graphqlError(err error) error {
+	return &codedError{message: errorBody(err)["error"].(string), code: proto_actor.CodeOf(err)}
+}
+
+// nullIfMissing resolves a field to null when the entity it names does not
+// exist, rather than failing the field.
+func nullIfMissing[T any](value T, err error) (interface{}, error) {
+	if proto_actor.CodeOf(err) == proto_actor.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, graphqlError(err)
+	}
+	return value, nil
+}
+
+// GraphQLHandler runs a GraphQL query, sent as JSON by POST or as query
+// parameters by GET, for the user given by the viewer_id query parameter.
+// Queries that fail to parse, validate or stay within the limits are
+// answered with 400 and do not run.
+func GraphQLHandler(c *gin.Context) {
+	var req graphqlRequest
+	var err error
+	if c.Request.Method == http.MethodGet {
+		err = c.ShouldBindQuery(&req)
+	} else {
+		err = c.ShouldBindJSON(&req)
+	}
+	if err != nil {
+		writeBindError(c, err)
+		return
+	}
+	viewerID, ok := bindViewer(c)
+	if !ok {
+		return
+	}
+
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	if validation := graphql.ValidateDocument(&GraphQLSchema, document, nil); !validation.IsValid {
+		c.JSON(http.StatusBadRequest, &graphql.Result{Errors: validation.Errors})
+		return
+	}
+	if err := checkQueryLimits(document, req.OperationName, req.Variables); err != nil {
+		c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, graphql.Execute(graphql.ExecuteParams{
+		Schema:        GraphQLSchema,
+		AST:           document,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withViewer(withLoaders(c.Request.Context()), viewerID),
+	}))
+}
+
+// queryCost walks a validated document to measure the depth and complexity
+// of the operation that will run.
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+func checkQueryLimits(document *ast.Document, operationName string, variables map[string]interface{}) error {
+	cost := queryCost{fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			cost.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operation == nil || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+
+	depth, complexity := cost.selections(GraphQLSchema.QueryType(), operation.SelectionSet)
+	if limit := Config.Limits.GraphQLDepth; depth > limit {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, limit)
+	}
+	if limit := Config.Limits.GraphQLComplexity; complexity > limit {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, limit)
+	}
+	return nil
+}
+
+// selections returns the depth and complexity of a selection set on parent.
+func (q queryCost) selections(parent *graphql.Object, set *ast.SelectionSet) (int, int) {
+	if set == nil || parent == nil {
+		return 0, 0
+	}
+	depth, complexity := 0, 0
+	add := func(d, c int) {
+		depth = max(depth, d)
+		complexity += c
+	}
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			add(q.field(parent, selection))
+		case *ast.InlineFragment:
+			add(q.selections(parent, selection.SelectionSet))
+		case *ast.FragmentSpread:
+			if fragment, found := q.fragments[selection.Name.Value]; found {
+				add(q.selections(parent, fragment.SelectionSet))
+			}
+		}
+	}
+	return depth, complexity
+}
+
+func (q queryCost) field(parent *graphql.Object, field *ast.Field) (int, int) {
+	name := field.Name.Value
+	definition, found := parent.Fields()[name]
+	if strings.HasPrefix(name, "__") || !found {
+		return 1, 1
+	}
+
+	list := false
+	t := definition.Type
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.NonNull:
+			t = wrapped.OfType
+			continue
+		case *graphql.List:
+			list = true
+			t = wrapped.OfType
+			continue
+		}
+		break
+	}
+	child, _ := t.(*graphql.Object)
+	depth, complexity := q.selections(child, field.SelectionSet)
+	if list {
+		complexity *= q.listSize(definition, field)
+	}
+	return depth + 1, complexity + 1
+}
+
+// listSize is the number of items a list field may return: its first
+// argument, or its default, or UnboundedListCost.
+func (q queryCost) listSize(definition *graphql.FieldDefinition, field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			var first int
+			if _, err := fmt.Sscan(value.Value, &first); err == nil && first > 0 {
+				return min(first, proto_actor.MaxPageLimit)
+			}
+		case *ast.Variable:
+			if first, ok := q.variables[value.Name.Value].(float64); ok && first > 0 {
+				return min(int(first), proto_actor.MaxPageLimit)
+			}
+		}
+	}
+	for _, argument := range definition.Args {
+		if first, ok := argument.DefaultValue.(int); ok && argument.PrivateName == "first" {
+			return first
+		}
+	}
+	return UnboundedListCost
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"sync"
+)
+
+// loader batches lookups by ID within one GraphQL request. Resolvers call
+// load, which only queues the ID and returns a thunk; the executor runs the
+// thunks of a level after every resolver of that level has queued its IDs,
+// so the first thunk fetches all of them in one manager request and the rest
+// are answered from the loader's cache.
+type loader[V any] struct {
+	fetch func(ids []string) (map[string]V, error)
+
+	lock    sync.Mutex
+	queued  map[string]bool
+	pending []string
+	values  map[string]V
+	fetched map[string]bool
+}
+
+func newLoader[V any](fetch func(ids []string) (map[string]V, error)) *loader[V] {
+	return &loader[V]{
+		fetch:   fetch,
+		queued:  make(map[string]bool),
+		values:  make(map[string]V),
+		fetched: make(map[string]bool),
+	}
+}
+
+// prime records a value that was fetched by other means, such as the posts
+// of a listing, so later loads of it need no request.
+func (l *loader[V]) prime(id string, value V) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.values[id] = value
+	l.fetched[id] = true
+}
+
+func (l *loader[V]) enqueue(id string) {
+	if !l.fetched[id] && !l.queued[id] {
+		l.queued[id] = true
+		l.pending = append(l.pending, id)
+	}
+}
+
+// load queues id and returns a thunk that yields its value, or nil when it
+// does not exist.
+func (l *loader[V]) load(id string) func() (interface{}, error) {
+	l.lock.Lock()
+	l.enqueue(id)
+	l.lock.Unlock()
+
+	return func() (interface{}, error) {
+		value, found, err := l.get(id)
+		if err != nil || !found {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// get returns the value of id, first fetching every queued ID if id has not
+// been fetched yet.
+func (l *loader[V]) get(id string) (V, bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !l.fetched[id] {
+		l.enqueue(id)
+		ids := l.pending
+		l.pending = nil
+		for _, queued := range ids {
+			delete(l.queued, queued)
+		}
+
+		values, err := l.fetch(ids)
+		if err != nil {
+			var zero V
+			return zero, false, err
+		}
+		for _, fetched := range ids {
+			l.fetched[fetched] = true
+			if value, found := values[fetched]; found {
+				l.values[fetched] = value
+			}
+		}
+	}
+	value, found := l.values[id]
+	return value, found, nil
+}
+
+// thread is the reply tree of one post, rebuilt from its flat comments.
+type thread struct {
+	comments map[string]*schemas.Comment
+	children map[string][]*schemas.Comment
+}
+
+// roots are the post's top-level comments, oldest first.
+func (t *thread) roots() []*schemas.Comment {
+	return t.children[""]
+}
+
+// loaders are the per-request loaders of the GraphQL endpoint. Each request
+// gets its own, so nothing is cached between requests.
+type loaders struct {
+	users   *loader[*schemas.Account]
+	forums  *loader[*schemas.Subreddit]
+	posts   *loader[*schemas.Post]
+	threads *loader[*thread]
+}
+
+func newLoaders(ctx context.Context) *loaders {
+	return &loaders{
+		users: newLoader(func(ids []string) (map[string]*schemas.Account, error) {
+			accounts, err := ask(ctx, UserActor, &proto_actor.FetchUsers{ProfileIDs: ids})
+			return indexByID(accounts, func(a *schemas.Account) string { return a.ID }), err
+		}),
+		forums: newLoader(func(ids []string) (map[string]*schemas.Subreddit, error) {
+			forums, err := ask(ctx, SubredditActor, &proto_actor.RetrieveForums{ForumIDs: ids})
+			return indexByID(forums, func(f *schemas.Subreddit) string { return f.ID }), err
+		}),
+		posts: newLoader(func(ids []string) (map[string]*schemas.Post, error) {
+			posts, err := ask(ctx, PostActor, &proto_actor.RetrievePosts{ContentIDs: ids})
+			return indexByID(posts, func(p *schemas.Post) string { return p.ID }), err
+		}),
+		threads: newLoader(func(ids []string) (map[string]*thread, error) {
+			comments, err := ask(ctx, CommentActor, &proto_actor.FetchThreads{PostIDs: ids})
+			if err != nil {
+				return nil, err
+			}
+			threads := make(map[string]*thread, len(ids))
+			for _, id := range ids {
+				threads[id] = &thread{comments: map[string]*schemas.Comment{}, children: map[string][]*schemas.Comment{}}
+			}
+			for _, comment := range comments {
+				t := threads[comment.PostID]
+				t.comments[comment.ID] = comment
+			}
+			// Replies whose parent was removed are left out of the tree,
+			// as they are by the thread actors.
+			for _, comment := range comments {
+				t := threads[comment.PostID]
+				if _, linked := t.comments[comment.ParentID]; comment.ParentID == "" || linked {
+					t.children[comment.ParentID] = append(t.children[comment.ParentID], comment)
+				}
+			}
+			return threads, nil
+		}),
+	}
+}
+
+func indexByID[V any](values []V, id func(V) string) map[string]V {
+	index := make(map[string]V, len(values))
+	for _, value := range values {
+		index[id(value)] = value
+	}
+	return index
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, newLoaders(ctx))
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"reddit-clone/core/content"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"sort"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The GraphQL schema. Scalar fields read the entity passed down as Source;
+// fields that reach another entity go through the request's loaders, so a
+// listing of N posts costs one manager request per level, not one per post.
+
+var (
+	accountType   *graphql.Object
+	subredditType *graphql.Object
+	forumRuleType *graphql.Object
+	postType      *graphql.Object
+	commentType   *graphql.Object
+	messageType   *graphql.Object
+)
+
+// GraphQLSchema is the schema served by GraphQLHandler.
+var GraphQLSchema graphql.Schema
+
+func init() {
+	accountType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Account",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        idField(func(a *schemas.Account) string { return a.ID }),
+				"username":  stringField(func(a *schemas.Account) string { return a.Username }),
+				"karma":     intField(func(a *schemas.Account) int { return a.Karma }),
+				"bio":       stringField(func(a *schemas.Account) string { return a.Bio }),
+				"avatarUrl": stringField(func(a *schemas.Account) string { return a.AvatarURL }),
+				"createdAt": timeField(func(a *schemas.Account) time.Time { return a.CreatedAt }),
+				"posts": &graphql.Field{
+					Type:        listOf(postType),
+					Description: "The account's posts, one page at a time",
+					Args:        pageArgs(),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						page, err := graphqlPage(p.Args)
+						if err != nil {
+							return nil, err
+						}
+						hidden, err := hiddenForums(p.Context, viewerOf(p.Context))
+						if err != nil {
+							return nil, graphqlError(err)
+						}
+						listing, err := ask(p.Context, PostActor, &proto_actor.RetrieveAuthorPosts{AuthorID: p.Source.(*schemas.Account).ID, Page: page, HiddenForums: hidden})
+						if err != nil {
+							return nil, graphqlError(err)
+						}
+						primePosts(p, listing.Posts)
+						return listing.Posts, nil
+					},
+				},
+				"comments": &graphql.Field{
+					Type:        listOf(commentType),
+					Description: "The account's comments, one page at a time",
+					Args:        pageArgs(),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						page, err := graphqlPage(p.Args)
+						if err != nil {
+							return nil, err
+						}
+						hidden, err := hiddenForums(p.Context, viewerOf(p.Context))
+						if err != nil {
+							return nil, graphqlError(err)
+						}
+						listing, err := ask(p.Context, CommentActor, &proto_actor.FetchAuthorComments{AuthorID: p.Source.(*schemas.Account).ID, Page: page, HiddenForums: hidden})
+						if err != nil {
+							return nil, graphqlError(err)
+						}
+						return listing.Comments, nil
+					},
+				},
+			}
+		}),
+	})
+
+	forumRuleType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ForumRule",
+		Fields: graphql.Fields{
+			"title":       stringField(func(r schemas.ForumRule) string { return r.Title }),
+			"description": stringField(func(r schemas.ForumRule) string { return r.Description }),
+		},
+	})
+
+	subredditType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subreddit",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          idField(func(s *schemas.Subreddit) string { return s.ID }),
+				"name":        stringField(func(s *schemas.Subreddit) string { return s.Name }),
+				"title":       stringField(func(s *schemas.Subreddit) string { return s.Title }),
+				"members":     intField(func(s *schemas.Subreddit) int { return len(s.Members) }),
+				"createdAt":   timeField(func(s *schemas.Subreddit) time.Time { return s.CreatedAt }),
+				"type":        stringField(func(s *schemas.Subreddit) string { return s.Type }),
+				"description": stringField(func(s *schemas.Subreddit) string { return s.Description }),
+				"sidebar":     stringField(func(s *schemas.Subreddit) string { return s.Sidebar }),
+				"sidebarHtml": stringField(func(s *schemas.Subreddit) string { return content.RenderMarkdown(s.Sidebar) }),
+				"bannerUrl":   stringField(func(s *schemas.Subreddit) string { return s.BannerURL }),
+				"iconUrl":     stringField(func(s *schemas.Subreddit) string { return s.IconURL }),
+				"nsfw":        boolField(func(s *schemas.Subreddit) bool { return s.NSFW }),
+				"rules": &graphql.Field{
+					Type:        listOf(forumRuleType),
+					Description: "The forum's rules, numbered from 1 in order",
+					Resolve: fromSource(func(s *schemas.Subreddit) []schemas.ForumRule {
+						if s.Rules == nil {
+							return []schemas.ForumRule{}
+						}
+						return s.Rules
+					}),
+				},
+				"creator": &graphql.Field{
+					Type: accountType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						creatorID := p.Source.(*schemas.Subreddit).CreatedBy
+						if creatorID == "" {
+							return nil, nil
+						}
+						return loadersFrom(p.Context).users.load(creatorID), nil
+					},
+				},
+				"posts": &graphql.Field{
+					Type:        listOf(postType),
+					Description: "The forum's newest posts, empty for a private forum the viewer may not read",
+					Args:        graphql.FieldConfigArgument{"first": firstArg()},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						forum := p.Source.(*schemas.Subreddit)
+						if !forum.CanView(viewerOf(p.Context)) {
+							return []*schemas.Post{}, nil
+						}
+						posts, err := ask(p.Context, PostActor, &proto_actor.RetrieveForumPosts{ForumID: forum.ID})
+						if err != nil {
+							return nil, graphqlError(err)
+						}
+						if posts, err = newest(posts, p.Args); err != nil {
+							return nil, err
+						}
+						primePosts(p, posts)
+						return posts, nil
+					},
+				},
+			}
+		}),
+	})
+
+	postType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          idField(func(p *schemas.Post) string { return p.ID }),
+				"content":     stringField(func(p *schemas.Post) string { return p.Content }),
+				"contentHtml": stringField(func(p *schemas.Post) string { return content.RenderMarkdown(p.Content) }),
+				"upvotes":     intField(func(p *schemas.Post) int { return p.Upvotes }),
+				"downvotes":   intField(func(p *schemas.Post) int { return p.Downvotes }),
+				"score":       intField(func(p *schemas.Post) int { return p.Upvotes - p.Downvotes }),
+				"createdAt":   timeField(func(p *schemas.Post) time.Time { return p.CreatedAt }),
+				"author": &graphql.Field{
+					Type: accountType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).users.load(p.Source.(*schemas.Post).AuthorID), nil
+					},
+				},
+				"forum": &graphql.Field{
+					Type: subredditType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).forums.load(p.Source.(*schemas.Post).SubredditID), nil
+					},
+				},
+				"comments": &graphql.Field{
+					Type:        listOf(commentType),
+					Description: "Top-level comments, oldest first",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return threadComments(p, p.Source.(*schemas.Post).ID, ""), nil
+					},
+				},
+			}
+		}),
+	})
+
+	commentType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Comment",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          idField(func(c *schemas.Comment) string { return c.ID }),
+				"content":     stringField(func(c *schemas.Comment) string { return c.Content }),
+				"contentHtml": stringField(func(c *schemas.Comment) string { return content.RenderMarkdown(c.Content) }),
+				"upvotes":     intField(func(c *schemas.Comment) int { return c.Upvotes }),
+				"downvotes":   intField(func(c *schemas.Comment) int { return c.Downvotes }),
+				"score":       intField(func(c *schemas.Comment) int { return c.Upvotes - c.Downvotes }),
+				"createdAt":   timeField(func(c *schemas.Comment) time.Time { return c.CreatedAt }),
+				"author": &graphql.Field{
+					Type: accountType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).users.load(p.Source.(*schemas.Comment).AuthorID), nil
+					},
+				},
+				"post": &graphql.Field{
+					Type: postType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).posts.load(p.Source.(*schemas.Comment).PostID), nil
+					},
+				},
+				"parent": &graphql.Field{
+					Type: commentType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						comment := p.Source.(*schemas.Comment)
+						if comment.ParentID == "" {
+							return nil, nil
+						}
+						load := loadersFrom(p.Context).threads.load(comment.PostID)
+						return func() (interface{}, error) {
+							t, err := load()
+							if t == nil || err != nil {
+								return nil, err
+							}
+							if parent, found := t.(*thread).comments[comment.ParentID]; found {
+								return parent, nil
+							}
+							return nil, nil
+						}, nil
+					},
+				},
+				"replies": &graphql.Field{
+					Type:        listOf(commentType),
+					Description: "Direct replies, oldest first",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						comment := p.Source.(*schemas.Comment)
+						return threadComments(p, comment.PostID, comment.ID), nil
+					},
+				},
+			}
+		}),
+	})
+
+	messageType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Message",
+		Fields: graphql.Fields{
+			"id":        idField(func(m *schemas.Message) string { return m.ID }),
+			"content":   stringField(func(m *schemas.Message) string { return m.Content }),
+			"createdAt": timeField(func(m *schemas.Message) time.Time { return m.CreatedAt }),
+			"sender": &graphql.Field{
+				Type: accountType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p.Context).users.load(p.Source.(*schemas.Message).SenderID), nil
+				},
+			},
+			"receiver": &graphql.Field{
+				Type: accountType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p.Context).users.load(p.Source.(*schemas.Message).ReceiverID), nil
+				},
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": &graphql.Field{
+				Type: postType,
+				Args: graphql.FieldConfigArgument{"id": idArg()},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullIfMissing(viewPost(p.Context, p.Args["id"].(string), viewerOf(p.Context)))
+				},
+			},
+			"posts": &graphql.Field{
+				Type:        listOf(postType),
+				Description: "The newest posts, across all forums the viewer may read",
+				Args:        graphql.FieldConfigArgument{"first": firstArg()},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					hidden, err := hiddenForums(p.Context, viewerOf(p.Context))
+					if err != nil {
+						return nil, graphqlError(err)
+					}
+					posts, err := ask(p.Context, PostActor, &proto_actor.RetrieveAllPosts{HiddenForums: hidden})
+					if err != nil {
+						return nil, graphqlError(err)
+					}
+					if posts, err = newest(posts, p.Args); err != nil {
+						return nil, err
+					}
+					primePosts(p, posts)
+					return posts, nil
+				},
+			},
+			"comment": &graphql.Field{
+				Type: commentType,
+				Args: graphql.FieldConfigArgument{"id": idArg()},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullIfMissing(viewComment(p.Context, p.Args["id"].(string), viewerOf(p.Context)))
+				},
+			},
+			"forum": &graphql.Field{
+				Type:        subredditType,
+				Description: "A forum by ID or name",
+				Args:        graphql.FieldConfigArgument{"ref": refArg()},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullIfMissing(resolveForumRef(p.Context, p.Args["ref"].(string)))
+				},
+			},
+			"forums": &graphql.Field{
+				Type: listOf(subredditType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					forums, err := ask(p.Context, SubredditActor, &proto_actor.RetrieveAllForums{})
+					if err != nil {
+						return nil, graphqlError(err)
+					}
+					sort.Slice(forums, func(i, j int) bool { return forums[i].Name < forums[j].Name })
+					return forums, nil
+				},
+			},
+			"user": &graphql.Field{
+				Type:        accountType,
+				Description: "An account by ID or username",
+				Args:        graphql.FieldConfigArgument{"ref": refArg()},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullIfMissing(resolveUserRef(p.Context, p.Args["ref"].(string)))
+				},
+			},
+			"messages": &graphql.Field{
+				Type:        listOf(messageType),
+				Description: "The messages sent or received by a user, given by ID or username",
+				Args:        graphql.FieldConfigArgument{"user": refArg()},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					user, err := resolveUserRef(p.Context, p.Args["user"].(string))
+					if err != nil {
+						return nil, graphqlError(err)
+					}
+					messages, err := ask(p.Context, MessageActor, &proto_actor.FetchMessages{UserID: user.ID})
+					if err != nil {
+						return nil, graphqlError(err)
+					}
+					result := make([]*schemas.Message, len(messages))
+					for i := range messages {
+						result[i] = &messages[i]
+					}
+					return result, nil
+				},
+			},
+		},
+	})
+
+	var err error
+	GraphQLSchema, err = graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func listOf(t graphql.Type) graphql.Output {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t)))
+}
+
+func idField[S any](get func(S) string) *graphql.Field {
+	return &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: fromSource(get)}
+}
+
+func stringField[S any](get func(S) string) *graphql.Field {
+	return &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: fromSource(get)}
+}
+
+func intField[S any](get func(S) int) *graphql.Field {
+	return &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: fromSource(get)}
+}
+
+func boolField[S any](get func(S) bool) *graphql.Field {
+	return &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: fromSource(get)}
+}
+
+func timeField[S any](get func(S) time.Time) *graphql.Field {
+	return &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: fromSource(get)}
+}
+
+// fromSource resolves a field from the entity of type S it belongs to.
+func fromSource[S, V any](get func(S) V) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(S)), nil
+	}
+}
+
+func idArg() *graphql.ArgumentConfig {
+	return &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}
+}
+
+func refArg() *graphql.ArgumentConfig {
+	return &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}
+}
+
+func firstArg() *graphql.ArgumentConfig {
+	return &graphql.ArgumentConfig{
+		Type:         graphql.Int,
+		DefaultValue: proto_actor.DefaultPageLimit,
+		Description:  "The number of items, at most 100",
+	}
+}
+
+func pageArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"sort":  &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: proto_actor.SortNew, Description: "new, old or top"},
+		"first": firstArg(),
+		"after": &graphql.ArgumentConfig{Type: graphql.String, Description: "The ID of the last item of the previous page"},
+	}
+}
+
+// firstOf reads the first argument, which is capped like a listing limit.
+func firstOf(args map[string]interface{}) (int, error) {
+	first := args["first"].(int)
+	if first < 1 {
+		return 0, graphqlError(proto_actor.Errorf(proto_actor.Invalid, "first must be a positive integer"))
+	}
+	return proto_actor.Page{Limit: first}.EffectiveLimit(), nil
+}
+
+// graphqlPage builds the listing page selected by pageArgs.
+func graphqlPage(args map[string]interface{}) (proto_actor.Page, error) {
+	first, err := firstOf(args)
+	if err != nil {
+		return proto_actor.Page{}, err
+	}
+	page := proto_actor.Page{Sort: args["sort"].(string), Limit: first}
+	if after, ok := args["after"].(string); ok {
+		page.After = after
+	}
+	if err := checkPage(page); err != nil {
+		return page, graphqlError(proto_actor.Errorf(proto_actor.Invalid, "%s", err.Error()))
+	}
+	return page, nil
+}
+
+// newest returns the first newest posts. IDs are creation ordered.
+func newest(posts []*schemas.Post, args map[string]interface{}) ([]*schemas.Post, error) {
+	first, err := firstOf(args)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].ID > posts[j].ID })
+	if len(posts) > first {
+		posts = posts[:first]
+	}
+	return posts, nil
+}
+
+// primePosts lets comments on posts that were just listed find their post
+// without another request.
+func primePosts(p graphql.ResolveParams, posts []*schemas.Post) {
+	for _, post := range posts {
+		loadersFrom(p.Context).posts.prime(post.ID, post)
+	}
+}
+
+// threadComments returns a thunk yielding the comments under parentID in the
+// thread of postID; an empty parentID selects the top-level comments.
+func threadComments(p graphql.ResolveParams, postID, parentID string) func() (interface{}, error) {
+	load := loadersFrom(p.Context).threads.load(postID)
+	return func() (interface{}, error) {
+		t, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			return []*schemas.Comment{}, nil
+		}
+		return append([]*schemas.Comment{}, t.(*thread).children[parentID]...), nil
+	}
+}
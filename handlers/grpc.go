@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/proto_actors/wire"
+	"reddit-clone/core/ratelimit"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RedditServer serves the gRPC API. It talks to the same actors as the JSON
+// handlers and applies the same validation.
+type RedditServer struct {
+	wire.UnimplementedRedditServer
+}
+
+// NewGRPCServer returns a gRPC server with the Reddit service registered and
+// every call logged, traced and rate limited.
+func NewGRPCServer(options ...grpc.ServerOption) *grpc.Server {
+	options = append([]grpc.ServerOption{grpc.ChainUnaryInterceptor(logCalls, traceCalls, limitCalls)}, options...)
+	server := grpc.NewServer(options...)
+	wire.RegisterRedditServer(server, &RedditServer{})
+	return server
+}
+
+var grpcCodes = map[proto_actor.Code]codes.Code{
+	proto_actor.NotFound:    codes.NotFound,
+	proto_actor.Conflict:    codes.AlreadyExists,
+	proto_actor.Forbidden:   codes.PermissionDenied,
+	proto_actor.Invalid:     codes.InvalidArgument,
+	proto_actor.Unavailable: codes.Unavailable,
+	proto_actor.RateLimited: codes.ResourceExhausted,
+}
+
+// grpcError converts a manager error to a gRPC status. As with writeError,
+// internal errors are not described to the client. A rate limit carries a
+// RetryInfo detail saying how long to wait.
+func grpcError(err error) error {
+	code, known := grpcCodes[proto_actor.CodeOf(err)]
+	if !known {
+		return status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+	}
+	st := status.New(code, err.Error())
+	if wait, limited := retryAfter(err); limited {
+		if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
+
+func invalid(message string) error {
+	return grpcError(proto_actor.Errorf(proto_actor.Invalid, "%s", message))
+}
+
+// validate applies the binding rules of the JSON API to req, a request body
+// filled from a gRPC message.
+func validate(req any) error {
+	if fields := fieldErrors(binding.Validator.ValidateStruct(req)); len(fields) > 0 {
+		return invalid(fields[0].Message)
+	}
+	return nil
+}
+
+// reply converts the reply to a request, or its error, to the response W.
+func reply[W proto.Message](value interface{}, err error) (W, error) {
+	var zero W
+	if err != nil {
+		return zero, grpcError(err)
+	}
+	encoded, err := proto_actor.EncodeMessage(value)
+	if err != nil {
+		return zero, grpcError(err)
+	}
+	response, ok := encoded.(W)
+	if !ok {
+		return zero, grpcError(proto_actor.Errorf(proto_actor.Internal, "%T is not a %T", encoded, zero))
+	}
+	return response, nil
+}
+
+// grpcViewer resolves the viewer-id metadata of a call, an account ID or
+// username, to the ID of the user it reads for. A call without one reads as
+// an anonymous visitor.
+func grpcViewer(ctx context.Context) (string, error) {
+	refs := metadata.ValueFromIncomingContext(ctx, "viewer-id")
+	if len(refs) == 0 || refs[0] == "" {
+		return "", nil
+	}
+	viewer, err := resolveUserRef(ctx, refs[0])
+	if err != nil {
+		return "", grpcError(err)
+	}
+	return viewer.ID, nil
+}
+
+// grpcHidden lists the forums the viewer of a call may not read.
+func grpcHidden(ctx context.Context) ([]string, error) {
+	viewerID, err := grpcViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := hiddenForums(ctx, viewerID)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return hidden, nil
+}
+
+func (s *RedditServer) ListPosts(ctx context.Context, req *wire.RetrieveAllPosts) (*wire.PostList, error) {
+	hidden, err := grpcHidden(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reply[*wire.PostList](ask(ctx, PostActor, &proto_actor.RetrieveAllPosts{HiddenForums: hidden}))
+}
+
+func (s *RedditServer) SubmitPost(ctx context.Context, req *wire.AddPost) (*wire.Post, error) {
+	if err := validate(&submitPostRequest{ForumID: req.ForumId, AuthorID: req.AuthorId, Text: req.Text}); err != nil {
+		return nil, err
+	}
+	author, err := resolveUserRef(ctx, req.AuthorId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	forum, err := resolveForumRef(ctx, req.ForumId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := limitWrite(ctx, author, ratelimit.Post, ratelimit.Key{Action: ratelimit.Forum, Subject: forum.ID}); err != nil {
+		return nil, grpcError(err)
+	}
+
+	post, err := submitPost(ctx, author, forum, req.Text)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return wire.FromPost(post), nil
+}
+
+func (s *RedditServer) GetPost(ctx context.Context, req *wire.RetrievePost) (*wire.Post, error) {
+	viewerID, err := grpcViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reply[*wire.Post](viewPost(ctx, req.ContentId, viewerID))
+}
+
+func (s *RedditServer) DeletePost(ctx context.Context, req *wire.RemovePost) (*wire.Removed, error) {
+	return reply[*wire.Removed](ask(ctx, PostActor, &proto_actor.RemovePost{ContentID: req.ContentId}))
+}
+
+func (s *RedditServer) VotePost(ctx context.Context, req *wire.VotePost) (*wire.Post, error) {
+	post, err := castPostVote(ctx, nil, req.ContentId, req.Upvote)
+	return reply[*wire.Post](post, err)
+}
+
+func (s *RedditServer) AddComment(ctx context.Context, req *wire.AddComment) (*wire.Comment, error) {
+	if err := validate(&addCommentRequest{PostID: req.PostId, ParentID: req.ParentId, AuthorID: req.AuthorId, Content: req.Content}); err != nil {
+		return nil, err
+	}
+	author, err := resolveUserRef(ctx, req.AuthorId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := limitWrite(ctx, author, ratelimit.Comment); err != nil {
+		return nil, grpcError(err)
+	}
+
+	comment, err := submitComment(ctx, author, req.PostId, req.ParentId, req.Content)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return wire.FromComment(comment), nil
+}
+
+func (s *RedditServer) GetComment(ctx context.Context, req *wire.FetchComment) (*wire.Comment, error) {
+	viewerID, err := grpcViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reply[*wire.Comment](viewComment(ctx, req.CommentId, viewerID))
+}
+
+func (s *RedditServer) DeleteComment(ctx context.Context, req *wire.RemoveComment) (*wire.Removed, error) {
+	return reply[*wire.Removed](ask(ctx, CommentActor, &proto_actor.RemoveComment{CommentID: req.CommentId}))
+}
+
+func (s *RedditServer) VoteComment(ctx context.Context, req *wire.VoteComment) (*wire.Comment, error) {
+	comment, err := castCommentVote(ctx, nil, req.CommentId, req.Upvote)
+	return reply[*wire.Comment](comment, err)
+}
+
+func (s *RedditServer) ListMessages(ctx context.Context, req *wire.FetchMessages) (*wire.MessageList, error) {
+	user, err := resolveUserRef(ctx, req.UserId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return reply[*wire.MessageList](ask(ctx, MessageActor, &proto_actor.FetchMessages{UserID: user.ID}))
+}
+
+func (s *RedditServer) SendMessage(ctx context.Context, req *wire.SendMessage) (*wire.Message, error) {
+	if err := validate(&sendMessageRequest{FromUserID: req.FromUserId, ToUserID: req.ToUserId, Body: req.Body}); err != nil {
+		return nil, err
+	}
+	from, err := resolveUserRef(ctx, req.FromUserId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	to, err := resolveUserRef(ctx, req.ToUserId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := limitWrite(ctx, from, ratelimit.Message); err != nil {
+		return nil, grpcError(err)
+	}
+
+	message, err := sendMessage(ctx, from, to.ID, req.Body)
+	return reply[*wire.Message](message, err)
+}
+
+func (s *RedditServer) DeleteMessage(ctx context.Context, req *wire.RemoveMessage) (*wire.Removed, error) {
+	return reply[*wire.Removed](ask(ctx, MessageActor, &proto_actor.RemoveMessage{MessageID: req.MessageId}))
+}
+
+func (s *RedditServer) AddForum(ctx context.Context, req *wire.AddForum) (*wire.Subreddit, error) {
+	if err := validate(&addForumRequest{Title: req.Title, Name: req.Name, CreatorID: req.CreatorId}); err != nil {
+		return nil, err
+	}
+	creator, err := resolveUserRef(ctx, req.CreatorId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return reply[*wire.Subreddit](ask(ctx, SubredditActor, &proto_actor.AddForum{Title: req.Title, Name: req.Name, CreatorID: creator.ID}))
+}
+
+func (s *RedditServer) GetForum(ctx context.Context, req *wire.RetrieveForum) (*wire.Subreddit, error) {
+	forum, err := resolveForumRef(ctx, req.ForumId)
+	return reply[*wire.Subreddit](forum, err)
+}
+
+func (s *RedditServer) GetForumByName(ctx context.Context, req *wire.RetrieveForumByName) (*wire.Subreddit, error) {
+	return reply[*wire.Subreddit](ask(ctx, SubredditActor, &proto_actor.RetrieveForumByName{Name: req.Name}))
+}
+
+func (s *RedditServer) RenameForum(ctx context.Context, req *wire.RenameForum) (*wire.Subreddit, error) {
+	if err := validate(&renameForumRequest{Title: req.Title, ModeratorID: req.ModeratorId}); err != nil {
+		return nil, err
+	}
+	forum, err := resolveForumRef(ctx, req.ForumId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	moderator, err := resolveUserRef(ctx, req.ModeratorId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return reply[*wire.Subreddit](ask(ctx, SubredditActor, &proto_actor.RenameForum{ForumID: forum.ID, Title: strings.TrimSpace(req.Title), ModeratorID: moderator.ID}))
+}
+
+func (s *RedditServer) DeleteForum(ctx context.Context, req *wire.RemoveForum) (*wire.Removed, error) {
+	forum, err := resolveForumRef(ctx, req.ForumId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return reply[*wire.Removed](ask(ctx, SubredditActor, &proto_actor.RemoveForum{ForumID: forum.ID}))
+}
+
+func (s *RedditServer) RegisterUser(ctx context.Context, req *wire.RegisterUser) (*wire.Account, error) {
+	return reply[*wire.Account](ask(ctx, UserActor, &proto_actor.RegisterUser{DisplayName: req.DisplayName}))
+}
+
+func (s *RedditServer) GetUser(ctx context.Context, req *wire.FetchUser) (*wire.Account, error) {
+	profile, err := resolveUserRef(ctx, req.ProfileId)
+	return reply[*wire.Account](profile, err)
+}
+
+func (s *RedditServer) GetUserByName(ctx context.Context, req *wire.FetchUserByName) (*wire.Account, error) {
+	return reply[*wire.Account](ask(ctx, UserActor, &proto_actor.FetchUserByName{Username: req.Username}))
+}
+
+func (s *RedditServer) UpdateProfile(ctx context.Context, req *wire.UpdateProfile) (*wire.Account, error) {
+	if err := validate(&updateProfileRequest{Bio: req.Bio, AvatarURL: req.AvatarUrl}); err != nil {
+		return nil, err
+	}
+	profile, err := resolveUserRef(ctx, req.ProfileId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return reply[*wire.Account](ask(ctx, UserActor, &proto_actor.UpdateProfile{
+		ProfileID: profile.ID,
+		Bio:       req.Bio,
+		AvatarURL: req.AvatarUrl,
+	}))
+}
+
+func (s *RedditServer) DeleteUser(ctx context.Context, req *wire.RemoveUser) (*wire.Removed, error) {
+	profile, err := resolveUserRef(ctx, req.ProfileId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return reply[*wire.Removed](ask(ctx, UserActor, &proto_actor.RemoveUser{ProfileID: profile.ID}))
+}
+
+// grpcPage is the page of a listing request. As with the JSON API, the sort
+// order defaults to new.
+func grpcPage(page *wire.Page) (proto_actor.Page, error) {
+	decoded := proto_actor.DecodePage(page)
+	if decoded.Sort == "" {
+		decoded.Sort = proto_actor.SortNew
+	}
+	if err := checkPage(decoded); err != nil {
+		return decoded, invalid(err.Error())
+	}
+	return decoded, nil
+}
+
+func (s *RedditServer) ListUserPosts(ctx context.Context, req *wire.RetrieveAuthorPosts) (*wire.PostListing, error) {
+	page, err := grpcPage(req.Page)
+	if err != nil {
+		return nil, err
+	}
+	author, err := resolveUserRef(ctx, req.AuthorId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	hidden, err := grpcHidden(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply[*wire.PostListing](ask(ctx, PostActor, &proto_actor.RetrieveAuthorPosts{AuthorID: author.ID, Page: page, HiddenForums: hidden}))
+}
+
+func (s *RedditServer) ListUserComments(ctx context.Context, req *wire.FetchAuthorComments) (*wire.CommentListing, error) {
+	page, err := grpcPage(req.Page)
+	if err != nil {
+		return nil, err
+	}
+	author, err := resolveUserRef(ctx, req.AuthorId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	hidden, err := grpcHidden(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply[*wire.CommentListing](ask(ctx, CommentActor, &proto_actor.FetchAuthorComments{AuthorID: author.ID, Page: page, HiddenForums: hidden}))
+}
+
+func (s *RedditServer) ListNotifications(ctx context.Context, req *wire.FetchNotifications) (*wire.NotificationList, error) {
+	user, err := resolveUserRef(ctx, req.UserId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return reply[*wire.NotificationList](ask(ctx, NotificationActor, &proto_actor.FetchNotifications{UserID: user.ID}))
+}
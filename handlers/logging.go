@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"reddit-clone/core/logging"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// logRequests gives each HTTP request an ID, taken from its X-Request-ID
+// header when that is a usable ID, echoes it in the response and logs the
+// request once it is served. Handlers pass c to ask, so the actor messages
+// of the request carry the ID too.
+func logRequests(c *gin.Context) {
+	id := c.GetHeader(logging.RequestIDHeader)
+	if !logging.ValidRequestID(id) {
+		id = logging.NewRequestID()
+	}
+	c.Header(logging.RequestIDHeader, id)
+	c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	level := slog.LevelInfo
+	if c.Writer.Status() >= 500 {
+		level = slog.LevelError
+	}
+	slog.Log(c, level, "request",
+		"method", c.Request.Method,
+		"route", route,
+		"status", c.Writer.Status(),
+		"duration", time.Since(start))
+}
+
+// logCalls does for gRPC calls what logRequests does for HTTP requests,
+// reading and returning the ID in the call's metadata.
+func logCalls(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	key := strings.ToLower(logging.RequestIDHeader)
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := metadataCarrier(md).Get(key)
+	if !logging.ValidRequestID(id) {
+		id = logging.NewRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(key, id))
+	ctx = logging.WithRequestID(ctx, id)
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unavailable {
+		level = slog.LevelError
+	}
+	slog.Log(ctx, level, "call",
+		"method", info.FullMethod,
+		"code", code.String(),
+		"duration", time.Since(start))
+	return resp, err
+}
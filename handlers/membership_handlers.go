@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"net/http"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetForumTypeHandler makes a forum public, restricted or private. Only its
+// moderators may, as for approving users and deciding join requests.
+func SetForumTypeHandler(c *gin.Context) {
+	var request forumTypeRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	forumID, ok := bindForumRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	moderatorID, ok := bindUserRef(c, request.ModeratorID, "moderator_id")
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.SetForumType{ForumID: forumID, Type: request.Type, ModeratorID: moderatorID}, templates.NewSubredditResponse)
+}
+
+// JoinForumHandler makes user_id a member of a forum. A private forum only
+// takes its approved users this way; others ask to join it.
+func JoinForumHandler(c *gin.Context) {
+	var request forumUserRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	forumID, ok := bindForumRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	userID, ok := bindUserRef(c, request.UserID, "user_id")
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.JoinForum{ForumID: forumID, UserID: userID}, templates.NewSubredditResponse)
+}
+
+func LeaveForumHandler(c *gin.Context) {
+	forumID, ok := bindForumRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	userID, ok := bindUserRef(c, c.Param("user"), "user")
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.LeaveForum{ForumID: forumID, UserID: userID}, templates.NewSubredditResponse)
+}
+
+// FetchApprovedUsersHandler lists the approved users of a forum by username
+// to one of its moderators.
+func FetchApprovedUsersHandler(c *gin.Context) {
+	forum, ok := bindModeratedForum(c)
+	if !ok {
+		return
+	}
+
+	userIDs := make([]string, 0, len(forum.ApprovedUsers))
+	for userID := range forum.ApprovedUsers {
+		userIDs = append(userIDs, userID)
+	}
+	accounts, err := ask[[]*schemas.Account](c, UserActor, &proto_actor.FetchUsers{ProfileIDs: userIDs})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Username < accounts[j].Username })
+
+	c.JSON(http.StatusOK, templates.NewAccountListResponse(accounts))
+}
+
+func ApproveUserHandler(c *gin.Context) {
+	forumID, userID, moderatorID, ok := bindModeration(c)
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.ApproveUser{ForumID: forumID, UserID: userID, ModeratorID: moderatorID}, templates.NewSubredditResponse)
+}
+
+func UnapproveUserHandler(c *gin.Context) {
+	forumID, userID, moderatorID, ok := bindModeration(c)
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.UnapproveUser{ForumID: forumID, UserID: userID, ModeratorID: moderatorID}, templates.NewSubredditResponse)
+}
+
+// AddModeratorHandler lets a moderator of a forum appoint another.
+func AddModeratorHandler(c *gin.Context) {
+	forumID, userID, moderatorID, ok := bindModeration(c)
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.AddModerator{ForumID: forumID, UserID: userID, ModeratorID: moderatorID}, templates.NewSubredditResponse)
+}
+
+// FetchJoinRequestsHandler lists the users waiting to join a private forum,
+// oldest request first, to one of its moderators.
+func FetchJoinRequestsHandler(c *gin.Context) {
+	forum, ok := bindModeratedForum(c)
+	if !ok {
+		return
+	}
+
+	accounts, err := ask[[]*schemas.Account](c, UserActor, &proto_actor.FetchUsers{ProfileIDs: forum.JoinRequests})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates.NewAccountListResponse(accounts))
+}
+
+// RequestJoinHandler asks the moderators of a private forum to let user_id
+// join it.
+func RequestJoinHandler(c *gin.Context) {
+	var request forumUserRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	forumID, ok := bindForumRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	userID, ok := bindUserRef(c, request.UserID, "user_id")
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.RequestJoin{ForumID: forumID, UserID: userID}, templates.NewSubredditResponse)
+}
+
+// ApproveJoinRequestHandler makes the requesting user a member.
+func ApproveJoinRequestHandler(c *gin.Context) {
+	resolveJoinRequest(c, true)
+}
+
+// DenyJoinRequestHandler drops the request without letting the user in.
+func DenyJoinRequestHandler(c *gin.Context) {
+	resolveJoinRequest(c, false)
+}
+
+func resolveJoinRequest(c *gin.Context, accept bool) {
+	forumID, userID, moderatorID, ok := bindModeration(c)
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.ResolveJoinRequest{ForumID: forumID, UserID: userID, Accept: accept, ModeratorID: moderatorID}, templates.NewSubredditResponse)
+}
+
+// bindModeration resolves the forum and user of the path and the moderator_id
+// of the body of a moderator's request about that user. The forum manager
+// checks that the moderator moderates the forum.
+func bindModeration(c *gin.Context) (forumID, userID, moderatorID string, ok bool) {
+	var request moderatorRequest
+	if !bindJSON(c, &request) {
+		return "", "", "", false
+	}
+
+	if forumID, ok = bindForumRef(c, c.Param("id"), "id"); !ok {
+		return "", "", "", false
+	}
+	if userID, ok = bindUserRef(c, c.Param("user"), "user"); !ok {
+		return "", "", "", false
+	}
+	if moderatorID, ok = bindUserRef(c, request.ModeratorID, "moderator_id"); !ok {
+		return "", "", "", false
+	}
+	return forumID, userID, moderatorID, true
+}
+
+// bindModeratedForum resolves the forum of the path for the moderator_id of
+// the query, refusing anyone who does not moderate it.
+func bindModeratedForum(c *gin.Context) (*schemas.Subreddit, bool) {
+	var query moderatorQuery
+	if !bindQuery(c, &query) {
+		return nil, false
+	}
+
+	forum, ok := bindForum(c, c.Param("id"), "id")
+	if !ok {
+		return nil, false
+	}
+	moderatorID, ok := bindUserRef(c, query.ModeratorID, "moderator_id")
+	if !ok {
+		return nil, false
+	}
+	if !forum.IsModerator(moderatorID) {
+		writeError(c, proto_actor.ErrNotModerator)
+		return nil, false
+	}
+	return forum, true
+}
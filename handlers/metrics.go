@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"reddit-clone/core/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics, when set before RegisterRoutes, times every HTTP request and is
+// served at /metrics.
+var Metrics *metrics.Metrics
+
+// observeRequests records the duration and status of each request under its
+// route pattern. Requests that match no route share the "unmatched" route.
+func observeRequests(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FetchModQueueHandler lists the queued content of the forum_id forum for
+// one of its moderators, or the held private messages for a message
+// moderator when forum_id is not given.
+func FetchModQueueHandler(c *gin.Context) {
+	var query modQueueQuery
+	if !bindQuery(c, &query) {
+		return
+	}
+
+	var forumID string
+	if query.ForumID != "" {
+		var ok bool
+		if forumID, ok = bindForumRef(c, query.ForumID, "forum_id"); !ok {
+			return
+		}
+	}
+	moderatorID, ok := bindUserRef(c, query.ModeratorID, "moderator_id")
+	if !ok {
+		return
+	}
+
+	serve(c, ModerationActor, &proto_actor.FetchModQueue{ForumID: forumID, ModeratorID: moderatorID}, templates.NewQueueItemListResponse)
+}
+
+// ApproveQueueItemHandler releases held content: a post or comment into the
+// listings, with notifications to the users it mentions, and a message to
+// its receiver. Approving reported content only dismisses the report.
+func ApproveQueueItemHandler(c *gin.Context) {
+	resolveQueueItem(c, release)
+}
+
+// RemoveQueueItemHandler deletes held content.
+func RemoveQueueItemHandler(c *gin.Context) {
+	resolveQueueItem(c, discard)
+}
+
+// resolveQueueItem takes the item named in the path off the modqueue, if
+// the moderator_id of the body may review it, and applies action to its
+// content. An item whose content was deleted in the meantime is dropped with
+// a not_found error; when action fails otherwise, the item is put back to be
+// resolved again.
+func resolveQueueItem(c *gin.Context, action func(context.Context, *schemas.QueueItem) error) {
+	var request moderatorRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	moderatorID, ok := bindUserRef(c, request.ModeratorID, "moderator_id")
+	if !ok {
+		return
+	}
+
+	item, err := ask[*schemas.QueueItem](c, ModerationActor, &proto_actor.ResolveQueueItem{ItemID: c.Param("id"), ModeratorID: moderatorID})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if err := action(c, item); err != nil {
+		if proto_actor.CodeOf(err) != proto_actor.NotFound {
+			if _, restoreErr := ask(c, ModerationActor, &proto_actor.RestoreQueueItem{Item: item}); restoreErr != nil {
+				slog.ErrorContext(c, "restoring modqueue item failed", "item_id", item.ID, "error", restoreErr)
+			}
+		}
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates.NewQueueItemResponse(item))
+}
+
+func release(ctx context.Context, item *schemas.QueueItem) error {
+	if !item.Held {
+		return nil
+	}
+	switch item.Kind {
+	case schemas.KindPost:
+		post, err := ask[*schemas.Post](ctx, PostActor, &proto_actor.ApprovePost{ContentID: item.ContentID})
+		if err != nil {
+			return err
+		}
+		notifyMentions(ctx, post.Mentions, post.ID, post.AuthorID)
+
+	case schemas.KindComment:
+		comment, err := ask[*schemas.Comment](ctx, CommentActor, &proto_actor.ApproveComment{CommentID: item.ContentID})
+		if err != nil {
+			return err
+		}
+		notifyMentions(ctx, comment.Mentions, comment.ID, comment.AuthorID)
+
+	case schemas.KindMessage:
+		_, err := ask[*schemas.Message](ctx, MessageActor, &proto_actor.DeliverMessage{MessageID: item.ContentID})
+		return err
+	}
+	return nil
+}
+
+func discard(ctx context.Context, item *schemas.QueueItem) error {
+	var err error
+	switch item.Kind {
+	case schemas.KindPost:
+		_, err = ask[bool](ctx, PostActor, &proto_actor.RemovePost{ContentID: item.ContentID})
+	case schemas.KindComment:
+		_, err = ask[bool](ctx, CommentActor, &proto_actor.RemoveComment{CommentID: item.ContentID})
+	case schemas.KindMessage:
+		_, err = ask[bool](ctx, MessageActor, &proto_actor.RemoveMessage{MessageID: item.ContentID})
+	}
+	return err
+}
+
+// FetchForumFiltersHandler returns the banned words and blocked domains a
+// forum adds to the site-wide lists.
+func FetchForumFiltersHandler(c *gin.Context) {
+	forum, err := resolveForumRef(c, c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates.NewForumFiltersResponse(forum))
+}
+
+// SetForumFiltersHandler replaces a forum's banned words and blocked
+// domains. Only its moderators may. Entries are trimmed and lower-cased, and
+// repeats dropped.
+func SetForumFiltersHandler(c *gin.Context) {
+	var request forumFiltersRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	forumID, ok := bindForumRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	moderatorID, ok := bindUserRef(c, request.ModeratorID, "moderator_id")
+	if !ok {
+		return
+	}
+
+	serve(c, SubredditActor, &proto_actor.SetForumFilters{
+		ForumID:        forumID,
+		BannedWords:    filterList(request.BannedWords),
+		BlockedDomains: filterList(request.BlockedDomains),
+		ModeratorID:    moderatorID,
+	}, templates.NewForumFiltersResponse)
+}
+
+func filterList(entries []string) []string {
+	list := []string{}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if !seen[entry] {
+			seen[entry] = true
+			list = append(list, entry)
+		}
+	}
+	return list
+}
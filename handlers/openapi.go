@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPISpec is the OpenAPI 3 description of the JSON API. The request
+// schemas mirror the binding tags in requests.go.
+//
+//go:embed openapi.yaml
+var OpenAPISpec []byte
+
+func OpenAPIHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml", OpenAPISpec)
+}
@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/ratelimit"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The page handlers serve the browser front-end. They talk to the same
+// actors as the JSON API and identify the visitor by the user_id cookie set
+// on login.
+
+const viewerCookie = "user_id"
+
+func renderPage(c *gin.Context, status int, name string, data any) {
+	var buf bytes.Buffer
+	if err := templates.RenderPage(&buf, name, data); err != nil {
+		slog.ErrorContext(c, "rendering page failed", "page", name, "error", err)
+		c.String(http.StatusInternalServerError, "Failed to render page")
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func renderError(c *gin.Context, status int, viewer *schemas.Account, message string) {
+	renderPage(c, status, "error", &templates.ErrorPage{
+		Page:    templates.Page{Title: http.StatusText(status), Viewer: viewer},
+		Message: message,
+	})
+}
+
+func currentViewer(c *gin.Context) *schemas.Account {
+	userID, err := c.Cookie(viewerCookie)
+	if err != nil || userID == "" {
+		return nil
+	}
+	return fetchAccount(c, userID)
+}
+
+// viewerIDOf returns the ID of viewer, or an empty ID for a visitor who is
+// not signed in.
+func viewerIDOf(viewer *schemas.Account) string {
+	if viewer == nil {
+		return ""
+	}
+	return viewer.ID
+}
+
+// requireViewer returns the signed-in account or redirects to the login page.
+func requireViewer(c *gin.Context) *schemas.Account {
+	viewer := currentViewer(c)
+	if viewer == nil {
+		c.Redirect(http.StatusSeeOther, "/login")
+	}
+	return viewer
+}
+
+func fetchAccount(ctx context.Context, profileID string) *schemas.Account {
+	profile, _ := ask[*schemas.Account](ctx, UserActor, &proto_actor.FetchUser{ProfileID: profileID})
+	return profile
+}
+
+func fetchAccountByName(ctx context.Context, username string) *schemas.Account {
+	profile, _ := ask[*schemas.Account](ctx, UserActor, &proto_actor.FetchUserByName{Username: username})
+	return profile
+}
+
+func fetchForum(ctx context.Context, forumID string) *schemas.Subreddit {
+	forum, _ := ask[*schemas.Subreddit](ctx, SubredditActor, &proto_actor.RetrieveForum{ForumID: forumID})
+	return forum
+}
+
+func fetchPosts(ctx context.Context, request proto_actor.Request[[]*schemas.Post]) []*schemas.Post {
+	posts, _ := ask(ctx, PostActor, request)
+	return posts
+}
+
+// displayNames resolves user and forum IDs to usernames and forum names for
+// rendering. IDs that cannot be resolved are left out.
+func displayNames(ctx context.Context, userIDs, forumIDs []string) map[string]string {
+	names := make(map[string]string)
+	for _, id := range userIDs {
+		if _, done := names[id]; done || id == "" {
+			continue
+		}
+		if profile := fetchAccount(ctx, id); profile != nil {
+			names[id] = profile.Username
+		}
+	}
+	for _, id := range forumIDs {
+		if _, done := names[id]; done || id == "" {
+			continue
+		}
+		if forum := fetchForum(ctx, id); forum != nil {
+			names[id] = forum.Name
+		}
+	}
+	return names
+}
+
+func postNames(ctx context.Context, posts []*schemas.Post) map[string]string {
+	var userIDs, forumIDs []string
+	for _, post := range posts {
+		userIDs = append(userIDs, post.AuthorID)
+		forumIDs = append(forumIDs, post.SubredditID)
+	}
+	return displayNames(ctx, userIDs, forumIDs)
+}
+
+func collectCommentAuthors(comments []*schemas.Comment, ids []string) []string {
+	for _, comment := range comments {
+		ids = append(ids, comment.AuthorID)
+		ids = collectCommentAuthors(comment.Replies, ids)
+	}
+	return ids
+}
+
+func FrontPageHandler(c *gin.Context) {
+	viewer := currentViewer(c)
+
+	forums, _ := ask[[]*schemas.Subreddit](c, SubredditActor, &proto_actor.RetrieveAllForums{})
+	sort.Slice(forums, func(i, j int) bool { return strings.ToLower(forums[i].Name) < strings.ToLower(forums[j].Name) })
+
+	hidden, _ := hiddenForums(c, viewerIDOf(viewer))
+	posts := fetchPosts(c, &proto_actor.RetrieveAllPosts{HiddenForums: hidden})
+	renderPage(c, http.StatusOK, "front", &templates.FrontPage{
+		Page:   templates.Page{Title: "Front page", Viewer: viewer},
+		Forums: forums,
+		Posts:  templates.NewPostViews(posts, postNames(c, posts)),
+	})
+}
+
+func ForumPageHandler(c *gin.Context) {
+	viewer := currentViewer(c)
+
+	forum, err := resolveForumRef(c, c.Param("id"))
+	if err != nil {
+		renderError(c, statusOf(err), viewer, "That forum does not exist.")
+		return
+	}
+	if err := checkView(forum, viewerIDOf(viewer)); err != nil {
+		renderError(c, statusOf(err), viewer, "r/"+forum.Name+" is private. Only its members can see it.")
+		return
+	}
+
+	posts := fetchPosts(c, &proto_actor.RetrieveForumPosts{ForumID: forum.ID})
+	names := postNames(c, posts)
+	if forum.CreatedBy != "" {
+		if _, known := names[forum.CreatedBy]; !known {
+			if creator := fetchAccount(c, forum.CreatedBy); creator != nil {
+				names[forum.CreatedBy] = creator.Username
+			}
+		}
+	}
+	renderPage(c, http.StatusOK, "forum", &templates.ForumPage{
+		Page:        templates.Page{Title: forum.Title, Viewer: viewer},
+		Forum:       forum,
+		CreatorName: names[forum.CreatedBy],
+		Posts:       templates.NewPostViews(posts, names),
+	})
+}
+
+func PostPageHandler(c *gin.Context) {
+	viewer := currentViewer(c)
+
+	post, err := viewPost(c, c.Param("id"), viewerIDOf(viewer))
+	if err != nil {
+		message := "That post does not exist."
+		if proto_actor.CodeOf(err) == proto_actor.Forbidden {
+			message = "That post is in a private forum. Only its members can see it."
+		}
+		renderError(c, statusOf(err), viewer, message)
+		return
+	}
+
+	comments, _ := ask[[]*schemas.Comment](c, CommentActor, &proto_actor.FetchPostComments{PostID: post.ID})
+
+	names := displayNames(c, collectCommentAuthors(comments, []string{post.AuthorID}), []string{post.SubredditID})
+	renderPage(c, http.StatusOK, "post", &templates.PostPage{
+		Page:     templates.Page{Title: "Post", Viewer: viewer},
+		Post:     templates.NewPostView(post, names),
+		Comments: templates.NewCommentViews(comments, names),
+	})
+}
+
+func ProfilePageHandler(c *gin.Context) {
+	viewer := currentViewer(c)
+
+	account, err := resolveUserRef(c, c.Param("id"))
+	if err != nil {
+		renderError(c, statusOf(err), viewer, "That user does not exist.")
+		return
+	}
+
+	hidden, err := hiddenForums(c, viewerIDOf(viewer))
+	if err != nil {
+		renderError(c, statusOf(err), viewer, "Could not load the profile.")
+		return
+	}
+
+	var posts []*schemas.Post
+	postListing, err := ask[*proto_actor.PostListing](c, PostActor, &proto_actor.RetrieveAuthorPosts{
+		AuthorID:     account.ID,
+		Page:         proto_actor.Page{Sort: proto_actor.SortNew},
+		HiddenForums: hidden,
+	})
+	if err == nil {
+		posts = postListing.Posts
+	}
+
+	var comments []*schemas.Comment
+	commentListing, err := ask[*proto_actor.CommentListing](c, CommentActor, &proto_actor.FetchAuthorComments{
+		AuthorID:     account.ID,
+		Page:         proto_actor.Page{Sort: proto_actor.SortNew},
+		HiddenForums: hidden,
+	})
+	if err == nil {
+		comments = commentListing.Comments
+	}
+
+	renderPage(c, http.StatusOK, "profile", &templates.ProfilePage{
+		Page:     templates.Page{Title: "u/" + account.Username, Viewer: viewer},
+		Account:  account,
+		Posts:    templates.NewPostViews(posts, postNames(c, posts)),
+		Comments: comments,
+	})
+}
+
+func UpdateProfileFormHandler(c *gin.Context) {
+	viewer := requireViewer(c)
+	if viewer == nil {
+		return
+	}
+
+	bio := strings.TrimSpace(c.PostForm("bio"))
+	avatarURL := strings.TrimSpace(c.PostForm("avatar_url"))
+	if utf8.RuneCountInString(bio) > Config.Limits.BioLength || !validAvatarURL(avatarURL) {
+		renderError(c, http.StatusBadRequest, viewer, "The bio is too long or the avatar URL is not an http(s) link.")
+		return
+	}
+
+	_, err := ask[*schemas.Account](c, UserActor, &proto_actor.UpdateProfile{
+		ProfileID: viewer.ID,
+		Bio:       bio,
+		AvatarURL: avatarURL,
+	})
+	if err != nil {
+		renderError(c, statusOf(err), viewer, "Could not update the profile.")
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/users/"+viewer.ID)
+}
+
+func InboxPageHandler(c *gin.Context) {
+	viewer := requireViewer(c)
+	if viewer == nil {
+		return
+	}
+
+	messages, _ := ask[[]schemas.Message](c, MessageActor, &proto_actor.FetchMessages{UserID: viewer.ID})
+
+	var notifications []*schemas.Notification
+	if NotificationActor != nil {
+		notifications, _ = ask[[]*schemas.Notification](c, NotificationActor, &proto_actor.FetchNotifications{UserID: viewer.ID})
+	}
+
+	var userIDs []string
+	for _, m := range messages {
+		userIDs = append(userIDs, m.SenderID, m.ReceiverID)
+	}
+
+	renderPage(c, http.StatusOK, "inbox", &templates.InboxPage{
+		Page:          templates.Page{Title: "Inbox", Viewer: viewer},
+		Messages:      templates.NewMessageViews(messages, displayNames(c, userIDs, nil)),
+		Notifications: notifications,
+	})
+}
+
+func LoginPageHandler(c *gin.Context) {
+	renderPage(c, http.StatusOK, "login", &templates.Page{Title: "Log in", Viewer: currentViewer(c)})
+}
+
+// LoginFormHandler signs the visitor in by username, registering the account
+// on first use. There are no passwords; this is a convenience for local use.
+func LoginFormHandler(c *gin.Context) {
+	username := strings.TrimSpace(c.PostForm("username"))
+	if username == "" {
+		renderError(c, http.StatusBadRequest, nil, "A username is required.")
+		return
+	}
+
+	profile := fetchAccountByName(c, username)
+	if profile == nil {
+		var err error
+		profile, err = ask[*schemas.Account](c, UserActor, &proto_actor.RegisterUser{DisplayName: username})
+		if err != nil {
+			renderError(c, statusOf(err), nil, "Could not register: "+err.Error()+".")
+			return
+		}
+	}
+
+	c.SetCookie(viewerCookie, profile.ID, 0, "/", "", false, true)
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+func LogoutFormHandler(c *gin.Context) {
+	c.SetCookie(viewerCookie, "", -1, "/", "", false, true)
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+func CreateForumFormHandler(c *gin.Context) {
+	viewer := requireViewer(c)
+	if viewer == nil {
+		return
+	}
+
+	title := strings.TrimSpace(c.PostForm("title"))
+	if title == "" {
+		renderError(c, http.StatusBadRequest, viewer, "A forum name is required.")
+		return
+	}
+
+	if err := limit(c, viewer, ratelimit.Key{Action: ratelimit.User, Subject: viewer.ID}); err != nil {
+		renderError(c, statusOf(err), viewer, "Slow down: "+err.Error()+".")
+		return
+	}
+
+	forum, err := ask[*schemas.Subreddit](c, SubredditActor, &proto_actor.AddForum{
+		Title:     title,
+		Name:      strings.TrimSpace(c.PostForm("name")),
+		CreatorID: viewer.ID,
+	})
+	if err != nil {
+		renderError(c, statusOf(err), viewer, "Could not create the forum: "+err.Error()+".")
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/r/"+forum.Name)
+}
+
+func SubmitPostFormHandler(c *gin.Context) {
+	viewer := requireViewer(c)
+	if viewer == nil {
+		return
+	}
+
+	forum, err := resolveForumRef(c, c.Param("id"))
+	if err != nil {
+		renderError(c, statusOf(err), viewer, "That forum does not exist.")
+		return
+	}
+	text := strings.TrimSpace(c.PostForm("text"))
+	if text == "" {
+		renderError(c, http.StatusBadRequest, viewer, "A post cannot be empty.")
+		return
+	}
+
+	if err := limitWrite(c, viewer, ratelimit.Post, ratelimit.Key{Action: ratelimit.Forum, Subject: forum.ID}); err != nil {
+		renderError(c, statusOf(err), viewer, "Slow down: "+err.Error()+".")
+		return
+	}
+
+	post, err := submitPost(c, viewer, forum, text)
+	if err != nil {
+		renderError(c, statusOf(err), viewer, failure(err, "Could not submit the post."))
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/posts/"+post.ID)
+}
+
+func AddCommentFormHandler(c *gin.Context) {
+	viewer := requireViewer(c)
+	if viewer == nil {
+		return
+	}
+
+	postID := c.Param("id")
+	text := strings.TrimSpace(c.PostForm("content"))
+	if text == "" {
+		renderError(c, http.StatusBadRequest, viewer, "A comment cannot be empty.")
+		return
+	}
+
+	if err := limitWrite(c, viewer, ratelimit.Comment); err != nil {
+		renderError(c, statusOf(err), viewer, "Slow down: "+err.Error()+".")
+		return
+	}
+
+	comment, err := submitComment(c, viewer, postID, c.PostForm("parent_id"), text)
+	if err != nil {
+		renderError(c, statusOf(err), viewer, failure(err, "Could not add the comment."))
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/posts/"+postID+"#"+comment.ID)
+}
+
+func VotePostFormHandler(c *gin.Context) {
+	viewer := requireViewer(c)
+	if viewer == nil {
+		return
+	}
+
+	upvote, ok := voteRequest{Direction: c.PostForm("direction")}.upvote()
+	if !ok {
+		renderError(c, http.StatusBadRequest, viewer, "Unknown vote direction.")
+		return
+	}
+	if _, err := castPostVote(c, viewer, c.Param("id"), upvote); err != nil {
+		renderError(c, statusOf(err), viewer, failure(err, "That post does not exist."))
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, backTo(c, "/posts/"+c.Param("id")))
+}
+
+func VoteCommentFormHandler(c *gin.Context) {
+	viewer := requireViewer(c)
+	if viewer == nil {
+		return
+	}
+
+	upvote, ok := voteRequest{Direction: c.PostForm("direction")}.upvote()
+	if !ok {
+		renderError(c, http.StatusBadRequest, viewer, "Unknown vote direction.")
+		return
+	}
+	comment, err := castCommentVote(c, viewer, c.Param("id"), upvote)
+	if err != nil {
+		renderError(c, statusOf(err), viewer, failure(err, "That comment does not exist."))
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/posts/"+comment.PostID+"#"+comment.ID)
+}
+
+func SendMessageFormHandler(c *gin.Context) {
+	viewer := requireViewer(c)
+	if viewer == nil {
+		return
+	}
+
+	body := strings.TrimSpace(c.PostForm("body"))
+	recipient := fetchAccountByName(c, strings.TrimSpace(c.PostForm("to")))
+	if recipient == nil || body == "" {
+		renderError(c, http.StatusBadRequest, viewer, "A known recipient and a message body are required.")
+		return
+	}
+
+	if err := limitWrite(c, viewer, ratelimit.Message); err != nil {
+		renderError(c, statusOf(err), viewer, "Slow down: "+err.Error()+".")
+		return
+	}
+
+	if _, err := sendMessage(c, viewer, recipient.ID, body); err != nil {
+		renderError(c, statusOf(err), viewer, failure(err, "Could not send the message."))
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/inbox")
+}
+
+// failure describes err to the viewer: how long to wait when it is a rate
+// limit, what was wrong when a content filter rejected the content, why it
+// was refused when the viewer may not do it, and message otherwise.
+func failure(err error, message string) string {
+	if _, limited := retryAfter(err); limited {
+		return "Slow down: " + err.Error() + "."
+	}
+	if rejected(err) {
+		return "Not accepted: " + err.Error() + "."
+	}
+	if proto_actor.CodeOf(err) == proto_actor.Forbidden {
+		return "Not allowed: " + err.Error() + "."
+	}
+	return message
+}
+
+// backTo returns the same-site page the form was submitted from, falling back
+// to fallback when the referer is missing or points elsewhere.
+func backTo(c *gin.Context, fallback string) string {
+	referer, err := url.Parse(c.Request.Referer())
+	if err != nil || referer.Host != c.Request.Host || !strings.HasPrefix(referer.Path, "/") {
+		return fallback
+	}
+	return referer.RequestURI()
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/url"
+	"reddit-clone/core/ids"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/templates"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageFromQuery binds the sort, offset, limit and after query parameters
+// used by the listing endpoints.
+func pageFromQuery(c *gin.Context) (proto_actor.Page, error) {
+	var query pageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return proto_actor.Page{}, err
+	}
+
+	page := proto_actor.Page{Sort: query.Sort, Offset: query.Offset, After: query.After}
+	if page.Sort == "" {
+		page.Sort = proto_actor.SortNew
+	}
+	if query.Limit != nil {
+		page.Limit = *query.Limit
+	}
+	return page, checkPage(page)
+}
+
+// checkPage validates a listing page. A zero limit stands for the default.
+// Its errors are *FieldError values naming the offending parameter.
+func checkPage(page proto_actor.Page) error {
+	switch page.Sort {
+	case proto_actor.SortNew, proto_actor.SortOld, proto_actor.SortTop:
+	default:
+		return &FieldError{Field: "sort", Rule: "oneof", Message: "sort must be new, old or top"}
+	}
+	if page.Offset < 0 {
+		return &FieldError{Field: "offset", Rule: "min", Message: "offset must be a non-negative integer"}
+	}
+	if page.Limit < 0 {
+		return &FieldError{Field: "limit", Rule: "min", Message: "limit must be a positive integer"}
+	}
+	if page.After != "" {
+		if page.Sort == proto_actor.SortTop {
+			return &FieldError{Field: "after", Rule: "cursor", Message: "after cannot be used with sort=top"}
+		}
+		if page.Offset != 0 {
+			return &FieldError{Field: "after", Rule: "cursor", Message: "after and offset cannot be combined"}
+		}
+		if _, _, err := ids.Parse(page.After); err != nil {
+			return &FieldError{Field: "after", Rule: "cursor", Message: "after must be an ID from a previous page"}
+		}
+	}
+	return nil
+}
+
+func validAvatarURL(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func UpdateProfileHandler(c *gin.Context) {
+	var request updateProfileRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	profileID, ok := bindUserRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+
+	serve(c, UserActor, &proto_actor.UpdateProfile{
+		ProfileID: profileID,
+		Bio:       request.Bio,
+		AvatarURL: request.AvatarURL,
+	}, templates.NewAccountResponse)
+}
+
+func FetchUserPostsHandler(c *gin.Context) {
+	page, err := pageFromQuery(c)
+	if err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	authorID, ok := bindUserRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	viewerID, ok := bindViewer(c)
+	if !ok {
+		return
+	}
+	hidden, err := hiddenForums(c, viewerID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	serve(c, PostActor, &proto_actor.RetrieveAuthorPosts{
+		AuthorID:     authorID,
+		Page:         page,
+		HiddenForums: hidden,
+	}, func(listing *proto_actor.PostListing) any {
+		return templates.NewPostPageResponse(listing.Posts, listing.Total, listing.Offset, page.EffectiveLimit(), listing.NextCursor)
+	})
+}
+
+func FetchUserCommentsHandler(c *gin.Context) {
+	page, err := pageFromQuery(c)
+	if err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	authorID, ok := bindUserRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
+	viewerID, ok := bindViewer(c)
+	if !ok {
+		return
+	}
+	hidden, err := hiddenForums(c, viewerID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	serve(c, CommentActor, &proto_actor.FetchAuthorComments{
+		AuthorID:     authorID,
+		Page:         page,
+		HiddenForums: hidden,
+	}, func(listing *proto_actor.CommentListing) any {
+		return templates.NewCommentPageResponse(listing.Comments, listing.Total, listing.Offset, page.EffectiveLimit(), listing.NextCursor)
+	})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/ratelimit"
+	"reddit-clone/schemas"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// RateLimiter bounds the rate of requests when it is set. main sets it when
+// Config.RateLimits is enabled. Every request is counted against its client
+// IP, and every post, comment, message and vote against the buckets of its
+// author and action; posts also count against their forum.
+var RateLimiter *ratelimit.Limiter
+
+// limitError is the RateLimited error of a refused request, with how long
+// the client should wait.
+type limitError struct {
+	err        *proto_actor.Error
+	retryAfter time.Duration
+}
+
+func (e *limitError) Error() string { return e.err.Error() }
+func (e *limitError) Unwrap() error { return e.err }
+
+// limit takes a token from each bucket named by keys, relaxed when user is
+// trusted. user is nil when the client is anonymous. When ctx is a gin
+// request, the RateLimit headers describe the decision.
+func limit(ctx context.Context, user *schemas.Account, keys ...ratelimit.Key) error {
+	if RateLimiter == nil {
+		return nil
+	}
+	decision := RateLimiter.Allow(user != nil && RateLimiter.Trusted(user.Karma), keys...)
+	if c, ok := ctx.(*gin.Context); ok {
+		writeRateLimitHeaders(c, decision)
+	}
+	if decision.Allowed {
+		return nil
+	}
+	return &limitError{
+		err:        proto_actor.Errorf(proto_actor.RateLimited, "too many %s; try again in %ss", decision.Key.Action.Noun(), seconds(decision.RetryAfter)),
+		retryAfter: decision.RetryAfter,
+	}
+}
+
+// limitWrite counts a write of action by user against the user's buckets
+// and the extra ones.
+func limitWrite(ctx context.Context, user *schemas.Account, action ratelimit.Action, extra ...ratelimit.Key) error {
+	keys := append([]ratelimit.Key{{Action: ratelimit.User, Subject: user.ID}, {Action: action, Subject: user.ID}}, extra...)
+	return limit(ctx, user, keys...)
+}
+
+// limitVote counts a vote by voter or, for the APIs that do not say who
+// votes, by the client's IP.
+func limitVote(ctx context.Context, voter *schemas.Account) error {
+	if voter == nil {
+		return limit(ctx, nil, ratelimit.Key{Action: ratelimit.Vote, Subject: clientIP(ctx)})
+	}
+	return limitWrite(ctx, voter, ratelimit.Vote)
+}
+
+// writeRateLimitHeaders sets the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers of the IETF draft, and Retry-After when the request
+// was refused. Times are in whole seconds, rounded up.
+func writeRateLimitHeaders(c *gin.Context, decision ratelimit.Decision) {
+	if decision.Limit == 0 {
+		return
+	}
+	c.Header("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	c.Header("RateLimit-Reset", seconds(decision.Reset))
+	if !decision.Allowed {
+		c.Header("Retry-After", seconds(decision.RetryAfter))
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// retryAfter returns how long the client should wait before retrying after
+// err, and whether err was a rate limit.
+func retryAfter(err error) (time.Duration, bool) {
+	var limited *limitError
+	if errors.As(err, &limited) {
+		return limited.retryAfter, true
+	}
+	return 0, false
+}
+
+// clientIP returns the address of the client of a gin request or gRPC call.
+func clientIP(ctx context.Context) string {
+	if c, ok := ctx.(*gin.Context); ok {
+		return c.ClientIP()
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// limitRequests refuses requests from a client IP that has used up its
+// bucket.
+func limitRequests(c *gin.Context) {
+	if err := limit(c, nil, ratelimit.Key{Action: ratelimit.IP, Subject: c.ClientIP()}); err != nil {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, errorBody(err))
+		return
+	}
+	c.Next()
+}
+
+// limitCalls is limitRequests for gRPC calls.
+func limitCalls(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := limit(ctx, nil, ratelimit.Key{Action: ratelimit.IP, Subject: clientIP(ctx)}); err != nil {
+		return nil, grpcError(err)
+	}
+	return handler(ctx, req)
+}
@@ -4,10 +4,9 @@ import "github.com/asynkron/protoactor-go/actor"
 import (
     "net/http"
 	"reddit-clone/core/proto_actors"
-	"reddit-clone/schemas"
+	"reddit-clone/core/ratelimit"
 	"reddit-clone/templates"
-	"time"
-    "log"
+    "log/slog"
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,76 +17,66 @@ var (
     PostActor      *actor.PID
     CommentActor   *actor.PID
     MessageActor   *actor.PID
+    NotificationActor *actor.PID
+    ModerationActor   *actor.PID
+    AutoModeratorActor *actor.PID
 	RootContext  *actor.RootContext
 )
 
 
 func SubmitPostHandler(c *gin.Context) {
 	if RootContext == nil {
-		log.Println("Error: RootContext is missing")
+		slog.ErrorContext(c, "RootContext is missing")
 		c.JSON(500, gin.H{"error": "Server error: RootContext is missing"})
 		return
 	}
 
 	if PostActor == nil {
-		log.Println("Error: PostActor is unavailable")
+		slog.ErrorContext(c, "PostActor is unavailable")
 		c.JSON(500, gin.H{"error": "Server error: PostActor is unavailable"})
 		return
 	}
 
-	var req struct {
-		ForumID  string `json:"forum_id"`
-		AuthorID string `json:"author_id"`
-		Text     string `json:"text"`
+	var req submitPostRequest
+	if !bindJSON(c, &req) {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("Error binding JSON: %v\n", err)
-		c.JSON(400, gin.H{"error": "Invalid input"})
+	author, ok := bindUser(c, req.AuthorID, "author_id")
+	if !ok {
 		return
 	}
-
-	log.Printf("Submitting post: ForumID=%s, AuthorID=%s, Text=%s\n", req.ForumID, req.AuthorID, req.Text)
-
-	result, err := RootContext.RequestFuture(PostActor, &proto_actor.AddPost{
-		ForumID:  req.ForumID,
-		AuthorID: req.AuthorID,
-		Text:     req.Text,
-	}, 5*time.Second).Result()
-
-	if err != nil {
-		log.Printf("Error from PostActor: %v\n", err)
-		c.JSON(500, gin.H{"error": err.Error()})
+	forum, ok := bindForum(c, req.ForumID, "forum_id")
+	if !ok {
+		return
+	}
+	if err := limitWrite(c, author, ratelimit.Post, ratelimit.Key{Action: ratelimit.Forum, Subject: forum.ID}); err != nil {
+		writeError(c, err)
 		return
 	}
 
-	post, ok := result.(*schemas.Post)
-	if !ok {
-		log.Printf("Unexpected result type from PostActor: %v\n", result)
-		c.JSON(500, gin.H{"error": "Failed to process post submission"})
+	post, err := submitPost(c, author, forum, req.Text)
+
+	if err != nil {
+		slog.WarnContext(c, "submitting post failed", "forum_id", forum.ID, "author_id", author.ID, "error", err)
+		writeError(c, err)
 		return
 	}
 
-	log.Printf("Post successfully created: %+v\n", post)
+	slog.InfoContext(c, "post submitted", "post_id", post.ID, "forum_id", post.SubredditID, "author_id", post.AuthorID)
 	c.JSON(200, templates.NewPostResponse(post))
 }
 
  
 func FetchPostHandler(c *gin.Context) {
-	contentID := c.Param("id")
-
-	result, err := RootContext.RequestFuture(PostActor, &proto_actor.RetrievePost{
-		ContentID: contentID,
-	}, 5*time.Second).Result()
-
-	if err != nil {
-		c.JSON(404, gin.H{"error": "Post not found"})
+	viewerID, ok := bindViewer(c)
+	if !ok {
 		return
 	}
 
-	post, ok := result.(*schemas.Post)
-	if !ok {
-		c.JSON(500, gin.H{"error": "Failed to process post retrieval"})
+	post, err := viewPost(c, c.Param("id"), viewerID)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
@@ -98,12 +87,8 @@ func FetchPostHandler(c *gin.Context) {
 func RemovePostHandler(c *gin.Context) {
 	contentID := c.Param("id")
 
-	result, err := RootContext.RequestFuture(PostActor, &proto_actor.RemovePost{
-		ContentID: contentID,
-	}, 5*time.Second).Result()
-
-	if err != nil || result.(bool) == false {
-		c.JSON(404, gin.H{"error": "Post not found"})
+	if _, err := ask[bool](c, PostActor, &proto_actor.RemovePost{ContentID: contentID}); err != nil {
+		writeError(c, err)
 		return
 	}
 
@@ -122,54 +107,40 @@ func FetchAllPostsHandler(c *gin.Context) {
 		return
 	}
 
-	result, err := RootContext.RequestFuture(PostActor, &proto_actor.RetrieveAllPosts{}, 5*time.Second).Result()
-
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Error fetching posts"})
+	viewerID, ok := bindViewer(c)
+	if !ok {
 		return
 	}
-
-	posts, ok := result.([]*schemas.Post)
-	if !ok {
-		c.JSON(500, gin.H{"error": "Failed to process posts"})
+	hidden, err := hiddenForums(c, viewerID)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(200, templates.NewPostListResponse(posts))
+	serve(c, PostActor, &proto_actor.RetrieveAllPosts{HiddenForums: hidden}, templates.NewPostListResponse)
 }
 
 
 
-
-const ActorRequestTimeout = 5 * time.Second
-
-
 func AddCommentHandler(c *gin.Context) {
-	var req struct {
-		ParentID string `json:"parent_id"`
-		AuthorID string `json:"author_id"`
-		Content  string `json:"content"`
+	var req addCommentRequest
+	if !bindJSON(c, &req) {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input"})
+	author, ok := bindUser(c, req.AuthorID, "author_id")
+	if !ok {
 		return
 	}
-
-	result, err := RootContext.RequestFuture(CommentActor, &proto_actor.AddComment{
-		ParentID: req.ParentID,
-		AuthorID: req.AuthorID,
-		Content:  req.Content,
-	}, ActorRequestTimeout).Result()
-
-	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+	if err := limitWrite(c, author, ratelimit.Comment); err != nil {
+		writeError(c, err)
 		return
 	}
 
-	comment, ok := result.(*schemas.Comment)
-	if !ok {
-		c.JSON(500, gin.H{"error": "Failed to process comment"})
+	comment, err := submitComment(c, author, req.PostID, req.ParentID, req.Content)
+
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
@@ -178,20 +149,14 @@ func AddCommentHandler(c *gin.Context) {
 
 
 func FetchCommentHandler(c *gin.Context) {
-	commentID := c.Param("id")
-
-	result, err := RootContext.RequestFuture(CommentActor, &proto_actor.FetchComment{
-		CommentID: commentID,
-	}, ActorRequestTimeout).Result()
-
-	if err != nil {
-		c.JSON(404, gin.H{"error": "Comment not found"})
+	viewerID, ok := bindViewer(c)
+	if !ok {
 		return
 	}
 
-	comment, ok := result.(*schemas.Comment)
-	if !ok {
-		c.JSON(500, gin.H{"error": "Failed to process comment"})
+	comment, err := viewComment(c, c.Param("id"), viewerID)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
@@ -202,12 +167,8 @@ func FetchCommentHandler(c *gin.Context) {
 func RemoveCommentHandler(c *gin.Context) {
 	commentID := c.Param("id")
 
-	result, err := RootContext.RequestFuture(CommentActor, &proto_actor.RemoveComment{
-		CommentID: commentID,
-	}, ActorRequestTimeout).Result()
-
-	if err != nil || !result.(bool) {
-		c.JSON(404, gin.H{"error": "Comment not found"})
+	if _, err := ask[bool](c, CommentActor, &proto_actor.RemoveComment{CommentID: commentID}); err != nil {
+		writeError(c, err)
 		return
 	}
 
@@ -219,46 +180,41 @@ func RemoveCommentHandler(c *gin.Context) {
 
 
 func SendMessageHandler(c *gin.Context) {
-	var request struct {
-		FromUserID string `json:"from_user_id"`
-		ToUserID   string `json:"to_user_id"`
-		Body       string `json:"body"`
+	var request sendMessageRequest
+	if !bindJSON(c, &request) {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+	from, ok := bindUser(c, request.FromUserID, "from_user_id")
+	if !ok {
+		return
+	}
+	toUserID, ok := bindUserRef(c, request.ToUserID, "to_user_id")
+	if !ok {
+		return
+	}
+	if err := limitWrite(c, from, ratelimit.Message); err != nil {
+		writeError(c, err)
 		return
 	}
 
-	result, err := RootContext.RequestFuture(MessageActor, &proto_actor.SendMessage{
-		FromUserID: request.FromUserID,
-		ToUserID:   request.ToUserID,
-		Body:       request.Body,
-	}, 5*time.Second).Result()
-
+	message, err := sendMessage(c, from, toUserID, request.Body)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the message"})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, templates.NewMessageResponse(message))
 }
 
 
 func FetchMessagesHandler(c *gin.Context) {
-	userID := c.Query("user_id")
-
-
-	result, err := RootContext.RequestFuture(MessageActor, &proto_actor.FetchMessages{
-		UserID: userID,
-	}, 5*time.Second).Result()
-
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No messages available"})
+	userID, ok := bindUserRef(c, c.Query("user_id"), "user_id")
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	serve(c, MessageActor, &proto_actor.FetchMessages{UserID: userID}, templates.NewMessageListResponse)
 }
 
 
@@ -266,12 +222,8 @@ func RemoveMessageHandler(c *gin.Context) {
 	messageID := c.Param("id")
 
 
-	result, err := RootContext.RequestFuture(MessageActor, &proto_actor.RemoveMessage{
-		MessageID: messageID,
-	}, 5*time.Second).Result()
-
-	if err != nil || result.(bool) == false {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found for deletion"})
+	if _, err := ask[bool](c, MessageActor, &proto_actor.RemoveMessage{MessageID: messageID}); err != nil {
+		writeError(c, err)
 		return
 	}
 
@@ -284,51 +236,28 @@ func RemoveMessageHandler(c *gin.Context) {
 
 
 func AddForumHandler(c *gin.Context) {
-	var request struct {
-		Title string `json:"title"`
-	}
-
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input"})
-		return
-	}
-
-
-	result, err := RootContext.RequestFuture(SubredditActor, &proto_actor.AddForum{
-		Title: request.Title,
-	}, 5*time.Second).Result()
-
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to add forum"})
+	var request addForumRequest
+	if !bindJSON(c, &request) {
 		return
 	}
 
-	forum, ok := result.(*schemas.Subreddit)
+	creatorID, ok := bindUserRef(c, request.CreatorID, "creator_id")
 	if !ok {
-		c.JSON(500, gin.H{"error": "Invalid response from actor"})
 		return
 	}
 
-	c.JSON(200, templates.NewSubredditResponse(forum))
+	serve(c, SubredditActor, &proto_actor.AddForum{
+		Title:     request.Title,
+		Name:      request.Name,
+		CreatorID: creatorID,
+	}, templates.NewSubredditResponse)
 }
 
 
 func GetForumHandler(c *gin.Context) {
-	forumID := c.Param("id")
-
-	
-	result, err := RootContext.RequestFuture(SubredditActor, &proto_actor.RetrieveForum{
-		ForumID: forumID,
-	}, 5*time.Second).Result()
-
+	forum, err := resolveForumRef(c, c.Param("id"))
 	if err != nil {
-		c.JSON(404, gin.H{"error": "Forum not found"})
-		return
-	}
-
-	forum, ok := result.(*schemas.Subreddit)
-	if !ok {
-		c.JSON(500, gin.H{"error": "Invalid response from actor"})
+		writeError(c, err)
 		return
 	}
 
@@ -337,15 +266,14 @@ func GetForumHandler(c *gin.Context) {
 
 
 func DeleteForumHandler(c *gin.Context) {
-	forumID := c.Param("id")
-
+	forumID, ok := bindForumRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
 
-	result, err := RootContext.RequestFuture(SubredditActor, &proto_actor.RemoveForum{
-		ForumID: forumID,
-	}, 5*time.Second).Result()
 
-	if err != nil || result.(bool) == false {
-		c.JSON(404, gin.H{"error": "Forum not found"})
+	if _, err := ask[bool](c, SubredditActor, &proto_actor.RemoveForum{ForumID: forumID}); err != nil {
+		writeError(c, err)
 		return
 	}
 
@@ -357,51 +285,20 @@ func DeleteForumHandler(c *gin.Context) {
 
 
 func RegisterUserHandler(c *gin.Context) {
-	var request struct {
-		DisplayName string `json:"display_name"`
-	}
-
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input data"})
+	var request registerUserRequest
+	if !bindJSON(c, &request) {
 		return
 	}
 
 	
-	result, err := RootContext.RequestFuture(MessageActor, &proto_actor.RegisterUser{
-		DisplayName: request.DisplayName,
-	}, 5*time.Second).Result()
-
-	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-
-	profile, ok := result.(*schemas.Account)
-	if !ok {
-		c.JSON(500, gin.H{"error": "Unexpected response from actor"})
-		return
-	}
-
-	c.JSON(200, templates.NewAccountResponse(profile))
+	serve(c, UserActor, &proto_actor.RegisterUser{DisplayName: request.DisplayName}, templates.NewAccountResponse)
 }
 
 
 func FetchUserHandler(c *gin.Context) {
-	profileID := c.Param("id")
-
-	
-	result, err := RootContext.RequestFuture(MessageActor, &proto_actor.FetchUser{
-		ProfileID: profileID,
-	}, 5*time.Second).Result()
-
+	profile, err := resolveUserRef(c, c.Param("id"))
 	if err != nil {
-		c.JSON(404, gin.H{"error": "User profile not found"})
-		return
-	}
-
-	profile, ok := result.(*schemas.Account)
-	if !ok {
-		c.JSON(500, gin.H{"error": "Unexpected response from actor"})
+		writeError(c, err)
 		return
 	}
 
@@ -410,15 +307,14 @@ func FetchUserHandler(c *gin.Context) {
 
 
 func RemoveUserHandler(c *gin.Context) {
-	profileID := c.Param("id")
+	profileID, ok := bindUserRef(c, c.Param("id"), "id")
+	if !ok {
+		return
+	}
 
 	
-	result, err := RootContext.RequestFuture(MessageActor, &proto_actor.RemoveUser{
-		ProfileID: profileID,
-	}, 5*time.Second).Result()
-
-	if err != nil || result.(bool) == false {
-		c.JSON(404, gin.H{"error": "User profile not found"})
+	if _, err := ask[bool](c, UserActor, &proto_actor.RemoveUser{ProfileID: profileID}); err != nil {
+		writeError(c, err)
 		return
 	}
 
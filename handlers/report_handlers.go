@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reportFilter names user reports in the modqueue, where the content filters
+// and AutoModerator name themselves.
+const reportFilter = "report"
+
+// ReportPostHandler queues a post for the moderators of its forum, for a
+// reason citing one of the forum's rules. Reporters may only report what
+// they can read.
+func ReportPostHandler(c *gin.Context) {
+	var request reportRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	reporterID, ok := bindUserRef(c, request.ReporterID, "reporter_id")
+	if !ok {
+		return
+	}
+	post, err := viewPost(c, c.Param("id"), reporterID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	report(c, request, schemas.KindPost, post.ID, post.AuthorID, post.SubredditID)
+}
+
+// ReportCommentHandler is ReportPostHandler for a comment, which is reported
+// to the moderators of its post's forum.
+func ReportCommentHandler(c *gin.Context) {
+	var request reportRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	reporterID, ok := bindUserRef(c, request.ReporterID, "reporter_id")
+	if !ok {
+		return
+	}
+	comment, err := viewComment(c, c.Param("id"), reporterID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	post, err := ask[*schemas.Post](c, PostActor, &proto_actor.RetrievePost{ContentID: comment.PostID})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	report(c, request, schemas.KindComment, comment.ID, comment.AuthorID, post.SubredditID)
+}
+
+func report(c *gin.Context, request reportRequest, kind, contentID, authorID, forumID string) {
+	forum, err := ask[*schemas.Subreddit](c, SubredditActor, &proto_actor.RetrieveForum{ForumID: forumID})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	reason, err := reportReason(forum, request.Rule, request.Reason)
+	if err != nil {
+		writeFieldError(c, err, "rule")
+		return
+	}
+
+	serve(c, ModerationActor, &proto_actor.ReportContent{
+		Kind:      kind,
+		ContentID: contentID,
+		ForumID:   forum.ID,
+		AuthorID:  authorID,
+		Filter:    reportFilter,
+		Reason:    reason,
+	}, templates.NewQueueItemResponse)
+}
+
+// reportReason is the modqueue reason of a report citing rule, a number of a
+// rule of forum or 0 for none, with the reporter's own words, if any, after
+// it.
+func reportReason(forum *schemas.Subreddit, number int, details string) (string, error) {
+	details = strings.TrimSpace(details)
+	if number == 0 {
+		return details, nil
+	}
+	rule, ok := forum.Rule(number)
+	if !ok {
+		return "", proto_actor.Errorf(proto_actor.Invalid, "r/%s has no rule %d", forum.Name, number)
+	}
+	reason := fmt.Sprintf("Rule %d: %s", number, rule.Title)
+	if details != "" {
+		reason += " (" + details + ")"
+	}
+	return reason, nil
+}
@@ -0,0 +1,156 @@
+package handlers
+
+// The request bodies and queries of the JSON API. Forum and user fields
+// accept either an ID or a name, which the handlers resolve after binding.
+// The limit rule checks the length of user-written text against the limit
+// of that name in Config.Limits; openapi.yaml documents the defaults.
+
+type submitPostRequest struct {
+	ForumID  string `json:"forum_id" binding:"required"`
+	AuthorID string `json:"author_id" binding:"required"`
+	Text     string `json:"text" binding:"required,notblank,limit=post"`
+}
+
+// addCommentRequest is a top-level comment on PostID or a reply to ParentID.
+type addCommentRequest struct {
+	PostID   string `json:"post_id" binding:"required_without=ParentID"`
+	ParentID string `json:"parent_id"`
+	AuthorID string `json:"author_id" binding:"required"`
+	Content  string `json:"content" binding:"required,notblank,limit=comment"`
+}
+
+type sendMessageRequest struct {
+	FromUserID string `json:"from_user_id" binding:"required"`
+	ToUserID   string `json:"to_user_id" binding:"required"`
+	Body       string `json:"body" binding:"required,notblank,limit=message"`
+}
+
+// addForumRequest leaves Name to the forum manager, which derives it from
+// Title when it is empty and owns the naming rules. CreatorID becomes the
+// forum's first moderator.
+type addForumRequest struct {
+	Title     string `json:"title" binding:"required,notblank,limit=forum_title"`
+	Name      string `json:"name"`
+	CreatorID string `json:"creator_id" binding:"required"`
+}
+
+// renameForumRequest names the moderator renaming the forum.
+type renameForumRequest struct {
+	Title       string `json:"title" binding:"required,notblank,limit=forum_title"`
+	ModeratorID string `json:"moderator_id" binding:"required"`
+}
+
+// forumInfoRequest replaces everything a forum tells its visitors; an
+// omitted field is emptied.
+type forumInfoRequest struct {
+	Description string             `json:"description" binding:"limit=forum_description"`
+	Sidebar     string             `json:"sidebar" binding:"limit=forum_sidebar"`
+	Rules       []forumRuleRequest `json:"rules" binding:"max=15,dive"`
+	BannerURL   string             `json:"banner_url" binding:"omitempty,http_url"`
+	IconURL     string             `json:"icon_url" binding:"omitempty,http_url"`
+	NSFW        bool               `json:"nsfw"`
+	ModeratorID string             `json:"moderator_id" binding:"required"`
+}
+
+type forumRuleRequest struct {
+	Title       string `json:"title" binding:"required,notblank,max=100"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// forumFiltersRequest replaces both lists of a forum; an omitted list is
+// emptied.
+type forumFiltersRequest struct {
+	BannedWords    []string `json:"banned_words" binding:"max=1000,dive,notblank,max=100"`
+	BlockedDomains []string `json:"blocked_domains" binding:"max=1000,dive,notblank,max=253"`
+	ModeratorID    string   `json:"moderator_id" binding:"required"`
+}
+
+// modQueueQuery lists the modqueue of the forum_id forum, or the held
+// private messages when forum_id is not given, for the moderator reviewing
+// them.
+type modQueueQuery struct {
+	ForumID     string `form:"forum_id"`
+	ModeratorID string `form:"moderator_id" binding:"required"`
+}
+
+// moderatorQuery names the moderator listing who asked to join a forum or
+// who is approved in it.
+type moderatorQuery struct {
+	ModeratorID string `form:"moderator_id" binding:"required"`
+}
+
+// autoModeratorRequest replaces a forum's AutoModerator rules, given as
+// YAML; empty rules remove them all.
+type autoModeratorRequest struct {
+	Rules       string `json:"rules" binding:"max=50000"`
+	ModeratorID string `json:"moderator_id" binding:"required"`
+}
+
+// autoModeratorTestRequest is a dry run of AutoModerator rules on a post or
+// comment by author_id. Rules defaults to the forum's own.
+type autoModeratorTestRequest struct {
+	Rules    *string `json:"rules" binding:"omitempty,max=50000"`
+	Kind     string  `json:"kind" binding:"required,oneof=post comment"`
+	Text     string  `json:"text" binding:"required,notblank,limit=post"`
+	AuthorID string  `json:"author_id" binding:"required"`
+}
+
+type forumTypeRequest struct {
+	Type        string `json:"type" binding:"required,oneof=public restricted private"`
+	ModeratorID string `json:"moderator_id" binding:"required"`
+}
+
+// moderatorRequest names the moderator approving users, deciding join
+// requests, appointing other moderators or resolving modqueue items.
+type moderatorRequest struct {
+	ModeratorID string `json:"moderator_id" binding:"required"`
+}
+
+// forumUserRequest names the user joining a forum or asking to join it.
+type forumUserRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// registerUserRequest leaves the username rules to the user manager.
+type registerUserRequest struct {
+	DisplayName string `json:"display_name" binding:"required"`
+}
+
+type updateProfileRequest struct {
+	Bio       string `json:"bio" binding:"limit=bio"`
+	AvatarURL string `json:"avatar_url" binding:"omitempty,http_url"`
+}
+
+// reportRequest reports a post or comment to the moderators of its forum,
+// citing one of the forum's rules by number, a reason of the reporter's own,
+// or both.
+type reportRequest struct {
+	ReporterID string `json:"reporter_id" binding:"required"`
+	Rule       int    `json:"rule" binding:"omitempty,min=1"`
+	Reason     string `json:"reason" binding:"required_without=Rule,omitempty,notblank,max=500"`
+}
+
+type voteRequest struct {
+	Direction string `json:"direction" binding:"required,oneof=up down"`
+}
+
+// upvote reports the direction of the vote and whether it is known. The
+// browser forms, which do not bind through gin, rely on the second result.
+func (r voteRequest) upvote() (bool, bool) {
+	switch r.Direction {
+	case "up":
+		return true, true
+	case "down":
+		return false, true
+	}
+	return false, false
+}
+
+// pageQuery is the query of the listing endpoints. Limit is a pointer so an
+// explicit limit=0 is rejected rather than taken for the default.
+type pageQuery struct {
+	Sort   string `form:"sort" binding:"omitempty,oneof=new old top"`
+	Offset int    `form:"offset" binding:"min=0"`
+	Limit  *int   `form:"limit" binding:"omitempty,min=1"`
+	After  string `form:"after"`
+}
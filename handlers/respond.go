@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reddit-clone/core/logging"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/tracing"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/gin-gonic/gin"
+)
+
+// ask sends request to pid and waits for its typed reply. The request
+// carries the trace and request ID of ctx, which handlers pass as c.
+func ask[T any](ctx context.Context, pid *actor.PID, request proto_actor.Request[T]) (T, error) {
+	return proto_actor.Ask(rootFor(ctx), pid, request, Config.Actors.RequestTimeout)
+}
+
+// rootFor returns a root context whose messages carry the span and request
+// ID of ctx, or RootContext when ctx has neither.
+func rootFor(ctx context.Context) *actor.RootContext {
+	header := map[string]string{}
+	tracing.Inject(ctx, header)
+	logging.Inject(ctx, header)
+	if len(header) == 0 {
+		return RootContext
+	}
+	return actor.NewRootContext(RootContext.ActorSystem(), header, tracing.Stamp, logging.Stamp)
+}
+
+// statusOf maps the code of a manager error to an HTTP status.
+func statusOf(err error) int {
+	switch proto_actor.CodeOf(err) {
+	case proto_actor.NotFound:
+		return http.StatusNotFound
+	case proto_actor.Conflict:
+		return http.StatusConflict
+	case proto_actor.Forbidden:
+		return http.StatusForbidden
+	case proto_actor.Invalid:
+		return http.StatusBadRequest
+	case proto_actor.Unavailable:
+		return http.StatusServiceUnavailable
+	case proto_actor.RateLimited:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError answers a JSON request with err and the status of its code.
+// Internal errors are not described to the client.
+func writeError(c *gin.Context, err error) {
+	c.JSON(statusOf(err), errorBody(err))
+}
+
+// writeFieldError is writeError for an error caused by the request field
+// named field.
+func writeFieldError(c *gin.Context, err error, field string) {
+	body := errorBody(err)
+	body["field"] = field
+	c.JSON(statusOf(err), body)
+}
+
+func errorBody(err error) gin.H {
+	message := err.Error()
+	if statusOf(err) == http.StatusInternalServerError {
+		message = http.StatusText(http.StatusInternalServerError)
+	}
+	return gin.H{"error": message, "code": proto_actor.CodeOf(err)}
+}
+
+// serve answers a JSON request with the reply to request, rendered by view,
+// or with the error the manager replied with.
+func serve[T, V any](c *gin.Context, pid *actor.PID, request proto_actor.Request[T], view func(T) V) {
+	reply, err := ask(c, pid, request)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, view(reply))
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes mounts the JSON API under /api and the browser front-end at
+// the site root, and /metrics when Metrics is set. The GraphQL API is
+// mounted unless Config.Features turns it off. Every request is logged
+// with an ID and traced; the spans are exported once tracing.Setup has been
+// called. Requests are rate limited when RateLimiter is set, by the client
+// IP that Config.Server.TrustedProxies vouch for.
+func RegisterRoutes(router *gin.Engine) {
+	// Handlers pass c as the context of their actor requests, so its values
+	// must include the request's ID and span.
+	router.ContextWithFallback = true
+	// gin trusts every proxy by default, which would let any client choose
+	// its IP with X-Forwarded-For. Config.Validate refuses entries that do
+	// not parse; should one get through, trust no proxy rather than all.
+	if err := router.SetTrustedProxies(Config.Server.TrustedProxies); err != nil {
+		router.SetTrustedProxies(nil)
+	}
+	router.Use(logRequests, traceRequests)
+	if Metrics != nil {
+		router.Use(observeRequests(Metrics))
+		router.GET("/metrics", gin.WrapH(Metrics.Handler()))
+	}
+	// Routes get the middleware added before them, so scrapes of /metrics
+	// are not rate limited.
+	if RateLimiter != nil {
+		router.Use(limitRequests)
+	}
+
+	api := router.Group("/api")
+	{
+		api.GET("/posts", FetchAllPostsHandler)
+		api.POST("/posts", SubmitPostHandler)
+		api.GET("/posts/:id", FetchPostHandler)
+		api.DELETE("/posts/:id", RemovePostHandler)
+		api.POST("/posts/:id/vote", VotePostHandler)
+		api.POST("/posts/:id/report", ReportPostHandler)
+
+		api.POST("/comments", AddCommentHandler)
+		api.GET("/comments/:id", FetchCommentHandler)
+		api.DELETE("/comments/:id", RemoveCommentHandler)
+		api.POST("/comments/:id/vote", VoteCommentHandler)
+		api.POST("/comments/:id/report", ReportCommentHandler)
+
+		api.GET("/messages", FetchMessagesHandler)
+		api.POST("/messages", SendMessageHandler)
+		api.DELETE("/messages/:id", RemoveMessageHandler)
+
+		api.POST("/forums", AddForumHandler)
+		api.GET("/forums/by-name/:name", FetchForumByNameHandler)
+		api.GET("/forums/:id", GetForumHandler)
+		api.PATCH("/forums/:id", RenameForumHandler)
+		api.PUT("/forums/:id/info", UpdateForumHandler)
+		api.DELETE("/forums/:id", DeleteForumHandler)
+		api.GET("/forums/:id/filters", FetchForumFiltersHandler)
+		api.PUT("/forums/:id/filters", SetForumFiltersHandler)
+		api.GET("/forums/:id/automoderator", FetchAutoModeratorHandler)
+		api.PUT("/forums/:id/automoderator", SetAutoModeratorHandler)
+		api.POST("/forums/:id/automoderator/test", TestAutoModeratorHandler)
+		api.PUT("/forums/:id/type", SetForumTypeHandler)
+		api.POST("/forums/:id/members", JoinForumHandler)
+		api.DELETE("/forums/:id/members/:user", LeaveForumHandler)
+		api.GET("/forums/:id/approved-users", FetchApprovedUsersHandler)
+		api.PUT("/forums/:id/approved-users/:user", ApproveUserHandler)
+		api.DELETE("/forums/:id/approved-users/:user", UnapproveUserHandler)
+		api.GET("/forums/:id/join-requests", FetchJoinRequestsHandler)
+		api.POST("/forums/:id/join-requests", RequestJoinHandler)
+		api.POST("/forums/:id/join-requests/:user/approve", ApproveJoinRequestHandler)
+		api.POST("/forums/:id/join-requests/:user/deny", DenyJoinRequestHandler)
+		api.PUT("/forums/:id/moderators/:user", AddModeratorHandler)
+
+		api.POST("/users", RegisterUserHandler)
+		api.GET("/users/by-name/:name", FetchUserByNameHandler)
+		api.GET("/users/:id", FetchUserHandler)
+		api.PATCH("/users/:id", UpdateProfileHandler)
+		api.DELETE("/users/:id", RemoveUserHandler)
+		api.GET("/users/:id/posts", FetchUserPostsHandler)
+		api.GET("/users/:id/comments", FetchUserCommentsHandler)
+
+		api.GET("/notifications", FetchNotificationsHandler)
+
+		api.GET("/modqueue", FetchModQueueHandler)
+		api.POST("/modqueue/:id/approve", ApproveQueueItemHandler)
+		api.POST("/modqueue/:id/remove", RemoveQueueItemHandler)
+
+		if Config.Features.GraphQL {
+			api.GET("/graphql", GraphQLHandler)
+			api.POST("/graphql", GraphQLHandler)
+		}
+
+		api.GET("/openapi.yaml", OpenAPIHandler)
+	}
+
+	router.GET("/", FrontPageHandler)
+	router.GET("/login", LoginPageHandler)
+	router.POST("/login", LoginFormHandler)
+	router.POST("/logout", LogoutFormHandler)
+	router.POST("/forums", CreateForumFormHandler)
+	router.GET("/forums/:id", ForumPageHandler)
+	router.POST("/forums/:id/posts", SubmitPostFormHandler)
+	router.GET("/r/:id", ForumPageHandler)
+	router.POST("/r/:id/posts", SubmitPostFormHandler)
+	router.GET("/posts/:id", PostPageHandler)
+	router.POST("/posts/:id/comments", AddCommentFormHandler)
+	router.POST("/posts/:id/vote", VotePostFormHandler)
+	router.POST("/comments/:id/vote", VoteCommentFormHandler)
+	router.GET("/users/:id", ProfilePageHandler)
+	router.POST("/profile", UpdateProfileFormHandler)
+	router.GET("/inbox", InboxPageHandler)
+	router.POST("/inbox", SendMessageFormHandler)
+}
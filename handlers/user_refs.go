@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resolveUserRef accepts either a generated account ID or a username and
+// returns the matching account. Usernames may not start with "user_", so the
+// prefix tells the two apart.
+func resolveUserRef(ctx context.Context, ref string) (*schemas.Account, error) {
+	if ref == "" {
+		return nil, proto_actor.ErrUserNotFound
+	}
+	if strings.HasPrefix(ref, "user_") {
+		return ask[*schemas.Account](ctx, UserActor, &proto_actor.FetchUser{ProfileID: ref})
+	}
+	return ask[*schemas.Account](ctx, UserActor, &proto_actor.FetchUserByName{Username: ref})
+}
+
+// bindUserRef resolves ref to an account ID for a JSON handler. It writes the
+// error, naming field, and returns false when no such user exists.
+func bindUserRef(c *gin.Context, ref, field string) (string, bool) {
+	profile, ok := bindUser(c, ref, field)
+	if !ok {
+		return "", false
+	}
+	return profile.ID, true
+}
+
+// bindUser is bindUserRef for handlers that need the account itself.
+func bindUser(c *gin.Context, ref, field string) (*schemas.Account, bool) {
+	profile, err := resolveUserRef(c, ref)
+	if err != nil {
+		writeFieldError(c, err, field)
+		return nil, false
+	}
+	return profile, true
+}
+
+func FetchUserByNameHandler(c *gin.Context) {
+	serve(c, UserActor, &proto_actor.FetchUserByName{Username: c.Param("name")}, templates.NewAccountResponse)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reddit-clone/core/proto_actors"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+)
+
+func init() {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	engine.RegisterTagNameFunc(wireName)
+	engine.RegisterValidation("notblank", validators.NotBlank)
+	engine.RegisterValidation("limit", withinLimit)
+}
+
+// withinLimit checks that a string has at most as many characters as the
+// limit of Config.Limits named by the rule's parameter.
+func withinLimit(fl validator.FieldLevel) bool {
+	limit, ok := Config.Limits.Length(fl.Param())
+	if !ok {
+		panic("unknown length limit " + fl.Param())
+	}
+	return utf8.RuneCountInString(fl.Field().String()) <= limit
+}
+
+// wireName names a request field the way clients spell it: by its json tag,
+// or its form tag for query parameters.
+func wireName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// FieldError describes why one request field was rejected. Rule is the
+// binding rule that failed, such as required or max.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// fieldErrors lists the fields of a request rejected by binding. It returns
+// nil when err is not about the request's fields, such as malformed JSON.
+func fieldErrors(err error) []*FieldError {
+	var (
+		invalid  validator.ValidationErrors
+		field    *FieldError
+		mistyped *json.UnmarshalTypeError
+		number   *strconv.NumError
+	)
+	switch {
+	case errors.As(err, &invalid):
+		fields := make([]*FieldError, len(invalid))
+		for i, fe := range invalid {
+			fields[i] = &FieldError{Field: fe.Field(), Rule: ruleName(fe), Message: ruleMessage(fe)}
+		}
+		return fields
+	case errors.As(err, &field):
+		return []*FieldError{field}
+	case errors.As(err, &mistyped):
+		return []*FieldError{{
+			Field:   mistyped.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("%s must be a %s", mistyped.Field, mistyped.Type),
+		}}
+	case errors.As(err, &number):
+		return []*FieldError{{Rule: "type", Message: fmt.Sprintf("%q is not an integer", number.Num)}}
+	}
+	return nil
+}
+
+// ruleName is the rule a client is told failed. A configured length limit is
+// reported as max, like a fixed one.
+func ruleName(fe validator.FieldError) string {
+	if fe.Tag() == "limit" {
+		return "max"
+	}
+	return fe.Tag()
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	unit := ""
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	}
+	switch fe.Tag() {
+	case "required", "notblank":
+		return fe.Field() + " is required"
+	case "required_without":
+		return fmt.Sprintf("%s is required when %s is missing", fe.Field(), snakeCase(fe.Param()))
+	case "max":
+		return fmt.Sprintf("%s must be at most %s%s", fe.Field(), fe.Param(), unit)
+	case "limit":
+		limit, _ := Config.Limits.Length(fe.Param())
+		return fmt.Sprintf("%s must be at most %d characters", fe.Field(), limit)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s%s", fe.Field(), fe.Param(), unit)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fe.Field(), fe.Param())
+	case "http_url":
+		return fe.Field() + " must be an http or https URL"
+	}
+	return fe.Field() + " is invalid"
+}
+
+// snakeCase spells the Go field name referenced by a rule parameter, such as
+// ParentID, the way its json tag does.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(rune(name[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// bindJSON binds the JSON body of a request to req and validates it. It
+// writes a 400 and returns false when the body is rejected.
+func bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBindError(c, err)
+		return false
+	}
+	return true
+}
+
+// bindQuery is bindJSON for the query of a request.
+func bindQuery(c *gin.Context, req any) bool {
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBindError(c, err)
+		return false
+	}
+	return true
+}
+
+// writeBindError answers a request whose body or query was rejected. Each
+// rejected field is listed under fields, and error repeats the first.
+func writeBindError(c *gin.Context, err error) {
+	body := gin.H{"error": "Invalid request body", "code": proto_actor.Invalid}
+	if fields := fieldErrors(err); len(fields) > 0 {
+		body["error"] = fields[0].Message
+		body["fields"] = fields
+	}
+	c.JSON(http.StatusBadRequest, body)
+}
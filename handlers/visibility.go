@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Every front end reads posts and comments on behalf of a viewer: the
+// signed-in user of the pages, the viewer_id query parameter of the JSON and
+// GraphQL APIs, and the viewer-id metadata of gRPC calls. Without one only
+// the content of public and restricted forums is shown.
+
+type viewerKey struct{}
+
+// withViewer returns ctx carrying the ID of the user GraphQL resolvers and
+// gRPC methods read for.
+func withViewer(ctx context.Context, viewerID string) context.Context {
+	return context.WithValue(ctx, viewerKey{}, viewerID)
+}
+
+// viewerOf returns the viewer ID carried by ctx, which is empty for an
+// anonymous visitor.
+func viewerOf(ctx context.Context) string {
+	viewerID, _ := ctx.Value(viewerKey{}).(string)
+	return viewerID
+}
+
+// bindViewer resolves the viewer_id query parameter of a JSON request to an
+// account ID. It writes the error and returns false when no such user
+// exists, and returns an empty ID when the parameter is missing.
+func bindViewer(c *gin.Context) (string, bool) {
+	ref := c.Query("viewer_id")
+	if ref == "" {
+		return "", true
+	}
+	return bindUserRef(c, ref, "viewer_id")
+}
+
+// checkView refuses with ErrForumPrivate when viewerID may not read forum.
+func checkView(forum *schemas.Subreddit, viewerID string) error {
+	if !forum.CanView(viewerID) {
+		return proto_actor.ErrForumPrivate
+	}
+	return nil
+}
+
+// checkPost refuses with ErrForumPrivate or ErrForumRestricted when authorID
+// may not post in forum.
+func checkPost(forum *schemas.Subreddit, authorID string) error {
+	if err := checkView(forum, authorID); err != nil {
+		return err
+	}
+	if !forum.CanPost(authorID) {
+		return proto_actor.ErrForumRestricted
+	}
+	return nil
+}
+
+// hiddenForums lists the private forums viewerID may not read, which the
+// listings leave out.
+func hiddenForums(ctx context.Context, viewerID string) ([]string, error) {
+	forums, err := ask[[]*schemas.Subreddit](ctx, SubredditActor, &proto_actor.RetrieveAllForums{})
+	if err != nil {
+		return nil, err
+	}
+	var hidden []string
+	for _, forum := range forums {
+		if !forum.CanView(viewerID) {
+			hidden = append(hidden, forum.ID)
+		}
+	}
+	return hidden, nil
+}
+
+// viewPost fetches a post for viewerID, refusing with ErrForumPrivate when it
+// is in a private forum viewerID may not read.
+func viewPost(ctx context.Context, postID, viewerID string) (*schemas.Post, error) {
+	post, err := ask[*schemas.Post](ctx, PostActor, &proto_actor.RetrievePost{ContentID: postID})
+	if err != nil {
+		return nil, err
+	}
+	forum, err := ask[*schemas.Subreddit](ctx, SubredditActor, &proto_actor.RetrieveForum{ForumID: post.SubredditID})
+	if err != nil {
+		// The posts of a deleted forum stay readable, as they do in the
+		// listings.
+		if proto_actor.CodeOf(err) == proto_actor.NotFound {
+			return post, nil
+		}
+		return nil, err
+	}
+	if err := checkView(forum, viewerID); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// viewComment is viewPost for a comment, which may be read wherever its post
+// may. The comments of a deleted post stay readable.
+func viewComment(ctx context.Context, commentID, viewerID string) (*schemas.Comment, error) {
+	comment, err := ask[*schemas.Comment](ctx, CommentActor, &proto_actor.FetchComment{CommentID: commentID})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := viewPost(ctx, comment.PostID, viewerID); err != nil && proto_actor.CodeOf(err) != proto_actor.NotFound {
+		return nil, err
+	}
+	return comment, nil
+}
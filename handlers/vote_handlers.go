@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+
+	"github.com/gin-gonic/gin"
+)
+
+// castPostVote records a vote on a post and moves its author's karma by one
+// point in the same direction. voter is nil when the API does not say who
+// votes; the vote is then rate limited by client IP, and refused in private
+// forums.
+func castPostVote(ctx context.Context, voter *schemas.Account, postID string, upvote bool) (*schemas.Post, error) {
+	if err := limitVote(ctx, voter); err != nil {
+		return nil, err
+	}
+	if _, err := viewPost(ctx, postID, viewerIDOf(voter)); err != nil {
+		return nil, err
+	}
+	post, err := ask[*schemas.Post](ctx, PostActor, &proto_actor.VotePost{
+		ContentID: postID,
+		Upvote:    upvote,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	adjustKarma(ctx, post.AuthorID, upvote)
+	return post, nil
+}
+
+func castCommentVote(ctx context.Context, voter *schemas.Account, commentID string, upvote bool) (*schemas.Comment, error) {
+	if err := limitVote(ctx, voter); err != nil {
+		return nil, err
+	}
+	if _, err := viewComment(ctx, commentID, viewerIDOf(voter)); err != nil {
+		return nil, err
+	}
+	comment, err := ask[*schemas.Comment](ctx, CommentActor, &proto_actor.VoteComment{
+		CommentID: commentID,
+		Upvote:    upvote,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	adjustKarma(ctx, comment.AuthorID, upvote)
+	return comment, nil
+}
+
+func adjustKarma(ctx context.Context, profileID string, upvote bool) {
+	if UserActor == nil {
+		return
+	}
+	delta := 1
+	if !upvote {
+		delta = -1
+	}
+	rootFor(ctx).Send(UserActor, &proto_actor.AdjustKarma{ProfileID: profileID, Delta: delta})
+}
+
+func VotePostHandler(c *gin.Context) {
+	var req voteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	upvote, _ := req.upvote()
+
+	post, err := castPostVote(c, nil, c.Param("id"), upvote)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates.NewPostResponse(post))
+}
+
+func VoteCommentHandler(c *gin.Context) {
+	var req voteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	upvote, _ := req.upvote()
+
+	comment, err := castCommentVote(c, nil, c.Param("id"), upvote)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates.NewCommentResponse(comment))
+}
@@ -1,10 +1,23 @@
 package main
 
 import (
-	"log"
-	"reddit-clone/handlers"
+	"context"
+	"flag"
+	"log/slog"
+	"net"
+	"os"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/clusternode"
+	"reddit-clone/core/config"
+	"reddit-clone/core/ids"
+	"reddit-clone/core/logging"
+	"reddit-clone/core/metrics"
 	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/ratelimit"
+	"reddit-clone/core/tracing"
+	"reddit-clone/handlers"
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/gin-gonic/gin"
 )
 
 var (
@@ -16,39 +29,143 @@ var (
 )
 
 func main() {
-	system := actor.NewActorSystem()
+	// Settings come from the file named by -config or CONFIG_FILE, the
+	// environment and the command line, in increasing precedence. Run
+	// cmd/config to print the result.
+	loader := config.NewLoader(flag.CommandLine)
+	flag.Parse()
+	cfg, err := loader.Load(os.LookupEnv)
+	if err != nil {
+		fatal("Invalid configuration", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("Invalid configuration", err)
+	}
+
+	// The level and format of the logs apply to the server, its actors and
+	// Proto.Actor itself.
+	logger, err := logging.New(logging.Config{Level: cfg.Logging.Level, Format: cfg.Logging.Format})
+	if err != nil {
+		fatal("Invalid logging configuration", err)
+	}
+	slog.SetDefault(logger)
+
+	// Every process sharing a data set needs its own node ID so that the
+	// IDs they generate cannot collide.
+	generator, err := ids.NewSnowflake(cfg.Server.NodeID)
+	if err != nil {
+		fatal("Invalid node ID", err)
+	}
+
+	system := actor.NewActorSystem(actor.WithLoggerFactory(func(*actor.ActorSystem) *slog.Logger {
+		return logger.With("lib", "Proto.Actor")
+	}))
+
+	// Every manager keeps its state in one Storage, so that a manager or
+	// entity restarted after a crash recovers it.
+	storage := proto_actor.NewStorage()
 
-	
-	userActor := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor { return 	proto_actor.NewMemberManager() }))
-	if userActor == nil {
-		log.Fatalf("Failed to initialize UserActor")
+	options := []proto_actor.Option{
+		proto_actor.WithStorage(storage),
+		proto_actor.WithIDGenerator(generator),
+		proto_actor.WithRequestTimeout(cfg.Actors.RequestTimeout),
+		proto_actor.WithPassivationTimeout(cfg.Actors.PassivationTimeout),
+		proto_actor.WithRestartPolicy(cfg.Actors.Restart),
+		proto_actor.WithInstrumentation(logging.Instrumentation{Logger: logger}),
+		proto_actor.WithMessageModerators(cfg.Filters.MessageModerators...),
 	}
 
-	subredditActor := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor { return proto_actor.NewForumManager() }))
-	if subredditActor == nil {
-		log.Fatalf("Failed to initialize SubredditActor")
+	// Actor, HTTP and entity metrics are served at /metrics.
+	if cfg.Features.Metrics {
+		registry := metrics.New()
+		registry.ObserveStorage(storage)
+		timeouts := registry.WatchTimeouts(system)
+		defer system.EventStream.Unsubscribe(timeouts)
+		handlers.Metrics = registry
+		options = append(options, proto_actor.WithInstrumentation(registry))
 	}
 
-	postActor := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor { return 	proto_actor.NewPostManager() }))
-	log.Println("PostActor initialized:", postActor != nil)
-	if postActor == nil {
-		log.Fatalf("Failed to initialize PostActor")
+	// The stdout exporter prints a span for every request and for every
+	// actor message it causes.
+	exporter, err := tracing.NewExporter(cfg.Tracing.Exporter, os.Stdout)
+	if err != nil {
+		fatal("Invalid trace exporter", err)
+	}
+	if exporter != nil {
+		shutdown := tracing.Setup(exporter)
+		defer shutdown(context.Background())
+		options = append(options, proto_actor.WithInstrumentation(tracing.Instrumentation{}))
+	}
+
+	// With a cluster port set, posts and comment threads are served by
+	// cluster grains. Their members must share this Storage, so the
+	// configuration only allows this process among the cluster's peers.
+	if cfg.Cluster.Enabled() {
+		c, err := clusternode.Start(system, clusternode.Config{
+			Host:         cfg.Cluster.Host,
+			Port:         cfg.Cluster.Port,
+			ProviderPort: cfg.Cluster.ProviderPort,
+			Peers:        cfg.Cluster.Peers,
+		}, options...)
+		if err != nil {
+			fatal("Failed to join cluster", err)
+		}
+		defer c.Shutdown(true)
+		options = append(options, proto_actor.WithCluster(c))
+	}
+
+	deadLetters := proto_actor.MonitorDeadLetters(system)
+	defer deadLetters.Stop()
+
+	managers, err := proto_actor.SpawnManagers(system.Root, options...)
+	if err != nil {
+		fatal("Failed to initialize managers", err)
 	}
 
-	commentActor := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor { return 	proto_actor.NewCommentService() }))
-	if commentActor == nil {
-		log.Fatalf("Failed to initialize CommentActor")
+	handlers.Config = cfg
+	handlers.RootContext = system.Root
+	handlers.UserActor = managers.Members
+	handlers.SubredditActor = managers.Forums
+	handlers.PostActor = managers.Posts
+	handlers.CommentActor = managers.Comments
+	handlers.MessageActor = managers.Messages
+	handlers.NotificationActor = managers.Notifications
+	handlers.ModerationActor = managers.Moderation
+	handlers.AutoModeratorActor = managers.AutoModerator
+	if cfg.RateLimits.Enabled {
+		handlers.RateLimiter = ratelimit.New(cfg.RateLimits.Policy(), clock.Real)
+	}
+	if cfg.Filters.Enabled {
+		handlers.ContentFilter = cfg.Filters.Pipeline(clock.Real)
 	}
 
-	messageActor := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor { return 	proto_actor.NewMessageManager() }))
-	if messageActor == nil {
-		log.Fatalf("Failed to initialize MessageActor")
+	// The gRPC API serves the core of the JSON one on its own port.
+	if cfg.Features.GRPC {
+		listener, err := net.Listen("tcp", cfg.Server.GRPCAddr())
+		if err != nil {
+			fatal("Failed to listen for gRPC", err)
+		}
+		grpcServer := handlers.NewGRPCServer()
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				slog.Error("gRPC server stopped", "error", err)
+			}
+		}()
+		defer grpcServer.GracefulStop()
 	}
 
-	handlers.SubredditActor = subredditActor
-	handlers.PostActor = postActor
-	handlers.CommentActor = commentActor
-	handlers.MessageActor = messageActor
+	// Requests are logged by the handlers, with their IDs.
+	router := gin.New()
+	router.Use(gin.Recovery())
+	handlers.RegisterRoutes(router)
 
+	if err := router.Run(cfg.Server.Addr()); err != nil {
+		fatal("Server stopped", err)
+	}
+
+}
 
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
 }
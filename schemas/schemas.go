@@ -1,8 +1,7 @@
 package schemas
 
 import (
-	"fmt"
-	"math/rand"
+	"reddit-clone/core/ids"
 	"time"
 )
 
@@ -15,41 +14,57 @@ type Post struct {
 	Upvotes     int        `json:"upvotes"`
 	Downvotes   int        `json:"downvotes"`
 	Comments    []*Comment `json:"comments"`
+	Mentions    []Mention  `json:"mentions"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+	// Held is set while a content filter holds the post for review. A held
+	// post can be fetched by ID but is left out of every listing.
+	Held        bool       `json:"held"`
+	// Flair is a label that AutoModerator put on the post.
+	Flair       string     `json:"flair"`
 }
 
 
-func NewPost(authorID, subredditID, content string) *Post {
+func NewPost(generator ids.Generator, authorID, subredditID, content string, now time.Time) *Post {
 	return &Post{
-		ID:          GenerateID("post"),
+		ID:          generator.NewID("post"),
 		Content:     content,
 		AuthorID:    authorID,
 		SubredditID: subredditID, 
 		Upvotes:     0,
 		Downvotes:   0,
 		Comments:    []*Comment{},
-		CreatedAt:   time.Now().UTC(),
-		UpdatedAt:   time.Now().UTC(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
 }
 
 
-func (p *Post) AddComment(comment *Comment) {
+// Clone returns a copy that shares no mutable state with p, so it can be
+// handed to another goroutine while p keeps changing.
+func (p *Post) Clone() *Post {
+	clone := *p
+	clone.Comments = append([]*Comment{}, p.Comments...)
+	clone.Mentions = append([]Mention(nil), p.Mentions...)
+	return &clone
+}
+
+
+func (p *Post) AddComment(comment *Comment, now time.Time) {
 	p.Comments = append(p.Comments, comment)
-	p.UpdatedAt = time.Now().UTC()
+	p.UpdatedAt = now
 }
 
 
-func (p *Post) AddUpvote() {
+func (p *Post) AddUpvote(now time.Time) {
 	p.Upvotes++
-	p.UpdatedAt = time.Now().UTC()
+	p.UpdatedAt = now
 }
 
 
-func (p *Post) AddDownvote() {
+func (p *Post) AddDownvote(now time.Time) {
 	p.Downvotes++
-	p.UpdatedAt = time.Now().UTC()
+	p.UpdatedAt = now
 }
 
 
@@ -61,17 +76,20 @@ type Message struct {
 	Content    string    `json:"content"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	// Held is set while a content filter holds the message for review. Only
+	// its sender sees it until it is delivered.
+	Held       bool      `json:"held"`
 }
 
 
-func NewMessage(senderID, receiverID, content string) *Message {
+func NewMessage(generator ids.Generator, senderID, receiverID, content string, now time.Time) *Message {
 	return &Message{
-		ID:         GenerateID("message"),
+		ID:         generator.NewID("message"),
 		SenderID:   senderID,
 		ReceiverID: receiverID,
 		Content:    content,
-		CreatedAt:  time.Now().UTC(),
-		UpdatedAt:  time.Now().UTC(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
 }
 
@@ -82,38 +100,284 @@ func NewMessage(senderID, receiverID, content string) *Message {
 type Subreddit struct {
 	ID        string            `json:"id"`
 	Name      string            `json:"name"`
+	Title     string            `json:"title"`
 	Members   map[string]bool   `json:"members"` 
 	Posts     []*Post           `json:"posts"`
 	CreatedAt time.Time         `json:"created_at"`
 	UpdatedAt time.Time         `json:"updated_at"`
+	// BannedWords and BlockedDomains are the forum's own additions to the
+	// site-wide lists of the content filters.
+	BannedWords    []string     `json:"banned_words"`
+	BlockedDomains []string     `json:"blocked_domains"`
+	// AutoModerator is the YAML source of the forum's AutoModerator rules.
+	AutoModerator string        `json:"automoderator"`
+	// Type is ForumPublic, ForumRestricted or ForumPrivate.
+	Type          string          `json:"type"`
+	// ApprovedUsers may post in a restricted forum and view a private one
+	// without being members.
+	ApprovedUsers map[string]bool `json:"approved_users"`
+	// JoinRequests holds the users waiting to join a private forum, oldest
+	// first.
+	JoinRequests  []string        `json:"join_requests"`
+	// CreatedBy is the ID of the user who created the forum, if known.
+	CreatedBy     string          `json:"created_by"`
+	// Moderators decide who may use the forum, creator first.
+	Moderators    []string        `json:"moderators"`
+	ForumInfo
+}
+
+// ForumInfo is what moderators tell visitors about a forum.
+type ForumInfo struct {
+	Description string      `json:"description"`
+	// Sidebar is Markdown, shown beside the forum's posts.
+	Sidebar     string      `json:"sidebar"`
+	// Rules are numbered from 1 in order; reports cite them by number.
+	Rules       []ForumRule `json:"rules"`
+	BannerURL   string      `json:"banner_url"`
+	IconURL     string      `json:"icon_url"`
+	NSFW        bool        `json:"nsfw"`
+}
+
+type ForumRule struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
 }
 
-func NewSubreddit(name string) *Subreddit {
+// The types of forum. Anyone may view a public or restricted forum, but only
+// approved users may post in a restricted one; only members and approved
+// users may view, post in or comment on a private one.
+const (
+	ForumPublic     = "public"
+	ForumRestricted = "restricted"
+	ForumPrivate    = "private"
+)
+
+func NewSubreddit(generator ids.Generator, name string, now time.Time) *Subreddit {
 	return &Subreddit{
-		ID:        GenerateID("subreddit"),
+		ID:        generator.NewID("subreddit"),
 		Name:      name,
+		Title:     name,
 		Members:   make(map[string]bool),
 		Posts:     []*Post{},
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Type:      ForumPublic,
+	}
+}
+
+// Clone returns a copy that shares no mutable state with s, so it can be
+// read outside the actor that owns s. The posts themselves are shared, as
+// the forum only lists them.
+func (s *Subreddit) Clone() *Subreddit {
+	clone := *s
+	clone.Members = cloneSet(s.Members)
+	clone.ApprovedUsers = cloneSet(s.ApprovedUsers)
+	clone.JoinRequests = append([]string(nil), s.JoinRequests...)
+	clone.Moderators = append([]string(nil), s.Moderators...)
+	clone.Posts = append([]*Post{}, s.Posts...)
+	clone.BannedWords = append([]string(nil), s.BannedWords...)
+	clone.BlockedDomains = append([]string(nil), s.BlockedDomains...)
+	clone.Rules = append([]ForumRule(nil), s.Rules...)
+	return &clone
+}
+
+func cloneSet(set map[string]bool) map[string]bool {
+	if set == nil {
+		return nil
+	}
+	clone := make(map[string]bool, len(set))
+	for key, value := range set {
+		clone[key] = value
+	}
+	return clone
+}
+
+// CanView reports whether userID, which is empty for an anonymous visitor,
+// may read the forum's posts and comments.
+func (s *Subreddit) CanView(userID string) bool {
+	return s.Type != ForumPrivate || s.Members[userID] || s.ApprovedUsers[userID]
+}
+
+// CanPost reports whether userID may submit posts to the forum. Anyone who
+// can view a forum may comment on its posts.
+func (s *Subreddit) CanPost(userID string) bool {
+	switch s.Type {
+	case ForumRestricted:
+		return s.ApprovedUsers[userID]
+	case ForumPrivate:
+		return s.CanView(userID)
+	}
+	return true
+}
+
+// SetType changes who may view and post in the forum.
+func (s *Subreddit) SetType(forumType string, now time.Time) {
+	s.Type = forumType
+	s.UpdatedAt = now
+}
+
+// Approve adds userID to the approved users. It also settles a join request
+// of userID, as an approved user need not join to view the forum.
+func (s *Subreddit) Approve(userID string, now time.Time) {
+	if s.ApprovedUsers == nil {
+		s.ApprovedUsers = make(map[string]bool)
+	}
+	s.ApprovedUsers[userID] = true
+	s.removeJoinRequest(userID)
+	s.UpdatedAt = now
+}
+
+func (s *Subreddit) Unapprove(userID string, now time.Time) {
+	delete(s.ApprovedUsers, userID)
+	s.UpdatedAt = now
+}
+
+// RequestJoin queues a request of userID to join. Requesting twice is
+// harmless.
+func (s *Subreddit) RequestJoin(userID string, now time.Time) {
+	if s.HasJoinRequest(userID) {
+		return
+	}
+	s.JoinRequests = append(s.JoinRequests, userID)
+	s.UpdatedAt = now
+}
+
+func (s *Subreddit) HasJoinRequest(userID string) bool {
+	for _, id := range s.JoinRequests {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveJoinRequest takes the request of userID off the queue and, when it
+// is accepted, makes userID a member.
+func (s *Subreddit) ResolveJoinRequest(userID string, accept bool, now time.Time) {
+	s.removeJoinRequest(userID)
+	if accept {
+		s.Members[userID] = true
+	}
+	s.UpdatedAt = now
+}
+
+func (s *Subreddit) removeJoinRequest(userID string) {
+	for i, id := range s.JoinRequests {
+		if id == userID {
+			s.JoinRequests = append(s.JoinRequests[:i:i], s.JoinRequests[i+1:]...)
+			return
+		}
 	}
 }
 
 
-func (s *Subreddit) AddMember(userID string) {
+func (s *Subreddit) AddMember(userID string, now time.Time) {
 	s.Members[userID] = true
-	s.UpdatedAt = time.Now().UTC()
+	s.removeJoinRequest(userID)
+	s.UpdatedAt = now
 }
 
 
-func (s *Subreddit) RemoveMember(userID string) {
+func (s *Subreddit) RemoveMember(userID string, now time.Time) {
 	delete(s.Members, userID)
-	s.UpdatedAt = time.Now().UTC()
+	s.UpdatedAt = now
+}
+
+// IsModerator reports whether userID moderates the forum.
+func (s *Subreddit) IsModerator(userID string) bool {
+	for _, id := range s.Moderators {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// AddModerator appoints userID as a moderator. Appointing twice is harmless.
+func (s *Subreddit) AddModerator(userID string, now time.Time) {
+	if s.IsModerator(userID) {
+		return
+	}
+	s.Moderators = append(s.Moderators, userID)
+	s.UpdatedAt = now
+}
+
+// Rename changes the display title. The canonical Name used in URLs and
+// r/ references never changes.
+func (s *Subreddit) Rename(title string, now time.Time) {
+	s.Title = title
+	s.UpdatedAt = now
+}
+
+// SetInfo replaces the forum's description, sidebar, rules, images and NSFW
+// flag.
+func (s *Subreddit) SetInfo(info ForumInfo, now time.Time) {
+	s.ForumInfo = info
+	s.UpdatedAt = now
+}
+
+// Rule returns the rule numbered number, counting from 1.
+func (s *Subreddit) Rule(number int) (ForumRule, bool) {
+	if number < 1 || number > len(s.Rules) {
+		return ForumRule{}, false
+	}
+	return s.Rules[number-1], true
+}
+
+// SetFilters replaces the forum's banned words and blocked domains.
+func (s *Subreddit) SetFilters(bannedWords, blockedDomains []string, now time.Time) {
+	s.BannedWords = bannedWords
+	s.BlockedDomains = blockedDomains
+	s.UpdatedAt = now
 }
 
-func (s *Subreddit) AddPost(post *Post) {
+// SetAutoModerator replaces the source of the forum's AutoModerator rules.
+func (s *Subreddit) SetAutoModerator(rules string, now time.Time) {
+	s.AutoModerator = rules
+	s.UpdatedAt = now
+}
+
+func (s *Subreddit) AddPost(post *Post, now time.Time) {
 	s.Posts = append(s.Posts, post)
-	s.UpdatedAt = time.Now().UTC()
+	s.UpdatedAt = now
+}
+
+
+const (
+	MentionUser  = "user"
+	MentionForum = "forum"
+)
+
+// Mention is an @username or r/forum reference found in post or comment
+// content. Start and End are byte offsets of the whole reference in the text.
+type Mention struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	TargetID string `json:"target_id"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+
+type Notification struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Kind      string    `json:"kind"`
+	SourceID  string    `json:"source_id"`
+	ActorID   string    `json:"actor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+
+func NewNotification(generator ids.Generator, userID, kind, sourceID, actorID string, now time.Time) *Notification {
+	return &Notification{
+		ID:        generator.NewID("notification"),
+		UserID:    userID,
+		Kind:      kind,
+		SourceID:  sourceID,
+		ActorID:   actorID,
+		CreatedAt: now,
+	}
 }
 
 
@@ -121,27 +385,98 @@ type Comment struct {
 	ID        string    `json:"id"`
 	Content   string    `json:"content"`
 	AuthorID  string    `json:"author_id"`
+	PostID    string    `json:"post_id"`
+	ParentID  string    `json:"parent_id"`
+	Upvotes   int       `json:"upvotes"`
+	Downvotes int       `json:"downvotes"`
 	Replies   []*Comment `json:"replies"`
+	Mentions  []Mention `json:"mentions"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Held is set while a content filter holds the comment for review. A
+	// held comment can be fetched by ID but is not linked into its thread.
+	Held      bool      `json:"held"`
 }
 
 
-func NewComment(authorID, content string) *Comment {
+func NewComment(generator ids.Generator, authorID, content string, now time.Time) *Comment {
 	return &Comment{
-		ID:        GenerateID("comment"),
+		ID:        generator.NewID("comment"),
 		Content:   content,
 		AuthorID:  authorID,
 		Replies:   []*Comment{},
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+
+// Clone returns a deep copy of c and its reply tree.
+func (c *Comment) Clone() *Comment {
+	clone := *c
+	clone.Mentions = append([]Mention(nil), c.Mentions...)
+	clone.Replies = make([]*Comment, len(c.Replies))
+	for i, reply := range c.Replies {
+		clone.Replies[i] = reply.Clone()
 	}
+	return &clone
 }
 
 
-func (c *Comment) AddReply(reply *Comment) {
+func (c *Comment) AddReply(reply *Comment, now time.Time) {
 	c.Replies = append(c.Replies, reply)
-	c.UpdatedAt = time.Now().UTC()
+	c.UpdatedAt = now
+}
+
+
+func (c *Comment) AddUpvote(now time.Time) {
+	c.Upvotes++
+	c.UpdatedAt = now
+}
+
+
+func (c *Comment) AddDownvote(now time.Time) {
+	c.Downvotes++
+	c.UpdatedAt = now
+}
+
+
+
+// The kinds of content a QueueItem refers to.
+const (
+	KindPost    = "post"
+	KindComment = "comment"
+	KindMessage = "message"
+)
+
+// QueueItem is an entry of the modqueue: a post, comment or message that a
+// content filter or AutoModerator held for review, or that AutoModerator
+// reported while leaving it in sight. ForumID is empty for messages.
+type QueueItem struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	ContentID string    `json:"content_id"`
+	ForumID   string    `json:"forum_id"`
+	AuthorID  string    `json:"author_id"`
+	Filter    string    `json:"filter"`
+	Reason    string    `json:"reason"`
+	// Held is set when the content is kept out of sight until the item is
+	// approved.
+	Held      bool      `json:"held"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewQueueItem(generator ids.Generator, kind, contentID, forumID, authorID, filter, reason string, now time.Time) *QueueItem {
+	return &QueueItem{
+		ID:        generator.NewID("queue"),
+		Kind:      kind,
+		ContentID: contentID,
+		ForumID:   forumID,
+		AuthorID:  authorID,
+		Filter:    filter,
+		Reason:    reason,
+		CreatedAt: now,
+	}
 }
 
 
@@ -150,34 +485,45 @@ type Account struct {
 	ID        string    `json:"id"`
 	Username  string    `json:"username"`
 	Karma     int       `json:"karma"`
+	Bio       string    `json:"bio"`
+	AvatarURL string    `json:"avatar_url"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 
-func NewAccount(username string) *Account {
+func NewAccount(generator ids.Generator, username string, now time.Time) *Account {
 	return &Account{
-		ID:        GenerateID("user"),
+		ID:        generator.NewID("user"),
 		Username:  username,
 		Karma:     0,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 }
 
 
-func (a *Account) IncrementKarma(value int) {
+// Clone returns a copy of a, so it can be read outside the actor that owns
+// a while its karma and profile keep changing.
+func (a *Account) Clone() *Account {
+	clone := *a
+	return &clone
+}
+
+
+func (a *Account) IncrementKarma(value int, now time.Time) {
 	a.Karma += value
-	a.UpdatedAt = time.Now().UTC()
+	a.UpdatedAt = now
 }
 
-func (a *Account) DecrementKarma(value int) {
+func (a *Account) DecrementKarma(value int, now time.Time) {
 	a.Karma -= value
-	a.UpdatedAt = time.Now().UTC()
+	a.UpdatedAt = now
 }
 
 
-func GenerateID(prefix string) string {
-	rand.Seed(time.Now().UnixNano())
-	return fmt.Sprintf("%s_%d", prefix, rand.Int63())
+func (a *Account) UpdateProfile(bio, avatarURL string, now time.Time) {
+	a.Bio = bio
+	a.AvatarURL = avatarURL
+	a.UpdatedAt = now
 }
@@ -0,0 +1,167 @@
+package templates
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"reddit-clone/core/content"
+	"reddit-clone/schemas"
+	"sort"
+	"time"
+)
+
+//go:embed html/*.html
+var pageFiles embed.FS
+
+var pageFuncs = template.FuncMap{
+	// Markdown output is sanitized by the renderer, so it is safe to mark
+	// as trusted HTML here.
+	"markdown": func(source string) template.HTML {
+		return template.HTML(content.RenderMarkdown(source))
+	},
+	"timestamp": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04")
+	},
+	"dict": func(pairs ...any) map[string]any {
+		m := make(map[string]any, len(pairs)/2)
+		for i := 0; i+1 < len(pairs); i += 2 {
+			m[pairs[i].(string)] = pairs[i+1]
+		}
+		return m
+	},
+}
+
+var pages = map[string]*template.Template{}
+
+func init() {
+	for _, name := range []string{"front", "forum", "post", "profile", "inbox", "login", "error"} {
+		pages[name] = template.Must(template.New("layout.html").Funcs(pageFuncs).ParseFS(pageFiles, "html/layout.html", "html/"+name+".html"))
+	}
+}
+
+// RenderPage writes the named page wrapped in the site layout.
+func RenderPage(w io.Writer, name string, data any) error {
+	return pages[name].ExecuteTemplate(w, "layout.html", data)
+}
+
+// Page carries what every page needs: the title and the signed-in account,
+// which is nil for anonymous visitors.
+type Page struct {
+	Title  string
+	Viewer *schemas.Account
+}
+
+type PostView struct {
+	*schemas.Post
+	AuthorName string
+	ForumName  string
+	Score      int
+}
+
+type CommentView struct {
+	*schemas.Comment
+	AuthorName string
+	Score      int
+	Replies    []*CommentView
+}
+
+type FrontPage struct {
+	Page
+	Forums []*schemas.Subreddit
+	Posts  []*PostView
+}
+
+type ForumPage struct {
+	Page
+	Forum       *schemas.Subreddit
+	CreatorName string
+	Posts       []*PostView
+}
+
+type PostPage struct {
+	Page
+	Post     *PostView
+	Comments []*CommentView
+}
+
+type ProfilePage struct {
+	Page
+	Account  *schemas.Account
+	Posts    []*PostView
+	Comments []*schemas.Comment
+}
+
+type InboxPage struct {
+	Page
+	Messages      []*MessageView
+	Notifications []*schemas.Notification
+}
+
+type MessageView struct {
+	*schemas.Message
+	SenderName   string
+	ReceiverName string
+}
+
+type ErrorPage struct {
+	Page
+	Message string
+}
+
+// NewPostViews builds ranked post views, highest score first and newest first
+// among equal scores. names maps user and forum IDs to display names.
+func NewPostViews(posts []*schemas.Post, names map[string]string) []*PostView {
+	views := make([]*PostView, len(posts))
+	for i, post := range posts {
+		views[i] = NewPostView(post, names)
+	}
+	sort.SliceStable(views, func(i, j int) bool {
+		if views[i].Score != views[j].Score {
+			return views[i].Score > views[j].Score
+		}
+		return views[i].ID > views[j].ID
+	})
+	return views
+}
+
+func NewPostView(post *schemas.Post, names map[string]string) *PostView {
+	return &PostView{
+		Post:       post,
+		AuthorName: displayName(names, post.AuthorID),
+		ForumName:  displayName(names, post.SubredditID),
+		Score:      post.Upvotes - post.Downvotes,
+	}
+}
+
+func NewCommentViews(comments []*schemas.Comment, names map[string]string) []*CommentView {
+	views := make([]*CommentView, len(comments))
+	for i, comment := range comments {
+		views[i] = &CommentView{
+			Comment:    comment,
+			AuthorName: displayName(names, comment.AuthorID),
+			Score:      comment.Upvotes - comment.Downvotes,
+			Replies:    NewCommentViews(comment.Replies, names),
+		}
+	}
+	return views
+}
+
+func NewMessageViews(messages []schemas.Message, names map[string]string) []*MessageView {
+	views := make([]*MessageView, len(messages))
+	for i := range messages {
+		message := messages[len(messages)-1-i]
+		views[i] = &MessageView{
+			Message:      &message,
+			SenderName:   displayName(names, message.SenderID),
+			ReceiverName: displayName(names, message.ReceiverID),
+		}
+	}
+	return views
+}
+
+func displayName(names map[string]string, id string) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return id
+}
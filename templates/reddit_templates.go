@@ -1,6 +1,10 @@
 package templates
 
-import "reddit-clone/schemas"
+import (
+	"reddit-clone/core/automod"
+	"reddit-clone/core/content"
+	"reddit-clone/schemas"
+)
 
 
 type PostResponse struct {
@@ -8,8 +12,14 @@ type PostResponse struct {
 	SubredditID string `json:"subreddit_id"`
 	AuthorID    string `json:"user_id"`
 	Content     string `json:"content"`
+	ContentHTML string `json:"content_html"`
+	Mentions    []schemas.Mention `json:"mentions"`
+	Spans       []content.Span    `json:"spans"`
+	Upvotes     int    `json:"upvotes"`
+	Downvotes   int    `json:"downvotes"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
+	Flair       string `json:"flair,omitempty"`
 }
 
 
@@ -19,8 +29,14 @@ func NewPostResponse(post *schemas.Post) *PostResponse {
 		SubredditID: post.SubredditID,
 		AuthorID:    post.AuthorID,
 		Content:     post.Content,
+		ContentHTML: content.RenderMarkdown(post.Content),
+		Mentions:    post.Mentions,
+		Spans:       content.Spans(post.Content, post.Mentions),
+		Upvotes:     post.Upvotes,
+		Downvotes:   post.Downvotes,
 		CreatedAt:   post.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:   post.UpdatedAt.Format("2006-01-02 15:04:05"),
+		Flair:       post.Flair,
 	}
 }
 
@@ -40,6 +56,7 @@ type MessageResponse struct {
 	SenderID   string `json:"sender_id"`
 	ReceiverID string `json:"receiver_id"`
 	Content    string `json:"content"`
+	ContentHTML string `json:"content_html"`
 }
 
 func NewMessageResponse(message *schemas.Message) *MessageResponse {
@@ -48,53 +65,289 @@ func NewMessageResponse(message *schemas.Message) *MessageResponse {
 		SenderID:   message.SenderID,
 		ReceiverID: message.ReceiverID,
 		Content:    message.Content,
+		ContentHTML: content.RenderMarkdown(message.Content),
 	}
 }
 
+func NewMessageListResponse(messages []schemas.Message) []*MessageResponse {
+	responses := make([]*MessageResponse, len(messages))
+	for i := range messages {
+		responses[i] = NewMessageResponse(&messages[i])
+	}
+	return responses
+}
+
 
 
 
 type CommentResponse struct {
 	ID       string `json:"id"`
 	Content  string `json:"content"`
+	ContentHTML string `json:"content_html"`
 	AuthorID string `json:"author_id"`
+	PostID   string `json:"post_id"`
+	ParentID string `json:"parent_id"`
+	Upvotes   int   `json:"upvotes"`
+	Downvotes int   `json:"downvotes"`
+	Mentions []schemas.Mention `json:"mentions"`
+	Spans    []content.Span    `json:"spans"`
 }
 
 func NewCommentResponse(comment *schemas.Comment) *CommentResponse {
 	return &CommentResponse{
 		ID:       comment.ID,
 		Content:  comment.Content,
+		ContentHTML: content.RenderMarkdown(comment.Content),
 		AuthorID: comment.AuthorID,
+		PostID:   comment.PostID,
+		ParentID: comment.ParentID,
+		Upvotes:   comment.Upvotes,
+		Downvotes: comment.Downvotes,
+		Mentions: comment.Mentions,
+		Spans:    content.Spans(comment.Content, comment.Mentions),
 	}
 }
 
 
 type SubredditResponse struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Members int    `json:"members"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Members     int    `json:"members"`
+	CreatedAt   string `json:"created_at"`
+	CreatedBy   string `json:"created_by,omitempty"`
+	Moderators  []string `json:"moderators"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Sidebar     string `json:"sidebar"`
+	SidebarHTML string `json:"sidebar_html"`
+	Rules       []*ForumRuleResponse `json:"rules"`
+	BannerURL   string `json:"banner_url,omitempty"`
+	IconURL     string `json:"icon_url,omitempty"`
+	NSFW        bool   `json:"nsfw"`
+}
+
+// ForumRuleResponse is a forum rule with the number reports cite it by.
+type ForumRuleResponse struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
 }
 
 func NewSubredditResponse(subreddit *schemas.Subreddit) *SubredditResponse {
+	rules := make([]*ForumRuleResponse, len(subreddit.Rules))
+	for i, rule := range subreddit.Rules {
+		rules[i] = &ForumRuleResponse{Number: i + 1, Title: rule.Title, Description: rule.Description}
+	}
 	return &SubredditResponse{
-		ID:      subreddit.ID,
-		Name:    subreddit.Name,
-		Members: len(subreddit.Members),
+		ID:          subreddit.ID,
+		Name:        subreddit.Name,
+		Title:       subreddit.Title,
+		Members:     len(subreddit.Members),
+		CreatedAt:   subreddit.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:   subreddit.CreatedBy,
+		Moderators:  append([]string{}, subreddit.Moderators...),
+		Type:        subreddit.Type,
+		Description: subreddit.Description,
+		Sidebar:     subreddit.Sidebar,
+		SidebarHTML: content.RenderMarkdown(subreddit.Sidebar),
+		Rules:       rules,
+		BannerURL:   subreddit.BannerURL,
+		IconURL:     subreddit.IconURL,
+		NSFW:        subreddit.NSFW,
 	}
 }
 
 
 
 type AccountResponse struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Karma    int    `json:"karma"`
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Karma     int    `json:"karma"`
+	Bio       string `json:"bio"`
+	AvatarURL string `json:"avatar_url"`
+	CreatedAt string `json:"created_at"`
 }
 
 func NewAccountResponse(account *schemas.Account) *AccountResponse {
 	return &AccountResponse{
-		ID:       account.ID,
-		Username: account.Username,
-		Karma:    account.Karma,
+		ID:        account.ID,
+		Username:  account.Username,
+		Karma:     account.Karma,
+		Bio:       account.Bio,
+		AvatarURL: account.AvatarURL,
+		CreatedAt: account.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func NewAccountListResponse(accounts []*schemas.Account) []*AccountResponse {
+	responses := make([]*AccountResponse, len(accounts))
+	for i, account := range accounts {
+		responses[i] = NewAccountResponse(account)
+	}
+	return responses
+}
+
+
+type PostPageResponse struct {
+	Posts      []*PostResponse `json:"posts"`
+	Total      int             `json:"total"`
+	Offset     int             `json:"offset"`
+	Limit      int             `json:"limit"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func NewPostPageResponse(posts []*schemas.Post, total, offset, limit int, nextCursor string) *PostPageResponse {
+	return &PostPageResponse{
+		Posts:      NewPostListResponse(posts),
+		Total:      total,
+		Offset:     offset,
+		Limit:      limit,
+		NextCursor: nextCursor,
+	}
+}
+
+
+type CommentPageResponse struct {
+	Comments   []*CommentResponse `json:"comments"`
+	Total      int                `json:"total"`
+	Offset     int                `json:"offset"`
+	Limit      int                `json:"limit"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func NewCommentPageResponse(comments []*schemas.Comment, total, offset, limit int, nextCursor string) *CommentPageResponse {
+	responses := make([]*CommentResponse, len(comments))
+	for i, comment := range comments {
+		responses[i] = NewCommentResponse(comment)
+	}
+	return &CommentPageResponse{
+		Comments:   responses,
+		Total:      total,
+		Offset:     offset,
+		Limit:      limit,
+		NextCursor: nextCursor,
+	}
+}
+
+
+
+type NotificationResponse struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	SourceID  string `json:"source_id"`
+	ActorID   string `json:"actor_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+func NewNotificationListResponse(notifications []*schemas.Notification) []*NotificationResponse {
+	responses := make([]*NotificationResponse, len(notifications))
+	for i, n := range notifications {
+		responses[i] = &NotificationResponse{
+			ID:        n.ID,
+			Kind:      n.Kind,
+			SourceID:  n.SourceID,
+			ActorID:   n.ActorID,
+			CreatedAt: n.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+	}
+	return responses
+}
+
+
+// ForumFiltersResponse is a forum's own banned words and blocked domains.
+type ForumFiltersResponse struct {
+	ForumID        string   `json:"forum_id"`
+	BannedWords    []string `json:"banned_words"`
+	BlockedDomains []string `json:"blocked_domains"`
+}
+
+func NewForumFiltersResponse(subreddit *schemas.Subreddit) *ForumFiltersResponse {
+	response := &ForumFiltersResponse{
+		ForumID:        subreddit.ID,
+		BannedWords:    subreddit.BannedWords,
+		BlockedDomains: subreddit.BlockedDomains,
+	}
+	if response.BannedWords == nil {
+		response.BannedWords = []string{}
+	}
+	if response.BlockedDomains == nil {
+		response.BlockedDomains = []string{}
+	}
+	return response
+}
+
+
+type QueueItemResponse struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	ContentID string `json:"content_id"`
+	ForumID   string `json:"forum_id,omitempty"`
+	AuthorID  string `json:"author_id"`
+	Filter    string `json:"filter"`
+	Reason    string `json:"reason"`
+	Held      bool   `json:"held"`
+	CreatedAt string `json:"created_at"`
+}
+
+func NewQueueItemResponse(item *schemas.QueueItem) *QueueItemResponse {
+	return &QueueItemResponse{
+		ID:        item.ID,
+		Kind:      item.Kind,
+		ContentID: item.ContentID,
+		ForumID:   item.ForumID,
+		AuthorID:  item.AuthorID,
+		Filter:    item.Filter,
+		Reason:    item.Reason,
+		Held:      item.Held,
+		CreatedAt: item.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func NewQueueItemListResponse(items []*schemas.QueueItem) []*QueueItemResponse {
+	responses := make([]*QueueItemResponse, len(items))
+	for i, item := range items {
+		responses[i] = NewQueueItemResponse(item)
+	}
+	return responses
+}
+
+
+// AutoModeratorResponse is the YAML source of a forum's AutoModerator rules.
+type AutoModeratorResponse struct {
+	ForumID string `json:"forum_id"`
+	Rules   string `json:"rules"`
+}
+
+func NewAutoModeratorResponse(subreddit *schemas.Subreddit) *AutoModeratorResponse {
+	return &AutoModeratorResponse{ForumID: subreddit.ID, Rules: subreddit.AutoModerator}
+}
+
+// AutoModResultResponse is what AutoModerator would do with a post or
+// comment.
+type AutoModResultResponse struct {
+	Action   string   `json:"action"`
+	Rule     string   `json:"rule,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	Flair    string   `json:"flair,omitempty"`
+	Comments []string `json:"comments"`
+	Matched  []string `json:"matched"`
+}
+
+func NewAutoModResultResponse(result *automod.Result) *AutoModResultResponse {
+	response := &AutoModResultResponse{
+		Action:   result.Action.String(),
+		Rule:     result.Rule,
+		Reason:   result.Reason,
+		Flair:    result.Flair,
+		Comments: result.Comments,
+		Matched:  result.Matched,
+	}
+	if response.Comments == nil {
+		response.Comments = []string{}
+	}
+	if response.Matched == nil {
+		response.Matched = []string{}
 	}
+	return response
 }
@@ -0,0 +1,261 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/core/automod"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func TestAutoModParse(t *testing.T) {
+	for _, test := range []struct {
+		source, err string
+	}{
+		{"", ""},
+		{"- action: remove\n  body: spam\n---\naction: hold\n", ""},
+		{"action: remove\naction: hold\n", "rule 1 (line 2): action is given twice"},
+		{"body: spam\n", "rule 1 (line 1): a rule must have an action, set_flair or comment"},
+		{"action: delete\n", `rule 1 (line 1): action must be remove, hold or report, not "delete"`},
+		{"type: comment\nset_flair: Spam\n", "rule 1 (line 1): set_flair applies only to posts"},
+		{"action: hold\n---\naction: hold\ncolour: red\n", `rule 2 (line 4): unknown key "colour"`},
+		{"action: hold\nbody (regex): '('\n", "rule 1 (line 2)"},
+		{"action: hold\nbody (sounds-like): spam\n", "rule 1 (line 2)"},
+		{"action: hold\ndomain (includes): spam.example\n", "rule 1 (line 2): domain takes no match modifier"},
+		{"action: hold\nauthor:\n  karma: lots\n", "rule 1 (line 3)"},
+		{"action: hold\nauthor:\n  account_age: \"< a while\"\n", "rule 1 (line 3)"},
+		{"action: hold\nauthor:\n  followers: \"> 10\"\n", `rule 1 (line 3): unknown author key "followers"`},
+		{"just text", "rule 1 (line 1): a rule must be a mapping of keys to values"},
+	} {
+		_, err := automod.Parse(test.source)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("Expected %q to parse, got %v", test.source, err)
+		case test.err != "" && (err == nil || !strings.HasPrefix(err.Error(), test.err)):
+			t.Errorf("Expected %q to fail with %q, got %v", test.source, test.err, err)
+		}
+	}
+	if _, err := automod.Parse(strings.Repeat("- action: hold\n", automod.MaxRules+1)); err == nil {
+		t.Errorf("Expected too many rules to be refused")
+	}
+}
+
+func TestAutoModEvaluate(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	ruleset, err := automod.Parse(`
+- name: New accounts
+  author:
+    account_age: "< 7 days"
+    karma: "<= 5"
+  action: report
+- name: Sales
+  type: post
+  title (regex): '^\[sale\]'
+  set_flair: Sale
+  comment: Hi {{author}}, sales go in the weekly thread.
+- name: Spam links
+  domain: spam.example
+  action: remove
+  action_reason: Links to spam
+- name: Crypto
+  body: crypto
+  action: hold
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evaluate := func(kind, text string, karma int, age time.Duration) *automod.Result {
+		return ruleset.Evaluate(&automod.Item{Kind: kind, Text: text, AuthorName: "alice", AuthorKarma: karma, AuthorCreatedAt: now.Add(-age)}, now)
+	}
+
+	result := evaluate(schemas.KindPost, "# [SALE] Old laptop\nCheap.", 100, 30*24*time.Hour)
+	if result.Action != automod.None || result.Flair != "Sale" || len(result.Comments) != 1 || result.Comments[0] != "Hi alice, sales go in the weekly thread." {
+		t.Errorf("Expected a flaired post with a reply, got %+v", result)
+	}
+	if result := evaluate(schemas.KindComment, "[sale] laptop", 100, 30*24*time.Hour); len(result.Matched) != 0 {
+		t.Errorf("Expected a post rule to skip comments, got %+v", result)
+	}
+	if result := evaluate(schemas.KindPost, "Hello\n[sale] laptop", 100, 30*24*time.Hour); len(result.Matched) != 0 {
+		t.Errorf("Expected the title to be the first line only, got %+v", result)
+	}
+
+	result = evaluate(schemas.KindComment, "CRYPTO at https://www.spam.example", 0, time.Hour)
+	if result.Action != automod.Remove || result.Rule != "Spam links" || result.Reason != "Links to spam" || strings.Join(result.Matched, ",") != "New accounts,Spam links,Crypto" {
+		t.Errorf("Expected the strongest action to win, got %+v", result)
+	}
+	result = evaluate(schemas.KindComment, "cryptocurrency", 0, 8*24*time.Hour)
+	if result.Action != automod.None {
+		t.Errorf("Expected whole words and old accounts only to match, got %+v", result)
+	}
+	result = evaluate(schemas.KindComment, "crypto!", 6, time.Hour)
+	if result.Action != automod.Hold || result.Reason != `matched the rule "Crypto"` || strings.Join(result.Matched, ",") != "Crypto" {
+		t.Errorf("Expected the karma to keep the report out, got %+v", result)
+	}
+}
+
+func TestAutoModerator(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	router := newTestRouterWithClock(fake)
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("Unexpected response %s: %v", w.Body.String(), err)
+		}
+	}
+	setRules := func(rules string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"rules": rules, "moderator_id": "alice"})
+		return call(http.MethodPut, "/api/forums/golang/automoderator", string(body))
+	}
+	queue := func() []*templates.QueueItemResponse {
+		t.Helper()
+		var items []*templates.QueueItemResponse
+		decode(call(http.MethodGet, "/api/modqueue?forum_id=golang&moderator_id=alice", ""), &items)
+		return items
+	}
+
+	call(http.MethodPost, "/api/users", `{"display_name":"alice"}`)
+	call(http.MethodPost, "/api/forums", `{"title":"golang","creator_id":"alice"}`)
+	fake.Advance(30 * 24 * time.Hour)
+	call(http.MethodPost, "/api/users", `{"display_name":"bob"}`)
+
+	var rules templates.AutoModeratorResponse
+	decode(call(http.MethodGet, "/api/forums/golang/automoderator", ""), &rules)
+	if rules.Rules != "" {
+		t.Errorf("Expected no rules, got %+v", rules)
+	}
+	w := setRules("action: hold\nbody (regex): '('\n")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "rule 1 (line 2)") {
+		t.Errorf("Expected invalid rules to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	source := `
+- name: Spam
+  body: free money
+  action: remove
+  action_reason: No spam
+- name: New accounts
+  author:
+    account_age: "< 1 day"
+  action: hold
+- name: Questions
+  type: post
+  title (ends-with): "?"
+  set_flair: Question
+  comment: Thanks {{author}}, remember to search first.
+- name: Links
+  domain: example.com
+  action: report
+`
+	decode(setRules(source), &rules)
+	if rules.Rules != source {
+		t.Errorf("Expected the rules to be stored, got %+v", rules)
+	}
+	w = call(http.MethodPut, "/api/forums/golang/automoderator", `{"rules":"action: remove\n","moderator_id":"bob"}`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "moderators") {
+		t.Errorf("Expected a non-moderator to be refused, got %d %s", w.Code, w.Body.String())
+	}
+
+	// A removed post is refused.
+	w = call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"alice","text":"Free money!"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "the post was removed by AutoModerator: No spam") {
+		t.Errorf("Expected the post to be removed, got %d %s", w.Code, w.Body.String())
+	}
+
+	// A flaired post gets a reply from AutoModerator.
+	var post templates.PostResponse
+	decode(call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"alice","text":"Generics?\nHow do they work"}`), &post)
+	if post.Flair != "Question" {
+		t.Errorf("Expected the post to be flaired, got %+v", post)
+	}
+	var replies templates.CommentPageResponse
+	decode(call(http.MethodGet, "/api/users/AutoModerator/comments", ""), &replies)
+	if replies.Total != 1 || replies.Comments[0].PostID != post.ID || replies.Comments[0].Content != "Thanks alice, remember to search first." {
+		t.Errorf("Expected a reply on the post, got %+v", replies)
+	}
+
+	// Content by a new account is held.
+	var comment templates.CommentResponse
+	decode(call(http.MethodPost, "/api/comments", `{"post_id":"`+post.ID+`","author_id":"bob","content":"Like this"}`), &comment)
+	items := queue()
+	if len(items) != 1 || items[0].ContentID != comment.ID || items[0].Filter != "automoderator" || !items[0].Held || items[0].Reason != `matched the rule "New accounts"` {
+		t.Fatalf("Expected the comment held, got %+v", items)
+	}
+	decode(call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/approve", `{"moderator_id":"alice"}`), &items[0])
+
+	// Reported content is listed, and approving the report only dismisses it.
+	var reported templates.PostResponse
+	decode(call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"alice","text":"See https://go.example.com"}`), &reported)
+	items = queue()
+	if len(items) != 1 || items[0].ContentID != reported.ID || items[0].Held {
+		t.Fatalf("Expected the post reported, got %+v", items)
+	}
+	if w := call(http.MethodGet, "/api/posts/"+reported.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the reported post to stay, got %d %s", w.Code, w.Body.String())
+	}
+	decode(call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/approve", `{"moderator_id":"alice"}`), &items[0])
+	if items := queue(); len(items) != 0 {
+		t.Errorf("Expected the report dismissed, got %+v", items)
+	}
+
+	// A dry run tries the forum's rules, or others, without acting.
+	var result templates.AutoModResultResponse
+	decode(call(http.MethodPost, "/api/forums/golang/automoderator/test", `{"kind":"comment","text":"free money","author_id":"bob"}`), &result)
+	if result.Action != "remove" || result.Rule != "Spam" || strings.Join(result.Matched, ",") != "Spam,New accounts" {
+		t.Errorf("Expected the comment to be removed, got %+v", result)
+	}
+	decode(call(http.MethodPost, "/api/forums/golang/automoderator/test", `{"rules":"set_flair: Any","kind":"post","text":"free money","author_id":"bob"}`), &result)
+	if result.Action != "none" || result.Flair != "Any" || len(result.Comments) != 0 {
+		t.Errorf("Expected the given rules to be tried, got %+v", result)
+	}
+	if w := call(http.MethodPost, "/api/forums/golang/automoderator/test", `{"rules":"nonsense","kind":"post","text":"x","author_id":"bob"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected invalid rules to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	if items := queue(); len(items) != 0 {
+		t.Errorf("Expected a dry run to queue nothing, got %+v", items)
+	}
+
+	// Nobody can register as AutoModerator.
+	if w := call(http.MethodPost, "/api/users", `{"display_name":"automoderator"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected the username to be reserved, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// Submissions send AutoModerator the rules of the forum they read, which
+// later changes to the rules must not reach.
+func TestAutoModeratorRulesAreCopied(t *testing.T) {
+	system := actor.NewActorSystem()
+	forumManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewForumManager()
+	}))
+
+	forum, err := proto_actor.Ask(system.Root, forumManager, &proto_actor.AddForum{Title: "golang", CreatorID: "user_1"}, time.Second)
+	if err != nil {
+		t.Fatalf("AddForum failed: %v", err)
+	}
+	proto_actor.Ask(system.Root, forumManager, &proto_actor.SetAutoModerator{ForumID: forum.ID, Rules: "action: hold\n", ModeratorID: "user_1"}, time.Second)
+	read, err := proto_actor.Ask(system.Root, forumManager, &proto_actor.RetrieveForum{ForumID: forum.ID}, time.Second)
+	if err != nil {
+		t.Fatalf("RetrieveForum failed: %v", err)
+	}
+	proto_actor.Ask(system.Root, forumManager, &proto_actor.SetAutoModerator{ForumID: forum.ID, Rules: "action: remove\n", ModeratorID: "user_1"}, time.Second)
+	if read.AutoModerator != "action: hold\n" {
+		t.Errorf("Expected the rules as read, got %q", read.AutoModerator)
+	}
+}
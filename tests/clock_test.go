@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2025, 6, 1, 9, 30, 0, 0, time.FixedZone("X", 3600))
+	fake := clock.NewFake(start)
+	if !fake.Now().Equal(start) || fake.Now().Location() != time.UTC {
+		t.Errorf("Fake clock should start at %v in UTC, got %v", start, fake.Now())
+	}
+	if got := fake.Advance(90 * time.Second); !got.Equal(start.Add(90 * time.Second)) {
+		t.Errorf("Unexpected time after Advance: %v", got)
+	}
+	fake.Set(start)
+	if !fake.Now().Equal(start) {
+		t.Errorf("Unexpected time after Set: %v", fake.Now())
+	}
+}
+
+func TestCommentTimestampsFollowClock(t *testing.T) {
+	system := actor.NewActorSystem()
+	created := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(created)
+	commentService := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewCommentService(proto_actor.WithClock(fake))
+	}))
+
+	res, _ := system.Root.RequestFuture(commentService, &proto_actor.AddComment{PostID: "post", AuthorID: "author", Content: "hi"}, 3*time.Second).Result()
+	comment := res.(*schemas.Comment)
+	if !comment.CreatedAt.Equal(created) || !comment.UpdatedAt.Equal(created) {
+		t.Errorf("Comment not stamped with the fake clock: %v / %v", comment.CreatedAt, comment.UpdatedAt)
+	}
+
+	voted := fake.Advance(time.Hour)
+	res, _ = system.Root.RequestFuture(commentService, &proto_actor.VoteComment{CommentID: comment.ID, Upvote: true}, 3*time.Second).Result()
+	comment = res.(*schemas.Comment)
+	if !comment.CreatedAt.Equal(created) || !comment.UpdatedAt.Equal(voted) {
+		t.Errorf("Vote should move only UpdatedAt: %v / %v", comment.CreatedAt, comment.UpdatedAt)
+	}
+}
+
+func TestAPITimestampsFollowClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC))
+	router := newTestRouterWithClock(fake)
+	call := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	call("/api/users", `{"display_name":"tick"}`)
+	w := call("/api/forums", `{"title":"clocks","creator_id":"tick"}`)
+
+	var forum struct {
+		CreatedAt string `json:"created_at"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &forum); err != nil || forum.CreatedAt != "2025-02-03 04:05:06" {
+		t.Errorf("Unexpected created_at: %s", w.Body.String())
+	}
+}
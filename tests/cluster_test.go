@@ -0,0 +1,267 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"reddit-clone/core/clusternode"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/cluster"
+)
+
+type testNode struct {
+	system   *actor.ActorSystem
+	cluster  *cluster.Cluster
+	posts    *actor.PID
+	comments *actor.PID
+	stopOnce sync.Once
+}
+
+// stop leaves the cluster; it may be called more than once.
+func (n *testNode) stop() {
+	n.stopOnce.Do(func() { n.cluster.Shutdown(true) })
+}
+
+func freePorts(t *testing.T, n int) []int {
+	t.Helper()
+	ports := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("No free port: %v", err)
+		}
+		defer listener.Close()
+		ports = append(ports, listener.Addr().(*net.TCPAddr).Port)
+	}
+	return ports
+}
+
+// startCluster starts n members in this process. They share one Storage,
+// standing in for the database separate processes would share.
+func startCluster(t *testing.T, n int) []*testNode {
+	t.Helper()
+	ports := freePorts(t, 2*n)
+	peers := make([]string, n)
+	for i := range peers {
+		peers[i] = fmt.Sprintf("127.0.0.1:%d", ports[n+i])
+	}
+
+	storage := proto_actor.NewStorage()
+	nodes := make([]*testNode, n)
+	for i := range nodes {
+		system := actor.NewActorSystem(actor.WithLoggerFactory(func(*actor.ActorSystem) *slog.Logger {
+			return slog.New(slog.NewTextHandler(io.Discard, nil))
+		}))
+		c, err := clusternode.Start(system, clusternode.Config{
+			Port:         ports[i],
+			ProviderPort: ports[n+i],
+			Peers:        peers,
+			Refresh:      200 * time.Millisecond,
+		}, proto_actor.WithStorage(storage))
+		if err != nil {
+			t.Fatalf("Failed to start node %d: %v", i, err)
+		}
+		options := []proto_actor.Option{proto_actor.WithStorage(storage), proto_actor.WithCluster(c)}
+		nodes[i] = &testNode{
+			system:  system,
+			cluster: c,
+			posts: system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+				return proto_actor.NewPostManager(options...)
+			})),
+			comments: system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+				return proto_actor.NewCommentService(options...)
+			})),
+		}
+		node := nodes[i]
+		t.Cleanup(node.stop)
+	}
+
+	// Wait until every member sees the full topology.
+	deadline := time.Now().Add(10 * time.Second)
+	for _, node := range nodes {
+		for len(node.cluster.MemberList.Members().Members()) < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("Members did not discover each other")
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	return nodes
+}
+
+func request(t *testing.T, node *testNode, pid *actor.PID, message interface{}) interface{} {
+	t.Helper()
+	res, err := node.system.Root.RequestFuture(pid, message, 10*time.Second).Result()
+	if err != nil {
+		t.Fatalf("%T failed: %v", message, err)
+	}
+	return res
+}
+
+func TestClusterRouting(t *testing.T) {
+	nodes := startCluster(t, 3)
+
+	posts := make([]*schemas.Post, 12)
+	for i := range posts {
+		posts[i] = request(t, nodes[i%3], nodes[i%3].posts, &proto_actor.AddPost{ForumID: "forum", AuthorID: "author", Text: "hello"}).(*schemas.Post)
+	}
+
+	// Vote on every post once through each node; all votes must reach the
+	// same grain regardless of the entry node.
+	for _, post := range posts {
+		for _, node := range nodes {
+			res := request(t, node, node.posts, &proto_actor.VotePost{ContentID: post.ID, Upvote: true})
+			if _, ok := res.(*schemas.Post); !ok {
+				t.Fatalf("Invalid response for VotePost: %v", res)
+			}
+		}
+		res := request(t, nodes[0], nodes[0].posts, &proto_actor.RetrievePost{ContentID: post.ID})
+		if retrieved, ok := res.(*schemas.Post); !ok || retrieved.Upvotes != 3 {
+			t.Fatalf("Expected 3 upvotes on %s, got %v", post.ID, res)
+		}
+	}
+
+	hosting, total := 0, 0
+	for _, node := range nodes {
+		if n := countActive(t, node.system, node.posts); n > 0 {
+			hosting++
+			total += n
+		}
+	}
+	if total != len(posts) {
+		t.Errorf("Expected one grain per post, got %d", total)
+	}
+	if hosting < 2 {
+		t.Errorf("Expected grains spread over several nodes, got %d hosting", hosting)
+	}
+
+	res := request(t, nodes[1], nodes[1].comments, &proto_actor.AddComment{PostID: posts[0].ID, AuthorID: "author", Content: "first"})
+	comment, ok := res.(*schemas.Comment)
+	if !ok {
+		t.Fatalf("Invalid response for AddComment: %v", res)
+	}
+	res = request(t, nodes[2], nodes[2].comments, &proto_actor.AddComment{PostID: posts[0].ID, ParentID: comment.ID, AuthorID: "author", Content: "reply"})
+	if _, ok := res.(*schemas.Comment); !ok {
+		t.Fatalf("Invalid response for reply: %v", res)
+	}
+	res = request(t, nodes[0], nodes[0].comments, &proto_actor.FetchPostComments{PostID: posts[0].ID})
+	if thread, ok := res.([]*schemas.Comment); !ok || len(thread) != 1 || len(thread[0].Replies) != 1 {
+		t.Fatalf("Expected one comment with one reply, got %v", res)
+	}
+
+	// Held content is released by its grain, whichever node approves it.
+	held := request(t, nodes[0], nodes[0].posts, &proto_actor.AddPost{ForumID: "forum", AuthorID: "author", Text: "held", Held: true}).(*schemas.Post)
+	res = request(t, nodes[1], nodes[1].posts, &proto_actor.ApprovePost{ContentID: held.ID})
+	if approved, ok := res.(*schemas.Post); !ok || approved.Held {
+		t.Errorf("Expected the post to be released, got %v", res)
+	}
+	res = request(t, nodes[2], nodes[2].posts, &proto_actor.RetrievePost{ContentID: held.ID})
+	if retrieved, ok := res.(*schemas.Post); !ok || retrieved.Held {
+		t.Errorf("Expected the released post, got %v", res)
+	}
+	res = request(t, nodes[0], nodes[0].comments, &proto_actor.AddComment{PostID: posts[0].ID, AuthorID: "author", Content: "held", Held: true})
+	heldComment, ok := res.(*schemas.Comment)
+	if !ok {
+		t.Fatalf("Invalid response for AddComment: %v", res)
+	}
+	res = request(t, nodes[1], nodes[1].comments, &proto_actor.ApproveComment{CommentID: heldComment.ID})
+	if approved, ok := res.(*schemas.Comment); !ok || approved.Held {
+		t.Errorf("Expected the comment to be released, got %v", res)
+	}
+	res = request(t, nodes[2], nodes[2].comments, &proto_actor.FetchPostComments{PostID: posts[0].ID})
+	if thread, ok := res.([]*schemas.Comment); !ok || len(thread) != 2 {
+		t.Errorf("Expected the released comment in the thread, got %v", res)
+	}
+
+	res = request(t, nodes[2], nodes[2].posts, &proto_actor.VotePost{ContentID: "post_missing", Upvote: true})
+	if err, ok := res.(*proto_actor.Error); !ok || err.Code != proto_actor.NotFound || err.Error() != "post not found" {
+		t.Errorf("Expected post not found, got %v", res)
+	}
+}
+
+func TestClusterFailover(t *testing.T) {
+	nodes := startCluster(t, 3)
+
+	post := request(t, nodes[0], nodes[0].posts, &proto_actor.AddPost{ForumID: "forum", AuthorID: "author", Text: "hello"}).(*schemas.Post)
+	request(t, nodes[0], nodes[0].posts, &proto_actor.VotePost{ContentID: post.ID, Upvote: true})
+
+	host := -1
+	for i, node := range nodes {
+		if countActive(t, node.system, node.posts) == 1 {
+			host = i
+		}
+	}
+	if host < 0 {
+		t.Fatalf("No node hosts the post grain")
+	}
+	nodes[host].stop()
+	survivor := nodes[(host+1)%3]
+
+	// The survivors notice the lost member on their next refresh and place
+	// the grain on one of themselves, where it reloads from storage.
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		res, err := survivor.system.Root.RequestFuture(survivor.posts, &proto_actor.VotePost{ContentID: post.ID, Upvote: true}, 10*time.Second).Result()
+		if voted, ok := res.(*schemas.Post); err == nil && ok {
+			if voted.Upvotes != 2 {
+				t.Fatalf("Expected state to survive failover with 2 upvotes, got %d", voted.Upvotes)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Grain was not reactivated after failover: %v %v", res, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	active := 0
+	for i, node := range nodes {
+		if i != host {
+			active += countActive(t, node.system, node.posts)
+		}
+	}
+	if active != 1 {
+		t.Errorf("Expected the grain on a surviving node, got %d activations", active)
+	}
+}
+
+// Members in separate processes cannot share an in-memory Storage, so a
+// member that finds another with a different Storage must refuse to join
+// rather than route grains to state it cannot see.
+func TestClusterRefusesSeparateStorages(t *testing.T) {
+	ports := freePorts(t, 4)
+	peers := []string{fmt.Sprintf("127.0.0.1:%d", ports[2]), fmt.Sprintf("127.0.0.1:%d", ports[3])}
+	start := func(i int) (*cluster.Cluster, error) {
+		system := actor.NewActorSystem(actor.WithLoggerFactory(func(*actor.ActorSystem) *slog.Logger {
+			return slog.New(slog.NewTextHandler(io.Discard, nil))
+		}))
+		return clusternode.Start(system, clusternode.Config{
+			Port:         ports[i],
+			ProviderPort: ports[2+i],
+			Peers:        peers,
+			Refresh:      200 * time.Millisecond,
+		}, proto_actor.WithStorage(proto_actor.NewStorage()))
+	}
+
+	first, err := start(0)
+	if err != nil {
+		t.Fatalf("Failed to start the first node: %v", err)
+	}
+	t.Cleanup(func() { first.Shutdown(true) })
+
+	second, err := start(1)
+	if !errors.Is(err, clusternode.ErrStorageNotShared) {
+		if second != nil {
+			second.Shutdown(true)
+		}
+		t.Fatalf("Expected the second node to be refused, got %v", err)
+	}
+}
@@ -79,3 +79,26 @@ func BenchmarkMessageActor(b *testing.B) {
 		}
 	})
 }
+
+func TestFetchedMessagesAreCopies(t *testing.T) {
+	system := actor.NewActorSystem()
+	messageActor := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewMessageManager()
+	}))
+
+	held, err := proto_actor.Ask(system.Root, messageActor, &proto_actor.SendMessage{FromUserID: "userA", ToUserID: "userB", Body: "held", Held: true}, time.Second)
+	if err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	proto_actor.Ask(system.Root, messageActor, &proto_actor.SendMessage{FromUserID: "userA", ToUserID: "userB", Body: "later"}, time.Second)
+	fetched, err := proto_actor.Ask(system.Root, messageActor, &proto_actor.FetchMessages{UserID: "userA"}, time.Second)
+	if err != nil || len(fetched) != 2 {
+		t.Fatalf("FetchMessages failed: %v %v", fetched, err)
+	}
+
+	proto_actor.Ask(system.Root, messageActor, &proto_actor.DeliverMessage{MessageID: held.ID}, time.Second)
+	proto_actor.Ask(system.Root, messageActor, &proto_actor.RemoveMessage{MessageID: held.ID}, time.Second)
+	if fetched[0].ID != held.ID || !fetched[0].Held || fetched[1].Content != "later" {
+		t.Errorf("Expected the fetched messages to stay as they were, got %+v", fetched)
+	}
+}
@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reddit-clone/core/config"
+	"reddit-clone/handlers"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env returns a lookup over vars, standing in for os.LookupEnv.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	cfg, err := config.Parse(nil, env(nil))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, config.Default()) {
+		t.Errorf("Expected the defaults without a file, environment or flags")
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected the defaults to be valid: %v", err)
+	}
+
+	files := map[string]string{
+		"server.yaml": `
+server:
+  port: 7000
+  grpc_port: 7001
+actors:
+  request_timeout: 2s
+limits:
+  post_length: 280
+cluster:
+  peers: [a:1, b:2]
+features:
+  graphql: false
+`,
+		"server.toml": `
+[server]
+port = 7000
+grpc_port = 7001
+
+[actors]
+request_timeout = "2s"
+
+[limits]
+post_length = 280
+
+[cluster]
+peers = ["a:1", "b:2"]
+
+[features]
+graphql = false
+`,
+	}
+	for name, content := range files {
+		path := writeFile(t, name, content)
+		// The environment overrides the file, and flags the environment.
+		cfg, err := config.Parse(
+			[]string{"-config", path, "-actors.request_timeout", "3s"},
+			env(map[string]string{"PORT": "7100", "ACTOR_REQUEST_TIMEOUT": "4s", "LOG_FORMAT": "json"}),
+		)
+		if err != nil {
+			t.Fatalf("%s: Parse failed: %v", name, err)
+		}
+		for _, check := range []struct {
+			setting   string
+			got, want any
+		}{
+			{"server.port", cfg.Server.Port, 7100},
+			{"server.grpc_port", cfg.Server.GRPCPort, 7001},
+			{"actors.request_timeout", cfg.Actors.RequestTimeout, 3 * time.Second},
+			{"limits.post_length", cfg.Limits.PostLength, 280},
+			{"cluster.peers", cfg.Cluster.Peers, []string{"a:1", "b:2"}},
+			{"features.graphql", cfg.Features.GraphQL, false},
+			{"logging.format", cfg.Logging.Format, "json"},
+			{"limits.comment_length", cfg.Limits.CommentLength, config.Default().Limits.CommentLength},
+		} {
+			if !reflect.DeepEqual(check.got, check.want) {
+				t.Errorf("%s: expected %s to be %v, got %v", name, check.setting, check.want, check.got)
+			}
+		}
+	}
+
+	// CONFIG_FILE names the file when -config does not.
+	path := writeFile(t, "env.yaml", "server:\n  node_id: 3\n")
+	if cfg, err := config.Parse(nil, env(map[string]string{"CONFIG_FILE": path})); err != nil || cfg.Server.NodeID != 3 {
+		t.Errorf("Expected CONFIG_FILE to be read, got %v (%v)", cfg, err)
+	}
+}
+
+func TestConfigRejectsBadSettings(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown.yaml":  "server:\n  prot: 80\n",
+		"duration.yaml": "actors:\n  request_timeout: 5\n",
+		"number.toml":   "[server]\nport = \"eighty\"\n",
+		"config.json":   "{}",
+	} {
+		if _, err := config.Parse([]string{"-config", writeFile(t, name, content)}, env(nil)); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+	if _, err := config.Parse(nil, env(map[string]string{"PORT": "http"})); err == nil || !strings.Contains(err.Error(), "$PORT") {
+		t.Errorf("Expected an invalid PORT to be rejected, got %v", err)
+	}
+	if _, err := config.Parse([]string{"-no-such-flag"}, env(nil)); err == nil {
+		t.Errorf("Expected an unknown flag to be rejected")
+	}
+
+	cfg := config.Default()
+	cfg.Storage.Backend = "postgres"
+	cfg.Actors.RequestTimeout = 0
+	cfg.Limits.PostLength = -1
+	cfg.RateLimits.Vote.Burst = 0
+	cfg.Logging.Level = "loud"
+	cfg.Cluster.Port = 6000
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "proxy.local"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected the configuration to be invalid")
+	}
+	for _, setting := range []string{"storage.backend", "actors.request_timeout", "limits.post_length", "rate_limits.vote.burst", "logging.level", "cluster.provider_port", "cluster.peers", "server.trusted_proxies"} {
+		if !strings.Contains(err.Error(), setting) {
+			t.Errorf("Expected %s to be reported in %v", setting, err)
+		}
+	}
+}
+
+func TestConfigRefusesClusterWithoutSharedStorage(t *testing.T) {
+	cfg := config.Default()
+	cfg.Cluster = config.Cluster{Port: 7001, ProviderPort: 6331, Peers: []string{"127.0.0.1:6331"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected a single member to be valid: %v", err)
+	}
+	cfg.Cluster.Peers = append(cfg.Cluster.Peers, "127.0.0.1:6332")
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "cannot be shared between processes") {
+		t.Errorf("Expected several members with the memory backend to be refused, got %v", err)
+	}
+}
+
+func TestConfigWriteRoundTrips(t *testing.T) {
+	cfg, err := config.Parse([]string{"-server.port", "7200", "-cluster.peers", "a:1,b:2", "-rate_limits.trusted_factor", "1.5"}, env(nil))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	for _, format := range []string{"yaml", "toml"} {
+		var out bytes.Buffer
+		if err := cfg.Write(&out, format); err != nil {
+			t.Fatalf("Write %s failed: %v", format, err)
+		}
+		path := writeFile(t, "dump."+format, out.String())
+		read, err := config.Parse([]string{"-config", path}, env(nil))
+		if err != nil {
+			t.Fatalf("Reading the %s dump failed: %v\n%s", format, err, out.String())
+		}
+		if !reflect.DeepEqual(read, cfg) {
+			t.Errorf("Expected the %s dump to load the same configuration:\n%s", format, out.String())
+		}
+	}
+	if err := cfg.Write(&bytes.Buffer{}, "ini"); err == nil {
+		t.Errorf("Expected an unknown format to be rejected")
+	}
+}
+
+func TestHandlersApplyConfiguredLimits(t *testing.T) {
+	defaults := handlers.Config
+	defer func() { handlers.Config = defaults }()
+	handlers.Config = config.Default()
+	handlers.Config.Limits.PostLength = 10
+	handlers.Config.Limits.ForumDescriptionLength = 5
+	handlers.Config.Limits.ForumSidebarLength = 5
+	handlers.Config.Features.GraphQL = false
+
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	w := call(http.MethodPost, "/api/posts", `{"forum_id":"f","author_id":"u","text":"more than ten characters"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"rule":"max"`) || !strings.Contains(w.Body.String(), "at most 10 characters") {
+		t.Errorf("Expected the configured post length to be enforced, got %d %s", w.Code, w.Body.String())
+	}
+	for _, field := range []string{"description", "sidebar"} {
+		w := call(http.MethodPut, "/api/forums/f/info", `{"moderator_id":"u","`+field+`":"longer than five"}`)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"`+field+`"`) || !strings.Contains(w.Body.String(), "at most 5 characters") {
+			t.Errorf("Expected the configured %s length to be enforced, got %d %s", field, w.Code, w.Body.String())
+		}
+	}
+	if w := call(http.MethodPost, "/api/graphql", `{"query":"{ posts { id } }"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected no GraphQL API when it is turned off, got %d", w.Code)
+	}
+}
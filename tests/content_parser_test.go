@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"reddit-clone/core/content"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func TestParseMentions(t *testing.T) {
+	text := "hey @alice, see r/golang and /r/rust_lang. mail bob@example.com @al cc u/carol"
+	mentions := content.Parse(text)
+
+	expected := []schemas.Mention{
+		{Kind: schemas.MentionUser, Name: "alice"},
+		{Kind: schemas.MentionForum, Name: "golang"},
+		{Kind: schemas.MentionForum, Name: "rust_lang"},
+		{Kind: schemas.MentionUser, Name: "carol"},
+	}
+	if len(mentions) != len(expected) {
+		t.Fatalf("Unexpected mention count. Got: %v, Expected: %v (%+v)", len(mentions), len(expected), mentions)
+	}
+	for i, m := range mentions {
+		if m.Kind != expected[i].Kind || m.Name != expected[i].Name {
+			t.Errorf("Unexpected mention %d. Got: %+v, Expected: %+v", i, m, expected[i])
+		}
+	}
+
+	if got := text[mentions[0].Start:mentions[0].End]; got != "@alice" {
+		t.Errorf("Unexpected user mention span. Got: %q", got)
+	}
+	if got := text[mentions[2].Start:mentions[2].End]; got != "/r/rust_lang" {
+		t.Errorf("Unexpected forum reference span. Got: %q", got)
+	}
+}
+
+func TestMentionSpans(t *testing.T) {
+	text := "ping @alice and @ghost in r/golang"
+	mentions := content.Parse(text)
+	for i := range mentions {
+		switch mentions[i].Name {
+		case "alice":
+			mentions[i].TargetID = "user_1"
+		case "golang":
+			mentions[i].TargetID = "subreddit_1"
+		}
+	}
+
+	spans := content.Spans(text, mentions)
+	expected := []content.Span{
+		{Text: "ping "},
+		{Text: "@alice", Href: "/users/user_1"},
+		{Text: " and @ghost in "},
+		{Text: "r/golang", Href: "/r/golang"},
+	}
+	if len(spans) != len(expected) {
+		t.Fatalf("Unexpected span count. Got: %+v", spans)
+	}
+	for i := range spans {
+		if spans[i] != expected[i] {
+			t.Errorf("Unexpected span %d. Got: %+v, Expected: %+v", i, spans[i], expected[i])
+		}
+	}
+
+	ids := content.MentionedUserIDs(mentions)
+	if len(ids) != 1 || ids[0] != "user_1" {
+		t.Errorf("Unexpected mentioned user IDs: %v", ids)
+	}
+}
+
+func TestMentionLookups(t *testing.T) {
+	system := actor.NewActorSystem()
+	memberManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewMemberManager()
+	}))
+	forumManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewForumManager()
+	}))
+
+	res, err := system.Root.RequestFuture(memberManager, &proto_actor.RegisterUser{
+		DisplayName: "Alice",
+	}, 3*time.Second).Result()
+	if err != nil {
+		t.Fatalf("RegisterUser failed: %v", err)
+	}
+	profile := res.(*schemas.Account)
+
+	res, err = system.Root.RequestFuture(memberManager, &proto_actor.FetchUserByName{
+		Username: "alice",
+	}, 3*time.Second).Result()
+	if err != nil {
+		t.Fatalf("FetchUserByName failed: %v", err)
+	}
+	found, ok := res.(*schemas.Account)
+	if !ok || found.ID != profile.ID {
+		t.Fatalf("Invalid response for FetchUserByName: %v", res)
+	}
+
+	_, err = system.Root.RequestFuture(forumManager, &proto_actor.AddForum{
+		Title:     "golang",
+		CreatorID: profile.ID,
+	}, 3*time.Second).Result()
+	if err != nil {
+		t.Fatalf("AddForum failed: %v", err)
+	}
+
+	res, err = system.Root.RequestFuture(forumManager, &proto_actor.RetrieveForumByName{
+		Name: "GoLang",
+	}, 3*time.Second).Result()
+	if err != nil {
+		t.Fatalf("RetrieveForumByName failed: %v", err)
+	}
+	if _, ok := res.(*schemas.Subreddit); !ok {
+		t.Fatalf("Invalid response for RetrieveForumByName: %v", res)
+	}
+}
+
+func TestNotificationManager(t *testing.T) {
+	system := actor.NewActorSystem()
+	notificationManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewNotificationManager()
+	}))
+
+	_, err := system.Root.RequestFuture(notificationManager, &proto_actor.PushNotification{
+		UserID:   "user_1",
+		Kind:     "mention",
+		SourceID: "post_1",
+		ActorID:  "user_2",
+	}, 3*time.Second).Result()
+	if err != nil {
+		t.Fatalf("PushNotification failed: %v", err)
+	}
+
+	res, err := system.Root.RequestFuture(notificationManager, &proto_actor.FetchNotifications{
+		UserID: "user_1",
+	}, 3*time.Second).Result()
+	if err != nil {
+		t.Fatalf("FetchNotifications failed: %v", err)
+	}
+
+	notifications, ok := res.([]*schemas.Notification)
+	if !ok || len(notifications) != 1 || notifications[0].SourceID != "post_1" {
+		t.Fatalf("Invalid response for FetchNotifications: %v", res)
+	}
+}
+
+// A reply shares no backing array with the inbox, so a caller appending to
+// it and a later notification do not overwrite each other.
+func TestFetchedNotificationsAreCopies(t *testing.T) {
+	system := actor.NewActorSystem()
+	notificationManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewNotificationManager()
+	}))
+	push := func(sourceID string) {
+		t.Helper()
+		if _, err := proto_actor.Ask(system.Root, notificationManager, &proto_actor.PushNotification{UserID: "user_1", Kind: "mention", SourceID: sourceID, ActorID: "user_2"}, time.Second); err != nil {
+			t.Fatalf("PushNotification failed: %v", err)
+		}
+	}
+
+	for _, sourceID := range []string{"post_1", "post_2", "post_3"} {
+		push(sourceID)
+	}
+	fetched, err := proto_actor.Ask(system.Root, notificationManager, &proto_actor.FetchNotifications{UserID: "user_1"}, time.Second)
+	if err != nil || len(fetched) != 3 {
+		t.Fatalf("FetchNotifications failed: %v %v", fetched, err)
+	}
+	mine := &schemas.Notification{SourceID: "mine"}
+	fetched = append(fetched, mine)
+	push("post_4")
+
+	if fetched[3] != mine {
+		t.Errorf("Expected the fetched notifications to stay as they were, got %+v", fetched[3])
+	}
+	inbox, _ := proto_actor.Ask(system.Root, notificationManager, &proto_actor.FetchNotifications{UserID: "user_1"}, time.Second)
+	if len(inbox) != 4 || inbox[3].SourceID != "post_4" {
+		t.Errorf("Expected the inbox to keep its own notifications, got %+v", inbox)
+	}
+}
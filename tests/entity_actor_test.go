@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"fmt"
+	"reddit-clone/core/ids"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func countActive(t *testing.T, system *actor.ActorSystem, pid *actor.PID) int {
+	t.Helper()
+	res, err := system.Root.RequestFuture(pid, &proto_actor.CountActive{}, 3*time.Second).Result()
+	if err != nil {
+		t.Fatalf("CountActive failed: %v", err)
+	}
+	return res.(int)
+}
+
+func waitForActive(t *testing.T, system *actor.ActorSystem, pid *actor.PID, want int) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for countActive(t, system, pid) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d active entities, got %d", want, countActive(t, system, pid))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPostActivationAndPassivation(t *testing.T) {
+	system := actor.NewActorSystem()
+	postManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewPostManager(proto_actor.WithPassivationTimeout(50 * time.Millisecond))
+	}))
+
+	res, _ := system.Root.RequestFuture(postManager, &proto_actor.AddPost{ForumID: "forum", AuthorID: "author", Text: "hello"}, 3*time.Second).Result()
+	post := res.(*schemas.Post)
+	if n := countActive(t, system, postManager); n != 0 {
+		t.Errorf("Creating a post should not activate it, got %d active", n)
+	}
+
+	res, _ = system.Root.RequestFuture(postManager, &proto_actor.VotePost{ContentID: post.ID, Upvote: true}, 3*time.Second).Result()
+	if voted, ok := res.(*schemas.Post); !ok || voted.Upvotes != 1 {
+		t.Fatalf("Invalid response for VotePost: %v", res)
+	}
+	if n := countActive(t, system, postManager); n != 1 {
+		t.Errorf("Voting should activate the post, got %d active", n)
+	}
+
+	waitForActive(t, system, postManager, 0)
+
+	// A fresh activation must see the state written by the passivated one.
+	res, _ = system.Root.RequestFuture(postManager, &proto_actor.VotePost{ContentID: post.ID, Upvote: true}, 3*time.Second).Result()
+	if voted, ok := res.(*schemas.Post); !ok || voted.Upvotes != 2 {
+		t.Fatalf("State lost across passivation: %v", res)
+	}
+
+	res, _ = system.Root.RequestFuture(postManager, &proto_actor.RetrievePost{ContentID: "post_missing"}, 3*time.Second).Result()
+	if err, ok := res.(*proto_actor.Error); !ok || err.Code != proto_actor.NotFound {
+		t.Errorf("Unknown post should respond NotFound, got %v", res)
+	}
+	if n := countActive(t, system, postManager); n != 1 {
+		t.Errorf("Unknown post should not be activated, got %d active", n)
+	}
+}
+
+func TestNoVotesLostDuringPassivation(t *testing.T) {
+	system := actor.NewActorSystem()
+	postManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewPostManager(proto_actor.WithPassivationTimeout(time.Millisecond))
+	}))
+	res, _ := system.Root.RequestFuture(postManager, &proto_actor.AddPost{ForumID: "forum", AuthorID: "author", Text: "hot"}, 3*time.Second).Result()
+	post := res.(*schemas.Post)
+
+	// With a 1ms idle timeout the post keeps passivating and reactivating
+	// between bursts of votes.
+	const voters, votes = 8, 50
+	var failures int32
+	var wg sync.WaitGroup
+	for v := 0; v < voters; v++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < votes; i++ {
+				if _, err := system.Root.RequestFuture(postManager, &proto_actor.VotePost{ContentID: post.ID, Upvote: true}, 3*time.Second).Result(); err != nil {
+					atomic.AddInt32(&failures, 1)
+				}
+				if i%10 == 0 {
+					time.Sleep(2 * time.Millisecond)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	res, _ = system.Root.RequestFuture(postManager, &proto_actor.RetrievePost{ContentID: post.ID}, 3*time.Second).Result()
+	if got := res.(*schemas.Post).Upvotes; got != voters*votes || failures != 0 {
+		t.Errorf("Expected %d upvotes and no failures, got %d upvotes and %d failures", voters*votes, got, failures)
+	}
+}
+
+func TestCommentThreadActivation(t *testing.T) {
+	system := actor.NewActorSystem()
+	commentService := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewCommentService(proto_actor.WithPassivationTimeout(50 * time.Millisecond))
+	}))
+
+	add := func(postID, parentID, content string) *schemas.Comment {
+		res, _ := system.Root.RequestFuture(commentService, &proto_actor.AddComment{PostID: postID, ParentID: parentID, AuthorID: "author", Content: content}, 3*time.Second).Result()
+		comment, ok := res.(*schemas.Comment)
+		if !ok {
+			t.Fatalf("AddComment failed: %v", res)
+		}
+		return comment
+	}
+	root := add("post_a", "", "root")
+	reply := add("", root.ID, "reply")
+	add("post_b", "", "other thread")
+	if reply.PostID != "post_a" {
+		t.Errorf("Reply did not join its parent's thread: %q", reply.PostID)
+	}
+	if n := countActive(t, system, commentService); n != 2 {
+		t.Errorf("Expected one activated thread per post, got %d", n)
+	}
+
+	waitForActive(t, system, commentService, 0)
+
+	res, _ := system.Root.RequestFuture(commentService, &proto_actor.FetchPostComments{PostID: "post_a"}, 3*time.Second).Result()
+	roots := res.([]*schemas.Comment)
+	if len(roots) != 1 || len(roots[0].Replies) != 1 || roots[0].Replies[0].ID != reply.ID {
+		t.Fatalf("Reply tree not rebuilt after passivation: %+v", roots)
+	}
+
+	res, _ = system.Root.RequestFuture(commentService, &proto_actor.VoteComment{CommentID: reply.ID, Upvote: true}, 3*time.Second).Result()
+	if voted, ok := res.(*schemas.Comment); !ok || voted.Upvotes != 1 {
+		t.Errorf("Invalid response for VoteComment: %v", res)
+	}
+	if n := countActive(t, system, commentService); n != 1 {
+		t.Errorf("Only the voted thread should be active, got %d", n)
+	}
+}
+
+// singleMailboxPostManager is the design the per-entity actors replaced:
+// one actor owns every post, so all votes queue in a single mailbox. It is
+// kept here only so BenchmarkPostVotes can compare the two. Like the
+// current managers it replies with copies.
+type singleMailboxPostManager struct {
+	posts map[string]*schemas.Post
+	ids   ids.Generator
+}
+
+func (pm *singleMailboxPostManager) Receive(ctx actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case *proto_actor.AddPost:
+		post := schemas.NewPost(pm.ids, msg.AuthorID, msg.ForumID, msg.Text, time.Now())
+		pm.posts[post.ID] = post
+		ctx.Respond(post.Clone())
+
+	case *proto_actor.VotePost:
+		post, exists := pm.posts[msg.ContentID]
+		if !exists {
+			ctx.Respond(proto_actor.ErrPostNotFound)
+			return
+		}
+		if msg.Upvote {
+			post.AddUpvote(time.Now())
+		} else {
+			post.AddDownvote(time.Now())
+		}
+		ctx.Respond(post.Clone())
+	}
+}
+
+// BenchmarkPostVotes measures vote throughput when many clients vote on
+// many different posts at once, for the single-mailbox manager and for the
+// per-post actors that can serve them in parallel.
+func BenchmarkPostVotes(b *testing.B) {
+	for _, design := range []struct {
+		name  string
+		props *actor.Props
+	}{
+		{"SingleMailbox", actor.PropsFromProducer(func() actor.Actor {
+			return &singleMailboxPostManager{posts: make(map[string]*schemas.Post), ids: ids.NewSequential()}
+		})},
+		{"EntityActors", actor.PropsFromProducer(func() actor.Actor {
+			return proto_actor.NewPostManager()
+		})},
+	} {
+		b.Run(design.name, func(b *testing.B) {
+			benchmarkPostVotes(b, design.props)
+		})
+	}
+}
+
+func benchmarkPostVotes(b *testing.B, props *actor.Props) {
+	system := actor.NewActorSystem()
+	postManager := system.Root.Spawn(props)
+	defer system.Root.Stop(postManager)
+
+	const posts = 1000
+	ids := make([]string, posts)
+	for i := range ids {
+		res, _ := system.Root.RequestFuture(postManager, &proto_actor.AddPost{
+			ForumID:  "bench",
+			AuthorID: fmt.Sprintf("author-%d", i%50),
+			Text:     "benchmark post",
+		}, 3*time.Second).Result()
+		ids[i] = res.(*schemas.Post).ID
+	}
+
+	var next uint64
+	b.SetParallelism(16)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			id := ids[atomic.AddUint64(&next, 1)%posts]
+			if _, err := system.Root.RequestFuture(postManager, &proto_actor.VotePost{ContentID: id, Upvote: true}, 3*time.Second).Result(); err != nil {
+				b.Fatalf("VotePost failed: %v", err)
+			}
+		}
+	})
+}
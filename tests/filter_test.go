@@ -0,0 +1,423 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/config"
+	"reddit-clone/core/content"
+	"reddit-clone/core/filter"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/handlers"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func TestFilters(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	alice := &schemas.Account{ID: "user_1"}
+	bob := &schemas.Account{ID: "user_2"}
+	forum := &schemas.Subreddit{ID: "subreddit_1", BannedWords: []string{"heck"}, BlockedDomains: []string{"*.spam.example"}}
+	pipeline := filter.New(filter.NewBannedWords([]string{"buy now"}), filter.NewBlockedDomains([]string{"evil.example"}))
+	duplicates := filter.NewDuplicates(time.Minute, fake)
+	pipeline.Use(duplicates, filter.NewLinks(2))
+	check := func(author *schemas.Account, kind, text string) filter.Decision {
+		return pipeline.Check(&filter.Content{Kind: kind, Author: author, Forum: forum, Text: text})
+	}
+
+	for _, test := range []struct {
+		text    string
+		verdict filter.Verdict
+		filter  string
+		reason  string
+	}{
+		{"Hello, world", filter.Allow, "", ""},
+		{"BUY   now!", filter.Reject, "banned_words", `contains the banned word "buy now"`},
+		{"buying nowhere", filter.Allow, "", ""},
+		{"what the heck", filter.Reject, "banned_words", `contains the banned word "heck"`},
+		{"see https://www.evil.example/x", filter.Reject, "blocked_domains", "links to the blocked domain www.evil.example"},
+		{"see https://notevil.example", filter.Allow, "", ""},
+		{"see www.a.spam.example and https://spam.example", filter.Reject, "blocked_domains", "links to the blocked domain www.a.spam.example"},
+		{"https://a.example https://b.example https://c.example", filter.Hold, "links", "has 3 links, more than 2"},
+	} {
+		d := check(alice, schemas.KindPost, test.text)
+		if d.Verdict != test.verdict || d.Filter != test.filter || d.Reason != test.reason {
+			t.Errorf("Expected %q to be %v by %q (%q), got %+v", test.text, test.verdict, test.filter, test.reason, d)
+		}
+	}
+	if d := pipeline.Check(&filter.Content{Kind: schemas.KindMessage, Author: alice, Text: "what the heck"}); d.Verdict != filter.Allow {
+		t.Errorf("Expected a forum's words not to apply outside it, got %+v", d)
+	}
+
+	// Repeats by the same author of the same kind are held within the window.
+	pipeline.Record(&filter.Content{Kind: schemas.KindPost, Author: alice, Forum: forum, Text: "Hello, world"})
+	if d := check(alice, schemas.KindPost, "hello world!!"); d.Verdict != filter.Hold || d.Filter != "duplicate" || d.Reason != "repeats a post by the same author from the last 1m0s" {
+		t.Errorf("Expected a repeat to be held, got %+v", d)
+	}
+	if d := check(bob, schemas.KindPost, "hello world"); d.Verdict != filter.Allow {
+		t.Errorf("Expected another author's post to pass, got %+v", d)
+	}
+	if d := check(alice, schemas.KindComment, "hello world"); d.Verdict != filter.Allow {
+		t.Errorf("Expected a comment repeating a post to pass, got %+v", d)
+	}
+	if d := check(alice, schemas.KindPost, "hello world heck"); d.Verdict != filter.Reject {
+		t.Errorf("Expected a rejection to win over a hold, got %+v", d)
+	}
+	fake.Advance(time.Minute)
+	later := &filter.Content{Kind: schemas.KindPost, Author: alice, Forum: forum, Text: "hello world"}
+	if d := pipeline.Check(later); d.Verdict != filter.Allow {
+		t.Errorf("Expected a repeat after the window to pass, got %+v", d)
+	}
+	pipeline.Release(later)
+
+	// Expired hashes are swept.
+	for i := 0; i < 1023; i++ {
+		duplicates.Record(&filter.Content{Kind: schemas.KindPost, Author: bob, Text: "same"})
+	}
+	if n := duplicates.Len(); n != 1 {
+		t.Errorf("Expected the expired hash to be swept, got %d", n)
+	}
+
+	if !content.MatchDomain("A.Example.com", ".example.com") || content.MatchDomain("badexample.com", "example.com") || content.MatchDomain("example.com", "*.") {
+		t.Errorf("MatchDomain matched the wrong hosts")
+	}
+	var zero filter.Pipeline
+	if d := zero.Check(&filter.Content{Text: "anything"}); d.Verdict != filter.Allow {
+		t.Errorf("Expected the zero pipeline to allow everything, got %+v", d)
+	}
+}
+
+// Check reserves what it allows, so of the same text submitted at once
+// only one passes, and a reservation lasts until it is recorded or the
+// content is released.
+func TestDuplicatesReserveWhatTheyAllow(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	alice := &schemas.Account{ID: "user_1"}
+	duplicates := filter.NewDuplicates(time.Minute, fake)
+	pipeline := filter.New(duplicates, filter.NewBannedWords([]string{"heck"}))
+
+	var allowed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if d := pipeline.Check(&filter.Content{Kind: schemas.KindPost, Author: alice, Text: "first!"}); d.Verdict == filter.Allow {
+				atomic.AddInt32(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 1 {
+		t.Errorf("Expected one of the concurrent repeats to pass, got %d", allowed)
+	}
+
+	post := &filter.Content{Kind: schemas.KindPost, Author: alice, Text: "second"}
+	repeat := &filter.Content{Kind: schemas.KindPost, Author: alice, Text: "second"}
+	if d := pipeline.Check(post); d.Verdict != filter.Allow {
+		t.Fatalf("Expected the post to pass, got %+v", d)
+	}
+	if d := pipeline.Check(repeat); d.Verdict != filter.Hold {
+		t.Errorf("Expected the repeat to be held while the post is reserved, got %+v", d)
+	}
+	pipeline.Release(repeat)
+	if d := pipeline.Check(repeat); d.Verdict != filter.Hold {
+		t.Errorf("Expected another content's release to keep the reservation, got %+v", d)
+	}
+	pipeline.Release(post)
+	if d := pipeline.Check(repeat); d.Verdict != filter.Allow {
+		t.Errorf("Expected a released post to be forgotten, got %+v", d)
+	}
+
+	before := duplicates.Len()
+	if d := pipeline.Check(&filter.Content{Kind: schemas.KindPost, Author: alice, Text: "what the heck"}); d.Verdict != filter.Reject {
+		t.Fatalf("Expected the post to be rejected, got %+v", d)
+	}
+	if n := duplicates.Len(); n != before {
+		t.Errorf("Expected a rejected post to be released, got %d hashes instead of %d", n, before)
+	}
+}
+
+func TestFiltersConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.Filters.BannedWords = []string{"spam"}
+	cfg.Filters.MaxLinks = 0
+	pipeline := cfg.Filters.Pipeline(clock.Real)
+	author := &schemas.Account{ID: "user_1"}
+	if d := pipeline.Check(&filter.Content{Kind: schemas.KindPost, Author: author, Text: "no spam please"}); d.Verdict != filter.Reject {
+		t.Errorf("Expected the configured word to be banned, got %+v", d)
+	}
+	if d := pipeline.Check(&filter.Content{Kind: schemas.KindPost, Author: author, Text: strings.Repeat("https://a.example ", 20)}); d.Verdict != filter.Allow {
+		t.Errorf("Expected no link limit, got %+v", d)
+	}
+
+	cfg.Filters.DuplicateWindow = -time.Second
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "filters.duplicate_window") {
+		t.Errorf("Expected a negative window to be refused, got %v", err)
+	}
+}
+
+// withContentFilter screens the content the test submits with pipeline.
+func withContentFilter(t *testing.T, pipeline *filter.Pipeline) {
+	handlers.ContentFilter = pipeline
+	t.Cleanup(func() { handlers.ContentFilter = nil })
+}
+
+func TestHeldContent(t *testing.T) {
+	withContentFilter(t, filter.New(filter.NewBannedWords([]string{"scam"}), filter.NewLinks(1)))
+	router := newTestRouterWithOptions(proto_actor.WithMessageModerators("carol"))
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("Unexpected response %s: %v", w.Body.String(), err)
+		}
+	}
+	queue := func(query string) []*templates.QueueItemResponse {
+		t.Helper()
+		var items []*templates.QueueItemResponse
+		decode(call(http.MethodGet, "/api/modqueue"+query, ""), &items)
+		return items
+	}
+	listed := func(path, id string) bool {
+		t.Helper()
+		var posts []*templates.PostResponse
+		decode(call(http.MethodGet, path, ""), &posts)
+		for _, post := range posts {
+			if post.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	var alice, bob templates.AccountResponse
+	decode(call(http.MethodPost, "/api/users", `{"display_name":"alice"}`), &alice)
+	decode(call(http.MethodPost, "/api/users", `{"display_name":"bob"}`), &bob)
+	call(http.MethodPost, "/api/users", `{"display_name":"carol"}`)
+	var forum templates.SubredditResponse
+	decode(call(http.MethodPost, "/api/forums", `{"title":"golang","creator_id":"alice"}`), &forum)
+
+	// Rejected content is refused and never stored.
+	w := call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"alice","text":"a scam"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `the post contains the banned word \"scam\"`) {
+		t.Errorf("Expected the post to be rejected, got %d %s", w.Code, w.Body.String())
+	}
+
+	// A held post is answered as if accepted but kept out of listings.
+	var held templates.PostResponse
+	decode(call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"alice","text":"https://a.example https://b.example @bob"}`), &held)
+	if listed("/api/posts", held.ID) {
+		t.Errorf("Expected the held post to be kept out of the listing")
+	}
+	var fetched templates.PostResponse
+	decode(call(http.MethodGet, "/api/posts/"+held.ID, ""), &fetched)
+	items := queue("?forum_id=golang&moderator_id=alice")
+	if len(items) != 1 || items[0].Kind != "post" || items[0].ContentID != held.ID || items[0].ForumID != forum.ID || items[0].AuthorID != alice.ID || items[0].Filter != "links" || items[0].Reason != "has 2 links, more than 1" {
+		t.Fatalf("Expected the post in the modqueue, got %+v", items)
+	}
+	var notifications []*templates.NotificationResponse
+	decode(call(http.MethodGet, "/api/notifications?user_id=bob", ""), &notifications)
+	if len(notifications) != 0 {
+		t.Errorf("Expected no notification for a held post, got %+v", notifications)
+	}
+
+	// Only the forum's moderators may review its queue.
+	for _, query := range []string{"?forum_id=golang&moderator_id=bob", "?forum_id=golang&moderator_id=carol"} {
+		if w := call(http.MethodGet, "/api/modqueue"+query, ""); w.Code != http.StatusForbidden {
+			t.Errorf("Expected %s to be refused, got %d %s", query, w.Code, w.Body.String())
+		}
+	}
+	if w := call(http.MethodGet, "/api/modqueue?forum_id=golang", ""); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"moderator_id"`) {
+		t.Errorf("Expected a queue without a moderator to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/approve", `{"moderator_id":"bob"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a non-moderator's approval to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/remove", `{"moderator_id":"bob"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a non-moderator's removal to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	if listed("/api/posts", held.ID) || len(queue("?forum_id=golang&moderator_id=alice")) != 1 {
+		t.Errorf("Expected the refused resolutions to leave the post held")
+	}
+
+	// Approving it lists it and notifies the users it mentions.
+	var item templates.QueueItemResponse
+	decode(call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/approve", `{"moderator_id":"alice"}`), &item)
+	if item.ContentID != held.ID || !listed("/api/posts", held.ID) || len(queue("?forum_id=golang&moderator_id=alice")) != 0 {
+		t.Errorf("Expected the approved post to be listed, got %+v", item)
+	}
+	decode(call(http.MethodGet, "/api/notifications?user_id=bob", ""), &notifications)
+	if len(notifications) != 1 || notifications[0].SourceID != held.ID {
+		t.Errorf("Expected a notification once approved, got %+v", notifications)
+	}
+	if w := call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/approve", `{"moderator_id":"alice"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a resolved item to be gone, got %d %s", w.Code, w.Body.String())
+	}
+
+	// A held comment stays out of its thread until approved.
+	var comment templates.CommentResponse
+	decode(call(http.MethodPost, "/api/comments", `{"post_id":"`+held.ID+`","author_id":"bob","content":"https://a.example https://b.example"}`), &comment)
+	var thread templates.CommentPageResponse
+	decode(call(http.MethodGet, "/api/users/bob/comments", ""), &thread)
+	if thread.Total != 0 {
+		t.Errorf("Expected the held comment to be kept out of listings, got %+v", thread)
+	}
+	if w := call(http.MethodPost, "/api/comments", `{"parent_id":"`+comment.ID+`","author_id":"alice","content":"reply"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected a reply to a held comment to be stored, got %d %s", w.Code, w.Body.String())
+	}
+	items = queue("?forum_id=" + forum.ID + "&moderator_id=" + alice.ID)
+	if len(items) != 1 || items[0].Kind != "comment" || items[0].ContentID != comment.ID {
+		t.Fatalf("Expected the comment in the modqueue, got %+v", items)
+	}
+	decode(call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/approve", `{"moderator_id":"alice"}`), &item)
+	decode(call(http.MethodGet, "/api/users/bob/comments", ""), &thread)
+	if thread.Total != 1 || thread.Comments[0].ID != comment.ID {
+		t.Errorf("Expected the approved comment to be listed, got %+v", thread)
+	}
+
+	// A held message reaches its receiver only once approved.
+	var message templates.MessageResponse
+	decode(call(http.MethodPost, "/api/messages", `{"from_user_id":"alice","to_user_id":"bob","body":"https://a.example https://b.example"}`), &message)
+	var inbox []*templates.MessageResponse
+	decode(call(http.MethodGet, "/api/messages?user_id=bob", ""), &inbox)
+	if len(inbox) != 0 {
+		t.Errorf("Expected the held message to be kept from bob, got %+v", inbox)
+	}
+	if items := queue("?forum_id=golang&moderator_id=alice"); len(items) != 0 {
+		t.Errorf("Expected a forum's queue not to list messages, got %+v", items)
+	}
+	if w := call(http.MethodGet, "/api/modqueue?moderator_id=alice", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected held messages to be kept from a forum moderator, got %d %s", w.Code, w.Body.String())
+	}
+	items = queue("?moderator_id=carol")
+	if len(items) != 1 || items[0].Kind != "message" || items[0].ForumID != "" {
+		t.Fatalf("Expected the message in the modqueue, got %+v", items)
+	}
+	if w := call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/approve", `{"moderator_id":"alice"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a forum moderator's approval of a message to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	decode(call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/approve", `{"moderator_id":"carol"}`), &item)
+	decode(call(http.MethodGet, "/api/messages?user_id=bob", ""), &inbox)
+	if len(inbox) != 1 || inbox[0].ID != message.ID {
+		t.Errorf("Expected the approved message in bob's inbox, got %+v", inbox)
+	}
+
+	// A release that fails leaves the item queued to be resolved again.
+	decode(call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"bob","text":"https://a.example https://b.example"}`), &held)
+	items = queue("?forum_id=golang&moderator_id=alice")
+	posts := handlers.PostActor
+	handlers.PostActor = handlers.RootContext.Spawn(actor.PropsFromFunc(func(ctx actor.Context) {
+		if _, ok := ctx.Message().(*proto_actor.ApprovePost); ok {
+			ctx.Respond(proto_actor.Errorf(proto_actor.Unavailable, "posts are down"))
+		}
+	}))
+	w = call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/approve", `{"moderator_id":"alice"}`)
+	handlers.PostActor = posts
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the release to fail, got %d %s", w.Code, w.Body.String())
+	}
+	if retried := queue("?forum_id=golang&moderator_id=alice"); len(retried) != 1 || retried[0].ID != items[0].ID || !retried[0].Held {
+		t.Fatalf("Expected the item to stay queued, got %+v", retried)
+	}
+	decode(call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/approve", `{"moderator_id":"alice"}`), &item)
+	if !listed("/api/posts", held.ID) || len(queue("?forum_id=golang&moderator_id=alice")) != 0 {
+		t.Errorf("Expected the retried release to list the post")
+	}
+
+	// Removing held content deletes it.
+	decode(call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"bob","text":"https://a.example https://b.example"}`), &held)
+	items = queue("?forum_id=golang&moderator_id=alice")
+	if len(items) != 1 {
+		t.Fatalf("Expected one held post, got %+v", items)
+	}
+	decode(call(http.MethodPost, "/api/modqueue/"+items[0].ID+"/remove", `{"moderator_id":"alice"}`), &item)
+	if w := call(http.MethodGet, "/api/posts/"+held.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected the removed post to be gone, got %d %s", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodGet, "/api/modqueue?forum_id=nowhere&moderator_id=alice", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected an unknown forum to be refused, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestForumFilters(t *testing.T) {
+	withContentFilter(t, filter.New(filter.NewBannedWords(nil), filter.NewBlockedDomains(nil)))
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	call(http.MethodPost, "/api/users", `{"display_name":"alice"}`)
+	call(http.MethodPost, "/api/users", `{"display_name":"bob"}`)
+	call(http.MethodPost, "/api/forums", `{"title":"golang","creator_id":"alice"}`)
+	call(http.MethodPost, "/api/forums", `{"title":"rust","creator_id":"alice"}`)
+
+	w := call(http.MethodGet, "/api/forums/golang/filters", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"banned_words":[],"blocked_domains":[]`) {
+		t.Errorf("Expected empty filters, got %d %s", w.Code, w.Body.String())
+	}
+	w = call(http.MethodPut, "/api/forums/golang/filters", `{"moderator_id":"bob"}`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "moderators") {
+		t.Errorf("Expected a non-moderator to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	w = call(http.MethodPut, "/api/forums/golang/filters", `{"moderator_id":"alice","banned_words":[" Generics ","generics","go to"],"blocked_domains":["Spam.example"]}`)
+	var filters templates.ForumFiltersResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &filters); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Setting the filters failed: %d %s", w.Code, w.Body.String())
+	}
+	if strings.Join(filters.BannedWords, ",") != "generics,go to" || strings.Join(filters.BlockedDomains, ",") != "spam.example" {
+		t.Errorf("Expected normalized lists, got %+v", filters)
+	}
+	if w := call(http.MethodPut, "/api/forums/golang/filters", `{"moderator_id":"alice","banned_words":[" "]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a blank word to be refused, got %d %s", w.Code, w.Body.String())
+	}
+
+	for _, test := range []struct {
+		forum, text string
+		code        int
+	}{
+		{"golang", "I love generics", http.StatusBadRequest},
+		{"golang", "Go to https://www.spam.example", http.StatusBadRequest},
+		{"golang", "Go, to", http.StatusBadRequest},
+		{"golang", "going to", http.StatusOK},
+		{"rust", "I love generics", http.StatusOK},
+	} {
+		w := call(http.MethodPost, "/api/posts", `{"forum_id":"`+test.forum+`","author_id":"alice","text":"`+test.text+`"}`)
+		if w.Code != test.code {
+			t.Errorf("Expected %q in %s to get %d, got %d %s", test.text, test.forum, test.code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRejectedPages(t *testing.T) {
+	withContentFilter(t, filter.New(filter.NewBannedWords([]string{"scam"})))
+	b := &browser{t: t, router: newTestRouter()}
+	b.submit("/login", url.Values{"username": {"alice"}})
+	forumPath := b.submit("/forums", url.Values{"title": {"golang"}})
+
+	w := b.do(http.MethodPost, forumPath+"/posts", url.Values{"text": {"a scam"}})
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Not accepted: the post contains the banned word") {
+		t.Errorf("Expected the form to say why the post was refused, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/templates"
+	"strings"
+	"testing"
+)
+
+func TestForumInfo(t *testing.T) {
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("Unexpected response %s: %v", w.Body.String(), err)
+		}
+	}
+
+	var alice templates.AccountResponse
+	decode(call(http.MethodPost, "/api/users", `{"display_name":"alice"}`), &alice)
+	call(http.MethodPost, "/api/users", `{"display_name":"bob"}`)
+	var forum templates.SubredditResponse
+	decode(call(http.MethodPost, "/api/forums", `{"title":"golang","creator_id":"alice"}`), &forum)
+	if forum.CreatedBy != alice.ID || forum.Description != "" || len(forum.Rules) != 0 || forum.NSFW {
+		t.Errorf("Expected a bare forum created by alice, got %+v", forum)
+	}
+	if w := call(http.MethodPost, "/api/forums", `{"title":"other","creator_id":"nobody"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected an unknown creator to be refused, got %d %s", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{
+		`{"description":"Go"}`,
+		`{"moderator_id":"alice","banner_url":"ftp://example.com/b.png"}`,
+		`{"moderator_id":"alice","rules":[{"title":" "}]}`,
+		`{"moderator_id":"alice","description":"` + strings.Repeat("d", 501) + `"}`,
+		`{"moderator_id":"alice","rules":[` + strings.Repeat(`{"title":"r"},`, 15) + `{"title":"r"}]}`,
+	} {
+		if w := call(http.MethodPut, "/api/forums/golang/info", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be refused, got %d %s", body, w.Code, w.Body.String())
+		}
+	}
+	w := call(http.MethodPut, "/api/forums/golang/info", `{"moderator_id":"bob","description":"Mine now"}`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "moderators") {
+		t.Errorf("Expected a non-moderator to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	decode(call(http.MethodPut, "/api/forums/golang/info", `{
+		"moderator_id": "alice",
+		"description": " All about Go ",
+		"sidebar": "See **the tour**.",
+		"rules": [{"title": "Be kind"}, {"title": "No spam", "description": "Self-promotion included."}],
+		"icon_url": "https://example.com/gopher.png",
+		"nsfw": true
+	}`), &forum)
+	decode(call(http.MethodGet, "/api/forums/golang", ""), &forum)
+	if forum.Description != "All about Go" || forum.SidebarHTML != "<p>See <strong>the tour</strong>.</p>\n" || forum.IconURL != "https://example.com/gopher.png" || !forum.NSFW {
+		t.Errorf("Expected the forum's info, got %+v", forum)
+	}
+	if len(forum.Rules) != 2 || forum.Rules[1].Number != 2 || forum.Rules[1].Title != "No spam" || forum.Rules[1].Description != "Self-promotion included." {
+		t.Errorf("Expected two numbered rules, got %+v", forum.Rules)
+	}
+
+	// Reports cite the forum's rules by number.
+	var post templates.PostResponse
+	decode(call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"alice","text":"Buy my course"}`), &post)
+	var item templates.QueueItemResponse
+	decode(call(http.MethodPost, "/api/posts/"+post.ID+"/report", `{"reporter_id":"bob","rule":2,"reason":"again"}`), &item)
+	if item.ContentID != post.ID || item.ForumID != forum.ID || item.Filter != "report" || item.Held || item.Reason != "Rule 2: No spam (again)" {
+		t.Errorf("Expected a report citing rule 2, got %+v", item)
+	}
+	var comment templates.CommentResponse
+	decode(call(http.MethodPost, "/api/comments", `{"post_id":"`+post.ID+`","author_id":"alice","content":"Really"}`), &comment)
+	decode(call(http.MethodPost, "/api/comments/"+comment.ID+"/report", `{"reporter_id":"bob","reason":"rude"}`), &item)
+	if item.Kind != "comment" || item.ForumID != forum.ID || item.Reason != "rude" {
+		t.Errorf("Expected a report in the post's forum, got %+v", item)
+	}
+	w = call(http.MethodPost, "/api/posts/"+post.ID+"/report", `{"reporter_id":"bob","rule":3}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "r/golang has no rule 3") || !strings.Contains(w.Body.String(), `"field":"rule"`) {
+		t.Errorf("Expected an unknown rule to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/posts/"+post.ID+"/report", `{"reporter_id":"bob"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a report without a reason to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	var items []*templates.QueueItemResponse
+	decode(call(http.MethodGet, "/api/modqueue?forum_id=golang&moderator_id=alice", ""), &items)
+	if len(items) != 2 {
+		t.Errorf("Expected both reports queued, got %+v", items)
+	}
+
+	// Reporters must be able to read what they report.
+	call(http.MethodPut, "/api/forums/golang/type", `{"type":"private","moderator_id":"alice"}`)
+	if w := call(http.MethodPost, "/api/posts/"+post.ID+"/report", `{"reporter_id":"bob","rule":1}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected the report to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	call(http.MethodPut, "/api/forums/golang/type", `{"type":"public","moderator_id":"alice"}`)
+
+	// The forum page and GraphQL show the info too.
+	body := call(http.MethodGet, "/r/golang", "").Body.String()
+	for _, want := range []string{"All about Go", "<strong>the tour</strong>", "<strong>No spam</strong>", `class="nsfw"`, ">alice</a>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Forum page is missing %q", want)
+		}
+	}
+	w = call(http.MethodPost, "/api/graphql", `{"query":"{ forum(ref: \"golang\") { nsfw creator { username } rules { title } } }"}`)
+	if !strings.Contains(w.Body.String(), `"creator":{"username":"alice"}`) || !strings.Contains(w.Body.String(), `"rules":[{"title":"Be kind"},{"title":"No spam"}]`) {
+		t.Errorf("Expected GraphQL to return the forum's info, got %s", w.Body.String())
+	}
+
+	// Replacing the info empties what is left out.
+	forum = templates.SubredditResponse{}
+	decode(call(http.MethodPut, "/api/forums/golang/info", `{"moderator_id":"alice","description":"Go"}`), &forum)
+	if forum.Description != "Go" || forum.Sidebar != "" || len(forum.Rules) != 0 || forum.IconURL != "" || forum.NSFW {
+		t.Errorf("Expected the rest of the info to be emptied, got %+v", forum)
+	}
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func TestNormalizeForumName(t *testing.T) {
+	cases := []struct {
+		name, title, want string
+		err               error
+	}{
+		{"", "Go Programming", "go_programming", nil},
+		{"", "C++ & Rust!", "c_rust", nil},
+		{"GoLang", "Anything", "golang", nil},
+		{"", "x", "", proto_actor.ErrForumNameInvalid},
+		{"has space", "", "", proto_actor.ErrForumNameInvalid},
+		{"all", "", "", proto_actor.ErrForumNameReserved},
+		{"subreddit_1", "", "", proto_actor.ErrForumNameReserved},
+	}
+	for _, tc := range cases {
+		got, err := proto_actor.NormalizeForumName(tc.name, tc.title)
+		if got != tc.want || !errors.Is(err, tc.err) {
+			t.Errorf("NormalizeForumName(%q, %q) = %q, %v; expected %q, %v", tc.name, tc.title, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestUniqueForumNames(t *testing.T) {
+	system := actor.NewActorSystem()
+	forumManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewForumManager()
+	}))
+
+	res, _ := system.Root.RequestFuture(forumManager, &proto_actor.AddForum{Title: "Go Programming", CreatorID: "user_1"}, 3*time.Second).Result()
+	forum, ok := res.(*schemas.Subreddit)
+	if !ok || forum.Name != "go_programming" || forum.Title != "Go Programming" {
+		t.Fatalf("Invalid response for AddForum: %v", res)
+	}
+
+	res, _ = system.Root.RequestFuture(forumManager, &proto_actor.AddForum{Title: "GO programming", CreatorID: "user_1"}, 3*time.Second).Result()
+	if err, ok := res.(error); !ok || !errors.Is(err, proto_actor.ErrForumNameTaken) {
+		t.Errorf("Duplicate forum name accepted: %v", res)
+	}
+
+	res, _ = system.Root.RequestFuture(forumManager, &proto_actor.RenameForum{ForumID: forum.ID, Title: "Gophers", ModeratorID: "user_2"}, 3*time.Second).Result()
+	if !errors.Is(res.(error), proto_actor.ErrNotModerator) {
+		t.Errorf("Rename by a non-moderator accepted: %v", res)
+	}
+	res, _ = system.Root.RequestFuture(forumManager, &proto_actor.RenameForum{ForumID: forum.ID, Title: "Gophers", ModeratorID: "user_1"}, 3*time.Second).Result()
+	if renamed, ok := res.(*schemas.Subreddit); !ok || renamed.Title != "Gophers" || renamed.Name != "go_programming" {
+		t.Errorf("Rename changed the canonical name or failed: %v", res)
+	}
+
+	res, _ = system.Root.RequestFuture(forumManager, &proto_actor.RetrieveForumByName{Name: "Go_Programming"}, 3*time.Second).Result()
+	if found, ok := res.(*schemas.Subreddit); !ok || found.ID != forum.ID {
+		t.Errorf("Lookup by name failed: %v", res)
+	}
+
+	system.Root.RequestFuture(forumManager, &proto_actor.RemoveForum{ForumID: forum.ID}, 3*time.Second).Result()
+	res, _ = system.Root.RequestFuture(forumManager, &proto_actor.AddForum{Title: "Go Programming", CreatorID: "user_1"}, 3*time.Second).Result()
+	if _, ok := res.(*schemas.Subreddit); !ok {
+		t.Errorf("Forum name was not released when the forum was removed: %v", res)
+	}
+}
+
+func TestForumNameRouting(t *testing.T) {
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := call(http.MethodPost, "/api/users", `{"display_name":"chef"}`); w.Code != http.StatusOK {
+		t.Fatalf("RegisterUser failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/forums", `{"title":"Cooking Tips","name":"cooking","creator_id":"chef"}`); w.Code != http.StatusOK {
+		t.Fatalf("AddForum failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/forums", `{"title":"More cooking","name":"Cooking","creator_id":"chef"}`); w.Code != http.StatusConflict {
+		t.Errorf("Duplicate forum name should conflict, got %d", w.Code)
+	}
+
+	call(http.MethodPost, "/api/users", `{"display_name":"guest"}`)
+	if w := call(http.MethodPatch, "/api/forums/cooking", `{"title":"Home Cooking"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Rename without a moderator should be invalid, got %d", w.Code)
+	}
+	if w := call(http.MethodPatch, "/api/forums/cooking", `{"title":"Home Cooking","moderator_id":"guest"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Rename by a non-moderator should be forbidden, got %d", w.Code)
+	}
+	w := call(http.MethodPatch, "/api/forums/cooking", `{"title":"Home Cooking","moderator_id":"chef"}`)
+	var forum struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &forum); err != nil || forum.Title != "Home Cooking" || forum.Name != "cooking" {
+		t.Fatalf("Rename failed: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := call(http.MethodGet, "/api/forums/by-name/COOKING", ""); w.Code != http.StatusOK {
+		t.Errorf("Lookup by name failed: %d", w.Code)
+	}
+	if w := call(http.MethodGet, "/api/forums/"+forum.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("Lookup by ID failed: %d", w.Code)
+	}
+	if w := call(http.MethodGet, "/api/forums/nope", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Unknown forum should 404, got %d", w.Code)
+	}
+
+	w = call(http.MethodGet, "/r/cooking", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Home Cooking") {
+		t.Errorf("Forum page by name failed: %d", w.Code)
+	}
+}
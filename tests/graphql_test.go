@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/handlers"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// requestCounter stands in front of a manager and counts the requests it
+// forwards to it, by message type.
+type requestCounter struct {
+	lock   sync.Mutex
+	counts map[string]int
+}
+
+func (rc *requestCounter) proxy(target *actor.PID) *actor.PID {
+	return handlers.RootContext.Spawn(actor.PropsFromFunc(func(ctx actor.Context) {
+		switch ctx.Message().(type) {
+		case *actor.Started, *actor.Stopping, *actor.Stopped, *actor.Restarting:
+			return
+		}
+		rc.lock.Lock()
+		rc.counts[fmt.Sprintf("%T", ctx.Message())]++
+		rc.lock.Unlock()
+		ctx.Forward(target)
+	}))
+}
+
+func (rc *requestCounter) count(message string) int {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	return rc.counts["*proto_actor."+message]
+}
+
+type graphqlResult struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code string `json:"code"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func TestGraphQLBatchesManagerRequests(t *testing.T) {
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	mustCall := func(path, body string) map[string]interface{} {
+		w := call(http.MethodPost, path, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("POST %s failed: %d %s", path, w.Code, w.Body.String())
+		}
+		var reply map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &reply)
+		return reply
+	}
+	query := func(q string) (*httptest.ResponseRecorder, graphqlResult) {
+		body, _ := json.Marshal(map[string]string{"query": q})
+		w := call(http.MethodPost, "/api/graphql", string(body))
+		var result graphqlResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Unreadable GraphQL response: %s", w.Body.String())
+		}
+		return w, result
+	}
+
+	users := []string{"gina", "hank", "ivan", "june"}
+	for _, name := range users {
+		mustCall("/api/users", `{"display_name":"`+name+`"}`)
+	}
+	mustCall("/api/forums", `{"title":"graphs","creator_id":"gina"}`)
+	mustCall("/api/forums", `{"title":"trees","creator_id":"gina"}`)
+	for i, name := range users {
+		forum := []string{"graphs", "trees"}[i%2]
+		post := mustCall("/api/posts", fmt.Sprintf(`{"forum_id":%q,"author_id":%q,"text":"post %d"}`, forum, name, i))
+		comment := mustCall("/api/comments", fmt.Sprintf(`{"post_id":%q,"author_id":%q,"content":"comment %d"}`, post["id"], users[(i+1)%4], i))
+		mustCall("/api/comments", fmt.Sprintf(`{"parent_id":%q,"author_id":%q,"content":"reply %d"}`, comment["id"], users[(i+2)%4], i))
+	}
+
+	counter := &requestCounter{counts: map[string]int{}}
+	handlers.UserActor = counter.proxy(handlers.UserActor)
+	handlers.SubredditActor = counter.proxy(handlers.SubredditActor)
+	handlers.PostActor = counter.proxy(handlers.PostActor)
+	handlers.CommentActor = counter.proxy(handlers.CommentActor)
+
+	w, result := query(`{
+		posts(first: 10) {
+			content
+			author { username karma }
+			forum { name }
+			comments {
+				content
+				author { username }
+				post { id }
+				replies { content author { username } parent { content } }
+			}
+		}
+	}`)
+	if w.Code != http.StatusOK || len(result.Errors) != 0 {
+		t.Fatalf("Query failed: %d %s", w.Code, w.Body.String())
+	}
+
+	var data struct {
+		Posts []struct {
+			Content  string
+			Author   struct{ Username string }
+			Forum    struct{ Name string }
+			Comments []struct {
+				Content string
+				Author  struct{ Username string }
+				Replies []struct {
+					Content string
+					Author  struct{ Username string }
+					Parent  struct{ Content string }
+				}
+			}
+		}
+	}
+	json.Unmarshal(result.Data["posts"], &data.Posts)
+	if len(data.Posts) != 4 {
+		t.Fatalf("Expected 4 posts, got %s", result.Data["posts"])
+	}
+	// Newest first: the last post was written by june in trees.
+	last := data.Posts[0]
+	if last.Content != "post 3" || last.Author.Username != "june" || last.Forum.Name != "trees" {
+		t.Errorf("Unexpected newest post: %+v", last)
+	}
+	if len(last.Comments) != 1 || last.Comments[0].Author.Username != "gina" ||
+		len(last.Comments[0].Replies) != 1 || last.Comments[0].Replies[0].Author.Username != "hank" ||
+		last.Comments[0].Replies[0].Parent.Content != "comment 3" {
+		t.Errorf("Unexpected thread: %+v", last.Comments)
+	}
+
+	// One request per level for each kind of entity, and none per item.
+	for message, want := range map[string]int{
+		"RetrieveAllPosts":  1,
+		"RetrieveForums":    1,
+		"FetchThreads":      1,
+		"FetchUser":         0,
+		"RetrieveForum":     0,
+		"RetrievePost":      0,
+		"RetrievePosts":     0,
+		"FetchPostComments": 0,
+	} {
+		if got := counter.count(message); got != want {
+			t.Errorf("Expected %d %s requests, got %d", want, message, got)
+		}
+	}
+
+	// Authors appear at three levels, so at most three user lookups.
+	lookups := counter.count("FetchUsers")
+	if lookups < 1 || lookups > 3 {
+		t.Errorf("Expected one to three FetchUsers requests, got %d", lookups)
+	}
+
+	// Loaders are per request: the same query fetches afresh.
+	query(`{ posts(first: 1) { author { username } } }`)
+	if got := counter.count("FetchUsers"); got != lookups+1 {
+		t.Errorf("Expected the second query to fetch its authors, got %d FetchUsers in total", got)
+	}
+}
+
+func TestGraphQLLookupsAndErrors(t *testing.T) {
+	router := newTestRouter()
+	query := func(method, q string) (*httptest.ResponseRecorder, graphqlResult) {
+		var req *http.Request
+		if method == http.MethodGet {
+			req = httptest.NewRequest(method, "/api/graphql?query="+strings.ReplaceAll(q, " ", "+"), nil)
+		} else {
+			body, _ := json.Marshal(map[string]string{"query": q})
+			req = httptest.NewRequest(method, "/api/graphql", strings.NewReader(string(body)))
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var result graphqlResult
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return w, result
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(`{"display_name":"kate"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	w, result := query(http.MethodGet, `{ user(ref: "kate") { username karma } post(id: "post_missing") { id } }`)
+	if w.Code != http.StatusOK || len(result.Errors) != 0 || string(result.Data["post"]) != "null" ||
+		!strings.Contains(string(result.Data["user"]), `"username":"kate"`) {
+		t.Errorf("Unexpected lookup result: %d %s", w.Code, w.Body.String())
+	}
+
+	w, result = query(http.MethodPost, `{ user(ref: "kate") { posts(first: 0) { id } } }`)
+	if w.Code != http.StatusOK || len(result.Errors) != 1 || result.Errors[0].Extensions.Code != "invalid" {
+		t.Errorf("Expected an invalid argument error, got %d %s", w.Code, w.Body.String())
+	}
+
+	for _, tc := range []struct {
+		name, query, reason string
+	}{
+		{"syntax", `{ posts { id }`, "Syntax Error"},
+		{"unknown field", `{ posts { title } }`, "Cannot query field"},
+		{"depth", `{ posts { comments { replies { replies { replies { replies { replies { replies { replies { replies { id } } } } } } } } } } }`, "depth"},
+		{"complexity", `{ posts(first: 100) { author { posts(first: 100) { comments { author { username karma bio } } } } } }`, "complexity"},
+	} {
+		w, result := query(http.MethodPost, tc.query)
+		if w.Code != http.StatusBadRequest || len(result.Errors) == 0 || !strings.Contains(result.Errors[0].Message, tc.reason) || result.Data != nil {
+			t.Errorf("%s: expected a 400 mentioning %q, got %d %s", tc.name, tc.reason, w.Code, w.Body.String())
+		}
+	}
+
+	// Introspection is not limited by depth.
+	w, _ = query(http.MethodPost, `{ __schema { types { name fields { name type { name ofType { name ofType { name ofType { name ofType { name ofType { name ofType { name } } } } } } } } } } }`)
+	if w.Code != http.StatusOK {
+		t.Errorf("Introspection failed: %d %s", w.Code, w.Body.String())
+	}
+
+	if w, _ := query(http.MethodPost, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a missing query to be rejected, got %d", w.Code)
+	}
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"reddit-clone/core/proto_actors/wire"
+	"reddit-clone/handlers"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCClient serves the gRPC API over an in-memory listener, backed by the
+// same managers as newTestRouter.
+func newGRPCClient(t *testing.T) wire.RedditClient {
+	t.Helper()
+	newTestRouter()
+
+	listener := bufconn.Listen(1 << 20)
+	server := handlers.NewGRPCServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return wire.NewRedditClient(conn)
+}
+
+func expectCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Errorf("Expected %v, got %v (%v)", want, got, err)
+	}
+}
+
+func TestGRPCPostsAndComments(t *testing.T) {
+	client := newGRPCClient(t)
+	ctx := context.Background()
+
+	alice, err := client.RegisterUser(ctx, &wire.RegisterUser{DisplayName: "alice"})
+	if err != nil {
+		t.Fatalf("RegisterUser failed: %v", err)
+	}
+	bob, err := client.RegisterUser(ctx, &wire.RegisterUser{DisplayName: "bob"})
+	if err != nil {
+		t.Fatalf("RegisterUser failed: %v", err)
+	}
+	if _, err := client.AddForum(ctx, &wire.AddForum{Title: "golang"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a forum without a creator to be refused, got %v", err)
+	}
+	if _, err := client.AddForum(ctx, &wire.AddForum{Title: "golang", CreatorId: alice.Id}); err != nil {
+		t.Fatalf("AddForum failed: %v", err)
+	}
+	if _, err := client.RenameForum(ctx, &wire.RenameForum{ForumId: "golang", Title: "Go"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a rename without a moderator to be refused, got %v", err)
+	}
+	if _, err := client.RenameForum(ctx, &wire.RenameForum{ForumId: "golang", Title: "Go", ModeratorId: "bob"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a rename by a non-moderator to be refused, got %v", err)
+	}
+	if forum, err := client.RenameForum(ctx, &wire.RenameForum{ForumId: "golang", Title: "Go", ModeratorId: "alice"}); err != nil || forum.Title != "Go" {
+		t.Errorf("RenameForum failed: %v %v", forum, err)
+	}
+
+	// Users and forums may be named instead of identified.
+	post, err := client.SubmitPost(ctx, &wire.AddPost{AuthorId: "alice", ForumId: "golang", Text: "hello u/bob"})
+	if err != nil {
+		t.Fatalf("SubmitPost failed: %v", err)
+	}
+	if post.AuthorId != alice.Id || len(post.Mentions) != 1 || post.Mentions[0].TargetId != bob.Id || post.CreatedAt == nil {
+		t.Errorf("Unexpected post: %v", post)
+	}
+
+	voted, err := client.VotePost(ctx, &wire.VotePost{ContentId: post.Id, Upvote: true})
+	if err != nil || voted.Upvotes != 1 {
+		t.Fatalf("VotePost failed: %v %v", voted, err)
+	}
+
+	comment, err := client.AddComment(ctx, &wire.AddComment{PostId: post.Id, AuthorId: bob.Id, Content: "hi"})
+	if err != nil {
+		t.Fatalf("AddComment failed: %v", err)
+	}
+	if _, err := client.AddComment(ctx, &wire.AddComment{ParentId: comment.Id, AuthorId: "alice", Content: "reply"}); err != nil {
+		t.Fatalf("Reply failed: %v", err)
+	}
+	fetched, err := client.GetComment(ctx, &wire.FetchComment{CommentId: comment.Id})
+	if err != nil || len(fetched.Replies) != 1 {
+		t.Errorf("GetComment failed: %v %v", fetched, err)
+	}
+
+	listing, err := client.ListUserPosts(ctx, &wire.RetrieveAuthorPosts{AuthorId: "alice", Page: &wire.Page{Limit: 10}})
+	if err != nil || listing.Total != 1 || listing.Posts[0].Id != post.Id {
+		t.Errorf("ListUserPosts failed: %v %v", listing, err)
+	}
+	notifications, err := client.ListNotifications(ctx, &wire.FetchNotifications{UserId: "bob"})
+	if err != nil || len(notifications.Notifications) != 1 {
+		t.Errorf("Expected one mention notification, got %v %v", notifications, err)
+	}
+
+	if _, err := client.DeletePost(ctx, &wire.RemovePost{ContentId: post.Id}); err != nil {
+		t.Fatalf("DeletePost failed: %v", err)
+	}
+	_, err = client.GetPost(ctx, &wire.RetrievePost{ContentId: post.Id})
+	expectCode(t, err, codes.NotFound)
+}
+
+func TestGRPCErrorCodes(t *testing.T) {
+	client := newGRPCClient(t)
+	ctx := context.Background()
+
+	if _, err := client.RegisterUser(ctx, &wire.RegisterUser{DisplayName: "carol"}); err != nil {
+		t.Fatalf("RegisterUser failed: %v", err)
+	}
+
+	_, err := client.RegisterUser(ctx, &wire.RegisterUser{DisplayName: "Carol"})
+	expectCode(t, err, codes.AlreadyExists)
+	_, err = client.RegisterUser(ctx, &wire.RegisterUser{DisplayName: "no spaces"})
+	expectCode(t, err, codes.InvalidArgument)
+	_, err = client.GetUser(ctx, &wire.FetchUser{ProfileId: "nobody"})
+	expectCode(t, err, codes.NotFound)
+	_, err = client.SubmitPost(ctx, &wire.AddPost{AuthorId: "carol", ForumId: "missing", Text: "hello"})
+	expectCode(t, err, codes.NotFound)
+	_, err = client.SubmitPost(ctx, &wire.AddPost{AuthorId: "carol", ForumId: "missing", Text: " "})
+	expectCode(t, err, codes.InvalidArgument)
+	_, err = client.ListUserPosts(ctx, &wire.RetrieveAuthorPosts{AuthorId: "carol", Page: &wire.Page{Sort: "random"}})
+	expectCode(t, err, codes.InvalidArgument)
+	_, err = client.UpdateProfile(ctx, &wire.UpdateProfile{ProfileId: "carol", AvatarUrl: "ftp://example.com/a.png"})
+	expectCode(t, err, codes.InvalidArgument)
+
+	messages, err := client.ListMessages(ctx, &wire.FetchMessages{UserId: "carol"})
+	if err != nil || len(messages.Messages) != 0 {
+		t.Errorf("Expected an empty inbox, got %v %v", messages, err)
+	}
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/ids"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func TestSnowflakeUniqueUnderConcurrency(t *testing.T) {
+	generator, err := ids.NewSnowflake(7)
+	if err != nil {
+		t.Fatalf("NewSnowflake failed: %v", err)
+	}
+
+	const workers, perWorker = 8, 5000
+	results := make([][]string, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				results[w] = append(results[w], generator.NewID("post"))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, workers*perWorker)
+	for _, batch := range results {
+		for i, id := range batch {
+			if seen[id] {
+				t.Fatalf("Duplicate ID generated: %s", id)
+			}
+			seen[id] = true
+			if i > 0 && id <= batch[i-1] {
+				t.Fatalf("IDs from one goroutine are not increasing: %s then %s", batch[i-1], id)
+			}
+		}
+	}
+}
+
+func TestSnowflakeClock(t *testing.T) {
+	fake := clock.NewFake(ids.Epoch.Add(time.Hour))
+	generator, _ := ids.NewSnowflakeWithClock(1, fake)
+
+	first := generator.NewID("user")
+	if stamp, err := ids.Timestamp(first); err != nil || !stamp.Equal(fake.Now()) {
+		t.Errorf("Unexpected timestamp for %s: %v %v", first, stamp, err)
+	}
+
+	// A clock that steps backwards must not produce smaller or repeated IDs.
+	fake.Advance(-time.Minute)
+	previous := first
+	for i := 0; i < 5000; i++ {
+		id := generator.NewID("user")
+		if id <= previous {
+			t.Fatalf("ID went backwards: %s then %s", previous, id)
+		}
+		previous = id
+	}
+
+	if _, err := ids.NewSnowflake(ids.MaxNode + 1); err != ids.ErrNodeOutOfRange {
+		t.Errorf("Out of range node accepted: %v", err)
+	}
+}
+
+func TestSequentialGenerator(t *testing.T) {
+	generator := ids.NewSequential()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	post := schemas.NewPost(generator, "author", "forum", "hello", now)
+	comment := schemas.NewComment(generator, "author", "hi", now)
+	if post.ID != "post_0000000000000000001" || comment.ID != "comment_0000000000000000002" {
+		t.Errorf("Unexpected deterministic IDs: %s %s", post.ID, comment.ID)
+	}
+}
+
+// Each set of managers takes its IDs from its own generator, which reaches
+// the entity actors too, so two sets do not share a sequence.
+func TestManagersUseTheirIDGenerator(t *testing.T) {
+	system := actor.NewActorSystem()
+	first := spawnManagers(t, system, proto_actor.WithIDGenerator(ids.NewSequential()))
+	second := spawnManagers(t, system, proto_actor.WithIDGenerator(ids.NewSequential()))
+
+	for _, managers := range []*proto_actor.Managers{first, second} {
+		user, err := proto_actor.Ask(system.Root, managers.Members, &proto_actor.RegisterUser{DisplayName: "alice"}, time.Second)
+		if err != nil {
+			t.Fatalf("RegisterUser failed: %v", err)
+		}
+		forum, err := proto_actor.Ask(system.Root, managers.Forums, &proto_actor.AddForum{Title: "golang", CreatorID: user.ID}, time.Second)
+		if err != nil {
+			t.Fatalf("AddForum failed: %v", err)
+		}
+		post, err := proto_actor.Ask(system.Root, managers.Posts, &proto_actor.AddPost{ForumID: forum.ID, AuthorID: user.ID, Text: "hello"}, time.Second)
+		if err != nil {
+			t.Fatalf("AddPost failed: %v", err)
+		}
+		comment, err := proto_actor.Ask(system.Root, managers.Comments, &proto_actor.AddComment{PostID: post.ID, AuthorID: user.ID, Content: "hi"}, time.Second)
+		if err != nil {
+			t.Fatalf("AddComment failed: %v", err)
+		}
+
+		got := strings.Join([]string{user.ID, forum.ID, post.ID, comment.ID}, ",")
+		if want := "user_0000000000000000001,subreddit_0000000000000000002,post_0000000000000000003,comment_0000000000000000004"; got != want {
+			t.Errorf("Unexpected IDs:\n got: %s\nwant: %s", got, want)
+		}
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	system := actor.NewActorSystem()
+	postManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewPostManager(proto_actor.WithIDGenerator(ids.NewSequential()))
+	}))
+	for i := 0; i < 5; i++ {
+		system.Root.RequestFuture(postManager, &proto_actor.AddPost{ForumID: "forum", AuthorID: "author", Text: "post"}, 3*time.Second).Result()
+	}
+
+	fetch := func(page proto_actor.Page) *proto_actor.PostListing {
+		res, _ := system.Root.RequestFuture(postManager, &proto_actor.RetrieveAuthorPosts{AuthorID: "author", Page: page}, 3*time.Second).Result()
+		return res.(*proto_actor.PostListing)
+	}
+
+	var collected []string
+	page := proto_actor.Page{Sort: proto_actor.SortNew, Limit: 2}
+	for {
+		listing := fetch(page)
+		for _, post := range listing.Posts {
+			collected = append(collected, post.ID)
+		}
+		if listing.NextCursor == "" {
+			break
+		}
+		// A post added mid-scan sorts before the cursor and must not shift
+		// the remaining pages.
+		system.Root.RequestFuture(postManager, &proto_actor.AddPost{ForumID: "forum", AuthorID: "author", Text: "late"}, 3*time.Second).Result()
+		page.After = listing.NextCursor
+	}
+
+	expected := []string{"post_0000000000000000005", "post_0000000000000000004", "post_0000000000000000003", "post_0000000000000000002", "post_0000000000000000001"}
+	if strings.Join(collected, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected cursor scan:\n got: %v\nwant: %v", collected, expected)
+	}
+
+	listing := fetch(proto_actor.Page{Sort: proto_actor.SortOld, Limit: 2, After: "post_0000000000000000002"})
+	if len(listing.Posts) != 2 || listing.Posts[0].ID != "post_0000000000000000003" || listing.NextCursor != "post_0000000000000000004" {
+		t.Errorf("Unexpected oldest-first cursor page: %+v next=%s", listing.Posts, listing.NextCursor)
+	}
+}
+
+func TestCursorPaginationEndpoint(t *testing.T) {
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	call(http.MethodPost, "/api/users", `{"display_name":"paula"}`)
+	call(http.MethodPost, "/api/forums", `{"title":"general","creator_id":"paula"}`)
+	for i := 0; i < 3; i++ {
+		call(http.MethodPost, "/api/posts", `{"forum_id":"general","author_id":"paula","text":"hello"}`)
+	}
+
+	var page struct {
+		Posts      []map[string]any `json:"posts"`
+		NextCursor string           `json:"next_cursor"`
+	}
+	w := call(http.MethodGet, "/api/users/paula/posts?limit=2", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || len(page.Posts) != 2 || page.NextCursor == "" {
+		t.Fatalf("Unexpected first page: %s", w.Body.String())
+	}
+
+	w = call(http.MethodGet, "/api/users/paula/posts?limit=2&after="+page.NextCursor, "")
+	page.NextCursor = ""
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || len(page.Posts) != 1 || page.NextCursor != "" {
+		t.Errorf("Unexpected second page: %s", w.Body.String())
+	}
+
+	for _, query := range []string{"after=bogus", "sort=top&after=post_0000000000000000001", "offset=1&after=post_0000000000000000001"} {
+		if w := call(http.MethodGet, "/api/users/paula/posts?"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("Invalid cursor query %q accepted: %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/core/logging"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/handlers"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/gin-gonic/gin"
+)
+
+// logBuffer collects log lines written from several goroutines.
+type logBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+// records decodes the JSON lines written so far.
+func (b *logBuffer) records(t *testing.T) []map[string]any {
+	t.Helper()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid JSON log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func (b *logBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestLoggerRedactsContentAndCredentials(t *testing.T) {
+	var out logBuffer
+	logger, err := logging.New(logging.Config{Level: "debug", Format: "json", Output: &out})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	logger.DebugContext(ctx, "submitted",
+		"post_id", "post_1",
+		"text", "my secret post",
+		"Password", "hunter2",
+		slog.Group("message", "body", "hello there", "to", "user_2"))
+
+	records := out.records(t)
+	if len(records) != 1 {
+		t.Fatalf("Expected one record, got %d", len(records))
+	}
+	record := records[0]
+	if record["request_id"] != "req-1" || record["post_id"] != "post_1" || record["level"] != "DEBUG" {
+		t.Errorf("Expected the request ID, post ID and level in %v", record)
+	}
+	if record["text"] != logging.Redacted || record["Password"] != logging.Redacted {
+		t.Errorf("Expected text and password to be redacted in %v", record)
+	}
+	if group, _ := record["message"].(map[string]any); group["body"] != logging.Redacted || group["to"] != "user_2" {
+		t.Errorf("Expected only the grouped body to be redacted in %v", record)
+	}
+	for _, secret := range []string{"my secret post", "hunter2", "hello there"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("Expected %q to be redacted", secret)
+		}
+	}
+
+	var text logBuffer
+	logger, err = logging.New(logging.Config{Level: "warn", Output: &text})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	logger.Info("dropped")
+	logger.Warn("kept", "content", "a comment")
+	if got := text.String(); strings.Contains(got, "dropped") || !strings.Contains(got, "msg=kept content="+logging.Redacted) {
+		t.Errorf("Expected only the warning, as text and redacted, got %q", got)
+	}
+
+	for _, config := range []logging.Config{{Level: "loud"}, {Format: "xml"}} {
+		if _, err := logging.New(config); err == nil {
+			t.Errorf("Expected %+v to be rejected", config)
+		}
+	}
+}
+
+func TestRequestIDsFollowRequestsIntoActors(t *testing.T) {
+	var out logBuffer
+	logger, err := logging.New(logging.Config{Level: "debug", Format: "json", Output: &out})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	system := actor.NewActorSystem()
+	managers := spawnManagers(t, system, proto_actor.WithInstrumentation(logging.Instrumentation{Logger: logger}))
+	handlers.RootContext = system.Root
+	handlers.UserActor = managers.Members
+	handlers.SubredditActor = managers.Forums
+	handlers.PostActor = managers.Posts
+	handlers.CommentActor = managers.Comments
+	handlers.MessageActor = managers.Messages
+	handlers.NotificationActor = managers.Notifications
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.RegisterRoutes(router)
+
+	call := func(method, path, body, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if id != "" {
+			req.Header.Set(logging.RequestIDHeader, id)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	for _, step := range []struct{ path, body string }{
+		{"/api/users", `{"display_name":"oskar"}`},
+		{"/api/forums", `{"title":"logs","creator_id":"oskar"}`},
+	} {
+		if w := call(http.MethodPost, step.path, step.body, ""); w.Code != http.StatusOK {
+			t.Fatalf("POST %s failed: %d %s", step.path, w.Code, w.Body.String())
+		}
+	}
+
+	w := call(http.MethodPost, "/api/posts", `{"forum_id":"logs","author_id":"oskar","text":"private thoughts"}`, "req-42")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Submitting failed: %d %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(logging.RequestIDHeader); got != "req-42" {
+		t.Errorf("Expected the request ID to be echoed, got %q", got)
+	}
+
+	has := func(want map[string]any) bool {
+		for _, record := range out.records(t) {
+			matches := true
+			for key, value := range want {
+				if record[key] != value {
+					matches = false
+				}
+			}
+			if matches {
+				return true
+			}
+		}
+		return false
+	}
+	for _, want := range []map[string]any{
+		{"msg": "request", "route": "/api/posts", "method": "POST", "status": float64(200), "request_id": "req-42"},
+		{"msg": "post submitted", "request_id": "req-42"},
+		{"msg": "message handled", "actor": "posts", "message": "AddPost", "request_id": "req-42"},
+		{"msg": "message handled", "actor": "members", "message": "FetchUserByName", "request_id": "req-42"},
+	} {
+		if !has(want) {
+			t.Errorf("Expected a record matching %v", want)
+		}
+	}
+	if strings.Contains(out.String(), "private thoughts") {
+		t.Errorf("Expected the post's text to stay out of the logs")
+	}
+
+	// The posts manager forwards a lookup to the post's entity, which logs
+	// it under the same request ID.
+	posts, err := proto_actor.Ask(system.Root, managers.Posts, &proto_actor.RetrieveAllPosts{}, time.Second)
+	if err != nil || len(posts) != 1 {
+		t.Fatalf("Expected one post, got %v (%v)", posts, err)
+	}
+	if w := call(http.MethodGet, "/api/posts/"+posts[0].ID, "", "req-43"); w.Code != http.StatusOK {
+		t.Fatalf("GET post failed: %d", w.Code)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !has(map[string]any{"msg": "message handled", "actor": "post", "message": "RetrievePost", "request_id": "req-43"}) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the post entity to log RetrievePost for req-43, got:\n%s", out.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Missing and unusable IDs are replaced by generated ones.
+	for _, id := range []string{"", "not a valid id!", strings.Repeat("x", 65)} {
+		w := call(http.MethodGet, "/api/posts", "", id)
+		got := w.Header().Get(logging.RequestIDHeader)
+		if got == "" || got == id || !logging.ValidRequestID(got) {
+			t.Errorf("Expected a generated request ID in place of %q, got %q", id, got)
+		}
+	}
+}
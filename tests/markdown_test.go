@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reddit-clone/core/content"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestRenderMarkdownGolden(t *testing.T) {
+	sources, err := filepath.Glob(filepath.Join("testdata", "markdown", "*.md"))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	if len(sources) == 0 {
+		t.Fatalf("No markdown corpus found")
+	}
+
+	for _, source := range sources {
+		name := strings.TrimSuffix(filepath.Base(source), ".md")
+		t.Run(name, func(t *testing.T) {
+			input, err := os.ReadFile(source)
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+
+			got := content.RenderMarkdown(string(input))
+			golden := strings.TrimSuffix(source, ".md") + ".html"
+
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatalf("WriteFile failed: %v", err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("Rendered HTML mismatch for %s.\nGot:\n%s\nExpected:\n%s", name, got, want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownSanitizes(t *testing.T) {
+	inputs := []string{
+		"<script>alert(1)</script>",
+		"[x](javascript:alert(1))",
+		"[x](JaVaScRiPt:alert(1))",
+		"<a href=\"https://example.com\" onclick=\"x\">y</a>",
+		"**<iframe src=x>**",
+	}
+	for _, input := range inputs {
+		got := content.RenderMarkdown(input)
+		lower := strings.ToLower(got)
+		for _, bad := range []string{"<script", "<iframe", "<a href=\"https://example.com\" onclick", "javascript:"} {
+			if strings.Contains(lower, bad) {
+				t.Errorf("Unsafe output for %q: %s", input, got)
+			}
+		}
+	}
+}
+
+func TestRenderMarkdownKeepsLinksOnSite(t *testing.T) {
+	for _, input := range []string{"[x](//evil.example)", `[x](/\evil.example)`} {
+		if got := content.RenderMarkdown(input); strings.Contains(got, "<a") {
+			t.Errorf("Expected %q to leave no link, got %s", input, got)
+		}
+	}
+	if got := content.RenderMarkdown("[x](/r/golang)"); !strings.Contains(got, `<a href="/r/golang"`) {
+		t.Errorf("Expected a site-relative link, got %s", got)
+	}
+}
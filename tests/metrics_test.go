@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/core/metrics"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/handlers"
+	"reddit-clone/schemas"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	system := actor.NewActorSystem()
+	storage := proto_actor.NewStorage()
+	registry := metrics.New()
+	registry.ObserveStorage(storage)
+	defer system.EventStream.Unsubscribe(registry.WatchTimeouts(system))
+	managers := spawnManagers(t, system, proto_actor.WithStorage(storage), proto_actor.WithInstrumentation(registry))
+
+	handlers.RootContext = system.Root
+	handlers.UserActor = managers.Members
+	handlers.SubredditActor = managers.Forums
+	handlers.PostActor = managers.Posts
+	handlers.CommentActor = managers.Comments
+	handlers.MessageActor = managers.Messages
+	handlers.NotificationActor = managers.Notifications
+	handlers.Metrics = registry
+	defer func() { handlers.Metrics = nil }()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.RegisterRoutes(router)
+
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	for _, step := range []struct{ path, body string }{
+		{"/api/users", `{"display_name":"lena"}`},
+		{"/api/users", `{"display_name":"milo"}`},
+		{"/api/forums", `{"title":"metrics","creator_id":"lena"}`},
+		{"/api/posts", `{"forum_id":"metrics","author_id":"lena","text":"counted"}`},
+		{"/api/messages", `{"from_user_id":"lena","to_user_id":"milo","body":"hi"}`},
+	} {
+		if w := call(http.MethodPost, step.path, step.body); w.Code != http.StatusOK {
+			t.Fatalf("POST %s failed: %d %s", step.path, w.Code, w.Body.String())
+		}
+	}
+	posts, _ := proto_actor.Ask(system.Root, managers.Posts, &proto_actor.RetrieveAllPosts{}, time.Second)
+	if w := call(http.MethodPost, "/api/comments", fmt.Sprintf(`{"post_id":%q,"author_id":"milo","content":"me too"}`, posts[0].ID)); w.Code != http.StatusOK {
+		t.Fatalf("Commenting failed: %d %s", w.Code, w.Body.String())
+	}
+	call(http.MethodGet, "/api/posts/post_missing", "")
+	call(http.MethodGet, "/no/such/page", "")
+
+	// A request to an actor that never replies times out.
+	silent := system.Root.Spawn(actor.PropsFromFunc(func(actor.Context) {}))
+	if _, err := proto_actor.Ask[*schemas.Account](system.Root, silent, &proto_actor.FetchUser{ProfileID: "user_1"}, 10*time.Millisecond); proto_actor.CodeOf(err) != proto_actor.Unavailable {
+		t.Fatalf("Expected the request to time out, got %v", err)
+	}
+
+	w := call(http.MethodGet, "/metrics", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics failed: %d", w.Code)
+	}
+	exposition := w.Body.String()
+	for _, want := range []string{
+		`reddit_actor_messages_total{actor="members",message="RegisterUser"} 2`,
+		`reddit_actor_messages_total{actor="forums",message="AddForum"} 1`,
+		`reddit_actor_messages_total{actor="posts",message="AddPost"} 1`,
+		`reddit_actor_messages_total{actor="messages",message="SendMessage"} 1`,
+		`reddit_actor_message_duration_seconds_count{actor="comments",message="AddComment"} 1`,
+		`reddit_actor_mailbox_depth{actor="posts"}`,
+		`reddit_request_timeouts_total{message="FetchUser"} 1`,
+		`reddit_http_request_duration_seconds_count{method="POST",route="/api/posts",status="200"} 1`,
+		`reddit_http_request_duration_seconds_count{method="POST",route="/api/users",status="200"} 2`,
+		`reddit_http_request_duration_seconds_count{method="GET",route="/api/posts/:id",status="404"} 1`,
+		`reddit_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		"reddit_users 2",
+		"reddit_forums 1",
+		"reddit_posts 1",
+		"reddit_comments 1",
+		"reddit_messages 1",
+		"go_goroutines",
+	} {
+		if !strings.Contains(exposition, want) {
+			t.Errorf("Expected the metrics to contain %s", want)
+		}
+	}
+	// Lifecycle messages and per-entity IDs do not become labels.
+	for _, unwanted := range []string{`message="Started"`, posts[0].ID} {
+		if strings.Contains(exposition, unwanted) {
+			t.Errorf("Expected no %s in the metrics", unwanted)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/handlers"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter() *gin.Engine {
+	return newTestRouterWithClock(clock.Real)
+}
+
+func newTestRouterWithClock(c clock.Clock) *gin.Engine {
+	return newTestRouterWithOptions(proto_actor.WithClock(c))
+}
+
+func newTestRouterWithOptions(options ...proto_actor.Option) *gin.Engine {
+	system := actor.NewActorSystem()
+	managers, err := proto_actor.SpawnManagers(system.Root, options...)
+	if err != nil {
+		panic(err)
+	}
+
+	handlers.RootContext = system.Root
+	handlers.UserActor = managers.Members
+	handlers.SubredditActor = managers.Forums
+	handlers.PostActor = managers.Posts
+	handlers.CommentActor = managers.Comments
+	handlers.MessageActor = managers.Messages
+	handlers.NotificationActor = managers.Notifications
+	handlers.ModerationActor = managers.Moderation
+	handlers.AutoModeratorActor = managers.AutoModerator
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.RegisterRoutes(router)
+	return router
+}
+
+type browser struct {
+	t      *testing.T
+	router *gin.Engine
+	cookie *http.Cookie
+}
+
+func (b *browser) do(method, path string, form url.Values) *httptest.ResponseRecorder {
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	if b.cookie != nil {
+		req.AddCookie(b.cookie)
+	}
+
+	w := httptest.NewRecorder()
+	b.router.ServeHTTP(w, req)
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "user_id" {
+			b.cookie = cookie
+		}
+	}
+	return w
+}
+
+func (b *browser) submit(path string, form url.Values) string {
+	w := b.do(http.MethodPost, path, form)
+	if w.Code != http.StatusSeeOther {
+		b.t.Fatalf("POST %s: unexpected status %d: %s", path, w.Code, w.Body.String())
+	}
+	return w.Header().Get("Location")
+}
+
+func TestBrowserFlow(t *testing.T) {
+	b := &browser{t: t, router: newTestRouter()}
+
+	if w := b.do(http.MethodGet, "/inbox", nil); w.Code != http.StatusSeeOther {
+		t.Fatalf("Anonymous inbox should redirect to login, got %d", w.Code)
+	}
+
+	b.submit("/login", url.Values{"username": {"alice"}})
+	forumPath := b.submit("/forums", url.Values{"title": {"golang"}})
+	if forumPath != "/r/golang" {
+		t.Fatalf("Unexpected forum redirect: %s", forumPath)
+	}
+
+	postPath := b.submit(forumPath+"/posts", url.Values{"text": {"Hello **gophers** <b>x</b>"}})
+	if !strings.HasPrefix(postPath, "/posts/") {
+		t.Fatalf("Unexpected post redirect: %s", postPath)
+	}
+
+	b.submit(postPath+"/vote", url.Values{"direction": {"up"}})
+	commentPath := b.submit(postPath+"/comments", url.Values{"content": {"first comment"}})
+	commentID := commentPath[strings.Index(commentPath, "#")+1:]
+	b.submit(postPath+"/comments", url.Values{"content": {"a reply"}, "parent_id": {commentID}})
+
+	w := b.do(http.MethodGet, postPath, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Post page returned %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"<strong>gophers</strong>",
+		"&lt;b&gt;x&lt;/b&gt;",
+		`<span class="score">1</span>`,
+		"first comment",
+		"a reply",
+		">alice</a>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Post page is missing %q", want)
+		}
+	}
+	if strings.Index(body, "a reply") < strings.Index(body, "first comment") {
+		t.Errorf("Reply rendered before its parent comment")
+	}
+
+	w = b.do(http.MethodGet, "/", nil)
+	if !strings.Contains(w.Body.String(), "r/golang") {
+		t.Errorf("Front page does not list the forum")
+	}
+
+	profilePath := regexp.MustCompile(`/users/user_\d+`).FindString(body)
+	w = b.do(http.MethodGet, profilePath, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "1 karma") {
+		t.Errorf("Profile page does not show karma from the upvote: %d", w.Code)
+	}
+
+	if w := b.do(http.MethodGet, "/posts/post_missing", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Missing post should render 404, got %d", w.Code)
+	}
+}
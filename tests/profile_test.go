@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func TestAuthorPostHistory(t *testing.T) {
+	system := actor.NewActorSystem()
+	fake := clock.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	postManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewPostManager(proto_actor.WithClock(fake))
+	}))
+
+	var posts []*schemas.Post
+	for _, text := range []string{"first", "second", "third"} {
+		res, err := system.Root.RequestFuture(postManager, &proto_actor.AddPost{
+			ForumID:  "forum",
+			AuthorID: "author",
+			Text:     text,
+		}, 3*time.Second).Result()
+		if err != nil {
+			t.Fatalf("AddPost failed: %v", err)
+		}
+		posts = append(posts, res.(*schemas.Post))
+		fake.Advance(time.Minute)
+	}
+	if !posts[2].CreatedAt.Equal(posts[0].CreatedAt.Add(2 * time.Minute)) {
+		t.Errorf("Posts were not stamped with the injected clock: %v, %v", posts[0].CreatedAt, posts[2].CreatedAt)
+	}
+	system.Root.RequestFuture(postManager, &proto_actor.AddPost{ForumID: "forum", AuthorID: "other", Text: "x"}, 3*time.Second).Result()
+	system.Root.RequestFuture(postManager, &proto_actor.VotePost{ContentID: posts[1].ID, Upvote: true}, 3*time.Second).Result()
+
+	fetch := func(page proto_actor.Page) *proto_actor.PostListing {
+		res, err := system.Root.RequestFuture(postManager, &proto_actor.RetrieveAuthorPosts{
+			AuthorID: "author",
+			Page:     page,
+		}, 3*time.Second).Result()
+		if err != nil {
+			t.Fatalf("RetrieveAuthorPosts failed: %v", err)
+		}
+		listing, ok := res.(*proto_actor.PostListing)
+		if !ok {
+			t.Fatalf("Invalid response for RetrieveAuthorPosts: %v", res)
+		}
+		return listing
+	}
+
+	listing := fetch(proto_actor.Page{Sort: proto_actor.SortNew})
+	if listing.Total != 3 || listing.Posts[0].Content != "third" {
+		t.Errorf("Unexpected newest-first listing: total=%d first=%v", listing.Total, listing.Posts[0].Content)
+	}
+
+	listing = fetch(proto_actor.Page{Sort: proto_actor.SortTop, Limit: 1})
+	if len(listing.Posts) != 1 || listing.Posts[0].ID != posts[1].ID {
+		t.Errorf("Unexpected top listing: %+v", listing.Posts)
+	}
+
+	listing = fetch(proto_actor.Page{Sort: proto_actor.SortOld, Offset: 1, Limit: 5})
+	if len(listing.Posts) != 2 || listing.Posts[0].Content != "second" || listing.Offset != 1 {
+		t.Errorf("Unexpected paginated oldest-first listing: %+v", listing.Posts)
+	}
+
+	system.Root.RequestFuture(postManager, &proto_actor.RemovePost{ContentID: posts[0].ID}, 3*time.Second).Result()
+	listing = fetch(proto_actor.Page{})
+	if listing.Total != 2 {
+		t.Errorf("Removed post still listed in author history: total=%d", listing.Total)
+	}
+}
+
+func TestAuthorCommentHistory(t *testing.T) {
+	system := actor.NewActorSystem()
+	commentService := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewCommentService()
+	}))
+
+	res, _ := system.Root.RequestFuture(commentService, &proto_actor.AddComment{PostID: "post", AuthorID: "author", Content: "one"}, 3*time.Second).Result()
+	first := res.(*schemas.Comment)
+	system.Root.RequestFuture(commentService, &proto_actor.AddComment{PostID: "post", ParentID: first.ID, AuthorID: "author", Content: "two"}, 3*time.Second).Result()
+	system.Root.RequestFuture(commentService, &proto_actor.AddComment{PostID: "post", AuthorID: "other", Content: "three"}, 3*time.Second).Result()
+
+	res, err := system.Root.RequestFuture(commentService, &proto_actor.FetchAuthorComments{AuthorID: "author"}, 3*time.Second).Result()
+	if err != nil {
+		t.Fatalf("FetchAuthorComments failed: %v", err)
+	}
+	listing, ok := res.(*proto_actor.CommentListing)
+	if !ok || listing.Total != 2 {
+		t.Fatalf("Invalid response for FetchAuthorComments: %v", res)
+	}
+
+	system.Root.RequestFuture(commentService, &proto_actor.RemoveComment{CommentID: first.ID}, 3*time.Second).Result()
+	res, _ = system.Root.RequestFuture(commentService, &proto_actor.FetchAuthorComments{AuthorID: "author"}, 3*time.Second).Result()
+	if listing := res.(*proto_actor.CommentListing); listing.Total != 1 || listing.Comments[0].Content != "two" {
+		t.Errorf("Removed comment still listed in author history: %+v", listing.Comments)
+	}
+}
+
+func TestProfileEndpoints(t *testing.T) {
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call(http.MethodPost, "/api/users", `{"display_name":"carol"}`)
+	var account struct {
+		ID        string `json:"id"`
+		Bio       string `json:"bio"`
+		AvatarURL string `json:"avatar_url"`
+		CreatedAt string `json:"created_at"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &account); err != nil || account.ID == "" || account.CreatedAt == "" {
+		t.Fatalf("Unexpected register response: %s", w.Body.String())
+	}
+
+	w = call(http.MethodPatch, "/api/users/"+account.ID, `{"bio":"hi there","avatar_url":"https://example.com/a.png"}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &account); err != nil || account.Bio != "hi there" || account.AvatarURL != "https://example.com/a.png" {
+		t.Fatalf("Unexpected update response: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := call(http.MethodPatch, "/api/users/"+account.ID, `{"avatar_url":"javascript:alert(1)"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Unsafe avatar URL accepted: %d", w.Code)
+	}
+
+	call(http.MethodPost, "/api/forums", `{"title":"general","creator_id":"`+account.ID+`"}`)
+	call(http.MethodPost, "/api/posts", `{"forum_id":"general","author_id":"`+account.ID+`","text":"hello"}`)
+	w = call(http.MethodGet, "/api/users/"+account.ID+"/posts?sort=top&limit=10", "")
+	var posts struct {
+		Posts []map[string]any `json:"posts"`
+		Total int              `json:"total"`
+		Limit int              `json:"limit"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &posts); err != nil || posts.Total != 1 || posts.Limit != 10 {
+		t.Errorf("Unexpected user posts response: %s", w.Body.String())
+	}
+
+	if w := call(http.MethodGet, "/api/users/"+account.ID+"/comments?sort=random", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Unknown sort accepted: %d", w.Code)
+	}
+}
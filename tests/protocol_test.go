@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/core/proto_actors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func TestAskTypedReplies(t *testing.T) {
+	system := actor.NewActorSystem()
+	managers := spawnManagers(t, system)
+
+	forum, err := proto_actor.Ask(system.Root, managers.Forums, &proto_actor.AddForum{Title: "golang", CreatorID: "user_1"}, time.Second)
+	if err != nil || forum.Name != "golang" {
+		t.Fatalf("AddForum failed: %v %v", forum, err)
+	}
+
+	for name, tc := range map[string]struct {
+		err  error
+		want proto_actor.Code
+	}{
+		"missing forum":  {err: askErr(proto_actor.Ask(system.Root, managers.Forums, &proto_actor.RetrieveForum{ForumID: "subreddit_missing"}, time.Second)), want: proto_actor.NotFound},
+		"taken name":     {err: askErr(proto_actor.Ask(system.Root, managers.Forums, &proto_actor.AddForum{Title: "golang", CreatorID: "user_1"}, time.Second)), want: proto_actor.Conflict},
+		"no creator":     {err: askErr(proto_actor.Ask(system.Root, managers.Forums, &proto_actor.AddForum{Title: "rust"}, time.Second)), want: proto_actor.Invalid},
+		"invalid name":   {err: askErr(proto_actor.Ask(system.Root, managers.Members, &proto_actor.RegisterUser{DisplayName: "no spaces"}, time.Second)), want: proto_actor.Invalid},
+		"missing post":   {err: askErr(proto_actor.Ask(system.Root, managers.Posts, &proto_actor.RemovePost{ContentID: "post_missing"}, time.Second)), want: proto_actor.NotFound},
+		"missing parent": {err: askErr(proto_actor.Ask(system.Root, managers.Comments, &proto_actor.AddComment{ParentID: "comment_missing", Content: "hi"}, time.Second)), want: proto_actor.NotFound},
+	} {
+		if code := proto_actor.CodeOf(tc.err); tc.err == nil || code != tc.want {
+			t.Errorf("%s: expected %v, got %v (%v)", name, tc.want, code, tc.err)
+		}
+	}
+
+	if !errors.Is(askErr(proto_actor.Ask(system.Root, managers.Forums, &proto_actor.AddForum{Title: "golang", CreatorID: "user_1"}, time.Second)), proto_actor.ErrForumNameTaken) {
+		t.Errorf("Sentinel errors should survive the reply")
+	}
+}
+
+func askErr[T any](_ T, err error) error {
+	return err
+}
+
+func TestAskTimeoutIsUnavailable(t *testing.T) {
+	system := actor.NewActorSystem()
+	silent := system.Root.Spawn(actor.PropsFromFunc(func(ctx actor.Context) {}))
+
+	_, err := proto_actor.Ask(system.Root, silent, &proto_actor.RetrievePost{ContentID: "post_1"}, 20*time.Millisecond)
+	if proto_actor.CodeOf(err) != proto_actor.Unavailable {
+		t.Errorf("Expected Unavailable, got %v", err)
+	}
+}
+
+func TestErrorStatusMapping(t *testing.T) {
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := call(http.MethodPost, "/api/users", `{"display_name":"quinn"}`); w.Code != http.StatusOK {
+		t.Fatalf("RegisterUser failed: %d %s", w.Code, w.Body.String())
+	}
+
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{http.MethodGet, "/api/posts/post_missing", "", http.StatusNotFound, "not_found"},
+		{http.MethodDelete, "/api/comments/comment_missing", "", http.StatusNotFound, "not_found"},
+		{http.MethodDelete, "/api/messages/message_missing", "", http.StatusNotFound, "not_found"},
+		{http.MethodPost, "/api/users", `{"display_name":"Quinn"}`, http.StatusConflict, "conflict"},
+		{http.MethodPost, "/api/users", `{"display_name":"no spaces"}`, http.StatusBadRequest, "invalid"},
+		{http.MethodGet, "/api/users/nobody", "", http.StatusNotFound, "not_found"},
+	} {
+		w := call(tc.method, tc.path, tc.body)
+		var body struct {
+			Error string `json:"error"`
+			Code  string `json:"code"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != tc.status || body.Code != tc.code || body.Error == "" {
+			t.Errorf("%s %s: expected %d %s, got %d %s", tc.method, tc.path, tc.status, tc.code, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reddit-clone/core/clock"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/proto_actors/wire"
+	"reddit-clone/core/ratelimit"
+	"reddit-clone/handlers"
+	"reddit-clone/templates"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenBuckets(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter := ratelimit.New(ratelimit.Policy{
+		Rates: map[ratelimit.Action]ratelimit.Rate{
+			ratelimit.Post:  {Every: 10 * time.Second, Burst: 2},
+			ratelimit.Forum: {Every: time.Second, Burst: 3},
+		},
+		TrustedKarma:  100,
+		TrustedFactor: 2,
+	}, fake)
+	alice := ratelimit.Key{Action: ratelimit.Post, Subject: "alice"}
+	bob := ratelimit.Key{Action: ratelimit.Post, Subject: "bob"}
+	forum := ratelimit.Key{Action: ratelimit.Forum, Subject: "golang"}
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		d := limiter.Allow(false, alice)
+		if !d.Allowed || d.Limit != 2 || d.Remaining != remaining || d.RetryAfter != 0 {
+			t.Errorf("Expected a post with %d left, got %+v", remaining, d)
+		}
+	}
+	d := limiter.Allow(false, alice)
+	if d.Allowed || d.Key != alice || d.RetryAfter != 10*time.Second || d.Reset != 20*time.Second {
+		t.Errorf("Expected the third post to wait 10s, got %+v", d)
+	}
+	fake.Advance(4 * time.Second)
+	if d := limiter.Allow(false, alice); d.Allowed || d.RetryAfter != 6*time.Second {
+		t.Errorf("Expected 6s more to wait, got %+v", d)
+	}
+	fake.Advance(6 * time.Second)
+	if d := limiter.Allow(false, alice); !d.Allowed || d.Remaining != 0 || d.Reset != 20*time.Second {
+		t.Errorf("Expected a token after 10s, got %+v", d)
+	}
+
+	// A refusal by one bucket takes nothing from the others.
+	for i := 0; i < 3; i++ {
+		limiter.Allow(false, forum)
+	}
+	if d := limiter.Allow(false, bob, forum); d.Allowed || d.Key != forum || d.RetryAfter != time.Second {
+		t.Errorf("Expected the forum to refuse, got %+v", d)
+	}
+	if d := limiter.Allow(false, bob); !d.Allowed || d.Remaining != 1 {
+		t.Errorf("Expected bob's bucket untouched by the refusal, got %+v", d)
+	}
+	// When allowed, the decision describes the emptiest bucket.
+	fake.Advance(time.Minute)
+	if d := limiter.Allow(false, bob, forum); !d.Allowed || d.Key != bob || d.Remaining != 1 {
+		t.Errorf("Expected bob's bucket to be reported, got %+v", d)
+	}
+
+	// Trusted users get twice the burst and refill rate, but the forum's
+	// bucket is shared and stays as it is.
+	if !limiter.Trusted(100) || limiter.Trusted(99) {
+		t.Errorf("Expected trust from 100 karma")
+	}
+	carol := ratelimit.Key{Action: ratelimit.Post, Subject: "carol"}
+	for i := 0; i < 4; i++ {
+		if d := limiter.Allow(true, carol); !d.Allowed || d.Limit != 4 {
+			t.Errorf("Expected trusted post %d to be allowed, got %+v", i+1, d)
+		}
+	}
+	if d := limiter.Allow(true, carol); d.Allowed || d.RetryAfter != 5*time.Second {
+		t.Errorf("Expected a trusted user to wait 5s, got %+v", d)
+	}
+	if d := limiter.Allow(true, forum); d.Limit != 3 {
+		t.Errorf("Expected the forum's limit to stay 3 for a trusted user, got %+v", d)
+	}
+
+	// Actions without a rate are not limited.
+	for i := 0; i < 10; i++ {
+		if d := limiter.Allow(false, ratelimit.Key{Action: ratelimit.Vote, Subject: "alice"}); !d.Allowed {
+			t.Fatalf("Expected unlimited votes, got %+v", d)
+		}
+	}
+
+	// Buckets that have refilled are forgotten.
+	fake.Advance(time.Hour)
+	for i := 0; i < 1024; i++ {
+		limiter.Allow(false, ratelimit.Key{Action: ratelimit.Forum, Subject: "busy"})
+		fake.Advance(time.Second)
+	}
+	if n := limiter.Len(); n != 1 {
+		t.Errorf("Expected only the busy bucket to be kept, got %d", n)
+	}
+}
+
+// withRateLimits limits the routers and gRPC servers the test creates by
+// policy, on the clock it returns.
+func withRateLimits(t *testing.T, policy ratelimit.Policy) *clock.Fake {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	handlers.RateLimiter = ratelimit.New(policy, fake)
+	t.Cleanup(func() { handlers.RateLimiter = nil })
+	return fake
+}
+
+func TestRateLimitedAPI(t *testing.T) {
+	fake := withRateLimits(t, ratelimit.Policy{
+		Rates: map[ratelimit.Action]ratelimit.Rate{
+			ratelimit.IP:    {Every: time.Millisecond, Burst: 1000},
+			ratelimit.User:  {Every: time.Second, Burst: 100},
+			ratelimit.Post:  {Every: time.Minute, Burst: 2},
+			ratelimit.Forum: {Every: time.Second, Burst: 3},
+			ratelimit.Vote:  {Every: 10 * time.Second, Burst: 1},
+		},
+		TrustedKarma:  10,
+		TrustedFactor: 2,
+	})
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	post := func(author string) *httptest.ResponseRecorder {
+		return call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"`+author+`","text":"hello"}`)
+	}
+	expectHeaders := func(w *httptest.ResponseRecorder, want map[string]string) {
+		t.Helper()
+		for name, value := range want {
+			if got := w.Header().Get(name); got != value {
+				t.Errorf("Expected %s: %s, got %q", name, value, got)
+			}
+		}
+	}
+
+	for _, name := range []string{"alice", "bob"} {
+		if w := call(http.MethodPost, "/api/users", `{"display_name":"`+name+`"}`); w.Code != http.StatusOK {
+			t.Fatalf("Registering %s failed: %d %s", name, w.Code, w.Body.String())
+		}
+	}
+	if w := call(http.MethodPost, "/api/forums", `{"title":"golang","creator_id":"alice"}`); w.Code != http.StatusOK {
+		t.Fatalf("Creating the forum failed: %d %s", w.Code, w.Body.String())
+	}
+
+	w := post("alice")
+	expectHeaders(w, map[string]string{"RateLimit-Limit": "2", "RateLimit-Remaining": "1", "RateLimit-Reset": "60"})
+	var first templates.PostResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &first); err != nil {
+		t.Fatalf("Unexpected post response: %v", err)
+	}
+	if w := post("alice"); w.Code != http.StatusOK {
+		t.Fatalf("Expected a second post, got %d %s", w.Code, w.Body.String())
+	}
+	w = post("alice")
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), `"code":"rate_limited"`) || !strings.Contains(w.Body.String(), "too many posts") {
+		t.Errorf("Expected a third post to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	expectHeaders(w, map[string]string{"Retry-After": "60", "RateLimit-Limit": "2", "RateLimit-Remaining": "0", "RateLimit-Reset": "120"})
+
+	// The forum's bucket is shared by its posters.
+	if w := post("bob"); w.Code != http.StatusOK {
+		t.Errorf("Expected bob's post, got %d %s", w.Code, w.Body.String())
+	}
+	w = post("bob")
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "posts in this forum") {
+		t.Errorf("Expected the forum to be full, got %d %s", w.Code, w.Body.String())
+	}
+	expectHeaders(w, map[string]string{"Retry-After": "1"})
+
+	fake.Advance(time.Minute)
+	if w := post("alice"); w.Code != http.StatusOK {
+		t.Errorf("Expected alice to post again after a minute, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Karma relaxes a user's own limits.
+	karma, err := proto_actor.Ask(handlers.RootContext, handlers.UserActor, &proto_actor.AdjustKarma{ProfileID: first.AuthorID, Delta: 10}, time.Second)
+	if err != nil || karma.Karma != 10 {
+		t.Fatalf("AdjustKarma failed: %v %v", karma, err)
+	}
+	fake.Advance(10 * time.Minute)
+	for i := 0; i < 4; i++ {
+		if w := post("alice"); w.Code != http.StatusOK {
+			t.Errorf("Expected trusted post %d, got %d %s", i+1, w.Code, w.Body.String())
+		}
+		fake.Advance(time.Second)
+	}
+	w = post("alice")
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "too many posts") {
+		t.Errorf("Expected a fifth trusted post to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	expectHeaders(w, map[string]string{"RateLimit-Limit": "4"})
+
+	// The JSON API does not say who votes, so votes count against the IP.
+	path := "/api/posts/" + first.ID + "/vote"
+	if w := call(http.MethodPost, path, `{"direction":"up"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected a vote, got %d %s", w.Code, w.Body.String())
+	}
+	w = call(http.MethodPost, path, `{"direction":"up"}`)
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "too many votes") {
+		t.Errorf("Expected a second vote to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	expectHeaders(w, map[string]string{"Retry-After": "10"})
+}
+
+func TestRateLimitedIP(t *testing.T) {
+	fake := withRateLimits(t, ratelimit.Policy{
+		Rates: map[ratelimit.Action]ratelimit.Rate{ratelimit.IP: {Every: 500 * time.Millisecond, Burst: 2}},
+	})
+	router := newTestRouter()
+	forwarded := func(ip, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+		req.RemoteAddr = ip + ":1234"
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	get := func(ip string) *httptest.ResponseRecorder {
+		return forwarded(ip, "")
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := get("192.0.2.1"); w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to pass, got %d", i+1, w.Code)
+		}
+	}
+	w := get("192.0.2.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected the third request to be refused, got %d %v", w.Code, w.Header())
+	}
+	if w := get("192.0.2.2"); w.Code != http.StatusOK {
+		t.Errorf("Expected another client to pass, got %d", w.Code)
+	}
+	fake.Advance(500 * time.Millisecond)
+	if w := get("192.0.2.1"); w.Code != http.StatusOK {
+		t.Errorf("Expected a request after the refill, got %d", w.Code)
+	}
+
+	// A client cannot claim another IP, unless a trusted proxy forwards it.
+	for _, spoofed := range []string{"198.51.100.1", "198.51.100.2"} {
+		if w := forwarded("192.0.2.1", spoofed); w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected X-Forwarded-For %s to be ignored, got %d", spoofed, w.Code)
+		}
+	}
+	handlers.Config.Server.TrustedProxies = []string{"192.0.2.0/30"}
+	t.Cleanup(func() { handlers.Config.Server.TrustedProxies = nil })
+	router = newTestRouter()
+	for i := 0; i < 2; i++ {
+		forwarded("192.0.2.1", "198.51.100.1")
+	}
+	if w := forwarded("192.0.2.1", "198.51.100.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the forwarded client to be limited, got %d", w.Code)
+	}
+	if w := forwarded("192.0.2.2", "198.51.100.2"); w.Code != http.StatusOK {
+		t.Errorf("Expected another forwarded client to pass, got %d", w.Code)
+	}
+}
+
+func TestRateLimitedPages(t *testing.T) {
+	withRateLimits(t, ratelimit.Policy{
+		Rates: map[ratelimit.Action]ratelimit.Rate{ratelimit.Comment: {Every: time.Minute, Burst: 1}},
+	})
+	b := &browser{t: t, router: newTestRouter()}
+	b.submit("/login", url.Values{"username": {"alice"}})
+	forumPath := b.submit("/forums", url.Values{"title": {"golang"}})
+	postPath := b.submit(forumPath+"/posts", url.Values{"text": {"hello"}})
+
+	b.submit(postPath+"/comments", url.Values{"content": {"first"}})
+	w := b.do(http.MethodPost, postPath+"/comments", url.Values{"content": {"second"}})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" || !strings.Contains(w.Body.String(), "Slow down: too many comments") {
+		t.Errorf("Expected the second comment to be refused, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRateLimitedGRPC(t *testing.T) {
+	withRateLimits(t, ratelimit.Policy{
+		Rates: map[ratelimit.Action]ratelimit.Rate{ratelimit.Message: {Every: 30 * time.Second, Burst: 1}},
+	})
+	client := newGRPCClient(t)
+	ctx := context.Background()
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := client.RegisterUser(ctx, &wire.RegisterUser{DisplayName: name}); err != nil {
+			t.Fatalf("RegisterUser failed: %v", err)
+		}
+	}
+
+	message := &wire.SendMessage{FromUserId: "alice", ToUserId: "bob", Body: "hi"}
+	if _, err := client.SendMessage(ctx, message); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	_, err := client.SendMessage(ctx, message)
+	expectCode(t, err, codes.ResourceExhausted)
+	var retry *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil || retry.RetryDelay.AsDuration() != 30*time.Second {
+		t.Errorf("Expected a RetryInfo of 30s, got %v", status.Convert(err).Details())
+	}
+}
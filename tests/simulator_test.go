@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/simulator"
+	"reddit-clone/schemas"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func TestSimulatorDrivesManagers(t *testing.T) {
+	system := actor.NewActorSystem()
+	managers := spawnManagers(t, system)
+
+	config := simulator.Config{
+		Users:         60,
+		Forums:        6,
+		ForumsPerUser: 2,
+		ZipfExponent:  1.5,
+		PostRate:      10,
+		CommentRate:   10,
+		VoteRate:      10,
+		MessageRate:   5,
+		OnlineTime:    100 * time.Millisecond,
+		OfflineTime:   50 * time.Millisecond,
+		Duration:      700 * time.Millisecond,
+		Timeout:       time.Second,
+		Seed:          7,
+	}
+	report, err := simulator.Run(context.Background(), system.Root, managers, config)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if _, failed := report.Total(); failed != 0 {
+		t.Errorf("Expected no failed requests, got %d", failed)
+	}
+	if got := report.Operation(simulator.OpRegister).Count; got != config.Users {
+		t.Errorf("Expected %d registrations, got %d", config.Users, got)
+	}
+	if got := report.Operation(simulator.OpJoin).Count; got != config.Users*config.ForumsPerUser {
+		t.Errorf("Expected %d joins, got %d", config.Users*config.ForumsPerUser, got)
+	}
+	for _, op := range []string{simulator.OpPost, simulator.OpComment, simulator.OpVote, simulator.OpMessage, simulator.OpInbox} {
+		stat := report.Operation(op)
+		if stat.Count == 0 {
+			t.Errorf("Expected some %s requests", op)
+			continue
+		}
+		if stat.P50 > stat.P90 || stat.P90 > stat.P99 || stat.P99 > stat.Max {
+			t.Errorf("Percentiles of %s out of order: %+v", op, stat)
+		}
+	}
+	if report.Throughput() <= 0 {
+		t.Errorf("Expected a positive throughput, got %v", report.Throughput())
+	}
+
+	// The managers hold what the users did.
+	forums, err := proto_actor.Ask(system.Root, managers.Forums, &proto_actor.RetrieveAllForums{}, time.Second)
+	if err != nil || len(forums) != config.Forums {
+		t.Fatalf("Expected %d forums, got %d (%v)", config.Forums, len(forums), err)
+	}
+	sort.Slice(forums, func(i, j int) bool { return forums[i].Name < forums[j].Name })
+	members := 0
+	for _, forum := range forums {
+		members += len(forum.Members)
+	}
+	if members != config.Users*config.ForumsPerUser {
+		t.Errorf("Expected %d memberships, got %d", config.Users*config.ForumsPerUser, members)
+	}
+	// Popularity is skewed towards the first forums.
+	if first, last := len(forums[0].Members), len(forums[len(forums)-1].Members); first <= last {
+		t.Errorf("Expected sim0 to have more members than %s, got %d and %d", forums[len(forums)-1].Name, first, last)
+	}
+
+	posts, err := proto_actor.Ask(system.Root, managers.Posts, &proto_actor.RetrieveAllPosts{}, time.Second)
+	if err != nil || len(posts) != report.Operation(simulator.OpPost).Count {
+		t.Errorf("Expected %d posts, got %d (%v)", report.Operation(simulator.OpPost).Count, len(posts), err)
+	}
+	user, err := proto_actor.Ask[*schemas.Account](system.Root, managers.Members, &proto_actor.FetchUserByName{Username: "sim_0"}, time.Second)
+	if err != nil || user.Username != "sim_0" {
+		t.Errorf("Expected sim_0 to be registered, got %v (%v)", user, err)
+	}
+
+	var out strings.Builder
+	report.Print(&out)
+	for _, want := range []string{"60 users", "register", "p99", "inbox"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected the report to mention %q:\n%s", want, out.String())
+		}
+	}
+
+	if _, err := simulator.Run(context.Background(), system.Root, managers, simulator.Config{Users: 1}); err == nil {
+		t.Error("Expected an invalid config to be rejected")
+	}
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"fmt"
 	proto_actor "reddit-clone/core/proto_actors"
 	"reddit-clone/schemas"
 	"testing"
@@ -17,7 +18,8 @@ func TestForumManager(t *testing.T) {
 	}))
 
 	res, err := system.Root.RequestFuture(forumManager, &proto_actor.AddForum{
-		Title: "test-forum",
+		Title:     "test-forum",
+		CreatorID: "user_1",
 	}, 3*time.Second).Result()
 	if err != nil {
 		t.Fatalf("AddForum failed: %v", err)
@@ -69,10 +71,13 @@ func BenchmarkForumManager(b *testing.B) {
 	}))
 
 
+	created := 0
 	b.Run("AddForum", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
+			created++
 			_, err := system.Root.RequestFuture(forumManager, &proto_actor.AddForum{
-				Title: "benchmark-forum",
+				Title:     fmt.Sprintf("bench-%d", created),
+				CreatorID: "user_1",
 			}, 3*time.Second).Result()
 			if err != nil {
 				b.Fatalf("AddForum failed: %v", err)
@@ -82,7 +87,8 @@ func BenchmarkForumManager(b *testing.B) {
 
 
 	res, err := system.Root.RequestFuture(forumManager, &proto_actor.AddForum{
-		Title: "benchmark-forum",
+		Title:     "benchmark-forum",
+		CreatorID: "user_1",
 	}, 3*time.Second).Result()
 	if err != nil {
 		b.Fatalf("AddForum setup failed: %v", err)
@@ -109,7 +115,8 @@ func BenchmarkForumManager(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			
 			res, _ := system.Root.RequestFuture(forumManager, &proto_actor.AddForum{
-				Title: "benchmark-delete",
+				Title:     "benchmark-delete",
+				CreatorID: "user_1",
 			}, 3*time.Second).Result()
 			tempForum, _ := res.(*schemas.Subreddit)
 
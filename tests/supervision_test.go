@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"errors"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+type crash struct{}
+
+// panicWhen injects a failure into every actor for which when returns true
+// before it sees the message.
+func panicWhen(when func(a actor.Actor, message interface{}) bool) proto_actor.Option {
+	return proto_actor.WithMiddleware(func(next actor.ReceiverFunc) actor.ReceiverFunc {
+		return func(ctx actor.ReceiverContext, envelope *actor.MessageEnvelope) {
+			if when(ctx.Actor(), envelope.Message) {
+				panic("injected failure")
+			}
+			next(ctx, envelope)
+		}
+	})
+}
+
+var fastRestarts = proto_actor.WithRestartPolicy(proto_actor.RestartPolicy{
+	MaxRestarts:    3,
+	Window:         time.Minute,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     10 * time.Millisecond,
+})
+
+func spawnManagers(t *testing.T, system *actor.ActorSystem, options ...proto_actor.Option) *proto_actor.Managers {
+	t.Helper()
+	managers, err := proto_actor.SpawnManagers(system.Root, options...)
+	if err != nil {
+		t.Fatalf("SpawnManagers failed: %v", err)
+	}
+	return managers
+}
+
+func TestManagerRestartRecoversState(t *testing.T) {
+	system := actor.NewActorSystem()
+	managers := spawnManagers(t, system, fastRestarts, panicWhen(func(a actor.Actor, message interface{}) bool {
+		_, isCrash := message.(*crash)
+		return isCrash
+	}))
+
+	res, _ := system.Root.RequestFuture(managers.Forums, &proto_actor.AddForum{Title: "golang", CreatorID: "user_1"}, time.Second).Result()
+	forum := res.(*schemas.Subreddit)
+	res, _ = system.Root.RequestFuture(managers.Members, &proto_actor.RegisterUser{DisplayName: "alice"}, time.Second).Result()
+	user := res.(*schemas.Account)
+
+	system.Root.Send(managers.Forums, &crash{})
+	system.Root.Send(managers.Members, &crash{})
+
+	res, err := system.Root.RequestFuture(managers.Forums, &proto_actor.RetrieveForum{ForumID: forum.ID}, time.Second).Result()
+	if restored, ok := res.(*schemas.Subreddit); err != nil || !ok || restored.Name != "golang" {
+		t.Fatalf("Forum lost across restart: %v %v", res, err)
+	}
+	res, _ = system.Root.RequestFuture(managers.Forums, &proto_actor.AddForum{Title: "golang", CreatorID: "user_1"}, time.Second).Result()
+	if res != proto_actor.ErrForumNameTaken {
+		t.Errorf("Name index lost across restart, got %v", res)
+	}
+	res, err = system.Root.RequestFuture(managers.Members, &proto_actor.FetchUserByName{Username: "alice"}, time.Second).Result()
+	if restored, ok := res.(*schemas.Account); err != nil || !ok || restored.ID != user.ID {
+		t.Fatalf("User lost across restart: %v %v", res, err)
+	}
+}
+
+func TestEntityCrashRecovers(t *testing.T) {
+	var failing atomic.Bool
+	system := actor.NewActorSystem()
+	managers := spawnManagers(t, system, fastRestarts, panicWhen(func(a actor.Actor, message interface{}) bool {
+		_, isManager := a.(*proto_actor.PostManager)
+		_, isVote := message.(*proto_actor.VotePost)
+		return !isManager && isVote && failing.CompareAndSwap(true, false)
+	}))
+
+	res, _ := system.Root.RequestFuture(managers.Posts, &proto_actor.AddPost{ForumID: "forum", AuthorID: "author", Text: "hello"}, time.Second).Result()
+	post := res.(*schemas.Post)
+	vote := &proto_actor.VotePost{ContentID: post.ID, Upvote: true}
+
+	system.Root.RequestFuture(managers.Posts, vote, time.Second).Result()
+
+	// The vote the post actor crashes on is lost, but not the one before.
+	failing.Store(true)
+	if _, err := system.Root.RequestFuture(managers.Posts, vote, 100*time.Millisecond).Result(); !errors.Is(err, actor.ErrTimeout) {
+		t.Fatalf("Expected the crashing vote to time out, got %v", err)
+	}
+
+	res, err := system.Root.RequestFuture(managers.Posts, vote, time.Second).Result()
+	if voted, ok := res.(*schemas.Post); err != nil || !ok || voted.Upvotes != 2 {
+		t.Fatalf("Expected 2 upvotes after restart, got %v %v", res, err)
+	}
+	if n := countActive(t, system, managers.Posts); n != 1 {
+		t.Errorf("Expected the restarted post to stay active, got %d", n)
+	}
+}
+
+func TestRepeatedFailuresRespawnManager(t *testing.T) {
+	system := actor.NewActorSystem()
+	managers := spawnManagers(t, system, fastRestarts, panicWhen(func(a actor.Actor, message interface{}) bool {
+		_, isCrash := message.(*crash)
+		return isCrash
+	}))
+
+	res, _ := system.Root.RequestFuture(managers.Posts, &proto_actor.AddPost{ForumID: "forum", AuthorID: "author", Text: "hello"}, time.Second).Result()
+	post := res.(*schemas.Post)
+
+	// One failure more than MaxRestarts stops the manager; the guardian then
+	// spawns it again under the same PID.
+	for i := 0; i < 4; i++ {
+		system.Root.Send(managers.Posts, &crash{})
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		res, err := system.Root.RequestFuture(managers.Posts, &proto_actor.RetrievePost{ContentID: post.ID}, 100*time.Millisecond).Result()
+		if retrieved, ok := res.(*schemas.Post); err == nil && ok && retrieved.ID == post.ID {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Post manager did not come back: %v %v", res, err)
+		}
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	policy := proto_actor.RestartPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	for failures, want := range map[int]time.Duration{
+		1:  10 * time.Millisecond,
+		2:  20 * time.Millisecond,
+		3:  40 * time.Millisecond,
+		4:  50 * time.Millisecond,
+		10: 50 * time.Millisecond,
+	} {
+		if got := policy.Backoff(failures); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestDeadLetterMonitor(t *testing.T) {
+	system := actor.NewActorSystem()
+	monitor := proto_actor.MonitorDeadLetters(system)
+	defer monitor.Stop()
+
+	pid := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewNotificationManager()
+	}))
+	if err := system.Root.StopFuture(pid).Wait(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	system.Root.Send(pid, &proto_actor.FetchNotifications{UserID: "user"})
+
+	deadline := time.Now().Add(time.Second)
+	for monitor.Count() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Dead letter was not counted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
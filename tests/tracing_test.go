@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/tracing"
+	"reddit-clone/handlers"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingFollowsRequestsThroughActors(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := tracing.Setup(exporter)
+	defer shutdown(context.Background())
+
+	system := actor.NewActorSystem()
+	managers := spawnManagers(t, system, proto_actor.WithInstrumentation(tracing.Instrumentation{}))
+	handlers.RootContext = system.Root
+	handlers.UserActor = managers.Members
+	handlers.SubredditActor = managers.Forums
+	handlers.PostActor = managers.Posts
+	handlers.CommentActor = managers.Comments
+	handlers.MessageActor = managers.Messages
+	handlers.NotificationActor = managers.Notifications
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.RegisterRoutes(router)
+
+	call := func(method, path, body, traceparent string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if traceparent != "" {
+			req.Header.Set("traceparent", traceparent)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	for _, step := range []struct{ path, body string }{
+		{"/api/users", `{"display_name":"ines"}`},
+		{"/api/forums", `{"title":"traces","creator_id":"ines"}`},
+		{"/api/posts", `{"forum_id":"traces","author_id":"ines","text":"followed"}`},
+	} {
+		if w := call(http.MethodPost, step.path, step.body, ""); w.Code != http.StatusOK {
+			t.Fatalf("POST %s failed: %d %s", step.path, w.Code, w.Body.String())
+		}
+	}
+	posts, err := proto_actor.Ask(system.Root, managers.Posts, &proto_actor.RetrieveAllPosts{}, time.Second)
+	if err != nil || len(posts) != 1 {
+		t.Fatalf("Expected one post, got %v (%v)", posts, err)
+	}
+
+	// Requests sent without a span are not traced.
+	exporter.Reset()
+	if _, err := proto_actor.Ask(system.Root, managers.Posts, &proto_actor.RetrievePost{ContentID: posts[0].ID}, time.Second); err != nil {
+		t.Fatalf("RetrievePost failed: %v", err)
+	}
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Fatalf("Expected no spans for an untraced request, got %d", len(spans))
+	}
+
+	// A vote asks the posts manager, which forwards to the post's entity,
+	// and then tells the members manager to adjust the author's karma.
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const remoteSpan = "00f067aa0ba902b7"
+	if w := call(http.MethodPost, "/api/posts/"+posts[0].ID+"/vote", `{"direction":"up"}`, "00-"+traceID+"-"+remoteSpan+"-01"); w.Code != http.StatusOK {
+		t.Fatalf("Voting failed: %d %s", w.Code, w.Body.String())
+	}
+
+	find := func(name string) tracetest.SpanStub {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			for _, span := range exporter.GetSpans() {
+				if span.Name == name {
+					return span
+				}
+			}
+			if time.Now().After(deadline) {
+				var names []string
+				for _, span := range exporter.GetSpans() {
+					names = append(names, span.Name)
+				}
+				t.Fatalf("No span named %q among %v", name, names)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	request := find("POST /api/posts/:id/vote")
+	manager := find("posts VotePost")
+	entity := find("post VotePost")
+	karma := find("members AdjustKarma")
+
+	for _, span := range []tracetest.SpanStub{request, manager, entity, karma} {
+		if got := span.SpanContext.TraceID().String(); got != traceID {
+			t.Errorf("Expected %s to belong to trace %s, got %s", span.Name, traceID, got)
+		}
+	}
+	if got := request.Parent.SpanID().String(); got != remoteSpan || !request.Parent.IsRemote() {
+		t.Errorf("Expected the request span to continue the incoming traceparent, got parent %s", got)
+	}
+	if request.SpanKind != trace.SpanKindServer {
+		t.Errorf("Expected a server span for the request, got %v", request.SpanKind)
+	}
+	for _, link := range []struct{ child, parent tracetest.SpanStub }{
+		{manager, request},
+		{entity, manager},
+		{karma, request},
+	} {
+		if link.child.Parent.SpanID() != link.parent.SpanContext.SpanID() {
+			t.Errorf("Expected %s to be a child of %s", link.child.Name, link.parent.Name)
+		}
+	}
+
+	// Without an incoming traceparent the request span starts a new trace.
+	exporter.Reset()
+	if w := call(http.MethodGet, "/api/posts/"+posts[0].ID, "", ""); w.Code != http.StatusOK {
+		t.Fatalf("GET post failed: %d", w.Code)
+	}
+	request = find("GET /api/posts/:id")
+	manager = find("posts RetrievePost")
+	if request.Parent.IsValid() {
+		t.Errorf("Expected the request span to be a root, got parent %s", request.Parent.SpanID())
+	}
+	if manager.Parent.SpanID() != request.SpanContext.SpanID() || manager.SpanContext.TraceID() != request.SpanContext.TraceID() {
+		t.Errorf("Expected the manager span to be a child of the request span")
+	}
+}
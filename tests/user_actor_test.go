@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"fmt"
 	proto_actor "reddit-clone/core/proto_actors"
 	"reddit-clone/schemas"
 	"testing"
@@ -70,10 +71,12 @@ func BenchmarkMemberManager(b *testing.B) {
 	}))
 
 
+	registered := 0
 	b.Run("RegisterUser", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
+			registered++
 			_, err := system.Root.RequestFuture(memberManager, &proto_actor.RegisterUser{
-				DisplayName: "benchmark-user",
+				DisplayName: fmt.Sprintf("bench-%d", registered),
 			}, 3*time.Second).Result()
 			if err != nil {
 				b.Fatalf("RegisterUser failed: %v", err)
@@ -117,3 +120,44 @@ func BenchmarkMemberManager(b *testing.B) {
 		}
 	})
 }
+
+// Karma and profiles keep changing after a reply, so every reply must be a
+// copy of the stored account.
+func TestAccountRepliesAreCopies(t *testing.T) {
+	system := actor.NewActorSystem()
+	memberManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewMemberManager()
+	}))
+
+	registered, err := proto_actor.Ask(system.Root, memberManager, &proto_actor.RegisterUser{DisplayName: "alice"}, time.Second)
+	if err != nil {
+		t.Fatalf("RegisterUser failed: %v", err)
+	}
+	replies := []*schemas.Account{registered}
+	for _, msg := range []proto_actor.Request[*schemas.Account]{
+		&proto_actor.EnsureUser{Username: "alice"},
+		&proto_actor.FetchUser{ProfileID: registered.ID},
+		&proto_actor.FetchUserByName{Username: "alice"},
+		&proto_actor.AdjustKarma{ProfileID: registered.ID, Delta: 0},
+		&proto_actor.UpdateProfile{ProfileID: registered.ID},
+	} {
+		account, err := proto_actor.Ask(system.Root, memberManager, msg, time.Second)
+		if err != nil {
+			t.Fatalf("%T failed: %v", msg, err)
+		}
+		replies = append(replies, account)
+	}
+	accounts, err := proto_actor.Ask(system.Root, memberManager, &proto_actor.FetchUsers{ProfileIDs: []string{registered.ID}}, time.Second)
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("FetchUsers failed: %v %v", accounts, err)
+	}
+	replies = append(replies, accounts[0])
+
+	proto_actor.Ask(system.Root, memberManager, &proto_actor.AdjustKarma{ProfileID: registered.ID, Delta: 5}, time.Second)
+	proto_actor.Ask(system.Root, memberManager, &proto_actor.UpdateProfile{ProfileID: registered.ID, Bio: "changed"}, time.Second)
+	for i, reply := range replies {
+		if reply.Karma != 0 || reply.Bio != "" {
+			t.Errorf("Expected reply %d to stay unchanged, got %+v", i, reply)
+		}
+	}
+}
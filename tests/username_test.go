@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+func TestUniqueUsernames(t *testing.T) {
+	system := actor.NewActorSystem()
+	memberManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewMemberManager()
+	}))
+
+	register := func(name string) any {
+		res, err := system.Root.RequestFuture(memberManager, &proto_actor.RegisterUser{
+			DisplayName: name,
+		}, 3*time.Second).Result()
+		if err != nil {
+			t.Fatalf("RegisterUser failed: %v", err)
+		}
+		return res
+	}
+
+	first, ok := register("Dave").(*schemas.Account)
+	if !ok {
+		t.Fatalf("Registering a fresh username failed")
+	}
+
+	cases := map[string]error{
+		"dave":        proto_actor.ErrUsernameTaken,
+		"DAVE":        proto_actor.ErrUsernameTaken,
+		"ab":          proto_actor.ErrUsernameInvalid,
+		"has space":   proto_actor.ErrUsernameInvalid,
+		"émile":       proto_actor.ErrUsernameInvalid,
+		"Admin":       proto_actor.ErrUsernameReserved,
+		"user_123456": proto_actor.ErrUsernameReserved,
+	}
+	for name, want := range cases {
+		res := register(name)
+		if err, ok := res.(error); !ok || !errors.Is(err, want) {
+			t.Errorf("RegisterUser(%q): got %v, expected %v", name, res, want)
+		}
+	}
+
+	system.Root.RequestFuture(memberManager, &proto_actor.RemoveUser{ProfileID: first.ID}, 3*time.Second).Result()
+	if _, ok := register("dave").(*schemas.Account); !ok {
+		t.Errorf("Username was not released when its account was removed")
+	}
+}
+
+func TestUsernameEndpoints(t *testing.T) {
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := call(http.MethodPost, "/api/users", `{"display_name":"Erin"}`); w.Code != http.StatusOK {
+		t.Fatalf("Register failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/users", `{"display_name":"erin"}`); w.Code != http.StatusConflict {
+		t.Errorf("Duplicate username should conflict, got %d", w.Code)
+	}
+	if w := call(http.MethodPost, "/api/users", `{"display_name":"root"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Reserved username should be rejected, got %d", w.Code)
+	}
+	call(http.MethodPost, "/api/users", `{"display_name":"frank"}`)
+
+	w := call(http.MethodGet, "/api/users/by-name/ERIN", "")
+	var account struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &account); err != nil || account.Username != "Erin" {
+		t.Fatalf("Lookup by name failed: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := call(http.MethodGet, "/api/users/erin", ""); w.Code != http.StatusOK {
+		t.Errorf("Fetch by username failed: %d", w.Code)
+	}
+	if w := call(http.MethodGet, "/api/users/"+account.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("Fetch by ID failed: %d", w.Code)
+	}
+
+	if w := call(http.MethodPost, "/api/messages", `{"from_user_id":"erin","to_user_id":"frank","body":"hi"}`); w.Code != http.StatusOK {
+		t.Errorf("Sending a message by usernames failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/messages", `{"from_user_id":"erin","to_user_id":"nobody","body":"hi"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Message to unknown user should 404, got %d", w.Code)
+	}
+
+	call(http.MethodPost, "/api/forums", `{"title":"general","creator_id":"erin"}`)
+	w = call(http.MethodPost, "/api/posts", `{"forum_id":"general","author_id":"frank","text":"hello u/erin"}`)
+	var post struct {
+		AuthorID string `json:"user_id"`
+		Mentions []struct {
+			TargetID string `json:"target_id"`
+		} `json:"mentions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &post); err != nil || !strings.HasPrefix(post.AuthorID, "user_") {
+		t.Fatalf("Post by username failed: %d %s", w.Code, w.Body.String())
+	}
+	if len(post.Mentions) != 1 || post.Mentions[0].TargetID != account.ID {
+		t.Errorf("u/ mention did not resolve: %+v", post.Mentions)
+	}
+}
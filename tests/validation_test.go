@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reddit-clone/handlers"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	var spec struct {
+		OpenAPI    string                            `yaml:"openapi"`
+		Paths      map[string]map[string]interface{} `yaml:"paths"`
+		Components map[string]map[string]interface{} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(handlers.OpenAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.yaml does not parse: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("Expected an OpenAPI 3 document, got %q", spec.OpenAPI)
+	}
+
+	routed := map[string]bool{}
+	for _, route := range newTestRouter().Routes() {
+		path, ok := strings.CutPrefix(route.Path, "/api")
+		if !ok {
+			continue
+		}
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			if name, ok := strings.CutPrefix(segment, ":"); ok {
+				segments[i] = "{" + name + "}"
+			}
+		}
+		path = strings.Join(segments, "/")
+		method := strings.ToLower(route.Method)
+		routed[method+" "+path] = true
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("%s %s is not documented", route.Method, route.Path)
+		}
+	}
+	for path, item := range spec.Paths {
+		for method := range item {
+			if method != "parameters" && !routed[method+" "+path] {
+				t.Errorf("%s %s is documented but not routed", strings.ToUpper(method), path)
+			}
+		}
+	}
+
+	// Every local reference must name a component.
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			for key, value := range node {
+				if ref, ok := value.(string); ok && key == "$ref" {
+					parts := strings.Split(strings.TrimPrefix(ref, "#/components/"), "/")
+					if _, ok := spec.Components[parts[0]][parts[1]]; len(parts) != 2 || !ok {
+						t.Errorf("Dangling reference %s", ref)
+					}
+				}
+				walk(value)
+			}
+		case []interface{}:
+			for _, value := range node {
+				walk(value)
+			}
+		}
+	}
+	for _, item := range spec.Paths {
+		walk(item)
+	}
+	for _, group := range spec.Components {
+		walk(group)
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	router := newTestRouter()
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := call(http.MethodPost, "/api/users", `{"display_name":"vera"}`); w.Code != http.StatusOK {
+		t.Fatalf("RegisterUser failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/forums", `{"title":"validation","creator_id":"vera"}`); w.Code != http.StatusOK {
+		t.Fatalf("AddForum failed: %d %s", w.Code, w.Body.String())
+	}
+
+	long := strings.Repeat("a", 501)
+	for _, tc := range []struct {
+		method, path, body string
+		field, rule        string
+	}{
+		{http.MethodPost, "/api/posts", `{"forum_id":"validation","author_id":"vera"}`, "text", "required"},
+		{http.MethodPost, "/api/posts", `{"forum_id":"validation","author_id":"vera","text":"   "}`, "text", "notblank"},
+		{http.MethodPost, "/api/posts", `{"author_id":"vera","text":"hello"}`, "forum_id", "required"},
+		{http.MethodPost, "/api/posts", `{"forum_id":"validation","author_id":"vera","text":"` + strings.Repeat("a", handlers.Config.Limits.PostLength+1) + `"}`, "text", "max"},
+		{http.MethodPost, "/api/posts", `{"forum_id":"validation","author_id":7,"text":"hello"}`, "author_id", "type"},
+		{http.MethodPost, "/api/comments", `{"author_id":"vera","content":"hi"}`, "post_id", "required_without"},
+		{http.MethodPost, "/api/comments", `{"post_id":"post_1","author_id":"vera","content":""}`, "content", "required"},
+		{http.MethodPost, "/api/messages", `{"from_user_id":"vera","to_user_id":"vera","body":"\n"}`, "body", "notblank"},
+		{http.MethodPost, "/api/forums", `{"title":"","creator_id":"vera"}`, "title", "required"},
+		{http.MethodPost, "/api/forums", `{"title":"unmoderated"}`, "creator_id", "required"},
+		{http.MethodPatch, "/api/forums/validation", `{"title":"` + strings.Repeat("t", handlers.Config.Limits.ForumTitleLength+1) + `","moderator_id":"vera"}`, "title", "max"},
+		{http.MethodPost, "/api/users", `{}`, "display_name", "required"},
+		{http.MethodPatch, "/api/users/vera", `{"bio":"` + long + `"}`, "bio", "max"},
+		{http.MethodPatch, "/api/users/vera", `{"avatar_url":"ftp://example.com/a.png"}`, "avatar_url", "http_url"},
+		{http.MethodPost, "/api/posts/post_1/vote", `{"direction":"sideways"}`, "direction", "oneof"},
+		{http.MethodGet, "/api/users/vera/posts?limit=0", "", "limit", "min"},
+		{http.MethodGet, "/api/users/vera/posts?offset=-1", "", "offset", "min"},
+		{http.MethodGet, "/api/users/vera/posts?sort=top&after=post_1", "", "after", "cursor"},
+	} {
+		w := call(tc.method, tc.path, tc.body)
+		var body struct {
+			Error  string                `json:"error"`
+			Code   string                `json:"code"`
+			Fields []handlers.FieldError `json:"fields"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusBadRequest || body.Code != "invalid" {
+			t.Errorf("%s %s: expected 400 invalid, got %d %s", tc.method, tc.path, w.Code, w.Body.String())
+			continue
+		}
+		if len(body.Fields) != 1 || body.Fields[0].Field != tc.field || body.Fields[0].Rule != tc.rule || body.Error != body.Fields[0].Message {
+			t.Errorf("%s %s: expected %s to fail %s, got %s", tc.method, tc.path, tc.field, tc.rule, w.Body.String())
+		}
+	}
+
+	// Malformed JSON is rejected without naming a field.
+	if w := call(http.MethodPost, "/api/posts", `{"text":`); w.Code != http.StatusBadRequest || strings.Contains(w.Body.String(), `"fields"`) {
+		t.Errorf("Expected a 400 without fields for malformed JSON, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Surrounding whitespace does not count towards a valid title.
+	w := call(http.MethodPatch, "/api/forums/validation", `{"title":"  Validation  ","moderator_id":"vera"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"title":"Validation"`) {
+		t.Errorf("Rename failed: %d %s", w.Code, w.Body.String())
+	}
+
+	w = call(http.MethodGet, "/api/openapi.yaml", "")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "openapi: 3") {
+		t.Errorf("Expected the OpenAPI document, got %d", w.Code)
+	}
+}
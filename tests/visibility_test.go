@@ -0,0 +1,272 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reddit-clone/core/ids"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/core/proto_actors/wire"
+	"reddit-clone/handlers"
+	"reddit-clone/schemas"
+	"reddit-clone/templates"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestForumTypes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	forum := schemas.NewSubreddit(ids.NewSequential(), "golang", now)
+	if forum.Type != schemas.ForumPublic || !forum.CanView("") || !forum.CanPost("bob") {
+		t.Errorf("Expected a new forum to be public, got %+v", forum)
+	}
+
+	forum.SetType(schemas.ForumRestricted, now)
+	if !forum.CanView("") || forum.CanPost("bob") {
+		t.Errorf("Expected a restricted forum to be readable but closed to posts")
+	}
+	forum.Approve("bob", now)
+	if !forum.CanPost("bob") || forum.CanPost("carol") {
+		t.Errorf("Expected only the approved user to post")
+	}
+
+	forum.SetType(schemas.ForumPrivate, now)
+	if !forum.CanView("bob") || forum.CanView("carol") || forum.CanView("") || forum.CanPost("carol") {
+		t.Errorf("Expected only the approved user to see a private forum")
+	}
+	forum.RequestJoin("carol", now)
+	forum.RequestJoin("carol", now)
+	forum.RequestJoin("dave", now)
+	if strings.Join(forum.JoinRequests, ",") != "carol,dave" {
+		t.Errorf("Expected one request per user, got %v", forum.JoinRequests)
+	}
+	forum.ResolveJoinRequest("carol", true, now)
+	forum.ResolveJoinRequest("dave", false, now)
+	if !forum.CanView("carol") || !forum.CanPost("carol") || forum.CanView("dave") || len(forum.JoinRequests) != 0 {
+		t.Errorf("Expected carol to join and dave to stay out, got %+v", forum)
+	}
+	forum.Unapprove("bob", now)
+	if forum.CanView("bob") {
+		t.Errorf("Expected bob to lose access")
+	}
+}
+
+// Handlers read the membership of the forums they are sent, so later changes
+// must not reach them through a reply.
+func TestForumRepliesAreCopies(t *testing.T) {
+	system := actor.NewActorSystem()
+	forumManager := system.Root.Spawn(actor.PropsFromProducer(func() actor.Actor {
+		return proto_actor.NewForumManager()
+	}))
+
+	forum, err := proto_actor.Ask(system.Root, forumManager, &proto_actor.AddForum{Title: "golang", CreatorID: "alice"}, time.Second)
+	if err != nil {
+		t.Fatalf("AddForum failed: %v", err)
+	}
+	forums, err := proto_actor.Ask(system.Root, forumManager, &proto_actor.RetrieveAllForums{}, time.Second)
+	if err != nil || len(forums) != 1 {
+		t.Fatalf("RetrieveAllForums failed: %v %v", forums, err)
+	}
+	proto_actor.Ask(system.Root, forumManager, &proto_actor.JoinForum{ForumID: forum.ID, UserID: "bob"}, time.Second)
+	proto_actor.Ask(system.Root, forumManager, &proto_actor.ApproveUser{ForumID: forum.ID, UserID: "carol", ModeratorID: "alice"}, time.Second)
+
+	for _, reply := range []*schemas.Subreddit{forum, forums[0]} {
+		if len(reply.Members) != 0 || len(reply.ApprovedUsers) != 0 {
+			t.Errorf("Expected an earlier reply to stay unchanged, got %+v", reply)
+		}
+	}
+}
+
+func TestForumVisibility(t *testing.T) {
+	// The gRPC server shares the managers of the router registered after it.
+	client := newGRPCClient(t)
+	router := gin.New()
+	handlers.RegisterRoutes(router)
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("Unexpected response %s: %v", w.Body.String(), err)
+		}
+	}
+	expect := func(w *httptest.ResponseRecorder, code int) {
+		t.Helper()
+		if w.Code != code {
+			t.Errorf("Expected %d, got %d %s", code, w.Code, w.Body.String())
+		}
+	}
+	usernames := func(path string) string {
+		t.Helper()
+		var accounts []*templates.AccountResponse
+		decode(call(http.MethodGet, path, ""), &accounts)
+		names := make([]string, len(accounts))
+		for i, account := range accounts {
+			names[i] = account.Username
+		}
+		return strings.Join(names, ",")
+	}
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		call(http.MethodPost, "/api/users", `{"display_name":"`+name+`"}`)
+	}
+	call(http.MethodPost, "/api/forums", `{"title":"golang","creator_id":"alice"}`)
+	call(http.MethodPost, "/api/forums", `{"title":"secret","creator_id":"alice"}`)
+	const byAlice = `{"moderator_id":"alice"}`
+
+	// Only moderators decide who may use a forum, starting with its creator.
+	var forum templates.SubredditResponse
+	decode(call(http.MethodGet, "/api/forums/golang", ""), &forum)
+	if len(forum.Moderators) != 1 || forum.Moderators[0] != forum.CreatedBy {
+		t.Errorf("Expected the creator to moderate the forum, got %+v", forum)
+	}
+	expect(call(http.MethodPut, "/api/forums/golang/type", `{"type":"private"}`), http.StatusBadRequest)
+	expect(call(http.MethodPut, "/api/forums/golang/type", `{"type":"private","moderator_id":"nobody"}`), http.StatusNotFound)
+	w := call(http.MethodPut, "/api/forums/golang/type", `{"type":"private","moderator_id":"bob"}`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "moderators") {
+		t.Errorf("Expected a non-moderator to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	for _, path := range []string{"/api/forums/golang/approved-users/bob", "/api/forums/golang/moderators/bob"} {
+		expect(call(http.MethodPut, path, ""), http.StatusBadRequest)
+		expect(call(http.MethodPut, path, `{"moderator_id":"bob"}`), http.StatusForbidden)
+	}
+	expect(call(http.MethodDelete, "/api/forums/golang/approved-users/bob", `{"moderator_id":"bob"}`), http.StatusForbidden)
+	decode(call(http.MethodPut, "/api/forums/golang/moderators/carol", byAlice), &forum)
+	if len(forum.Moderators) != 2 {
+		t.Errorf("Expected carol to moderate the forum too, got %+v", forum)
+	}
+
+	// Only approved users post in a restricted forum, but anyone reads it.
+	decode(call(http.MethodPut, "/api/forums/golang/type", `{"type":"restricted","moderator_id":"carol"}`), &forum)
+	if forum.Type != "restricted" {
+		t.Errorf("Expected the forum to be restricted, got %+v", forum)
+	}
+	expect(call(http.MethodPut, "/api/forums/golang/type", `{"type":"hidden","moderator_id":"alice"}`), http.StatusBadRequest)
+	w = call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"bob","text":"Hi"}`)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "only approved users may post") {
+		t.Errorf("Expected the post to be refused, got %d %s", w.Code, w.Body.String())
+	}
+	decode(call(http.MethodPut, "/api/forums/golang/approved-users/bob", byAlice), &forum)
+	call(http.MethodPut, "/api/forums/golang/approved-users/alice", byAlice)
+	if names := usernames("/api/forums/golang/approved-users?moderator_id=alice"); names != "alice,bob" {
+		t.Errorf("Expected two approved users, got %s", names)
+	}
+	var public templates.PostResponse
+	decode(call(http.MethodPost, "/api/posts", `{"forum_id":"golang","author_id":"bob","text":"Hi"}`), &public)
+	expect(call(http.MethodPost, "/api/comments", `{"post_id":"`+public.ID+`","author_id":"carol","content":"Hello"}`), http.StatusOK)
+
+	// A private forum is hidden from everyone but its members and approved
+	// users.
+	call(http.MethodPut, "/api/forums/secret/type", `{"type":"private","moderator_id":"alice"}`)
+	call(http.MethodPut, "/api/forums/secret/approved-users/alice", byAlice)
+	expect(call(http.MethodPost, "/api/posts", `{"forum_id":"secret","author_id":"bob","text":"Let me in"}`), http.StatusForbidden)
+	var secret templates.PostResponse
+	decode(call(http.MethodPost, "/api/posts", `{"forum_id":"secret","author_id":"alice","text":"Members only"}`), &secret)
+	var comment templates.CommentResponse
+	decode(call(http.MethodPost, "/api/comments", `{"post_id":"`+secret.ID+`","author_id":"alice","content":"Quiet here"}`), &comment)
+	expect(call(http.MethodPost, "/api/comments", `{"post_id":"`+secret.ID+`","author_id":"bob","content":"Hello?"}`), http.StatusForbidden)
+
+	for _, viewer := range []string{"", "?viewer_id=bob"} {
+		expect(call(http.MethodGet, "/api/posts/"+secret.ID+viewer, ""), http.StatusForbidden)
+		expect(call(http.MethodGet, "/api/comments/"+comment.ID+viewer, ""), http.StatusForbidden)
+		var posts []*templates.PostResponse
+		decode(call(http.MethodGet, "/api/posts"+viewer, ""), &posts)
+		if len(posts) != 1 || posts[0].ID != public.ID {
+			t.Errorf("Expected only the public post for %q, got %+v", viewer, posts)
+		}
+		var history templates.PostPageResponse
+		decode(call(http.MethodGet, "/api/users/alice/posts"+viewer, ""), &history)
+		if history.Total != 0 {
+			t.Errorf("Expected alice's private post to be hidden for %q, got %+v", viewer, history)
+		}
+		var comments templates.CommentPageResponse
+		decode(call(http.MethodGet, "/api/users/alice/comments"+viewer, ""), &comments)
+		if comments.Total != 0 {
+			t.Errorf("Expected alice's private comment to be hidden for %q, got %+v", viewer, comments)
+		}
+	}
+	expect(call(http.MethodPost, "/api/posts/"+secret.ID+"/vote", `{"direction":"up"}`), http.StatusForbidden)
+	expect(call(http.MethodGet, "/api/posts?viewer_id=nobody", ""), http.StatusNotFound)
+	expect(call(http.MethodGet, "/api/posts/"+secret.ID+"?viewer_id=alice", ""), http.StatusOK)
+	expect(call(http.MethodGet, "/api/comments/"+comment.ID+"?viewer_id=alice", ""), http.StatusOK)
+	var posts []*templates.PostResponse
+	decode(call(http.MethodGet, "/api/posts?viewer_id=alice", ""), &posts)
+	if len(posts) != 2 {
+		t.Errorf("Expected alice to see both posts, got %+v", posts)
+	}
+
+	// Outsiders ask to join, and moderators decide.
+	expect(call(http.MethodPost, "/api/forums/secret/members", `{"user_id":"bob"}`), http.StatusForbidden)
+	expect(call(http.MethodPost, "/api/forums/golang/join-requests", `{"user_id":"bob"}`), http.StatusBadRequest)
+	call(http.MethodPost, "/api/forums/secret/join-requests", `{"user_id":"bob"}`)
+	call(http.MethodPost, "/api/forums/secret/join-requests", `{"user_id":"carol"}`)
+	if names := usernames("/api/forums/secret/join-requests?moderator_id=alice"); names != "bob,carol" {
+		t.Errorf("Expected two join requests, got %s", names)
+	}
+	for _, path := range []string{"/api/forums/secret/join-requests", "/api/forums/secret/approved-users"} {
+		expect(call(http.MethodGet, path, ""), http.StatusBadRequest)
+		expect(call(http.MethodGet, path+"?moderator_id=bob", ""), http.StatusForbidden)
+	}
+	expect(call(http.MethodPost, "/api/forums/secret/join-requests/bob/approve", `{"moderator_id":"bob"}`), http.StatusForbidden)
+	decode(call(http.MethodPost, "/api/forums/secret/join-requests/bob/approve", byAlice), &forum)
+	if forum.Members != 1 {
+		t.Errorf("Expected bob to be a member, got %+v", forum)
+	}
+	expect(call(http.MethodPost, "/api/forums/secret/join-requests/carol/deny", `{"moderator_id":"carol"}`), http.StatusForbidden)
+	call(http.MethodPost, "/api/forums/secret/join-requests/carol/deny", byAlice)
+	expect(call(http.MethodPost, "/api/forums/secret/join-requests/carol/deny", byAlice), http.StatusNotFound)
+	if names := usernames("/api/forums/secret/join-requests?moderator_id=alice"); names != "" {
+		t.Errorf("Expected no join requests left, got %s", names)
+	}
+	expect(call(http.MethodPost, "/api/forums/secret/join-requests", `{"user_id":"bob"}`), http.StatusConflict)
+	expect(call(http.MethodGet, "/api/posts/"+secret.ID+"?viewer_id=bob", ""), http.StatusOK)
+	expect(call(http.MethodGet, "/api/posts/"+secret.ID+"?viewer_id=carol", ""), http.StatusForbidden)
+
+	// Leaving takes the access away again.
+	call(http.MethodDelete, "/api/forums/secret/members/bob", "")
+	expect(call(http.MethodGet, "/api/posts/"+secret.ID+"?viewer_id=bob", ""), http.StatusForbidden)
+
+	// The pages show a private forum to its members only.
+	b := &browser{t: t, router: router}
+	if w := b.do(http.MethodGet, "/r/secret", nil); w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "private") {
+		t.Errorf("Expected the forum page to be refused, got %d", w.Code)
+	}
+	if w := b.do(http.MethodGet, "/", nil); strings.Contains(w.Body.String(), "Members only") {
+		t.Errorf("Expected the private post to stay off the front page")
+	}
+	b.submit("/login", url.Values{"username": {"alice"}})
+	if w := b.do(http.MethodGet, "/r/secret", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Members only") {
+		t.Errorf("Expected alice to see the forum, got %d", w.Code)
+	}
+
+	// gRPC and GraphQL read for the viewer they are given.
+	_, err := client.GetPost(context.Background(), &wire.RetrievePost{ContentId: secret.ID})
+	expectCode(t, err, codes.PermissionDenied)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "viewer-id", "alice")
+	if _, err := client.GetPost(ctx, &wire.RetrievePost{ContentId: secret.ID}); err != nil {
+		t.Errorf("Expected alice to read the post, got %v", err)
+	}
+	query := `{"query":"{ post(id: \"` + secret.ID + `\") { id } }"}`
+	if w := call(http.MethodPost, "/api/graphql", query); !strings.Contains(w.Body.String(), "forum is private") {
+		t.Errorf("Expected GraphQL to refuse the post, got %s", w.Body.String())
+	}
+	if w := call(http.MethodPost, "/api/graphql?viewer_id=alice", query); !strings.Contains(w.Body.String(), secret.ID) {
+		t.Errorf("Expected GraphQL to read the post for alice, got %s", w.Body.String())
+	}
+}
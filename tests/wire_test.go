@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"reddit-clone/core/automod"
+	"reddit-clone/core/proto_actors"
+	"reddit-clone/schemas"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWireRoundTrip(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mentions := []schemas.Mention{{Kind: schemas.MentionUser, Name: "bob", TargetID: "user_1", Start: 6, End: 11}}
+	reply := &schemas.Comment{ID: "comment_2", PostID: "post_1", ParentID: "comment_1", Replies: []*schemas.Comment{}, CreatedAt: at, UpdatedAt: at}
+	comment := &schemas.Comment{ID: "comment_1", Content: "hi", AuthorID: "user_1", PostID: "post_1", Upvotes: 2, Replies: []*schemas.Comment{reply}, Mentions: mentions, CreatedAt: at, UpdatedAt: at}
+	post := &schemas.Post{ID: "post_1", Content: "hello", AuthorID: "user_1", SubredditID: "subreddit_1", Upvotes: 3, Downvotes: 1, Comments: []*schemas.Comment{comment}, Mentions: mentions, CreatedAt: at, UpdatedAt: at}
+	page := proto_actor.Page{Sort: proto_actor.SortNew, Limit: 10, After: "post_0"}
+
+	for _, message := range []interface{}{
+		&proto_actor.AddForum{Title: "Go", Name: "golang"},
+		&proto_actor.RenameForum{ForumID: "subreddit_1", Title: "Go", ModeratorID: "user_1"},
+		&proto_actor.RetrieveAllForums{},
+		&proto_actor.JoinForum{ForumID: "subreddit_1", UserID: "user_1"},
+		&proto_actor.LeaveForum{ForumID: "subreddit_1", UserID: "user_1"},
+		&proto_actor.AdjustKarma{ProfileID: "user_1", Delta: -1},
+		&proto_actor.UpdateProfile{ProfileID: "user_1", Bio: "bio", AvatarURL: "https://example.com/a.png"},
+		&proto_actor.AddPost{ForumID: "subreddit_1", AuthorID: "user_1", Text: "hello @bob", Mentions: mentions},
+		&proto_actor.VotePost{ContentID: "post_1", Upvote: true},
+		&proto_actor.RetrieveAuthorPosts{AuthorID: "user_1", Page: page},
+		&proto_actor.SendMessage{FromUserID: "user_1", ToUserID: "user_2", Body: "hey"},
+		&proto_actor.AddComment{PostID: "post_1", ParentID: "comment_1", AuthorID: "user_1", Content: "hi"},
+		&proto_actor.FetchAuthorComments{AuthorID: "user_1", Page: page},
+		&proto_actor.RetrieveForums{ForumIDs: []string{"subreddit_1", "subreddit_2"}},
+		&proto_actor.FetchUsers{ProfileIDs: []string{"user_1"}},
+		&proto_actor.RetrievePosts{ContentIDs: []string{"post_1", "post_2"}},
+		&proto_actor.FetchThreads{PostIDs: []string{"post_1"}},
+		&proto_actor.PushNotification{UserID: "user_2", Kind: "mention", SourceID: "post_1", ActorID: "user_1"},
+		&proto_actor.AddPost{ForumID: "subreddit_1", AuthorID: "user_1", Text: "spam", Held: true},
+		&proto_actor.AddComment{PostID: "post_1", AuthorID: "user_1", Content: "spam", Held: true},
+		&proto_actor.SendMessage{FromUserID: "user_1", ToUserID: "user_2", Body: "spam", Held: true},
+		&proto_actor.SetForumFilters{ForumID: "subreddit_1", BannedWords: []string{"heck"}, BlockedDomains: []string{"spam.example"}, ModeratorID: "user_1"},
+		&proto_actor.ApprovePost{ContentID: "post_1"},
+		&proto_actor.ApproveComment{CommentID: "comment_1"},
+		&proto_actor.DeliverMessage{MessageID: "message_1"},
+		&proto_actor.HoldContent{Kind: schemas.KindPost, ContentID: "post_1", ForumID: "subreddit_1", AuthorID: "user_1", Filter: "links", Reason: "has 11 links, more than 10"},
+		&proto_actor.FetchModQueue{ForumID: "subreddit_1", ModeratorID: "user_1"},
+		&proto_actor.ResolveQueueItem{ItemID: "queue_1", ModeratorID: "user_1"},
+		&proto_actor.RestoreQueueItem{Item: &schemas.QueueItem{ID: "queue_1", Kind: schemas.KindPost, ContentID: "post_2", ForumID: "subreddit_1", AuthorID: "user_1", Filter: "links", Reason: "too many", Held: true, CreatedAt: at}},
+		&proto_actor.AddPost{ForumID: "subreddit_1", AuthorID: "user_1", Text: "why?", Flair: "Question"},
+		&proto_actor.SetAutoModerator{ForumID: "subreddit_1", Rules: "action: hold\n", ModeratorID: "user_1"},
+		&proto_actor.EnsureUser{Username: "AutoModerator"},
+		&proto_actor.ReportContent{Kind: schemas.KindComment, ContentID: "comment_1", ForumID: "subreddit_1", AuthorID: "user_1", Filter: "automoderator", Reason: "links"},
+		&proto_actor.EvaluateContent{ForumID: "subreddit_1", Rules: "action: hold\n", Kind: schemas.KindPost, Text: "hi", AuthorName: "alice", AuthorKarma: -3, AuthorCreatedAt: at},
+		&proto_actor.EvaluateContent{Kind: schemas.KindComment, Text: "hi"},
+		&proto_actor.SetForumType{ForumID: "subreddit_1", Type: schemas.ForumPrivate, ModeratorID: "user_3"},
+		&proto_actor.ApproveUser{ForumID: "subreddit_1", UserID: "user_1", ModeratorID: "user_3"},
+		&proto_actor.UnapproveUser{ForumID: "subreddit_1", UserID: "user_1", ModeratorID: "user_3"},
+		&proto_actor.RequestJoin{ForumID: "subreddit_1", UserID: "user_2"},
+		&proto_actor.ResolveJoinRequest{ForumID: "subreddit_1", UserID: "user_2", Accept: true, ModeratorID: "user_3"},
+		&proto_actor.AddModerator{ForumID: "subreddit_1", UserID: "user_2", ModeratorID: "user_3"},
+		&proto_actor.RetrieveAllPosts{HiddenForums: []string{"subreddit_2"}},
+		&proto_actor.AddForum{Title: "Go", Name: "golang", CreatorID: "user_1"},
+		&proto_actor.UpdateForum{ForumID: "subreddit_1", Info: schemas.ForumInfo{Description: "Go", Sidebar: "**hi**", Rules: []schemas.ForumRule{{Title: "Be kind"}, {Title: "No spam", Description: "None"}}, BannerURL: "https://example.com/b.png", IconURL: "https://example.com/i.png", NSFW: true}, ModeratorID: "user_1"},
+		&proto_actor.UpdateForum{ForumID: "subreddit_1"},
+		&proto_actor.RetrieveAuthorPosts{AuthorID: "user_1", Page: page, HiddenForums: []string{"subreddit_2"}},
+		&proto_actor.FetchAuthorComments{AuthorID: "user_1", Page: page, HiddenForums: []string{"subreddit_2"}},
+		post,
+		[]*schemas.Post{post},
+		&proto_actor.PostListing{Posts: []*schemas.Post{post}, Total: 4, Offset: 1, NextCursor: "post_1"},
+		[]*schemas.Comment{comment},
+		&schemas.Subreddit{ID: "subreddit_1", Name: "golang", Title: "Go", Members: map[string]bool{"user_1": true}, Posts: []*schemas.Post{}, CreatedAt: at, UpdatedAt: at},
+		&schemas.Account{ID: "user_1", Username: "alice", Karma: -2, CreatedAt: at, UpdatedAt: at},
+		[]*schemas.Account{{ID: "user_1", Username: "alice", CreatedAt: at, UpdatedAt: at}},
+		[]schemas.Message{{ID: "message_1", SenderID: "user_1", ReceiverID: "user_2", Content: "hey", CreatedAt: at, UpdatedAt: at}},
+		&schemas.Post{ID: "post_2", Content: "spam", AuthorID: "user_1", SubredditID: "subreddit_1", Comments: []*schemas.Comment{}, Held: true, CreatedAt: at, UpdatedAt: at},
+		&schemas.Subreddit{ID: "subreddit_1", Name: "golang", Title: "Go", Members: map[string]bool{}, Posts: []*schemas.Post{}, BannedWords: []string{"heck"}, BlockedDomains: []string{"spam.example"}, CreatedAt: at, UpdatedAt: at},
+		[]schemas.Message{{ID: "message_2", SenderID: "user_1", ReceiverID: "user_2", Content: "spam", Held: true, CreatedAt: at, UpdatedAt: at}},
+		&schemas.QueueItem{ID: "queue_1", Kind: schemas.KindMessage, ContentID: "message_2", AuthorID: "user_1", Filter: "duplicate", Reason: "repeats", CreatedAt: at},
+		[]*schemas.QueueItem{{ID: "queue_1", Kind: schemas.KindPost, ContentID: "post_2", ForumID: "subreddit_1", AuthorID: "user_1", CreatedAt: at}},
+		&schemas.Post{ID: "post_3", Content: "why?", AuthorID: "user_1", SubredditID: "subreddit_1", Comments: []*schemas.Comment{}, Flair: "Question", CreatedAt: at, UpdatedAt: at},
+		&schemas.Subreddit{ID: "subreddit_1", Name: "golang", Title: "Go", Members: map[string]bool{}, Posts: []*schemas.Post{}, AutoModerator: "action: hold\n", CreatedAt: at, UpdatedAt: at},
+		&schemas.Subreddit{ID: "subreddit_1", Name: "golang", Title: "Go", Members: map[string]bool{}, Posts: []*schemas.Post{}, Type: schemas.ForumPrivate, ApprovedUsers: map[string]bool{"user_1": true}, JoinRequests: []string{"user_2", "user_3"}, CreatedAt: at, UpdatedAt: at},
+		&schemas.Subreddit{ID: "subreddit_1", Name: "golang", Title: "Go", Members: map[string]bool{}, Posts: []*schemas.Post{}, CreatedBy: "user_1", Moderators: []string{"user_1", "user_2"}, ForumInfo: schemas.ForumInfo{Description: "Go", Sidebar: "**hi**", Rules: []schemas.ForumRule{{Title: "Be kind", Description: "Please"}}, BannerURL: "https://example.com/b.png", IconURL: "https://example.com/i.png", NSFW: true}, CreatedAt: at, UpdatedAt: at},
+		&schemas.QueueItem{ID: "queue_2", Kind: schemas.KindPost, ContentID: "post_3", ForumID: "subreddit_1", AuthorID: "user_1", Filter: "automoderator", Held: true, CreatedAt: at},
+		&automod.Result{Action: automod.Hold, Rule: "Links", Reason: "links", Flair: "Spam", Comments: []string{"hi alice"}, Matched: []string{"Links", "Other"}},
+		&automod.Result{},
+		[]*schemas.Notification{{ID: "notification_1", UserID: "user_2", Kind: "mention", CreatedAt: at}},
+		true,
+		7,
+		proto_actor.ErrForumNameTaken,
+	} {
+		encoded, err := proto_actor.EncodeMessage(message)
+		if err != nil {
+			t.Errorf("Encoding %T failed: %v", message, err)
+			continue
+		}
+		data, err := proto.Marshal(encoded)
+		if err != nil {
+			t.Fatalf("Marshalling %T failed: %v", encoded, err)
+		}
+		received := encoded.ProtoReflect().New().Interface()
+		if err := proto.Unmarshal(data, received); err != nil {
+			t.Fatalf("Unmarshalling %T failed: %v", encoded, err)
+		}
+		decoded, err := proto_actor.DecodeMessage(received)
+		if err != nil {
+			t.Errorf("Decoding %T failed: %v", received, err)
+			continue
+		}
+		if !reflect.DeepEqual(decoded, message) {
+			t.Errorf("%T did not survive the round trip:\n got %#v\nwant %#v", message, decoded, message)
+		}
+	}
+
+	if _, err := proto_actor.EncodeMessage(struct{}{}); err == nil {
+		t.Errorf("Messages without a wire form should not encode")
+	}
+}